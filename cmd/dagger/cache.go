@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"dagger.io/dagger"
+	"github.com/dagger/dagger/engine/client"
+	"github.com/juju/ansiterm/tabwriter"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+	"github.com/vito/progrock"
+)
+
+var cachePruneAll bool
+
+func init() {
+	cachePruneCmd.Flags().BoolVar(&cachePruneAll, "all", false, "Prune every cache volume on the engine")
+
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+
+	rootCmd.AddCommand(cacheCmd)
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache volumes stored on the engine",
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List cache volumes with their disk usage",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx := cmd.Context()
+		return withEngineAndTUI(ctx, client.Params{}, func(ctx context.Context, engineClient *client.Client) (err error) {
+			rec := progrock.FromContext(ctx)
+			vtx := rec.Vertex("cache-ls", strings.Join(os.Args, " "), progrock.Focused())
+			defer func() { vtx.Done(err) }()
+
+			volumes, err := engineClient.Dagger().CacheVolumes(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list cache volumes: %w", err)
+			}
+
+			tw := tabwriter.NewWriter(vtx.Stdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintf(tw, "%s\t%s\t%s\n",
+				termenv.String("key").Bold(),
+				termenv.String("size").Bold(),
+				termenv.String("last used").Bold(),
+			)
+			for _, volume := range volumes {
+				key, err := volume.Key(ctx)
+				if err != nil {
+					return err
+				}
+				if key == "" {
+					key = "<unknown>"
+				}
+				size, err := volume.Size(ctx)
+				if err != nil {
+					return err
+				}
+				lastUsed, err := volume.LastUsed(ctx)
+				if err != nil {
+					return err
+				}
+				lastUsedStr := "never"
+				if lastUsed != 0 {
+					lastUsedStr = time.Unix(int64(lastUsed), 0).Format(time.RFC3339)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", key, humanSize(int64(size)), lastUsedStr)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune [flags] [KEY...]",
+	Short: "Wipe the contents of cache volumes",
+	Long: `Wipe the contents of the cache volumes with the given keys.
+
+With --all, every cache volume on the engine is wiped, including those whose
+key is not known to the engine.`,
+	RunE: func(cmd *cobra.Command, keys []string) error {
+		if cachePruneAll == (len(keys) > 0) {
+			return fmt.Errorf("either specify cache volume keys or --all")
+		}
+
+		ctx := cmd.Context()
+		return withEngineAndTUI(ctx, client.Params{}, func(ctx context.Context, engineClient *client.Client) (err error) {
+			rec := progrock.FromContext(ctx)
+			vtx := rec.Vertex("cache-prune", strings.Join(os.Args, " "), progrock.Focused())
+			defer func() { vtx.Done(err) }()
+
+			dag := engineClient.Dagger()
+
+			var volumes []dagger.CacheVolume
+			if cachePruneAll {
+				volumes, err = dag.CacheVolumes(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to list cache volumes: %w", err)
+				}
+			} else {
+				for _, key := range keys {
+					volumes = append(volumes, *dag.CacheVolume(key))
+				}
+			}
+
+			var total int64
+			for _, volume := range volumes {
+				reclaimed, err := volume.Prune(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to prune cache volume: %w", err)
+				}
+				total += int64(reclaimed)
+			}
+
+			fmt.Fprintf(vtx.Stdout(), "Pruned %d cache volume(s), reclaimed %s\n", len(volumes), humanSize(total))
+			return nil
+		})
+	},
+}
+
+func humanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -3,13 +3,19 @@ package main
 import (
 	"fmt"
 
+	"github.com/dagger/dagger/engine/client"
 	"github.com/spf13/cobra"
 )
 
+var debugOnFailure bool
+
 var callCmd = &FuncCommand{
 	Name:  "call",
 	Short: "Call a module function",
 	Long:  "Call a module function and print the result.\n\nOn a container, the stdout will be returned. On a directory, the list of entries, and on a file, its contents.",
+	Init: func(cmd *cobra.Command) {
+		cmd.PersistentFlags().BoolVar(&debugOnFailure, "debug-on-failure", false, "Open a shell in the state of a failed exec")
+	},
 	OnSelectObjectLeaf: func(c *FuncCommand, name string) error {
 		switch name {
 		case Container:
@@ -26,9 +32,26 @@ var callCmd = &FuncCommand{
 		}
 		return nil
 	},
+	ClientParams: func(_ *FuncCommand) client.Params {
+		return client.Params{
+			KeepFailedExecs: debugOnFailure,
+		}
+	},
+	BeforeRequest: func(_ *FuncCommand, _ *cobra.Command, _ *modTypeDef) error {
+		if debugOnFailure {
+			return checkShellSupported()
+		}
+		return nil
+	},
 	AfterResponse: func(_ *FuncCommand, cmd *cobra.Command, _ *modTypeDef, response any) error {
 		return printResponse(cmd, response)
 	},
+	OnResponseError: func(c *FuncCommand, cmd *cobra.Command, err error) error {
+		if !debugOnFailure {
+			return err
+		}
+		return debugFailedExec(cmd.Context(), c.c, err)
+	},
 }
 
 func printResponse(cmd *cobra.Command, r any) error {
@@ -198,6 +198,16 @@ type FuncCommand struct {
 	// AfterResponse is called when the query has completed and returned a result.
 	AfterResponse func(*FuncCommand, *cobra.Command, *modTypeDef, any) error
 
+	// ClientParams is called once the flags are parsed, to get the parameters
+	// of the engine client to connect with.
+	ClientParams func(*FuncCommand) client.Params
+
+	// OnResponseError is called when the query has failed.
+	//
+	// It can be useful to handle specific errors. The returned error is the
+	// one reported by the command.
+	OnResponseError func(*FuncCommand, *cobra.Command, error) error
+
 	// cmd is the parent cobra command.
 	cmd *cobra.Command
 
@@ -273,7 +283,11 @@ func (fc *FuncCommand) Command() *cobra.Command {
 
 			// Between PreRunE and RunE, flags are validated.
 			RunE: func(c *cobra.Command, _ []string) error {
-				return withEngineAndTUI(c.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) (rerr error) {
+				var params client.Params
+				if fc.ClientParams != nil {
+					params = fc.ClientParams(fc)
+				}
+				return withEngineAndTUI(c.Context(), params, func(ctx context.Context, engineClient *client.Client) (rerr error) {
 					fc.c = engineClient
 
 					// withEngineAndTUI changes the context.
@@ -552,7 +566,11 @@ func (fc *FuncCommand) makeSubCmd(dag *dagger.Client, fn *modFunction) *cobra.Co
 			q := fc.q.Bind(&response)
 
 			if err := q.Execute(ctx, dag.GraphQLClient()); err != nil {
-				return fmt.Errorf("response from query: %w", err)
+				err = fmt.Errorf("response from query: %w", err)
+				if fc.OnResponseError != nil {
+					return fc.OnResponseError(fc, cmd, err)
+				}
+				return err
 			}
 
 			if fc.AfterResponse != nil {
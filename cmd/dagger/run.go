@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -9,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dagger/dagger/engine/client"
@@ -49,6 +52,7 @@ DAGGER_SESSION_PORT and DAGGER_SESSION_TOKEN will be convieniently injected auto
 
 var waitDelay time.Duration
 var runFocus bool
+var runInteractive bool
 
 func init() {
 	// don't require -- to disambiguate subcommand flags
@@ -62,6 +66,8 @@ func init() {
 	)
 
 	runCmd.Flags().BoolVar(&runFocus, "focus", false, "Only show output for focused commands.")
+
+	runCmd.Flags().BoolVar(&runInteractive, "interactive", false, "Open a shell in the state of the last failed exec when the command fails.")
 }
 
 func Run(cmd *cobra.Command, args []string) {
@@ -90,8 +96,14 @@ func run(ctx context.Context, args []string) error {
 	sessionToken := u.String()
 
 	focus = runFocus
+	if runInteractive {
+		if err := checkShellSupported(); err != nil {
+			return err
+		}
+	}
 	return withEngineAndTUI(ctx, client.Params{
-		SecretToken: sessionToken,
+		SecretToken:     sessionToken,
+		KeepFailedExecs: runInteractive,
 	}, func(ctx context.Context, engineClient *client.Client) error {
 		sessionL, err := net.Listen("tcp", "127.0.0.1:0")
 		if err != nil {
@@ -113,7 +125,14 @@ func run(ctx context.Context, args []string) error {
 		// shell because Ctrl+C sends to the process group.)
 		ensureChildProcessesAreKilled(subCmd)
 
-		go http.Serve(sessionL, engineClient) // nolint:gosec
+		// only inspect responses when a debug shell may need to be opened
+		var handler http.Handler = engineClient
+		var failedExecs *failedExecRecorder
+		if runInteractive {
+			failedExecs = &failedExecRecorder{handler: engineClient}
+			handler = failedExecs
+		}
+		go http.Serve(sessionL, handler) // nolint:gosec
 
 		var cmdErr error
 		if !silent {
@@ -136,6 +155,14 @@ func run(ctx context.Context, args []string) error {
 
 			cmdErr = subCmd.Run()
 			cmdVtx.Done(cmdErr)
+
+			if cmdErr != nil && runInteractive {
+				if debugID := failedExecs.DebugID(); debugID != "" {
+					if err := debugShell(ctx, engineClient, debugID); err != nil {
+						return errors.Join(cmdErr, fmt.Errorf("debug shell: %w", err))
+					}
+				}
+			}
 		} else {
 			subCmd.Stdout = os.Stdout
 			subCmd.Stderr = os.Stderr
@@ -145,3 +172,56 @@ func run(ctx context.Context, args []string) error {
 		return cmdErr
 	})
 }
+
+// failedExecRecorder proxies API requests to the session, recording the debug
+// ID of the last failed exec reported in their responses.
+type failedExecRecorder struct {
+	handler http.Handler
+
+	mu      sync.Mutex
+	debugID string
+}
+
+func (r *failedExecRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/query" {
+		r.handler.ServeHTTP(w, req)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	r.handler.ServeHTTP(teeResponseWriter{w, buf}, req)
+
+	var resp struct {
+		Errors []struct {
+			Extensions map[string]any `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+		return
+	}
+	for _, gqlErr := range resp.Errors {
+		if debugID, ok := gqlErr.Extensions["debugID"].(string); ok && debugID != "" {
+			r.mu.Lock()
+			r.debugID = debugID
+			r.mu.Unlock()
+		}
+	}
+}
+
+// DebugID returns the debug ID of the last failed exec, if any.
+func (r *failedExecRecorder) DebugID() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.debugID
+}
+
+// teeResponseWriter copies the response body to buf as it's written.
+type teeResponseWriter struct {
+	http.ResponseWriter
+	buf *bytes.Buffer
+}
+
+func (t teeResponseWriter) Write(p []byte) (int, error) {
+	t.buf.Write(p)
+	return t.ResponseWriter.Write(p)
+}
@@ -38,13 +38,7 @@ var shellCmd = &FuncCommand{
 		// Even though these flags are global, we only check them just before query
 		// execution because you may want to debug an error during loading or for
 		// --help.
-		if silent || !(progress == "auto" && autoTTY || progress == "tty") {
-			return fmt.Errorf("running shell without the TUI is not supported")
-		}
-		if debug {
-			return fmt.Errorf("running shell with --debug is not supported")
-		}
-		return nil
+		return checkShellSupported()
 	},
 	AfterResponse: func(c *FuncCommand, cmd *cobra.Command, returnType *modTypeDef, response any) error {
 		ctrID, ok := (response).(string)
@@ -66,6 +60,52 @@ var shellCmd = &FuncCommand{
 	},
 }
 
+// checkShellSupported returns an error if the global flags don't allow
+// attaching to a shell.
+func checkShellSupported() error {
+	if silent || !(progress == "auto" && autoTTY || progress == "tty") {
+		return fmt.Errorf("running shell without the TUI is not supported")
+	}
+	if debug {
+		return fmt.Errorf("running shell with --debug is not supported")
+	}
+	return nil
+}
+
+// debugFailedExec opens a shell in the state of the exec that caused err, if
+// any. The original error is always returned so the command still fails.
+func debugFailedExec(ctx context.Context, engineClient *client.Client, err error) error {
+	var debugID string
+	var execErr *dagger.ExecError
+	var timeoutErr *dagger.ExecTimeoutError
+	switch {
+	case errors.As(err, &execErr):
+		debugID = execErr.DebugID
+	case errors.As(err, &timeoutErr):
+		debugID = timeoutErr.DebugID
+	}
+	if debugID == "" {
+		return err
+	}
+	if shellErr := debugShell(ctx, engineClient, debugID); shellErr != nil {
+		return errors.Join(err, fmt.Errorf("debug shell: %w", shellErr))
+	}
+	return err
+}
+
+// debugShell opens a shell in the state of the failed exec with the given
+// debug ID.
+func debugShell(ctx context.Context, engineClient *client.Client, debugID string) error {
+	ctr := engineClient.Dagger().DebugContainer(debugID)
+
+	shellEndpoint, err := withShellExec(ctx, ctr).ShellEndpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get shell endpoint: %w", err)
+	}
+
+	return attachToShell(ctx, engineClient, shellEndpoint)
+}
+
 func withShellExec(ctx context.Context, ctr *dagger.Container) *dagger.Container {
 	args := shellEntrypoint
 
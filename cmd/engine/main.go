@@ -814,6 +814,7 @@ func newController(ctx context.Context, c *cli.Context, cfg *config.Config) (*se
 		UpstreamCacheExporters: remoteCacheExporterFuncs,
 		UpstreamCacheImporters: remoteCacheImporterFuncs,
 		DNSConfig:              getDNSConfig(cfg.DNS),
+		RegistryHosts:          resolverFn,
 	})
 	if err != nil {
 		return nil, nil, err
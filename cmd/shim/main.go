@@ -37,16 +37,22 @@ const (
 	metaMountPath = "/.dagger_meta_mount"
 	stdinPath     = metaMountPath + "/stdin"
 	exitCodePath  = metaMountPath + "/exitCode"
+	timeoutPath   = metaMountPath + "/timeout"
 	runcPath      = "/usr/local/bin/runc"
 	shimPath      = "/_shim"
 
 	errorExitCode = 125
+
+	// timeoutExitCode is the exit code recorded for a command that was killed
+	// after exceeding its timeout, as with timeout(1).
+	timeoutExitCode = 124
 )
 
 var (
-	stdoutPath = metaMountPath + "/stdout"
-	stderrPath = metaMountPath + "/stderr"
-	pipeWg     sync.WaitGroup
+	stdoutPath   = metaMountPath + "/stdout"
+	stderrPath   = metaMountPath + "/stderr"
+	combinedPath = metaMountPath + "/combined"
+	pipeWg       sync.WaitGroup
 )
 
 /*
@@ -110,7 +116,7 @@ func internalCommand() int {
 
 func check(args []string) error {
 	if len(args) == 0 {
-		return fmt.Errorf("usage: check <host> port/tcp [port/udp ...]")
+		return fmt.Errorf("usage: check <host> port/tcp[/http/path] [port/udp ...]")
 	}
 
 	host, ports := args[0], args[1:]
@@ -120,6 +126,7 @@ func check(args []string) error {
 		if !ok {
 			network = "tcp"
 		}
+		network, httpPath, hasHTTPPath := strings.Cut(network, "/")
 
 		pollAddr := net.JoinHostPort(host, port)
 
@@ -131,11 +138,48 @@ func check(args []string) error {
 		}
 
 		fmt.Println("port is up at", reached)
+
+		if hasHTTPPath {
+			pollURL := "http://" + pollAddr + "/" + httpPath
+
+			fmt.Println("polling for", pollURL)
+
+			if err := pollForHTTP(pollURL); err != nil {
+				return fmt.Errorf("poll %s: %w", pollURL, err)
+			}
+
+			fmt.Println("health check passed at", pollURL)
+		}
 	}
 
 	return nil
 }
 
+func pollForHTTP(url string) error {
+	retry := backoff.NewExponentialBackOff()
+	retry.InitialInterval = 100 * time.Millisecond
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+
+	return backoff.Retry(func() error {
+		resp, err := client.Get(url)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "health check failed: %s; elapsed: %s\n", err, retry.GetElapsedTime())
+			return err
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode >= 400 {
+			fmt.Fprintf(os.Stderr, "health check failed: %s; elapsed: %s\n", resp.Status, retry.GetElapsedTime())
+			return fmt.Errorf("unhealthy status: %s", resp.Status)
+		}
+
+		return nil
+	}, retry)
+}
+
 func pollForPort(network, addr string) (string, error) {
 	retry := backoff.NewExponentialBackOff()
 	retry.InitialInterval = 100 * time.Millisecond
@@ -183,8 +227,33 @@ func shim() (returnExitCode int) {
 		args = os.Args[2:]
 	}
 
-	cmd := exec.Command(name, args...)
+	_, expectNonZeroExit := internalEnv(core.ShimExpectNonZeroExitEnvVar)
+
+	var timeout time.Duration
+	if timeoutVal, found := internalEnv(core.ShimTimeoutEnvVar); found {
+		seconds, err := strconv.Atoi(timeoutVal)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid timeout %q: %v\n", timeoutVal, err)
+			return errorExitCode
+		}
+		timeout = time.Duration(seconds) * time.Second
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
 	_, isTTY := internalEnv(core.ShimEnableTTYEnvVar)
+	if timeout > 0 && !isTTY {
+		// run the command in its own process group so that any processes it
+		// spawns are killed along with it on timeout
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
+	cmd.Cancel = func() error {
+		if cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid {
+			return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+		return cmd.Process.Kill()
+	}
 	if isTTY {
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
@@ -242,8 +311,17 @@ func shim() (returnExitCode int) {
 			stderrRedirect = stderrRedirectFile
 		}
 
-		outWriter := io.MultiWriter(stdoutFile, stdoutRedirect, os.Stdout)
-		errWriter := io.MultiWriter(stderrFile, stderrRedirect, os.Stderr)
+		combinedFile, err := os.Create(combinedPath)
+		if err != nil {
+			panic(err)
+		}
+		defer combinedFile.Close()
+		// both streams are copied concurrently, so serialize their writes to
+		// keep the combined output interleaved in the order it was received
+		combinedWriter := &lockedWriter{w: combinedFile}
+
+		outWriter := io.MultiWriter(stdoutFile, stdoutRedirect, combinedWriter, os.Stdout)
+		errWriter := io.MultiWriter(stderrFile, stderrRedirect, combinedWriter, os.Stderr)
 
 		if len(secretsToScrub.Envs) == 0 && len(secretsToScrub.Files) == 0 {
 			cmd.Stdout = outWriter
@@ -283,11 +361,17 @@ func shim() (returnExitCode int) {
 	}
 
 	exitCode := 0
+	var timedOut bool
 	if err := runWithNesting(ctx, cmd); err != nil {
 		exitCode = errorExitCode
-		if exiterr, ok := err.(*exec.ExitError); ok {
+		var exiterr *exec.ExitError
+		switch {
+		case timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded):
+			exitCode = timeoutExitCode
+			timedOut = true
+		case errors.As(err, &exiterr):
 			exitCode = exiterr.ExitCode()
-		} else {
+		default:
 			panic(err)
 		}
 	}
@@ -296,6 +380,20 @@ func shim() (returnExitCode int) {
 		panic(err)
 	}
 
+	if timedOut {
+		fmt.Fprintf(os.Stderr, "command timed out after %s\n", timeout)
+		if err := os.WriteFile(timeoutPath, []byte(fmt.Sprintf("%d", int(timeout.Seconds()))), 0o600); err != nil {
+			panic(err)
+		}
+		// a timeout is never expected, so don't let expectNonZeroExit hide it
+		return exitCode
+	}
+
+	if expectNonZeroExit {
+		// the exit code has been recorded; let the caller decide what it means
+		return 0
+	}
+
 	return exitCode
 }
 
@@ -408,11 +506,13 @@ func setupBundle() int {
 	}
 
 	var hostsFilePath string
+	var resolvConfPath string
 	for i, mnt := range spec.Mounts {
 		switch mnt.Destination {
 		case "/etc/hosts":
 			hostsFilePath = mnt.Source
 		case "/etc/resolv.conf":
+			resolvConfPath = mnt.Source
 			if len(searchDomains) == 0 {
 				break
 			}
@@ -433,10 +533,13 @@ func setupBundle() int {
 			}
 
 			spec.Mounts[i].Source = newResolvPath
+			resolvConfPath = newResolvPath
 		}
 	}
 
 	var gpuParams string
+	var networkMode core.NetworkMode
+	var networkAllow []string
 	keepEnv := []string{}
 	for _, env := range spec.Process.Env {
 		switch {
@@ -484,6 +587,11 @@ func setupBundle() int {
 				fmt.Fprintln(os.Stderr, "host alias:", err)
 				return errorExitCode
 			}
+		case strings.HasPrefix(env, core.ShimNetworkModeEnvVar+"="):
+			// NB: don't keep these env vars, they're only for the bundling step
+			networkMode = core.NetworkMode(strings.TrimPrefix(env, core.ShimNetworkModeEnvVar+"="))
+		case strings.HasPrefix(env, core.ShimNetworkAllowEnvVar+"="):
+			networkAllow = strings.Split(strings.TrimPrefix(env, core.ShimNetworkAllowEnvVar+"="), ",")
 		case strings.HasPrefix(env, "_EXPERIMENTAL_DAGGER_GPU_PARAMS"):
 			splits := strings.Split(env, "=")
 			gpuParams = splits[1]
@@ -497,6 +605,22 @@ func setupBundle() int {
 		spec.Process.Env = append(spec.Process.Env, fmt.Sprintf("NVIDIA_VISIBLE_DEVICES=%s", gpuParams))
 	}
 
+	if execMetadata.ResourceLimits != nil {
+		applyResourceLimits(&spec, execMetadata.ResourceLimits)
+	}
+
+	if networkMode != "" && networkMode != core.NetworkModeDefault {
+		if err := restrictNetwork(&spec, networkMode, networkAllow, resolvConfPath, searchDomains); err != nil {
+			fmt.Fprintln(os.Stderr, "restrict network:", err)
+			return errorExitCode
+		}
+	}
+
+	if isDaggerExec && execMetadata.Timeout > 0 {
+		// the shim enforces the timeout, since it's the one waiting on the command
+		spec.Process.Env = append(spec.Process.Env, fmt.Sprintf("%s=%d", core.ShimTimeoutEnvVar, execMetadata.Timeout))
+	}
+
 	// write the updated config
 	configBytes, err = json.Marshal(spec)
 	if err != nil {
@@ -564,26 +688,9 @@ func appendHostAlias(hostsFilePath string, env string, searchDomains []string) e
 		return fmt.Errorf("malformed host alias: %s", env)
 	}
 
-	var ips []net.IP
-	var errs error
-	for _, domain := range append([]string{""}, searchDomains...) {
-		qualified := target
-
-		if domain != "" {
-			qualified += "." + domain
-		}
-
-		var err error
-		ips, err = net.LookupIP(qualified)
-		if err == nil {
-			errs = nil // ignore prior failures
-			break
-		}
-
-		errs = errors.Join(errs, err)
-	}
-	if errs != nil {
-		return errs
+	ips, err := lookupHost(target, searchDomains)
+	if err != nil {
+		return err
 	}
 
 	hostsFile, err := os.OpenFile(hostsFilePath, os.O_APPEND|os.O_WRONLY, 0o777)
@@ -600,6 +707,26 @@ func appendHostAlias(hostsFilePath string, env string, searchDomains []string) e
 	return hostsFile.Close()
 }
 
+// lookupHost resolves a hostname, trying each of the search domains in turn.
+func lookupHost(host string, searchDomains []string) ([]net.IP, error) {
+	var errs error
+	for _, domain := range append([]string{""}, searchDomains...) {
+		qualified := host
+
+		if domain != "" {
+			qualified += "." + domain
+		}
+
+		ips, err := net.LookupIP(qualified)
+		if err == nil {
+			return ips, nil
+		}
+
+		errs = errors.Join(errs, err)
+	}
+	return nil, errs
+}
+
 // nolint: unparam
 func execRunc() int {
 	args := []string{runcPath}
@@ -622,6 +749,18 @@ func internalEnv(name string) (string, bool) {
 	return val, true
 }
 
+// lockedWriter serializes writes from multiple goroutines to w.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
 func runWithNesting(ctx context.Context, cmd *exec.Cmd) error {
 	if _, found := internalEnv("_DAGGER_ENABLE_NESTING"); !found {
 		// no nesting; run as normal
@@ -703,6 +842,60 @@ func runWithNesting(ctx context.Context, cmd *exec.Cmd) error {
 	return nil
 }
 
+// applyResourceLimits sets the cgroup limits and rlimits in the container's
+// spec, overriding any limits already set.
+func applyResourceLimits(spec *specs.Spec, limits *buildkit.ExecResourceLimits) {
+	if spec.Linux == nil {
+		spec.Linux = &specs.Linux{}
+	}
+	if spec.Linux.Resources == nil {
+		spec.Linux.Resources = &specs.LinuxResources{}
+	}
+	resources := spec.Linux.Resources
+
+	if limits.CPUs > 0 {
+		period := uint64(100000)
+		quota := int64(limits.CPUs * float64(period))
+		if resources.CPU == nil {
+			resources.CPU = &specs.LinuxCPU{}
+		}
+		resources.CPU.Period = &period
+		resources.CPU.Quota = &quota
+	}
+
+	if limits.Memory > 0 {
+		memory := limits.Memory
+		if resources.Memory == nil {
+			resources.Memory = &specs.LinuxMemory{}
+		}
+		resources.Memory.Limit = &memory
+		// don't let the limit be worked around by swapping
+		resources.Memory.Swap = &memory
+	}
+
+	if limits.Pids > 0 {
+		resources.Pids = &specs.LinuxPids{Limit: limits.Pids}
+	}
+
+	for _, ulimit := range limits.Ulimits {
+		rlimit := specs.POSIXRlimit{
+			Type: "RLIMIT_" + strings.ToUpper(ulimit.Name),
+			Soft: uint64(ulimit.Soft),
+			Hard: uint64(ulimit.Hard),
+		}
+		var replaced bool
+		for i, existing := range spec.Process.Rlimits {
+			if existing.Type == rlimit.Type {
+				spec.Process.Rlimits[i] = rlimit
+				replaced = true
+			}
+		}
+		if !replaced {
+			spec.Process.Rlimits = append(spec.Process.Rlimits, rlimit)
+		}
+	}
+}
+
 func replaceSearch(dst io.Writer, resolv string, searchDomains []string) error {
 	src, err := os.Open(resolv)
 	if err != nil {
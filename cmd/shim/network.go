@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/dagger/dagger/core"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+)
+
+// restrictNetwork installs firewall rules in the container's network
+// namespace so that it can only reach the destinations allowed by the network
+// mode. It must be called before the container is started.
+func restrictNetwork(
+	spec *specs.Spec,
+	mode core.NetworkMode,
+	allow []string,
+	resolvConfPath string,
+	searchDomains []string,
+) error {
+	var netnsPath string
+	if spec.Linux != nil {
+		for _, ns := range spec.Linux.Namespaces {
+			if ns.Type == specs.NetworkNamespace {
+				netnsPath = ns.Path
+			}
+		}
+	}
+	if netnsPath == "" {
+		return fmt.Errorf("container has no network namespace to restrict")
+	}
+
+	var allowed []*net.IPNet
+	var nameservers []net.IP
+	switch mode {
+	case core.NetworkModeNone:
+	case core.NetworkModeServicesOnly:
+		var err error
+		nameservers, err = resolvNameservers(resolvConfPath)
+		if err != nil {
+			return fmt.Errorf("read nameservers: %w", err)
+		}
+
+		for _, dest := range allow {
+			ipNets, err := resolveDestination(dest, searchDomains)
+			if err != nil {
+				return fmt.Errorf("resolve allowed destination %s: %w", dest, err)
+			}
+			allowed = append(allowed, ipNets...)
+		}
+	default:
+		return fmt.Errorf("unknown network mode %q", mode)
+	}
+
+	return inNetNS(netnsPath, func() error {
+		if err := iptablesRestore("iptables-restore", egressRules(false, allowed, nameservers)); err != nil {
+			return err
+		}
+		if _, err := os.Stat("/proc/net/if_inet6"); err != nil {
+			// IPv6 is disabled, so there's nothing to restrict
+			return nil
+		}
+		return iptablesRestore("ip6tables-restore", egressRules(true, allowed, nameservers))
+	})
+}
+
+// egressRules returns iptables-restore input that rejects any outgoing
+// traffic apart from loopback, replies, DNS to the given nameservers, and the
+// allowed networks.
+func egressRules(ipv6 bool, allowed []*net.IPNet, nameservers []net.IP) string {
+	rules := new(strings.Builder)
+	fmt.Fprintln(rules, "*filter")
+	fmt.Fprintln(rules, ":INPUT ACCEPT [0:0]")
+	fmt.Fprintln(rules, ":FORWARD ACCEPT [0:0]")
+	fmt.Fprintln(rules, ":OUTPUT ACCEPT [0:0]")
+	fmt.Fprintln(rules, "-A OUTPUT -o lo -j ACCEPT")
+	fmt.Fprintln(rules, "-A OUTPUT -m conntrack --ctstate ESTABLISHED,RELATED -j ACCEPT")
+	for _, ns := range nameservers {
+		if (ns.To4() == nil) != ipv6 {
+			continue
+		}
+		for _, proto := range []string{"udp", "tcp"} {
+			fmt.Fprintf(rules, "-A OUTPUT -d %s -p %s --dport 53 -j ACCEPT\n", ns, proto)
+		}
+	}
+	for _, ipNet := range allowed {
+		if (ipNet.IP.To4() == nil) != ipv6 {
+			continue
+		}
+		fmt.Fprintf(rules, "-A OUTPUT -d %s -j ACCEPT\n", ipNet)
+	}
+	fmt.Fprintln(rules, "-A OUTPUT -j REJECT")
+	fmt.Fprintln(rules, "COMMIT")
+	return rules.String()
+}
+
+func iptablesRestore(bin string, rules string) error {
+	// #nosec G204
+	cmd := exec.Command(bin)
+	cmd.Stdin = strings.NewReader(rules)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", bin, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// inNetNS runs fn with the current thread in the network namespace at path,
+// so that any processes it starts inherit the namespace.
+func inNetNS(path string, fn func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		// NB: the thread is not unlocked if switching back fails, so that it's
+		// discarded rather than reused in the wrong namespace
+		runtime.LockOSThread()
+
+		orig, err := os.Open(fmt.Sprintf("/proc/self/task/%d/ns/net", unix.Gettid()))
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer orig.Close()
+
+		target, err := os.Open(path)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer target.Close()
+
+		if err := unix.Setns(int(target.Fd()), unix.CLONE_NEWNET); err != nil {
+			errCh <- fmt.Errorf("enter network namespace: %w", err)
+			return
+		}
+
+		fnErr := fn()
+
+		if err := unix.Setns(int(orig.Fd()), unix.CLONE_NEWNET); err != nil {
+			errCh <- errors.Join(fnErr, fmt.Errorf("leave network namespace: %w", err))
+			return
+		}
+
+		runtime.UnlockOSThread()
+		errCh <- fnErr
+	}()
+	return <-errCh
+}
+
+// resolveDestination resolves an allowed destination, which is a CIDR, an IP
+// or a hostname, to the networks it covers.
+//
+// Hostnames are only resolved once, before the command starts, so the command
+// can't reach any addresses they resolve to later on, e.g. when a DNS
+// round-robin rotates. This is documented on Container.withNetwork.
+func resolveDestination(dest string, searchDomains []string) ([]*net.IPNet, error) {
+	if strings.Contains(dest, "/") {
+		_, ipNet, err := net.ParseCIDR(dest)
+		if err != nil {
+			return nil, err
+		}
+		return []*net.IPNet{ipNet}, nil
+	}
+
+	ips := []net.IP{net.ParseIP(dest)}
+	if ips[0] == nil {
+		var err error
+		ips, err = lookupHost(dest, searchDomains)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ipNets := make([]*net.IPNet, 0, len(ips))
+	for _, ip := range ips {
+		bits := 128
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 32
+		}
+		ipNets = append(ipNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return ipNets, nil
+}
+
+func resolvNameservers(resolvConfPath string) ([]net.IP, error) {
+	if resolvConfPath == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(resolvConfPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var nameservers []net.IP
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		if ip := net.ParseIP(fields[1]); ip != nil {
+			nameservers = append(nameservers, ip)
+		}
+	}
+	return nameservers, scanner.Err()
+}
@@ -0,0 +1,287 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/containerd/containerd/platforms"
+	"github.com/dagger/dagger/engine"
+	"github.com/dagger/dagger/engine/buildkit"
+	"github.com/docker/distribution/reference"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	slsa02 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/frontend/attestations/sbom"
+	"github.com/moby/buildkit/solver/pb"
+	solverresult "github.com/moby/buildkit/solver/result"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	provenanceBuilderID = "https://dagger.io/engine"
+	provenanceBuildType = "https://mobyproject.org/buildkit@v1"
+
+	// Paths used by SBOM scanners following the BuildKit SBOM protocol:
+	// https://github.com/moby/buildkit/blob/master/docs/attestations/sbom-protocol.md
+	sbomScanSourceDir = "/run/src/core/" + sbom.CoreSBOMName
+	sbomScanOutputDir = "/run/out"
+)
+
+// ProvenanceMode determines how much detail is included in SLSA provenance.
+type ProvenanceMode string
+
+const (
+	// ProvenanceModeMin only records the builder, the build platform and the
+	// images the container was built from.
+	ProvenanceModeMin ProvenanceMode = "PROVENANCE_MIN"
+
+	// ProvenanceModeMax also records the image config and the full build
+	// definition.
+	ProvenanceModeMax ProvenanceMode = "PROVENANCE_MAX"
+)
+
+func (mode ProvenanceMode) EnumName() string {
+	return string(mode)
+}
+
+// ContainerAttestationOpts configures the attestations attached to a
+// published or exported image.
+type ContainerAttestationOpts struct {
+	// SBOMScanner is a container implementing the BuildKit SBOM scanner
+	// protocol, used to generate an SPDX SBOM for each platform.
+	SBOMScanner ContainerID
+
+	// Provenance attaches SLSA provenance with the given level of detail.
+	Provenance ProvenanceMode
+}
+
+// attestations returns the attestations to attach to the container's image.
+func (container *Container) attestations(ctx context.Context, opts ContainerAttestationOpts) ([]buildkit.ContainerAttestation, error) {
+	var atts []buildkit.ContainerAttestation
+
+	if opts.SBOMScanner != "" {
+		scanner, err := opts.SBOMScanner.Decode()
+		if err != nil {
+			return nil, err
+		}
+		att, err := container.sbomAttestation(ctx, scanner)
+		if err != nil {
+			return nil, fmt.Errorf("sbom: %w", err)
+		}
+		atts = append(atts, att)
+	}
+
+	if opts.Provenance != "" {
+		predicate, err := container.Provenance(opts.Provenance)
+		if err != nil {
+			return nil, fmt.Errorf("provenance: %w", err)
+		}
+		atts = append(atts, buildkit.ContainerAttestation{
+			Reason:        solverresult.AttestationReasonProvenance,
+			PredicateType: slsa02.PredicateSLSAProvenance,
+			Predicate:     predicate,
+		})
+	}
+
+	return atts, nil
+}
+
+// sbomAttestation runs the scanner against the container's rootfs, returning
+// the statements it writes as an attestation bundle.
+func (container *Container) sbomAttestation(ctx context.Context, scanner *Container) (buildkit.ContainerAttestation, error) {
+	cfg := scanner.Config
+	args := append(cloneSlice(cfg.Entrypoint), cfg.Cmd...)
+	if len(args) == 0 {
+		return buildkit.ContainerAttestation{}, fmt.Errorf("scanner has no entrypoint or default args")
+	}
+
+	scannerSt, err := scanner.FSState()
+	if err != nil {
+		return buildkit.ContainerAttestation{}, err
+	}
+	fsSt, err := container.FSState()
+	if err != nil {
+		return buildkit.ContainerAttestation{}, err
+	}
+
+	runOpts := []llb.RunOption{
+		llb.Args(args),
+		llb.AddEnv("BUILDKIT_SCAN_SOURCE", sbomScanSourceDir),
+		llb.AddEnv("BUILDKIT_SCAN_DESTINATION", sbomScanOutputDir),
+		llb.WithCustomName(fmt.Sprintf("generating sbom for %s", platforms.Format(container.Platform))),
+	}
+	for _, env := range cfg.Env {
+		name, val, _ := strings.Cut(env, "=")
+		runOpts = append(runOpts, llb.AddEnv(name, val))
+	}
+	if cfg.WorkingDir != "" {
+		runOpts = append(runOpts, llb.Dir(cfg.WorkingDir))
+	}
+	if cfg.User != "" {
+		runOpts = append(runOpts, llb.User(cfg.User))
+	}
+
+	scan := scannerSt.Run(runOpts...)
+	scan.AddMount("/tmp", llb.Scratch(), llb.Tmpfs())
+	scan.AddMount(sbomScanSourceDir, fsSt, llb.Readonly)
+	outSt := scan.AddMount(sbomScanOutputDir, llb.Scratch())
+
+	def, err := outSt.Marshal(ctx, llb.Platform(scanner.Platform))
+	if err != nil {
+		return buildkit.ContainerAttestation{}, err
+	}
+
+	return buildkit.ContainerAttestation{
+		Reason:        solverresult.AttestationReasonSBOM,
+		PredicateType: intoto.PredicateSPDX,
+		Definition:    def.ToPB(),
+	}, nil
+}
+
+// Provenance returns a SLSA v0.2 provenance predicate describing how the
+// container's rootfs was built.
+//
+// The predicate doesn't include timestamps, so it's the same as the one
+// attached to the container's image when it's published or exported.
+func (container *Container) Provenance(mode ProvenanceMode) ([]byte, error) {
+	switch mode {
+	case ProvenanceModeMin, ProvenanceModeMax:
+	default:
+		return nil, fmt.Errorf("unknown provenance mode %q", mode)
+	}
+
+	var ops []provenanceOp
+	if container.FS != nil {
+		for _, dt := range container.FS.Def {
+			var op pb.Op
+			if err := op.Unmarshal(dt); err != nil {
+				return nil, fmt.Errorf("failed to parse llb proto op: %w", err)
+			}
+			ops = append(ops, provenanceOp{
+				ID: digest.FromBytes(dt).String(),
+				Op: op,
+			})
+		}
+	}
+
+	materials, err := provenanceMaterials(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	predicate := slsa02.ProvenancePredicate{
+		Builder: slsacommon.ProvenanceBuilder{
+			ID: provenanceBuilderID,
+		},
+		BuildType: provenanceBuildType,
+		Invocation: slsa02.ProvenanceInvocation{
+			Environment: provenanceEnvironment{
+				Platform:      platforms.Format(container.Platform),
+				EngineVersion: engine.Version,
+			},
+		},
+		Metadata: &slsa02.ProvenanceMetadata{
+			Completeness: slsa02.ProvenanceComplete{
+				Parameters:  mode == ProvenanceModeMax,
+				Environment: true,
+			},
+		},
+		Materials: materials,
+	}
+
+	if mode == ProvenanceModeMax {
+		predicate.Invocation.Parameters = provenanceParameters{
+			Config: container.Config,
+		}
+		predicate.BuildConfig = provenanceBuildConfig{
+			Definition: ops,
+		}
+	}
+
+	return json.Marshal(predicate)
+}
+
+// ProvenanceFile returns the container's provenance predicate as a file.
+func (container *Container) ProvenanceFile(ctx context.Context, bk *buildkit.Client, svcs *Services, mode ProvenanceMode) (*File, error) {
+	predicate, err := container.Provenance(mode)
+	if err != nil {
+		return nil, err
+	}
+	return NewFileWithContents(ctx, bk, svcs, "provenance.json", predicate, 0o644, nil, container.Pipeline, container.Platform)
+}
+
+type provenanceEnvironment struct {
+	Platform      string `json:"platform"`
+	EngineVersion string `json:"engineVersion,omitempty"`
+}
+
+type provenanceParameters struct {
+	Config any `json:"config"`
+}
+
+type provenanceBuildConfig struct {
+	Definition []provenanceOp `json:"llbDefinition"`
+}
+
+type provenanceOp struct {
+	ID string `json:"id"`
+	Op pb.Op  `json:"op"`
+}
+
+// provenanceMaterials returns the remote sources used by the ops, i.e. images,
+// git repositories and HTTP downloads. Local sources aren't included.
+func provenanceMaterials(ops []provenanceOp) ([]slsacommon.ProvenanceMaterial, error) {
+	seen := map[string]struct{}{}
+	var materials []slsacommon.ProvenanceMaterial
+	for _, op := range ops {
+		src := op.Op.GetSource()
+		if src == nil {
+			continue
+		}
+		scheme, ref, ok := strings.Cut(src.Identifier, "://")
+		if !ok {
+			continue
+		}
+
+		var material slsacommon.ProvenanceMaterial
+		switch scheme {
+		case "docker-image":
+			named, err := reference.ParseNormalizedNamed(ref)
+			if err != nil {
+				return nil, fmt.Errorf("parse image source %q: %w", ref, err)
+			}
+			material.URI = "docker-image://" + named.String()
+			if canonical, ok := named.(reference.Canonical); ok {
+				material.Digest = slsacommon.DigestSet{
+					canonical.Digest().Algorithm().String(): canonical.Digest().Encoded(),
+				}
+			}
+		case "git", "http", "https":
+			material.URI = src.Identifier
+			if checksum, ok := src.Attrs[pb.AttrHTTPChecksum]; ok {
+				if dgst, err := digest.Parse(checksum); err == nil {
+					material.Digest = slsacommon.DigestSet{
+						dgst.Algorithm().String(): dgst.Encoded(),
+					}
+				}
+			}
+		default:
+			continue
+		}
+
+		if _, ok := seen[material.URI]; ok {
+			continue
+		}
+		seen[material.URI] = struct{}{}
+		materials = append(materials, material)
+	}
+
+	sort.Slice(materials, func(i, j int) bool {
+		return materials[i].URI < materials[j].URI
+	})
+	return materials, nil
+}
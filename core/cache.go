@@ -1,19 +1,27 @@
 package core
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"strings"
 
+	"github.com/dagger/dagger/core/pipeline"
 	"github.com/dagger/dagger/core/resourceid"
+	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 )
 
 // CacheVolume is a persistent volume with a globally scoped identifier.
 type CacheVolume struct {
 	Keys []string `json:"keys"`
+
+	// MountID identifies a volume found on the engine whose keys are not known,
+	// e.g. because it was created before the engine last restarted.
+	MountID string `json:"mountID,omitempty"`
 }
 
 var ErrInvalidCacheVolumeID = errors.New("invalid cache ID; create one using cacheVolume")
@@ -34,6 +42,10 @@ func (cache *CacheVolume) Digest() (digest.Digest, error) {
 
 // Sum returns a checksum of the cache tokens suitable for use as a cache key.
 func (cache *CacheVolume) Sum() string {
+	if cache.MountID != "" {
+		return cache.MountID
+	}
+
 	hash := sha256.New()
 	for _, tok := range cache.Keys {
 		_, _ = hash.Write([]byte(tok + "\x00"))
@@ -46,6 +58,92 @@ func (cache *CacheVolume) ID() (CacheVolumeID, error) {
 	return resourceid.Encode(cache)
 }
 
+// Key returns the user defined key of the cache volume, or an empty string if
+// it is not known.
+func (cache *CacheVolume) Key() string {
+	if len(cache.Keys) == 0 {
+		return ""
+	}
+	return cache.Keys[0]
+}
+
+// CacheVolumes returns the cache volumes stored on the engine.
+func CacheVolumes(ctx context.Context, bk *buildkit.Client) ([]*CacheVolume, error) {
+	usages, err := bk.CacheVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]string{}
+	SeenCacheKeys.Range(func(k any, _ any) bool {
+		key := k.(string)
+		keys[NewCache(key).Sum()] = key
+		return true
+	})
+
+	volumes := make([]*CacheVolume, 0, len(usages))
+	for _, usage := range usages {
+		if key, ok := keys[usage.ID]; ok {
+			volumes = append(volumes, NewCache(key))
+		} else {
+			volumes = append(volumes, &CacheVolume{MountID: usage.ID})
+		}
+	}
+	return volumes, nil
+}
+
+// Usage returns the disk usage of the cache volume on the engine. A volume
+// that has never been mounted has a zero usage.
+func (cache *CacheVolume) Usage(ctx context.Context, bk *buildkit.Client) (*buildkit.CacheVolumeUsage, error) {
+	usages, err := bk.CacheVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := cache.Sum()
+	for _, usage := range usages {
+		if usage.ID == sum {
+			return usage, nil
+		}
+	}
+	return &buildkit.CacheVolumeUsage{ID: sum}, nil
+}
+
+// WithContents replaces the contents of the cache volume on the engine with
+// the contents of the given directory. The contents are replaced eagerly,
+// once the volume is no longer mounted by any exec, rather than when the
+// returned cache volume is used; it is the same volume as cache.
+func (cache *CacheVolume) WithContents(ctx context.Context, bk *buildkit.Client, svcs *Services, dir *Directory) (*CacheVolume, error) {
+	detach, _, err := svcs.StartBindings(ctx, bk, dir.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	if err := bk.SetCacheVolumeContents(ctx, cache.Sum(), dir.LLB, dir.Dir); err != nil {
+		return nil, err
+	}
+
+	return cache, nil
+}
+
+// Snapshot returns a directory containing a copy of the current contents of
+// the cache volume on the engine.
+func (cache *CacheVolume) Snapshot(ctx context.Context, bk *buildkit.Client, pipeline pipeline.Path, platform specs.Platform) (*Directory, error) {
+	def, err := bk.CacheVolumeSnapshot(ctx, cache.Sum())
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDirectory(ctx, def, "", pipeline, platform, nil), nil
+}
+
+// Prune wipes the contents of the cache volume on the engine, returning the
+// number of bytes reclaimed.
+func (cache *CacheVolume) Prune(ctx context.Context, bk *buildkit.Client) (int64, error) {
+	return bk.PruneCacheVolume(ctx, cache.Sum())
+}
+
 // CacheSharingMode is a string deriving from CacheSharingMode enum
 // it can take values: SHARED, PRIVATE, LOCKED
 type CacheSharingMode string
@@ -1,10 +1,12 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
@@ -19,8 +21,10 @@ import (
 	"github.com/containerd/containerd/platforms"
 
 	"github.com/docker/distribution/reference"
+	units "github.com/docker/go-units"
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	dockerspec "github.com/moby/buildkit/exporter/containerimage/image"
 	"github.com/moby/buildkit/frontend/dockerui"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/identity"
@@ -46,7 +50,17 @@ type Container struct {
 	FS *pb.Definition `json:"fs"`
 
 	// Image configuration (env, workdir, etc)
-	Config specs.ImageConfig `json:"cfg"`
+	Config dockerspec.ImageConfig `json:"cfg"`
+
+	// OCI annotations to set on the image manifest when published or exported.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// OCI annotations to set on the image index when published or exported.
+	IndexAnnotations map[string]string `json:"indexAnnotations,omitempty"`
+
+	// Root filesystems at each layer boundary. The layers between consecutive
+	// boundaries are squashed when the image is published or exported.
+	LayerBoundaries []*pb.Definition `json:"layerBoundaries,omitempty"`
 
 	// List of GPU devices that will be exposed to the container
 	EnabledGPUs []string `json:"enabledGPUs,omitempty"`
@@ -75,6 +89,16 @@ type Container struct {
 	// Ports to expose from the container.
 	Ports []Port `json:"ports,omitempty"`
 
+	// Default resource limits for commands run in the container.
+	ResourceLimits *buildkit.ExecResourceLimits `json:"resourceLimits,omitempty"`
+
+	// Network access allowed to commands run in the container.
+	NetworkMode NetworkMode `json:"networkMode,omitempty"`
+
+	// Destinations reachable in addition to bound services when NetworkMode
+	// is NETWORK_SERVICES_ONLY.
+	NetworkAllow []string `json:"networkAllow,omitempty"`
+
 	// Services to start before running the container.
 	Services ServiceBindings `json:"services,omitempty"`
 
@@ -88,6 +112,11 @@ func (container *Container) PBDefinitions() ([]*pb.Definition, error) {
 	if container.FS != nil {
 		defs = append(defs, container.FS)
 	}
+	for _, boundary := range container.LayerBoundaries {
+		if boundary != nil {
+			defs = append(defs, boundary)
+		}
+	}
 	for _, mnt := range container.Mounts {
 		if mnt.Source != nil {
 			defs = append(defs, mnt.Source)
@@ -131,10 +160,26 @@ func (container *Container) Clone() *Container {
 	cp.Config.Cmd = cloneSlice(cp.Config.Cmd)
 	cp.Config.Volumes = cloneMap(cp.Config.Volumes)
 	cp.Config.Labels = cloneMap(cp.Config.Labels)
+	cp.Config.OnBuild = cloneSlice(cp.Config.OnBuild)
+	cp.Config.Shell = cloneSlice(cp.Config.Shell)
+	if cp.Config.Healthcheck != nil {
+		healthcheck := *cp.Config.Healthcheck
+		healthcheck.Test = cloneSlice(healthcheck.Test)
+		cp.Config.Healthcheck = &healthcheck
+	}
+	cp.Annotations = cloneMap(cp.Annotations)
+	cp.IndexAnnotations = cloneMap(cp.IndexAnnotations)
+	cp.LayerBoundaries = cloneSlice(cp.LayerBoundaries)
 	cp.Mounts = cloneSlice(cp.Mounts)
 	cp.Secrets = cloneSlice(cp.Secrets)
 	cp.Sockets = cloneSlice(cp.Sockets)
 	cp.Ports = cloneSlice(cp.Ports)
+	if cp.ResourceLimits != nil {
+		limits := *cp.ResourceLimits
+		limits.Ulimits = cloneSlice(limits.Ulimits)
+		cp.ResourceLimits = &limits
+	}
+	cp.NetworkAllow = cloneSlice(cp.NetworkAllow)
 	cp.Services = cloneSlice(cp.Services)
 	cp.Pipeline = cloneSlice(cp.Pipeline)
 	return &cp
@@ -271,7 +316,7 @@ func (mnts ContainerMounts) With(newMnt ContainerMount) ContainerMounts {
 	return mntsCp
 }
 
-func (container *Container) From(ctx context.Context, bk *buildkit.Client, addr string) (*Container, error) {
+func (container *Container) From(ctx context.Context, bk *buildkit.Client, addr string, verifyWith string) (*Container, error) {
 	container = container.Clone()
 
 	platform := container.Platform
@@ -292,7 +337,7 @@ func (container *Container) From(ctx context.Context, bk *buildkit.Client, addr
 		ResolveMode: llb.ResolveModeDefault.String(),
 	})
 	if err != nil {
-		return nil, err
+		return nil, imageResolveError(addr, err)
 	}
 
 	digested, err := reference.WithDigest(refName, digest)
@@ -300,7 +345,13 @@ func (container *Container) From(ctx context.Context, bk *buildkit.Client, addr
 		return nil, err
 	}
 
-	var imgSpec specs.Image
+	if verifyWith != "" {
+		if err := VerifyImageSignature(ctx, bk, digested.String(), []byte(verifyWith)); err != nil {
+			return nil, err
+		}
+	}
+
+	var imgSpec dockerspec.Image
 	if err := json.Unmarshal(cfgBytes, &imgSpec); err != nil {
 		return nil, err
 	}
@@ -317,6 +368,9 @@ func (container *Container) From(ctx context.Context, bk *buildkit.Client, addr
 
 	container.FS = def.ToPB()
 
+	// layer boundaries only apply to the filesystem they were set on
+	container.LayerBoundaries = nil
+
 	// associate vertexes to the 'from' sub-pipeline
 	buildkit.RecordVertexes(subRecorder, container.FS)
 
@@ -461,10 +515,11 @@ func (container *Container) buildUncached(
 
 	container.FS = def.ToPB()
 	container.FS.Source = nil
+	container.LayerBoundaries = nil
 
 	cfgBytes, found := res.Metadata[exptypes.ExporterImageConfigKey]
 	if found {
-		var imgSpec specs.Image
+		var imgSpec dockerspec.Image
 		if err := json.Unmarshal(cfgBytes, &imgSpec); err != nil {
 			return nil, err
 		}
@@ -499,6 +554,7 @@ func (container *Container) WithRootFS(ctx context.Context, dir *Directory) (*Co
 	}
 
 	container.FS = def.ToPB()
+	container.LayerBoundaries = nil
 
 	container.Services.Merge(dir.Services)
 
@@ -993,16 +1049,65 @@ func (container *Container) writeToPath(ctx context.Context, bk *buildkit.Client
 	return container.withMounted(ctx, bk, mount.Target, dir.LLB, mount.SourcePath, nil, "", false)
 }
 
-func (container *Container) ImageConfig(ctx context.Context) (specs.ImageConfig, error) {
+func (container *Container) ImageConfig(ctx context.Context) (dockerspec.ImageConfig, error) {
 	return container.Config, nil
 }
 
-func (container *Container) UpdateImageConfig(ctx context.Context, updateFn func(specs.ImageConfig) specs.ImageConfig) (*Container, error) {
+func (container *Container) UpdateImageConfig(ctx context.Context, updateFn func(dockerspec.ImageConfig) dockerspec.ImageConfig) (*Container, error) {
 	container = container.Clone()
 	container.Config = updateFn(container.Config)
 	return container, nil
 }
 
+// WithImageConfig applies a JSON merge patch (RFC 7386) to the image config.
+func (container *Container) WithImageConfig(ctx context.Context, patch []byte) (*Container, error) {
+	current, err := json.Marshal(container.Config)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := applyMergePatch(current, patch)
+	if err != nil {
+		return nil, fmt.Errorf("patch image config: %w", err)
+	}
+
+	var cfg dockerspec.ImageConfig
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid image config: %w", err)
+	}
+
+	return container.UpdateImageConfig(ctx, func(dockerspec.ImageConfig) dockerspec.ImageConfig {
+		return cfg
+	})
+}
+
+func (container *Container) WithAnnotation(ctx context.Context, name, value string, index bool) (*Container, error) {
+	container = container.Clone()
+	if index {
+		if container.IndexAnnotations == nil {
+			container.IndexAnnotations = map[string]string{}
+		}
+		container.IndexAnnotations[name] = value
+	} else {
+		if container.Annotations == nil {
+			container.Annotations = map[string]string{}
+		}
+		container.Annotations[name] = value
+	}
+	return container, nil
+}
+
+func (container *Container) WithoutAnnotation(ctx context.Context, name string, index bool) (*Container, error) {
+	container = container.Clone()
+	if index {
+		delete(container.IndexAnnotations, name)
+	} else {
+		delete(container.Annotations, name)
+	}
+	return container, nil
+}
+
 func (container *Container) WithPipeline(ctx context.Context, name, description string, labels []pipeline.Label) (*Container, error) {
 	container = container.Clone()
 
@@ -1065,6 +1170,40 @@ func (container *Container) WithExec(ctx context.Context, bk *buildkit.Client, p
 		runOpts = append(runOpts, llb.AddEnv("_DAGGER_ENABLE_NESTING_IN_SAME_SESSION", ""))
 	}
 
+	if opts.ExpectNonZeroExit {
+		runOpts = append(runOpts, llb.AddEnv(ShimExpectNonZeroExitEnvVar, ""))
+	}
+
+	if opts.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
+	}
+
+	var limits buildkit.ExecResourceLimits
+	if container.ResourceLimits != nil {
+		limits = *container.ResourceLimits
+	}
+	execLimits, err := opts.ExecResourceLimits()
+	if err != nil {
+		return nil, err
+	}
+	limits = limits.Merge(execLimits)
+
+	if opts.Timeout > 0 || !limits.IsZero() {
+		// NB: passed along with the uncached metadata so that changing limits
+		// doesn't bust the cache
+		execMD := buildkit.ContainerExecUncachedMetadata{
+			Timeout: opts.Timeout,
+		}
+		if !limits.IsZero() {
+			execMD.ResourceLimits = &limits
+		}
+		ftpProxy, err := execMD.ToPBFtpProxyVal()
+		if err != nil {
+			return nil, err
+		}
+		runOpts = append(runOpts, llb.WithProxy(llb.ProxyEnv{FTPProxy: ftpProxy}))
+	}
+
 	metaSt, metaSourcePath := metaMount(opts.Stdin)
 
 	// create /dagger mount point for the shim to write to
@@ -1086,6 +1225,23 @@ func (container *Container) WithExec(ctx context.Context, bk *buildkit.Client, p
 		}
 	}
 
+	if container.NetworkMode != "" {
+		// NB: set as env vars rather than uncached metadata, since the network
+		// a command can reach may change its result
+		runOpts = append(runOpts, llb.AddEnv(ShimNetworkModeEnvVar, string(container.NetworkMode)))
+
+		if container.NetworkMode == NetworkModeServicesOnly {
+			allow := []string{}
+			for _, bnd := range container.Services {
+				allow = append(allow, bnd.Hostname)
+			}
+			allow = append(allow, container.NetworkAllow...)
+			if len(allow) > 0 {
+				runOpts = append(runOpts, llb.AddEnv(ShimNetworkAllowEnvVar, strings.Join(allow, ",")))
+			}
+		}
+	}
+
 	if cfg.User != "" {
 		runOpts = append(runOpts, llb.User(cfg.User))
 	}
@@ -1112,6 +1268,12 @@ func (container *Container) WithExec(ctx context.Context, bk *buildkit.Client, p
 		if name == "_DAGGER_ENABLE_NESTING_IN_SAME_SESSION" && !opts.NestedInSameSession {
 			continue
 		}
+		if name == ShimExpectNonZeroExitEnvVar && !opts.ExpectNonZeroExit {
+			continue
+		}
+		if name == ShimNetworkModeEnvVar || name == ShimNetworkAllowEnvVar {
+			continue
+		}
 
 		runOpts = append(runOpts, llb.AddEnv(name, val))
 	}
@@ -1332,15 +1494,44 @@ func (container *Container) MetaFileContents(ctx context.Context, bk *buildkit.C
 	return string(content), nil
 }
 
+// ExitCode returns the exit code of the last executed command, as recorded by
+// the shim in the meta mount.
+func (container *Container) ExitCode(ctx context.Context, bk *buildkit.Client, svcs *Services, progSock string) (int, error) {
+	contents, err := container.MetaFileContents(ctx, bk, svcs, progSock, "exitCode")
+	if err != nil {
+		return 0, err
+	}
+
+	exitCode, err := strconv.Atoi(strings.TrimSpace(contents))
+	if err != nil {
+		return 0, fmt.Errorf("parse exit code: %w", err)
+	}
+
+	return exitCode, nil
+}
+
 func (container *Container) Publish(
 	ctx context.Context,
 	bk *buildkit.Client,
 	svcs *Services,
-	ref string,
+	refs []string,
 	platformVariants []ContainerID,
 	forcedCompression ImageLayerCompression,
 	mediaTypes ImageMediaTypes,
-) (string, error) {
+	attestations ContainerAttestationOpts,
+) ([]string, error) {
+	if len(refs) == 0 {
+		return nil, errors.New("no addresses to publish to")
+	}
+	refNames := make([]reference.Named, 0, len(refs))
+	for _, ref := range refs {
+		refName, err := reference.ParseNormalizedNamed(ref)
+		if err != nil {
+			return nil, err
+		}
+		refNames = append(refNames, refName)
+	}
+
 	if mediaTypes == "" {
 		// Modern registry implementations support oci types and docker daemons
 		// have been capable of pulling them since 2018:
@@ -1352,43 +1543,51 @@ func (container *Container) Publish(
 	inputByPlatform := map[string]buildkit.ContainerExport{}
 	id, err := container.ID()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	services := ServiceBindings{}
 	for _, variantID := range append([]ContainerID{id}, platformVariants...) {
 		variant, err := variantID.Decode()
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		if variant.FS == nil {
 			continue
 		}
-		st, err := variant.FSState()
+		st, err := variant.imageFSState()
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		def, err := st.Marshal(ctx, llb.Platform(variant.Platform))
 		if err != nil {
-			return "", err
+			return nil, err
+		}
+
+		atts, err := variant.attestations(ctx, attestations)
+		if err != nil {
+			return nil, err
 		}
 
 		platformString := platforms.Format(variant.Platform)
 		if _, ok := inputByPlatform[platformString]; ok {
-			return "", fmt.Errorf("duplicate platform %q", platformString)
+			return nil, fmt.Errorf("duplicate platform %q", platformString)
 		}
 		inputByPlatform[platforms.Format(variant.Platform)] = buildkit.ContainerExport{
-			Definition: def.ToPB(),
-			Config:     variant.Config,
+			Definition:       def.ToPB(),
+			Config:           variant.Config,
+			Attestations:     atts,
+			Annotations:      variant.Annotations,
+			IndexAnnotations: variant.IndexAnnotations,
 		}
 		services.Merge(variant.Services)
 	}
 	if len(inputByPlatform) == 0 {
 		// Could also just ignore and do nothing, airing on side of error until proven otherwise.
-		return "", errors.New("no containers to export")
+		return nil, errors.New("no containers to export")
 	}
 
 	opts := map[string]string{
-		string(exptypes.OptKeyName):     ref,
+		string(exptypes.OptKeyName):     strings.Join(refs, ","),
 		string(exptypes.OptKeyPush):     strconv.FormatBool(true),
 		string(exptypes.OptKeyOCITypes): strconv.FormatBool(mediaTypes == OCIMediaTypes),
 	}
@@ -1399,36 +1598,67 @@ func (container *Container) Publish(
 
 	detach, _, err := svcs.StartBindings(ctx, bk, services)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer detach()
 
 	resp, err := bk.PublishContainerImage(ctx, inputByPlatform, opts)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	refName, err := reference.ParseNormalizedNamed(ref)
+	imageDigest, found := resp[exptypes.ExporterImageDigestKey]
+	if !found {
+		return refs, nil
+	}
+
+	dig, err := digest.Parse(imageDigest)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("parse digest: %w", err)
 	}
+	return digestedRefs(refNames, dig)
+}
 
-	imageDigest, found := resp[exptypes.ExporterImageDigestKey]
-	if found {
-		dig, err := digest.Parse(imageDigest)
+// CopyImage copies the image at src to each of the destinations without
+// unpacking it, returning the fully qualified ref of each copy.
+func CopyImage(ctx context.Context, bk *buildkit.Client, src string, dests []string) ([]string, error) {
+	if len(dests) == 0 {
+		return nil, errors.New("no destinations to copy to")
+	}
+	destNames := make([]reference.Named, 0, len(dests))
+	for _, dest := range dests {
+		destName, err := reference.ParseNormalizedNamed(dest)
 		if err != nil {
-			return "", fmt.Errorf("parse digest: %w", err)
+			return nil, err
 		}
+		destNames = append(destNames, destName)
+	}
+
+	rec := progrock.FromContext(ctx)
 
+	vtx := rec.Vertex(
+		digest.Digest(identity.NewID()),
+		fmt.Sprintf("copy %s to %s", src, strings.Join(dests, ", ")),
+	)
+	dig, err := bk.CopyImage(ctx, src, dests)
+	vtx.Done(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return digestedRefs(destNames, dig)
+}
+
+func digestedRefs(refNames []reference.Named, dig digest.Digest) ([]string, error) {
+	digested := make([]string, 0, len(refNames))
+	for _, refName := range refNames {
 		withDig, err := reference.WithDigest(refName, dig)
 		if err != nil {
-			return "", fmt.Errorf("with digest: %w", err)
+			return nil, fmt.Errorf("with digest: %w", err)
 		}
-
-		return withDig.String(), nil
+		digested = append(digested, withDig.String())
 	}
-
-	return ref, nil
+	return digested, nil
 }
 
 func (container *Container) Export(
@@ -1439,6 +1669,7 @@ func (container *Container) Export(
 	platformVariants []ContainerID,
 	forcedCompression ImageLayerCompression,
 	mediaTypes ImageMediaTypes,
+	attestations ContainerAttestationOpts,
 ) error {
 	if mediaTypes == "" {
 		// Modern registry implementations support oci types and docker daemons
@@ -1462,7 +1693,7 @@ func (container *Container) Export(
 		if variant.FS == nil {
 			continue
 		}
-		st, err := variant.FSState()
+		st, err := variant.imageFSState()
 		if err != nil {
 			return err
 		}
@@ -1472,13 +1703,21 @@ func (container *Container) Export(
 			return err
 		}
 
+		atts, err := variant.attestations(ctx, attestations)
+		if err != nil {
+			return err
+		}
+
 		platformString := platforms.Format(variant.Platform)
 		if _, ok := inputByPlatform[platformString]; ok {
 			return fmt.Errorf("duplicate platform %q", platformString)
 		}
 		inputByPlatform[platforms.Format(variant.Platform)] = buildkit.ContainerExport{
-			Definition: def.ToPB(),
-			Config:     variant.Config,
+			Definition:       def.ToPB(),
+			Config:           variant.Config,
+			Attestations:     atts,
+			Annotations:      variant.Annotations,
+			IndexAnnotations: variant.IndexAnnotations,
 		}
 		services.Merge(variant.Services)
 	}
@@ -1533,7 +1772,7 @@ func (container *Container) AsTarball(
 		if variant.FS == nil {
 			continue
 		}
-		st, err := variant.FSState()
+		st, err := variant.imageFSState()
 		if err != nil {
 			return nil, err
 		}
@@ -1548,8 +1787,10 @@ func (container *Container) AsTarball(
 			return nil, fmt.Errorf("duplicate platform %q", platformString)
 		}
 		inputByPlatform[platforms.Format(variant.Platform)] = buildkit.ContainerExport{
-			Definition: def.ToPB(),
-			Config:     variant.Config,
+			Definition:       def.ToPB(),
+			Config:           variant.Config,
+			Annotations:      variant.Annotations,
+			IndexAnnotations: variant.IndexAnnotations,
 		}
 		services.Merge(variant.Services)
 	}
@@ -1668,6 +1909,7 @@ func (container *Container) Import(
 	}
 
 	container.FS = execDef.ToPB()
+	container.LayerBoundaries = nil
 
 	if release != nil {
 		// eagerly evaluate the OCI reference so Buildkit sets up a long-term lease
@@ -1700,7 +1942,7 @@ func (container *Container) Import(
 		return nil, fmt.Errorf("image archive read image config blob %s: %w", man.Config.Digest, err)
 	}
 
-	var imgSpec specs.Image
+	var imgSpec dockerspec.Image
 	err = json.Unmarshal(configBlob, &imgSpec)
 	if err != nil {
 		return nil, fmt.Errorf("load image config: %w", err)
@@ -1712,6 +1954,15 @@ func (container *Container) Import(
 }
 
 func (container *Container) WithExposedPort(port Port) (*Container, error) {
+	if port.HealthcheckPath != "" {
+		if port.Protocol != NetworkProtocolTCP {
+			return nil, fmt.Errorf("health check path is only supported for TCP ports")
+		}
+		if !strings.HasPrefix(port.HealthcheckPath, "/") {
+			return nil, fmt.Errorf("health check path %q must be absolute", port.HealthcheckPath)
+		}
+	}
+
 	container = container.Clone()
 
 	// replace existing port to avoid duplicates
@@ -1758,7 +2009,132 @@ func (container *Container) WithoutExposedPort(port int, protocol NetworkProtoco
 	return container, nil
 }
 
-func (container *Container) WithServiceBinding(ctx context.Context, svcs *Services, svc *Service, alias string) (*Container, error) {
+// WithHealthcheck sets the HEALTHCHECK of the container's image config, which
+// is run by services started from the container.
+func (container *Container) WithHealthcheck(healthcheck ContainerHealthcheck) (*Container, error) {
+	if len(healthcheck.Args) == 0 {
+		return nil, fmt.Errorf("health check command must not be empty")
+	}
+	if healthcheck.Interval < 0 || healthcheck.Timeout < 0 || healthcheck.Retries < 0 || healthcheck.StartPeriod < 0 {
+		return nil, fmt.Errorf("health check interval, timeout, retries and start period must not be negative")
+	}
+
+	container = container.Clone()
+	container.Config.Healthcheck = healthcheck.HealthConfig()
+	return container, nil
+}
+
+// ContainerResourceLimitOpts are the resource limits that can be set for a
+// command, or for every command run in a container.
+type ContainerResourceLimitOpts struct {
+	// Number of CPUs the command may use (e.g., 1.5)
+	CPULimit float64
+
+	// Maximum memory the command may use (e.g., "512m" or "2g")
+	MemoryLimit string
+
+	// Maximum number of processes the command may run at once
+	PidsLimit int
+
+	// Per-process limits in the form name=soft[:hard] (e.g., "nofile=1024:2048")
+	Ulimits []string
+}
+
+// ExecResourceLimits parses the options into limits for an exec.
+func (opts ContainerResourceLimitOpts) ExecResourceLimits() (buildkit.ExecResourceLimits, error) {
+	var limits buildkit.ExecResourceLimits
+
+	if opts.CPULimit < 0 {
+		return limits, fmt.Errorf("CPU limit must not be negative")
+	}
+	limits.CPUs = opts.CPULimit
+
+	if opts.MemoryLimit != "" {
+		memory, err := units.RAMInBytes(opts.MemoryLimit)
+		if err != nil {
+			return limits, fmt.Errorf("invalid memory limit %q: %w", opts.MemoryLimit, err)
+		}
+		if memory <= 0 {
+			return limits, fmt.Errorf("memory limit must be positive")
+		}
+		limits.Memory = memory
+	}
+
+	if opts.PidsLimit < 0 {
+		return limits, fmt.Errorf("pids limit must not be negative")
+	}
+	limits.Pids = int64(opts.PidsLimit)
+
+	for _, val := range opts.Ulimits {
+		ulimit, err := units.ParseUlimit(val)
+		if err != nil {
+			return limits, fmt.Errorf("invalid ulimit %q: %w", val, err)
+		}
+		limits.Ulimits = append(limits.Ulimits, buildkit.ExecUlimit{
+			Name: ulimit.Name,
+			Soft: ulimit.Soft,
+			Hard: ulimit.Hard,
+		})
+	}
+
+	return limits, nil
+}
+
+// WithResourceLimits sets the default resource limits for subsequent
+// commands, overriding any defaults already set.
+func (container *Container) WithResourceLimits(opts ContainerResourceLimitOpts) (*Container, error) {
+	limits, err := opts.ExecResourceLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	container = container.Clone()
+	if container.ResourceLimits != nil {
+		limits = container.ResourceLimits.Merge(limits)
+	}
+	container.ResourceLimits = &limits
+	return container, nil
+}
+
+// WithNetwork restricts the network access of commands run in the container.
+//
+// The allow list may only be given in NETWORK_SERVICES_ONLY mode, and contains
+// hostnames, IPs or CIDRs (e.g., "proxy.golang.org" or "10.0.0.0/8").
+// Hostnames are pinned to the addresses they resolve to when each command
+// starts.
+func (container *Container) WithNetwork(mode NetworkMode, allow []string) (*Container, error) {
+	switch mode {
+	case NetworkModeDefault, NetworkModeNone, NetworkModeServicesOnly:
+	default:
+		return nil, fmt.Errorf("unknown network mode %q", mode)
+	}
+
+	if len(allow) > 0 && mode != NetworkModeServicesOnly {
+		return nil, fmt.Errorf("allowed destinations require network mode %s", NetworkModeServicesOnly)
+	}
+
+	for _, dest := range allow {
+		if dest == "" || strings.ContainsAny(dest, ", \t\n") {
+			return nil, fmt.Errorf("invalid network destination %q", dest)
+		}
+		if strings.Contains(dest, "/") {
+			if _, _, err := net.ParseCIDR(dest); err != nil {
+				return nil, fmt.Errorf("invalid network destination %q: %w", dest, err)
+			}
+		}
+	}
+
+	container = container.Clone()
+	container.NetworkMode = mode
+	container.NetworkAllow = cloneSlice(allow)
+	if mode == NetworkModeDefault {
+		// the zero value is the default, so that the ID is unchanged
+		container.NetworkMode = ""
+	}
+	return container, nil
+}
+
+func (container *Container) WithServiceBinding(ctx context.Context, svcs *Services, svc *Service, alias string, dependsOn []string) (*Container, error) {
 	container = container.Clone()
 
 	host, err := svc.Hostname(ctx, svcs)
@@ -1771,14 +2147,32 @@ func (container *Container) WithServiceBinding(ctx context.Context, svcs *Servic
 		aliases = AliasSet{alias}
 	}
 
+	var deps []string
+	for _, name := range dependsOn {
+		dep, found := container.Services.Lookup(name)
+		if !found {
+			return nil, fmt.Errorf("service binding %q depends on unknown service binding %q", alias, name)
+		}
+		if dep.Hostname == host {
+			return nil, fmt.Errorf("service binding %q cannot depend on itself", alias)
+		}
+		deps = append(deps, dep.Hostname)
+	}
+
 	container.Services.Merge(ServiceBindings{
 		{
-			Service:  svc,
-			Hostname: host,
-			Aliases:  aliases,
+			Service:   svc,
+			Hostname:  host,
+			Aliases:   aliases,
+			DependsOn: deps,
 		},
 	})
 
+	// catch cycles early rather than when the services are started
+	if _, err := container.Services.StartOrder(); err != nil {
+		return nil, err
+	}
+
 	return container, nil
 }
 
@@ -1791,6 +2185,54 @@ func (container *Container) ImageRefOrErr(ctx context.Context, bk *buildkit.Clie
 	return "", errors.Errorf("Image reference can only be retrieved immediately after the 'Container.From' call. Error in fetching imageRef as the container image is changed")
 }
 
+// ImageDigest returns the digest of the container's image, as published with
+// the default options. The image is exported to the engine's content store
+// without being pushed, so it's cheap once the container has been published.
+func (container *Container) ImageDigest(ctx context.Context, bk *buildkit.Client, svcs *Services) (string, error) {
+	if container.FS == nil {
+		return "", errors.New("cannot compute the digest of an empty container")
+	}
+
+	st, err := container.imageFSState()
+	if err != nil {
+		return "", err
+	}
+	def, err := st.Marshal(ctx, llb.Platform(container.Platform))
+	if err != nil {
+		return "", err
+	}
+
+	detach, _, err := svcs.StartBindings(ctx, bk, container.Services)
+	if err != nil {
+		return "", err
+	}
+	defer detach()
+
+	resp, err := bk.PublishContainerImage(ctx, map[string]buildkit.ContainerExport{
+		platforms.Format(container.Platform): {
+			Definition:       def.ToPB(),
+			Config:           container.Config,
+			Annotations:      container.Annotations,
+			IndexAnnotations: container.IndexAnnotations,
+		},
+	}, map[string]string{
+		string(exptypes.OptKeyOCITypes): strconv.FormatBool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	imageDigest, found := resp[exptypes.ExporterImageDigestKey]
+	if !found {
+		return "", errors.New("image digest not found in export response")
+	}
+	dig, err := digest.Parse(imageDigest)
+	if err != nil {
+		return "", fmt.Errorf("parse digest: %w", err)
+	}
+	return dig.String(), nil
+}
+
 func (container *Container) Service(ctx context.Context, bk *buildkit.Client, progSock string) (*Service, error) {
 	if container.Meta == nil {
 		var err error
@@ -1878,6 +2320,15 @@ type ContainerExecOpts struct {
 	// Grant the process all root capabilities
 	InsecureRootCapabilities bool
 
+	// Record a non-zero exit code in the meta mount instead of failing the exec
+	ExpectNonZeroExit bool
+
+	// Kill the command if it runs for longer than the given number of seconds
+	Timeout int
+
+	// Resource limits for the command, overriding the container's defaults
+	ContainerResourceLimitOpts
+
 	// (Internal-only) If this exec is for a module function, this digest will be set in the
 	// grpc context metadata for any api requests back to the engine. It's used by the API
 	// server to determine which schema to serve and other module context metadata.
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dagger/dagger/core/pipeline"
+	"github.com/dagger/dagger/engine/buildkit"
+)
+
+// DebugContainer returns a container with the filesystem state, environment,
+// mounts and working directory of a failed exec, as identified by the debugID
+// of its EXEC_ERROR.
+//
+// Cache mounts, tmpfs mounts, secrets, sockets and service bindings of the
+// failed exec are not restored.
+func DebugContainer(ctx context.Context, bk *buildkit.Client, pipeline pipeline.Path, debugID string) (*Container, error) {
+	exec, err := bk.FailedExec(ctx, debugID)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := NewContainer("", pipeline, exec.Platform)
+	if err != nil {
+		return nil, err
+	}
+	container.Config.WorkingDir = exec.Cwd
+	container.Config.User = exec.User
+	for _, env := range exec.Env {
+		// internal variables set up for the shim
+		if strings.HasPrefix(env, "_DAGGER_") || strings.HasPrefix(env, "_EXPERIMENTAL_DAGGER_") {
+			continue
+		}
+		container.Config.Env = append(container.Config.Env, env)
+	}
+
+	for _, mnt := range exec.Mounts {
+		if mnt.Dest == "/" {
+			container.FS = mnt.Definition
+			continue
+		}
+		container.Mounts = container.Mounts.With(ContainerMount{
+			Source:     mnt.Definition,
+			SourcePath: mnt.SourcePath,
+			Target:     mnt.Dest,
+			Readonly:   mnt.Readonly,
+		})
+	}
+	return container, nil
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	continuityfs "github.com/containerd/continuity/fs"
 	"github.com/moby/buildkit/client/llb"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/identity"
@@ -206,12 +207,23 @@ func (dir *Directory) Stat(ctx context.Context, bk *buildkit.Client, svcs *Servi
 			}, nil
 		}
 
-		return nil, fmt.Errorf("%s: no such file or directory", src)
+		return nil, &PathNotFoundError{
+			original: fmt.Errorf("%s: no such file or directory", src),
+			Path:     src,
+		}
 	}
 
-	return ref.StatFile(ctx, bkgw.StatRequest{
+	stat, err := ref.StatFile(ctx, bkgw.StatRequest{
 		Path: src,
 	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, &PathNotFoundError{original: err, Path: src}
+		}
+		return nil, err
+	}
+
+	return stat, nil
 }
 
 func (dir *Directory) Entries(ctx context.Context, bk *buildkit.Client, svcs *Services, src string) ([]string, error) {
@@ -334,6 +346,249 @@ func (dir *Directory) Glob(ctx context.Context, bk *buildkit.Client, svcs *Servi
 	return paths, nil
 }
 
+// DirectoryEntry describes a file, directory or other entry in a Directory.
+type DirectoryEntry struct {
+	// Path is the path of the entry relative to the directory.
+	Path string `json:"path"`
+
+	Type FileType `json:"type"`
+
+	// Size is the size of the entry in bytes.
+	Size int `json:"size"`
+
+	// Permissions are the entry's permission bits, e.g. 0o644.
+	Permissions int `json:"permissions"`
+
+	// ModifiedAt is the entry's modification time as a Unix timestamp.
+	ModifiedAt int `json:"modifiedAt"`
+
+	// SymlinkTarget is the target of the entry, if it is a symlink.
+	SymlinkTarget string `json:"symlinkTarget,omitempty"`
+}
+
+func newDirectoryEntry(entryPath string, stat *fstypes.Stat) *DirectoryEntry {
+	mode := fs.FileMode(stat.GetMode())
+
+	// The path is relative to the directory even if the caller passed an
+	// absolute one, e.g. "/src"; strip the leading `/` as Entries does.
+	entryPath = strings.TrimPrefix(path.Clean(entryPath), "/")
+	if entryPath == "" {
+		entryPath = "."
+	}
+
+	return &DirectoryEntry{
+		Path:          entryPath,
+		Type:          fileTypeOf(mode),
+		Size:          int(stat.GetSize_()),
+		Permissions:   int(mode.Perm()),
+		ModifiedAt:    int(time.Unix(0, stat.GetModTime()).Unix()),
+		SymlinkTarget: stat.GetLinkname(),
+	}
+}
+
+// FileType is the type of an entry in a Directory.
+type FileType string
+
+const (
+	FileTypeRegular   FileType = "REGULAR"
+	FileTypeDirectory FileType = "DIR"
+	FileTypeSymlink   FileType = "SYMLINK"
+	FileTypeOther     FileType = "OTHER"
+)
+
+func (fileType FileType) EnumName() string {
+	return string(fileType)
+}
+
+func fileTypeOf(mode fs.FileMode) FileType {
+	switch {
+	case mode.IsRegular():
+		return FileTypeRegular
+	case mode.IsDir():
+		return FileTypeDirectory
+	case mode&fs.ModeSymlink != 0:
+		return FileTypeSymlink
+	default:
+		return FileTypeOther
+	}
+}
+
+// FileChange is a change to a path between two directories.
+type FileChange struct {
+	// Path is the path of the entry relative to the directory.
+	Path string `json:"path"`
+
+	// OldPath is the path the entry was renamed from, if it was renamed.
+	OldPath string `json:"oldPath,omitempty"`
+
+	Kind ChangeKind `json:"kind"`
+
+	// Type is the type of the entry, or its former type if it was deleted.
+	Type FileType `json:"type"`
+
+	// Permissions are the entry's permission bits after the change, or 0 if
+	// it was deleted.
+	Permissions int `json:"permissions"`
+
+	// OldPermissions are the entry's permission bits before the change, or 0
+	// if it was added.
+	OldPermissions int `json:"oldPermissions"`
+
+	// Size is the size of the entry in bytes after the change, or 0 if it was
+	// deleted or is not a regular file.
+	Size int `json:"size"`
+}
+
+// ArchiveFormat is the format of an archive created from a Directory.
+type ArchiveFormat string
+
+const (
+	ArchiveFormatTar ArchiveFormat = "TAR"
+	ArchiveFormatZip ArchiveFormat = "ZIP"
+)
+
+// archiveFormat returns the buildkit archive format for the given format and
+// compression.
+func archiveFormat(format ArchiveFormat, compression ImageLayerCompression) (buildkit.ArchiveFormat, error) {
+	switch format {
+	case ArchiveFormatTar, "":
+		switch compression {
+		case CompressionUncompressed, "":
+			return buildkit.ArchiveTar, nil
+		case CompressionGzip:
+			return buildkit.ArchiveTarGz, nil
+		case CompressionZstd:
+			return buildkit.ArchiveTarZst, nil
+		}
+	case ArchiveFormatZip:
+		switch compression {
+		case "":
+			return buildkit.ArchiveZip, nil
+		default:
+			return "", fmt.Errorf("zip archives are always compressed, compression %s cannot be set", compression)
+		}
+	default:
+		return "", fmt.Errorf("unsupported archive format %s", format)
+	}
+	return "", fmt.Errorf("unsupported archive compression %s", compression)
+}
+
+// StatEntry returns metadata for the entry at the given path.
+func (dir *Directory) StatEntry(ctx context.Context, bk *buildkit.Client, svcs *Services, src string) (*DirectoryEntry, error) {
+	stat, err := dir.Stat(ctx, bk, svcs, src)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDirectoryEntry(src, stat), nil
+}
+
+// Walk returns metadata for every entry under the given path, recursing into
+// subdirectories up to maxDepth levels deep, or without limit if maxDepth is 0.
+//
+// The filter's patterns are matched against paths relative to src. Excluded
+// directories are not recursed into. The returned paths are relative to the
+// directory.
+func (dir *Directory) Walk(ctx context.Context, bk *buildkit.Client, svcs *Services, src string, filter CopyFilter, maxDepth int) ([]*DirectoryEntry, error) {
+	root := path.Join(dir.Dir, src)
+
+	detach, _, err := svcs.StartBindings(ctx, bk, dir.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	res, err := bk.Solve(ctx, bkgw.SolveRequest{
+		Definition: dir.LLB,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := res.SingleRef()
+	if err != nil {
+		return nil, err
+	}
+	// empty directory, i.e. llb.Scratch()
+	if ref == nil {
+		if clean := path.Clean(root); clean == "." || clean == "/" {
+			return []*DirectoryEntry{}, nil
+		}
+		return nil, &PathNotFoundError{
+			original: fmt.Errorf("%s: no such file or directory", src),
+			Path:     src,
+		}
+	}
+
+	var excludes, includes *patternmatcher.PatternMatcher
+	if len(filter.Exclude) > 0 {
+		excludes, err = patternmatcher.New(filter.Exclude)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude patterns: %w", err)
+		}
+	}
+	if len(filter.Include) > 0 {
+		includes, err = patternmatcher.New(filter.Include)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include patterns: %w", err)
+		}
+	}
+
+	entries := []*DirectoryEntry{}
+
+	var walk func(rel string, depth int) error
+	walk = func(rel string, depth int) error {
+		stats, err := ref.ReadDir(ctx, bkgw.ReadDirRequest{
+			Path: path.Join(root, rel),
+		})
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return &PathNotFoundError{original: err, Path: path.Join(src, rel)}
+			}
+			return err
+		}
+
+		for _, stat := range stats {
+			entryRel := path.Join(rel, stat.GetPath())
+
+			if excludes != nil {
+				excluded, err := excludes.MatchesOrParentMatches(entryRel)
+				if err != nil {
+					return err
+				}
+				if excluded {
+					continue
+				}
+			}
+
+			included := true
+			if includes != nil {
+				included, err = includes.MatchesOrParentMatches(entryRel)
+				if err != nil {
+					return err
+				}
+			}
+			if included {
+				entries = append(entries, newDirectoryEntry(path.Join(src, entryRel), stat))
+			}
+
+			if stat.IsDir() && (maxDepth == 0 || depth < maxDepth) {
+				if err := walk(entryRel, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(".", 1); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
 func (dir *Directory) WithNewFile(ctx context.Context, dest string, content []byte, permissions fs.FileMode, ownership *Ownership) (*Directory, error) {
 	dir = dir.Clone()
 
@@ -634,6 +889,137 @@ func (dir *Directory) Diff(ctx context.Context, other *Directory) (*Directory, e
 	return dir, nil
 }
 
+// Changes returns the changes that turn this directory into the other
+// directory. Paths whose contents and metadata only differ by their
+// modification time are not reported, and files that were moved without
+// changing their contents are reported as renames.
+func (dir *Directory) Changes(ctx context.Context, bk *buildkit.Client, svcs *Services, other *Directory) ([]FileChange, error) {
+	detach, _, err := svcs.StartBindings(ctx, bk, dir.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	detachOther, _, err := svcs.StartBindings(ctx, bk, other.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detachOther()
+
+	bkChanges, err := bk.DirectoryChanges(ctx, dir.LLB, dir.Dir, other.LLB, other.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]FileChange, 0, len(bkChanges))
+	for _, bkChange := range bkChanges {
+		change := FileChange{
+			Path:           bkChange.Path,
+			OldPath:        bkChange.OldPath,
+			Type:           fileTypeOf(bkChange.Mode),
+			Permissions:    int(bkChange.Mode.Perm()),
+			OldPermissions: int(bkChange.OldMode.Perm()),
+			Size:           int(bkChange.Size),
+		}
+		switch {
+		case bkChange.OldPath != "":
+			change.Kind = ChangeKindRenamed
+		case bkChange.Kind == continuityfs.ChangeKindAdd:
+			change.Kind = ChangeKindAdded
+		case bkChange.Kind == continuityfs.ChangeKindModify:
+			change.Kind = ChangeKindModified
+		case bkChange.Kind == continuityfs.ChangeKindDelete:
+			change.Kind = ChangeKindDeleted
+			change.Type = fileTypeOf(bkChange.OldMode)
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// AsPatch returns a unified diff of the changes that turn this directory into
+// the other directory, in the format of git diff. Binary files are not
+// diffed, so WithPatch cannot apply changes to them.
+func (dir *Directory) AsPatch(ctx context.Context, bk *buildkit.Client, svcs *Services, other *Directory) (*File, error) {
+	detach, _, err := svcs.StartBindings(ctx, bk, dir.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	detachOther, _, err := svcs.StartBindings(ctx, bk, other.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detachOther()
+
+	const fileName = "changes.patch"
+	def, err := bk.DirectoryPatch(ctx, dir.Platform, dir.LLB, dir.Dir, other.LLB, other.Dir, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return NewFile(ctx, def, fileName, dir.Pipeline, dir.Platform, nil), nil
+}
+
+// WithPatch applies the unified diff in patch to the directory. The paths in
+// the patch are relative to the directory, with their first component
+// stripped as with patch -p1 (e.g., "a/src/main.go" patches src/main.go).
+func (dir *Directory) WithPatch(ctx context.Context, bk *buildkit.Client, svcs *Services, patch *File) (*Directory, error) {
+	detach, _, err := svcs.StartBindings(ctx, bk, dir.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	detachPatch, _, err := svcs.StartBindings(ctx, bk, patch.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detachPatch()
+
+	patchedDef, removed, err := bk.ApplyPatch(ctx, dir.Platform, dir.LLB, dir.Dir, patch.LLB, patch.File)
+	if err != nil {
+		return nil, err
+	}
+
+	dir = dir.Clone()
+
+	st, err := dir.State()
+	if err != nil {
+		return nil, err
+	}
+	var rm *llb.FileAction
+	for _, p := range removed {
+		p = path.Join(dir.Dir, p)
+		if rm == nil {
+			rm = llb.Rm(p, llb.WithAllowNotFound(true))
+		} else {
+			rm = rm.Rm(p, llb.WithAllowNotFound(true))
+		}
+	}
+	if rm != nil {
+		st = st.File(rm)
+	}
+
+	patchedSt, err := defToState(patchedDef)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dir.SetState(ctx, mergeStates(mergeStateInput{
+		Dest:    st,
+		DestDir: dir.Dir,
+		Src:     patchedSt,
+		SrcDir:  "/",
+	})); err != nil {
+		return nil, err
+	}
+
+	return dir, nil
+}
+
 func (dir *Directory) Without(ctx context.Context, path string) (*Directory, error) {
 	dir = dir.Clone()
 
@@ -650,30 +1036,59 @@ func (dir *Directory) Without(ctx context.Context, path string) (*Directory, err
 	return dir, nil
 }
 
+// AsArchive returns an archive of the directory's contents. Entries are
+// written in lexical order with a fixed modification time, so the same
+// contents always produce the same archive.
+func (dir *Directory) AsArchive(ctx context.Context, bk *buildkit.Client, svcs *Services, format ArchiveFormat, compression ImageLayerCompression) (*File, error) {
+	bkFormat, err := archiveFormat(format, compression)
+	if err != nil {
+		return nil, err
+	}
+
+	detach, _, err := svcs.StartBindings(ctx, bk, dir.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	fileName := "archive." + string(bkFormat)
+	def, err := bk.ArchiveDirectory(ctx, dir.Platform, dir.LLB, dir.Dir, fileName, bkFormat)
+	if err != nil {
+		return nil, err
+	}
+	return NewFile(ctx, def, fileName, dir.Pipeline, dir.Platform, nil), nil
+}
+
+// ContentDigest returns a hash of the directory's contents, covering the
+// names, contents, permissions and ownership of its entries but not their
+// modification times. Include and exclude patterns filter the entries that
+// are hashed.
+func (dir *Directory) ContentDigest(ctx context.Context, bk *buildkit.Client, svcs *Services, include, exclude []string) (digest.Digest, error) {
+	detach, _, err := svcs.StartBindings(ctx, bk, dir.Services)
+	if err != nil {
+		return "", err
+	}
+	defer detach()
+
+	return bk.DirectoryDigest(ctx, dir.LLB, dir.Dir, include, exclude)
+}
+
+// Export writes the contents of the directory to destPath on the host. Paths
+// matching the exclude patterns are not written. If mirror is set, any paths
+// under destPath that aren't in the directory are removed too, except for
+// those matching the exclude patterns.
 func (dir *Directory) Export(
 	ctx context.Context,
 	bk *buildkit.Client,
 	host *Host,
 	svcs *Services,
 	destPath string,
+	mirror bool,
+	exclude []string,
 ) (rerr error) {
-	var defPB *pb.Definition
-	if dir.Dir != "" {
-		src, err := dir.State()
-		if err != nil {
-			return err
-		}
-		src = llb.Scratch().File(llb.Copy(src, dir.Dir, ".", &llb.CopyInfo{
-			CopyDirContentsOnly: true,
-		}))
-
-		def, err := src.Marshal(ctx, llb.Platform(dir.Platform))
-		if err != nil {
-			return err
-		}
-		defPB = def.ToPB()
-	} else {
-		defPB = dir.LLB
+	defPB, err := dir.exportDefinition(ctx, exclude, nil)
+	if err != nil {
+		return err
 	}
 
 	rec := progrock.FromContext(ctx)
@@ -690,7 +1105,84 @@ func (dir *Directory) Export(
 	}
 	defer detach()
 
-	return bk.LocalDirExport(ctx, defPB, destPath)
+	return bk.LocalDirExport(ctx, defPB, destPath, mirror, exclude)
+}
+
+// ExportChanges returns the changes that exporting the directory to destPath
+// on the host with the same options would make, without writing anything.
+// Without mirror, nothing is ever deleted, so files that would otherwise be
+// reported as renamed are reported as added.
+func (dir *Directory) ExportChanges(
+	ctx context.Context,
+	bk *buildkit.Client,
+	host *Host,
+	svcs *Services,
+	destPath string,
+	mirror bool,
+	exclude []string,
+) ([]FileChange, error) {
+	// the host's files are imported as owned by root, so do the same here to
+	// only compare ownership that the export would actually change
+	defPB, err := dir.exportDefinition(ctx, exclude, &Ownership{0, 0})
+	if err != nil {
+		return nil, err
+	}
+	exported := NewDirectory(ctx, defPB, "", dir.Pipeline, dir.Platform, dir.Services)
+
+	current, err := host.exportTarget(ctx, bk, destPath, dir.Pipeline, dir.Platform, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := current.Changes(ctx, bk, svcs, exported)
+	if err != nil {
+		return nil, err
+	}
+	if mirror {
+		return changes, nil
+	}
+
+	filtered := changes[:0]
+	for _, change := range changes {
+		switch change.Kind {
+		case ChangeKindDeleted:
+			continue
+		case ChangeKindRenamed:
+			change.Kind = ChangeKindAdded
+			change.OldPath = ""
+			change.OldPermissions = 0
+		}
+		filtered = append(filtered, change)
+	}
+	return filtered, nil
+}
+
+// exportDefinition returns the definition of the contents the directory
+// exports, without the paths matching the exclude patterns and, if owner is
+// set, owned by owner.
+func (dir *Directory) exportDefinition(ctx context.Context, exclude []string, owner *Ownership) (*pb.Definition, error) {
+	if dir.Dir == "" && len(exclude) == 0 && owner == nil {
+		return dir.LLB, nil
+	}
+
+	src, err := dir.State()
+	if err != nil {
+		return nil, err
+	}
+	copyInfo := &llb.CopyInfo{
+		CopyDirContentsOnly: true,
+		ExcludePatterns:     exclude,
+	}
+	if owner != nil {
+		owner.Opt().SetCopyOption(copyInfo)
+	}
+	src = llb.Scratch().File(llb.Copy(src, path.Join("/", dir.Dir), ".", copyInfo))
+
+	def, err := src.Marshal(ctx, llb.Platform(dir.Platform))
+	if err != nil {
+		return nil, err
+	}
+	return def.ToPB(), nil
 }
 
 // Root removes any relative path from the directory.
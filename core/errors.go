@@ -0,0 +1,161 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	cerrdefs "github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes/docker"
+	remoteerrors "github.com/containerd/containerd/remotes/errors"
+)
+
+// Error types are surfaced to clients through the "_type" key of the GraphQL
+// error extensions, along with any structured fields. These codes are part of
+// the API and must not change once released. (EXEC_ERROR and
+// EXEC_TIMEOUT_ERROR are defined by buildkit.ExecError and
+// buildkit.ExecTimeoutError.)
+const (
+	ErrorTypeRegistryAuth  = "REGISTRY_AUTH_ERROR"
+	ErrorTypeImageNotFound = "IMAGE_NOT_FOUND_ERROR"
+	ErrorTypePathNotFound  = "PATH_NOT_FOUND_ERROR"
+	ErrorTypeServiceStart  = "SERVICE_START_ERROR"
+	ErrorTypeServiceHealth = "SERVICE_HEALTH_ERROR"
+	ErrorTypeInvalidInput  = "INVALID_INPUT_ERROR"
+)
+
+// RegistryAuthError is returned when a registry rejects the credentials (or
+// lack thereof) used to access an image.
+type RegistryAuthError struct {
+	original error
+	Address  string
+}
+
+func (e *RegistryAuthError) Error() string {
+	return e.original.Error()
+}
+
+func (e *RegistryAuthError) Unwrap() error {
+	return e.original
+}
+
+func (e *RegistryAuthError) Extensions() map[string]any {
+	return map[string]any{
+		"_type":   ErrorTypeRegistryAuth,
+		"address": e.Address,
+	}
+}
+
+// ImageNotFoundError is returned when an image reference cannot be resolved
+// by its registry.
+type ImageNotFoundError struct {
+	original error
+	Address  string
+}
+
+func (e *ImageNotFoundError) Error() string {
+	return e.original.Error()
+}
+
+func (e *ImageNotFoundError) Unwrap() error {
+	return e.original
+}
+
+func (e *ImageNotFoundError) Extensions() map[string]any {
+	return map[string]any{
+		"_type":   ErrorTypeImageNotFound,
+		"address": e.Address,
+	}
+}
+
+// PathNotFoundError is returned when a path does not exist in a filesystem.
+type PathNotFoundError struct {
+	original error
+	Path     string
+}
+
+func (e *PathNotFoundError) Error() string {
+	return e.original.Error()
+}
+
+func (e *PathNotFoundError) Unwrap() error {
+	return e.original
+}
+
+func (e *PathNotFoundError) Extensions() map[string]any {
+	return map[string]any{
+		"_type": ErrorTypePathNotFound,
+		"path":  e.Path,
+	}
+}
+
+// ServiceStartError is returned when a service's process exits before it
+// became healthy.
+type ServiceStartError struct {
+	original error
+	Hostname string
+	Cmd      []string
+	ExitCode int
+	Output   string
+}
+
+func (e *ServiceStartError) Error() string {
+	return e.original.Error()
+}
+
+func (e *ServiceStartError) Unwrap() error {
+	return e.original
+}
+
+func (e *ServiceStartError) Extensions() map[string]any {
+	return map[string]any{
+		"_type":    ErrorTypeServiceStart,
+		"hostname": e.Hostname,
+		"cmd":      e.Cmd,
+		"exitCode": e.ExitCode,
+		"output":   e.Output,
+	}
+}
+
+// ServiceHealthError is returned when a service's health check fails.
+type ServiceHealthError struct {
+	original error
+	Hostname string
+	Ports    []Port
+}
+
+func (e *ServiceHealthError) Error() string {
+	return e.original.Error()
+}
+
+func (e *ServiceHealthError) Unwrap() error {
+	return e.original
+}
+
+func (e *ServiceHealthError) Extensions() map[string]any {
+	ports := make([]string, 0, len(e.Ports))
+	for _, p := range e.Ports {
+		ports = append(ports, fmt.Sprintf("%d/%s", p.Port, p.Protocol.Network()))
+	}
+	return map[string]any{
+		"_type":    ErrorTypeServiceHealth,
+		"hostname": e.Hostname,
+		"ports":    ports,
+	}
+}
+
+// imageResolveError converts an error from resolving an image address into a
+// typed error, if the cause is recognized.
+func imageResolveError(addr string, err error) error {
+	var statusErr remoteerrors.ErrUnexpectedStatus
+	switch {
+	case errors.Is(err, docker.ErrInvalidAuthorization),
+		errors.As(err, &statusErr) &&
+			(statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden):
+		return &RegistryAuthError{original: err, Address: addr}
+	case cerrdefs.IsNotFound(err):
+		return &ImageNotFoundError{original: err, Address: addr}
+	default:
+		return err
+	}
+}
@@ -141,6 +141,55 @@ func (file *File) Evaluate(ctx context.Context, bk *buildkit.Client, svcs *Servi
 	return err
 }
 
+// Extract extracts the file as a tar (optionally gzip or zstd compressed) or
+// zip archive, detecting its format from its contents.
+func (file *File) Extract(ctx context.Context, bk *buildkit.Client, svcs *Services) (*Directory, error) {
+	detach, _, err := svcs.StartBindings(ctx, bk, file.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	def, err := bk.ExtractArchive(ctx, file.Platform, file.LLB, file.File)
+	if err != nil {
+		return nil, err
+	}
+	return NewDirectory(ctx, def, "/", file.Pipeline, file.Platform, nil), nil
+}
+
+// DigestAlgorithm is a hash algorithm used to compute the digest of a File.
+type DigestAlgorithm string
+
+const (
+	DigestAlgorithmSHA256 DigestAlgorithm = "SHA256"
+	DigestAlgorithmSHA384 DigestAlgorithm = "SHA384"
+	DigestAlgorithmSHA512 DigestAlgorithm = "SHA512"
+)
+
+// ContentDigest returns the digest of the file's contents, computed with the
+// given algorithm.
+func (file *File) ContentDigest(ctx context.Context, bk *buildkit.Client, svcs *Services, algorithm DigestAlgorithm) (digest.Digest, error) {
+	var alg digest.Algorithm
+	switch algorithm {
+	case DigestAlgorithmSHA256, "":
+		alg = digest.SHA256
+	case DigestAlgorithmSHA384:
+		alg = digest.SHA384
+	case DigestAlgorithmSHA512:
+		alg = digest.SHA512
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %s", algorithm)
+	}
+
+	detach, _, err := svcs.StartBindings(ctx, bk, file.Services)
+	if err != nil {
+		return "", err
+	}
+	defer detach()
+
+	return bk.FileDigest(ctx, file.LLB, file.File, alg)
+}
+
 // Contents handles file content retrieval
 func (file *File) Contents(ctx context.Context, bk *buildkit.Client, svcs *Services) ([]byte, error) {
 	detach, _, err := svcs.StartBindings(ctx, bk, file.Services)
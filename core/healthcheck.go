@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/moby/buildkit/client/llb"
+	dockerspec "github.com/moby/buildkit/exporter/containerimage/image"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/identity"
 	"github.com/moby/buildkit/solver/pb"
@@ -34,7 +37,8 @@ func (d *portHealthChecker) Check(ctx context.Context) (err error) {
 
 	args := []string{"check", d.host}
 	for _, port := range d.ports {
-		args = append(args, fmt.Sprintf("%d/%s", port.Port, port.Protocol.Network()))
+		// NB: the shim polls port/tcp/path over HTTP once the port is up
+		args = append(args, fmt.Sprintf("%d/%s%s", port.Port, port.Protocol.Network(), port.HealthcheckPath))
 	}
 
 	// show health-check logs in a --debug vertex
@@ -111,3 +115,265 @@ func (d *portHealthChecker) Check(ctx context.Context) (err error) {
 		return ctx.Err()
 	}
 }
+
+// ContainerHealthcheck is a command run periodically in a service container to
+// determine whether it is healthy, like the HEALTHCHECK instruction of a
+// Dockerfile.
+//
+// It is only used to set the HEALTHCHECK of a container's image config, which
+// is the health check that services actually run.
+type ContainerHealthcheck struct {
+	// Args is the command to run. It is considered healthy if it exits 0.
+	Args []string `json:"args"`
+
+	// Interval is the time to wait between checks, in seconds.
+	Interval int `json:"interval,omitempty"`
+
+	// Timeout is the time after which a single check fails, in seconds.
+	Timeout int `json:"timeout,omitempty"`
+
+	// Retries is the number of consecutive failures after which the service is
+	// unhealthy.
+	Retries int `json:"retries,omitempty"`
+
+	// StartPeriod is the time the service is given to start, in seconds.
+	// Failures during this period do not count towards Retries.
+	StartPeriod int `json:"startPeriod,omitempty"`
+}
+
+// HealthConfig returns the health check as the HEALTHCHECK of an image config.
+func (hc ContainerHealthcheck) HealthConfig() *dockerspec.HealthConfig {
+	return &dockerspec.HealthConfig{
+		Test:        append([]string{"CMD"}, hc.Args...),
+		Interval:    time.Duration(hc.Interval) * time.Second,
+		Timeout:     time.Duration(hc.Timeout) * time.Second,
+		StartPeriod: time.Duration(hc.StartPeriod) * time.Second,
+		Retries:     hc.Retries,
+	}
+}
+
+const (
+	defaultHealthcheckInterval = time.Second
+	defaultHealthcheckTimeout  = 30 * time.Second
+	defaultHealthcheckRetries  = 10
+)
+
+// execHealthcheck is the health check command run in a service container,
+// along with the HEALTHCHECK of the image config it came from.
+type execHealthcheck struct {
+	Args []string
+	dockerspec.HealthConfig
+}
+
+// imageHealthcheck returns the health check configured by the HEALTHCHECK of
+// an image config, or nil if there is none or it is disabled.
+func imageHealthcheck(cfg dockerspec.ImageConfig) (*execHealthcheck, error) {
+	if cfg.Healthcheck == nil || len(cfg.Healthcheck.Test) == 0 {
+		return nil, nil
+	}
+
+	var args []string
+	switch test := cfg.Healthcheck.Test; test[0] {
+	case "NONE":
+		return nil, nil
+	case "CMD":
+		args = test[1:]
+	case "CMD-SHELL":
+		if len(test) != 2 {
+			return nil, fmt.Errorf("health check CMD-SHELL must have exactly one argument, got %d", len(test)-1)
+		}
+		shell := cfg.Shell
+		if len(shell) == 0 {
+			shell = []string{"/bin/sh", "-c"}
+		}
+		args = append(cloneSlice(shell), test[1])
+	default:
+		return nil, fmt.Errorf("unsupported health check test %q", test[0])
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("health check command must not be empty")
+	}
+
+	return &execHealthcheck{
+		Args:         args,
+		HealthConfig: *cfg.Healthcheck,
+	}, nil
+}
+
+func (hc execHealthcheck) interval() time.Duration {
+	if hc.Interval == 0 {
+		return defaultHealthcheckInterval
+	}
+	return hc.Interval
+}
+
+// startInterval is the time to wait between checks during the start period.
+func (hc execHealthcheck) startInterval() time.Duration {
+	if hc.StartInterval == 0 {
+		return hc.interval()
+	}
+	return hc.StartInterval
+}
+
+func (hc execHealthcheck) timeout() time.Duration {
+	if hc.Timeout == 0 {
+		return defaultHealthcheckTimeout
+	}
+	return hc.Timeout
+}
+
+func (hc execHealthcheck) retries() int {
+	if hc.Retries == 0 {
+		return defaultHealthcheckRetries
+	}
+	return hc.Retries
+}
+
+// ServiceHealthStatus is the health of a running service.
+type ServiceHealthStatus string
+
+const (
+	// ServiceHealthy means all of the service's health checks have passed.
+	ServiceHealthy ServiceHealthStatus = "HEALTHY"
+
+	// ServiceUnhealthy means the service's health check command has failed too
+	// many times in a row since the service started.
+	ServiceUnhealthy ServiceHealthStatus = "UNHEALTHY"
+)
+
+func (status ServiceHealthStatus) EnumName() string {
+	return string(status)
+}
+
+// execHealthChecker runs the health check of an image config in a running
+// service container.
+type execHealthChecker struct {
+	ctr   bkgw.Container
+	check execHealthcheck
+	req   bkgw.StartRequest
+	vtx   *progrock.VertexRecorder
+
+	status ServiceHealthStatus
+	l      sync.Mutex
+}
+
+func newExecHealth(ctr bkgw.Container, check execHealthcheck, req bkgw.StartRequest, vtx *progrock.VertexRecorder) *execHealthChecker {
+	req.Args = check.Args
+	req.Tty = false
+	req.Stdin = nil
+	req.Stdout = nopCloser{vtx.Stdout()}
+	req.Stderr = nopCloser{vtx.Stderr()}
+	return &execHealthChecker{
+		ctr:   ctr,
+		check: check,
+		req:   req,
+		vtx:   vtx,
+	}
+}
+
+// Status returns the health of the service as of the last check.
+func (d *execHealthChecker) Status() ServiceHealthStatus {
+	d.l.Lock()
+	defer d.l.Unlock()
+	return d.status
+}
+
+func (d *execHealthChecker) setStatus(status ServiceHealthStatus) {
+	d.l.Lock()
+	defer d.l.Unlock()
+	d.status = status
+}
+
+// WaitHealthy runs the health check until it succeeds, or until it has failed
+// too many times in a row after the start period.
+func (d *execHealthChecker) WaitHealthy(ctx context.Context) error {
+	startPeriodEnd := time.Now().Add(d.check.StartPeriod)
+
+	var failures int
+	for {
+		err := d.run(ctx)
+		if err == nil {
+			d.setStatus(ServiceHealthy)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		interval := d.check.startInterval()
+		if time.Now().After(startPeriodEnd) {
+			failures++
+			interval = d.check.interval()
+		}
+		if failures >= d.check.retries() {
+			d.setStatus(ServiceUnhealthy)
+			return fmt.Errorf("health check failed %d times: %w", failures, err)
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Monitor keeps running the health check until ctx is canceled, updating the
+// service's health status.
+func (d *execHealthChecker) Monitor(ctx context.Context) {
+	var failures int
+	for {
+		select {
+		case <-time.After(d.check.interval()):
+		case <-ctx.Done():
+			return
+		}
+
+		err := d.run(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			failures = 0
+			d.setStatus(ServiceHealthy)
+			continue
+		}
+
+		failures++
+		if failures >= d.check.retries() {
+			d.setStatus(ServiceUnhealthy)
+		}
+	}
+}
+
+func (d *execHealthChecker) run(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, d.check.timeout())
+	defer cancel()
+
+	fmt.Fprintf(d.vtx.Stdout(), "running health check: %s\n", strings.Join(d.check.Args, " "))
+
+	proc, err := d.ctr.Start(ctx, d.req)
+	if err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- proc.Wait()
+	}()
+
+	select {
+	case err := <-exited:
+		if err != nil {
+			fmt.Fprintf(d.vtx.Stderr(), "health check failed: %s\n", err)
+		}
+		return err
+	case <-ctx.Done():
+		// NB: the check's ctx is done, so use a different one to kill it
+		if err := proc.Signal(context.Background(), syscall.SIGKILL); err != nil {
+			return fmt.Errorf("interrupt check: %w", err)
+		}
+		<-exited
+		return fmt.Errorf("health check timed out after %s", d.check.timeout())
+	}
+}
@@ -3,11 +3,14 @@ package core
 import (
 	"context"
 	"fmt"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/dagger/dagger/core/pipeline"
 	"github.com/dagger/dagger/core/socket"
 	"github.com/dagger/dagger/engine/buildkit"
+	"github.com/moby/buildkit/client/llb"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/vito/progrock"
 )
@@ -24,6 +27,16 @@ type CopyFilter struct {
 	Include []string
 }
 
+// IgnoreFilter excludes the paths ignored by ignore files in a host directory.
+type IgnoreFilter struct {
+	// Gitignore excludes the paths ignored by the .gitignore files in the
+	// directory and its subdirectories.
+	Gitignore bool
+	// IgnoreFile excludes the paths ignored by a .dockerignore-style file,
+	// relative to the directory.
+	IgnoreFile string
+}
+
 func (host *Host) Directory(
 	ctx context.Context,
 	bk *buildkit.Client,
@@ -32,6 +45,7 @@ func (host *Host) Directory(
 	pipelineNamePrefix string,
 	platform specs.Platform,
 	filter CopyFilter,
+	ignore IgnoreFilter,
 ) (*Directory, error) {
 	// TODO: enforcement that requester session is granted access to source session at this path
 
@@ -39,13 +53,63 @@ func (host *Host) Directory(
 	pipelineName := fmt.Sprintf("%s %s", pipelineNamePrefix, dirPath)
 	ctx, subRecorder := progrock.WithGroup(ctx, pipelineName, progrock.Weak())
 
-	defPB, err := bk.LocalImport(ctx, subRecorder, platform, dirPath, filter.Exclude, filter.Include)
+	defPB, err := bk.LocalImport(ctx, subRecorder, platform, dirPath, filter.Exclude, filter.Include, ignore.Gitignore, ignore.IgnoreFile)
 	if err != nil {
 		return nil, fmt.Errorf("host directory %s: %w", dirPath, err)
 	}
 	return NewDirectory(ctx, defPB, "", p, platform, nil), nil
 }
 
+// exportTarget returns the current contents of the host directory at dirPath,
+// without the paths matching the exclude patterns. If the directory doesn't
+// exist yet but its parent does, it's returned as an empty directory.
+func (host *Host) exportTarget(
+	ctx context.Context,
+	bk *buildkit.Client,
+	dirPath string,
+	p pipeline.Path,
+	platform specs.Platform,
+	exclude []string,
+) (*Directory, error) {
+	parentPath, base := filepath.Dir(dirPath), filepath.Base(dirPath)
+	if base == "." || base == ".." || base == string(filepath.Separator) {
+		// the path can't be imported from its parent, but it exists anyway
+		return host.Directory(ctx, bk, dirPath, p, "host.directory", platform, CopyFilter{
+			Exclude: exclude,
+		}, IgnoreFilter{})
+	}
+
+	// import the directory through its parent, so that it's fine for it not to
+	// exist
+	filter := CopyFilter{Include: []string{base}}
+	for _, pattern := range exclude {
+		if negated := strings.TrimPrefix(pattern, "!"); negated != pattern {
+			filter.Exclude = append(filter.Exclude, "!"+path.Join(base, negated))
+		} else {
+			filter.Exclude = append(filter.Exclude, path.Join(base, pattern))
+		}
+	}
+	parent, err := host.Directory(ctx, bk, parentPath, p, "host.directory", platform, filter, IgnoreFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := parent.State()
+	if err != nil {
+		return nil, err
+	}
+	st = llb.Scratch().File(llb.Copy(st, base, ".", &llb.CopyInfo{
+		CopyDirContentsOnly: true,
+		AllowWildcard:       true,
+		AllowEmptyWildcard:  true,
+	}))
+	def, err := st.Marshal(ctx, llb.Platform(platform))
+	if err != nil {
+		return nil, err
+	}
+	return NewDirectory(ctx, def.ToPB(), "", p, platform, nil), nil
+}
+
 func (host *Host) File(
 	ctx context.Context,
 	bk *buildkit.Client,
@@ -56,7 +120,7 @@ func (host *Host) File(
 ) (*File, error) {
 	parentDir, err := host.Directory(ctx, bk, filepath.Dir(path), p, "host.file", platform, CopyFilter{
 		Include: []string{filepath.Base(path)},
-	})
+	}, IgnoreFilter{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/containerd/containerd/platforms"
+	"github.com/dagger/dagger/engine/buildkit"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ImageMetadata describes an image in a registry, resolved without pulling
+// any of its layers.
+type ImageMetadata struct {
+	// Ref is the fully qualified ref of the image, including its digest.
+	Ref         string            `json:"ref"`
+	Digest      string            `json:"digest"`
+	MediaType   string            `json:"mediaType"`
+	Annotations map[string]string `json:"annotations"`
+	Manifests   []ImageManifest   `json:"manifests"`
+}
+
+// ImageManifest describes a single platform of an image.
+type ImageManifest struct {
+	Digest      string            `json:"digest"`
+	MediaType   string            `json:"mediaType"`
+	Platform    specs.Platform    `json:"platform"`
+	Annotations map[string]string `json:"annotations"`
+	Config      json.RawMessage   `json:"config"`
+	Layers      []ImageLayer      `json:"layers"`
+}
+
+// ImageLayer describes a layer of an image.
+type ImageLayer struct {
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType"`
+	Size      int    `json:"size"`
+}
+
+// ResolveImageMetadata fetches the metadata of the image at addr.
+func ResolveImageMetadata(ctx context.Context, bk *buildkit.Client, addr string) (*ImageMetadata, error) {
+	img, err := bk.ResolveRemoteImage(ctx, addr)
+	if err != nil {
+		return nil, imageResolveError(addr, err)
+	}
+
+	md := &ImageMetadata{
+		Ref:         img.Ref,
+		Digest:      img.Descriptor.Digest.String(),
+		MediaType:   img.Descriptor.MediaType,
+		Annotations: img.Annotations,
+	}
+	for _, m := range img.Manifests {
+		manifest := ImageManifest{
+			Digest:      m.Descriptor.Digest.String(),
+			MediaType:   m.Descriptor.MediaType,
+			Platform:    m.Platform,
+			Annotations: m.Annotations,
+			Config:      m.Config,
+		}
+		for _, layer := range m.Layers {
+			manifest.Layers = append(manifest.Layers, ImageLayer{
+				Digest:    layer.Digest.String(),
+				MediaType: layer.MediaType,
+				Size:      int(layer.Size),
+			})
+		}
+		md.Manifests = append(md.Manifests, manifest)
+	}
+	return md, nil
+}
+
+// Manifest returns the manifest matching the given platform.
+func (md *ImageMetadata) Manifest(platform specs.Platform) (ImageManifest, error) {
+	matcher := platforms.OnlyStrict(platform)
+	for _, manifest := range md.Manifests {
+		if matcher.Match(manifest.Platform) {
+			return manifest, nil
+		}
+	}
+	return ImageManifest{}, fmt.Errorf("image %s has no manifest for platform %s", md.Ref, platforms.Format(platform))
+}
+
+// ImageConfig parses the manifest's image config.
+func (manifest ImageManifest) ImageConfig() (specs.Image, error) {
+	var img specs.Image
+	err := json.Unmarshal(manifest.Config, &img)
+	return img, err
+}
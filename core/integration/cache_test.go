@@ -120,6 +120,116 @@ func TestCacheVolumeWithSubmount(t *testing.T) {
 	})
 }
 
+func TestCacheVolumeLifecycle(t *testing.T) {
+	t.Parallel()
+	c, ctx := connect(t)
+
+	key := identity.NewID()
+	cache := c.CacheVolume(key)
+
+	size, err := cache.Size(ctx)
+	require.NoError(t, err)
+	require.Zero(t, size)
+
+	_, err = c.Container().From(alpineImage).
+		WithMountedCache("/cache", cache).
+		WithExec([]string{"sh", "-c", "head -c 1048576 /dev/urandom > /cache/data"}).
+		Sync(ctx)
+	require.NoError(t, err)
+
+	volumes, err := c.CacheVolumes(ctx)
+	require.NoError(t, err)
+
+	var found *dagger.CacheVolume
+	for i, volume := range volumes {
+		volumeKey, err := volume.Key(ctx)
+		require.NoError(t, err)
+		if volumeKey == key {
+			found = &volumes[i]
+			break
+		}
+	}
+	require.NotNil(t, found)
+
+	size, err = found.Size(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, size, 1048576)
+
+	lastUsed, err := found.LastUsed(ctx)
+	require.NoError(t, err)
+	require.NotZero(t, lastUsed)
+
+	reclaimed, err := cache.Prune(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, reclaimed, 1048576)
+
+	out, err := c.Container().From(alpineImage).
+		WithMountedCache("/cache", cache).
+		WithEnvVariable("BUST", identity.NewID()).
+		WithExec([]string{"ls", "/cache"}).
+		Stdout(ctx)
+	require.NoError(t, err)
+	require.Empty(t, out)
+}
+
+func TestCacheVolumeContents(t *testing.T) {
+	t.Parallel()
+	c, ctx := connect(t)
+
+	cache := c.CacheVolume(identity.NewID())
+
+	// seed the volume, replacing anything already in it
+	_, err := c.Container().From(alpineImage).
+		WithMountedCache("/cache", cache).
+		WithExec([]string{"sh", "-c", "echo stale > /cache/stale"}).
+		Sync(ctx)
+	require.NoError(t, err)
+
+	seed := c.Directory().
+		WithNewFile("go.sum", "seeded").
+		WithNewFile("pkg/mod/foo", "bar")
+
+	_, err = cache.WithContents(seed).ID(ctx)
+	require.NoError(t, err)
+
+	out, err := c.Container().From(alpineImage).
+		WithMountedCache("/cache", cache).
+		WithEnvVariable("BUST", identity.NewID()).
+		WithExec([]string{"sh", "-c", "find /cache -type f | sort && cat /cache/go.sum"}).
+		Stdout(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "/cache/go.sum\n/cache/pkg/mod/foo\nseeded", out)
+
+	// snapshot the volume after modifying it
+	_, err = c.Container().From(alpineImage).
+		WithMountedCache("/cache", cache).
+		WithEnvVariable("BUST", identity.NewID()).
+		WithExec([]string{"sh", "-c", "echo modified > /cache/go.sum"}).
+		Sync(ctx)
+	require.NoError(t, err)
+
+	// load the snapshot by ID so that it is only taken once
+	snapshotID, err := cache.Snapshot().ID(ctx)
+	require.NoError(t, err)
+	snapshot := c.LoadDirectoryFromID(snapshotID)
+
+	entries, err := snapshot.Entries(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"go.sum", "pkg"}, entries)
+
+	contents, err := snapshot.File("go.sum").Contents(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "modified\n", contents)
+
+	// the snapshot is unaffected by later changes to the volume
+	_, err = cache.WithContents(c.Directory()).ID(ctx)
+	require.NoError(t, err)
+
+	contents, err = snapshot.File("pkg/mod/foo").Contents(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "bar", contents)
+}
+
 func TestLocalImportCacheReuse(t *testing.T) {
 	t.Parallel()
 
@@ -2,9 +2,14 @@ package core
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	_ "embed"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,6 +18,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/containerd/containerd/platforms"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -2513,6 +2519,214 @@ func TestContainerPublish(t *testing.T) {
 	require.Equal(t, "im-a-entrypoint\n", output)
 }
 
+func TestContainerPublishAttestations(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	// a minimal scanner following the BuildKit SBOM protocol
+	scanner := c.Container().
+		From(alpineImage).
+		WithEntrypoint([]string{"sh", "-c", `
+			test -f "$BUILDKIT_SCAN_SOURCE/etc/alpine-release"
+			cat > "$BUILDKIT_SCAN_DESTINATION/sbom.spdx.json" <<-EOF
+			{
+				"_type": "https://in-toto.io/Statement/v0.1",
+				"predicateType": "https://spdx.dev/Document",
+				"predicate": {"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "name": "test"}
+			}
+			EOF
+		`})
+
+	ctr := c.Container().
+		From(alpineImage).
+		WithExec([]string{"touch", "/foo"})
+
+	testRef := registryRef("container-publish-attestations")
+	_, err := ctr.Publish(ctx, testRef, dagger.ContainerPublishOpts{
+		SbomScanner: scanner,
+		Provenance:  dagger.ProvenanceMax,
+	})
+	require.NoError(t, err)
+
+	parsedRef, err := name.ParseReference(testRef, name.Insecure)
+	require.NoError(t, err)
+	idx, err := remote.Index(parsedRef, remote.WithTransport(http.DefaultTransport))
+	require.NoError(t, err)
+	idxManifest, err := idx.IndexManifest()
+	require.NoError(t, err)
+
+	var predicateTypes []string
+	for _, desc := range idxManifest.Manifests {
+		if desc.Annotations["vnd.docker.reference.type"] != "attestation-manifest" {
+			continue
+		}
+		img, err := idx.Image(desc.Digest)
+		require.NoError(t, err)
+		manifest, err := img.Manifest()
+		require.NoError(t, err)
+		for _, layer := range manifest.Layers {
+			predicateTypes = append(predicateTypes, layer.Annotations["in-toto.io/predicate-type"])
+		}
+	}
+	require.ElementsMatch(t, []string{
+		"https://spdx.dev/Document",
+		"https://slsa.dev/provenance/v0.2",
+	}, predicateTypes)
+
+	t.Run("provenance can be read back", func(t *testing.T) {
+		contents, err := ctr.Provenance(dagger.ContainerProvenanceOpts{
+			Mode: dagger.ProvenanceMin,
+		}).Contents(ctx)
+		require.NoError(t, err)
+
+		var provenance struct {
+			BuildType string
+			Materials []struct {
+				URI    string
+				Digest map[string]string
+			}
+			BuildConfig any
+		}
+		require.NoError(t, json.Unmarshal([]byte(contents), &provenance))
+		require.Equal(t, "https://mobyproject.org/buildkit@v1", provenance.BuildType)
+		require.Len(t, provenance.Materials, 1)
+		require.Contains(t, provenance.Materials[0].URI, "docker-image://docker.io/library/alpine")
+		require.NotEmpty(t, provenance.Materials[0].Digest["sha256"])
+		// the build definition is only included in max mode
+		require.Nil(t, provenance.BuildConfig)
+	})
+
+	t.Run("exported", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "image.tar")
+		_, err := ctr.Export(ctx, dest, dagger.ContainerExportOpts{
+			Provenance: dagger.ProvenanceMin,
+		})
+		require.NoError(t, err)
+
+		indexBytes := readTarFile(t, dest, "index.json")
+		var index ocispecs.Index
+		require.NoError(t, json.Unmarshal(indexBytes, &index))
+		indexBytes = readTarFile(t, dest, "blobs/sha256/"+index.Manifests[0].Digest.Encoded())
+		index = ocispecs.Index{}
+		require.NoError(t, json.Unmarshal(indexBytes, &index))
+
+		var found bool
+		for _, desc := range index.Manifests {
+			if desc.Annotations["vnd.docker.reference.type"] == "attestation-manifest" {
+				found = true
+			}
+		}
+		require.True(t, found)
+	})
+}
+
+func TestContainerPublishSigned(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	genKey := func(t *testing.T) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		privDER, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		pubDER, err := x509.MarshalPKIXPublicKey(key.Public())
+		require.NoError(t, err)
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})),
+			string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	}
+	privKey, pubKey := genKey(t)
+	_, otherPubKey := genKey(t)
+
+	ctr := c.Container().
+		From(alpineImage).
+		WithExec([]string{"touch", "/signed"})
+
+	testRef := registryRef("container-publish-signed")
+	pushedRef, err := ctr.Publish(ctx, testRef, dagger.ContainerPublishOpts{
+		SignWith: c.SetSecret("signing-key", privKey),
+	})
+	require.NoError(t, err)
+	require.Contains(t, pushedRef, "@sha256:")
+
+	t.Run("signature is stored like cosign does", func(t *testing.T) {
+		digested, err := name.NewDigest(pushedRef, name.Insecure)
+		require.NoError(t, err)
+		sigRef := digested.Context().Tag(strings.Replace(digested.DigestStr(), ":", "-", 1) + ".sig")
+		img, err := remote.Image(sigRef, remote.WithTransport(http.DefaultTransport))
+		require.NoError(t, err)
+		manifest, err := img.Manifest()
+		require.NoError(t, err)
+		require.Len(t, manifest.Layers, 1)
+		require.EqualValues(t, "application/vnd.dev.cosign.simplesigning.v1+json", manifest.Layers[0].MediaType)
+		require.NotEmpty(t, manifest.Layers[0].Annotations["dev.cosignproject.cosign/signature"])
+
+		layer, err := img.LayerByDigest(manifest.Layers[0].Digest)
+		require.NoError(t, err)
+		rc, err := layer.Compressed()
+		require.NoError(t, err)
+		defer rc.Close()
+		var payload struct {
+			Critical struct {
+				Image struct {
+					DockerManifestDigest string `json:"docker-manifest-digest"`
+				}
+				Type string
+			}
+		}
+		require.NoError(t, json.NewDecoder(rc).Decode(&payload))
+		require.Equal(t, digested.DigestStr(), payload.Critical.Image.DockerManifestDigest)
+		require.Equal(t, "cosign container image signature", payload.Critical.Type)
+	})
+
+	t.Run("verified with the right key", func(t *testing.T) {
+		out, err := c.Container().
+			From(pushedRef, dagger.ContainerFromOpts{VerifyWith: pubKey}).
+			WithExec([]string{"ls", "/signed"}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "/signed\n", out)
+	})
+
+	t.Run("refused with another key", func(t *testing.T) {
+		_, err := c.Container().
+			From(pushedRef, dagger.ContainerFromOpts{VerifyWith: otherPubKey}).
+			Sync(ctx)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no valid signature")
+	})
+
+	t.Run("unsigned image refused", func(t *testing.T) {
+		unsignedRef, err := ctr.Publish(ctx, registryRef("container-publish-unsigned"))
+		require.NoError(t, err)
+
+		_, err = c.Container().
+			From(unsignedRef, dagger.ContainerFromOpts{VerifyWith: pubKey}).
+			Sync(ctx)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not signed")
+	})
+
+	t.Run("private registry", func(t *testing.T) {
+		pushedRef, err := ctr.
+			WithRegistryAuth(
+				privateRegistryHost,
+				"john",
+				c.SetSecret("this-secret", "xFlejaPdjrt25Dvr"),
+			).
+			Publish(ctx, privateRegistryRef("container-publish-signed"), dagger.ContainerPublishOpts{
+				SignWith: c.SetSecret("signing-key", privKey),
+			})
+		require.NoError(t, err)
+
+		_, err = c.Container().
+			From(pushedRef, dagger.ContainerFromOpts{VerifyWith: pubKey}).
+			Sync(ctx)
+		require.NoError(t, err)
+	})
+}
+
 func TestExecFromScratch(t *testing.T) {
 	c, ctx := connect(t)
 
@@ -2852,6 +3066,601 @@ func TestContainerMultiPlatformPublish(t *testing.T) {
 	}
 }
 
+func TestContainerPublishAll(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	ctr := c.Container().
+		From(alpineImage).
+		WithExec([]string{"touch", "/published"})
+
+	testRefs := []string{
+		registryRef("container-publish-all"),
+		registryRef("container-publish-all"),
+		registryRef("container-publish-all-other"),
+	}
+	pushedRefs, err := ctr.PublishAll(ctx, testRefs)
+	require.NoError(t, err)
+	require.Len(t, pushedRefs, len(testRefs))
+
+	_, dgst, ok := strings.Cut(pushedRefs[0], "@")
+	require.True(t, ok)
+	for i, pushedRef := range pushedRefs {
+		require.Equal(t, testRefs[i]+"@"+dgst, pushedRef)
+
+		_, err := c.Container().
+			From(testRefs[i]).
+			WithExec([]string{"ls", "/published"}).
+			Sync(ctx)
+		require.NoError(t, err)
+	}
+}
+
+func TestCopyImage(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	variants := make([]*dagger.Container, 0, len(platformToUname))
+	for platform, uname := range platformToUname {
+		ctr := c.Container(dagger.ContainerOpts{Platform: platform}).
+			From(alpineImage).
+			WithEntrypoint([]string{"echo", uname})
+		variants = append(variants, ctr)
+	}
+
+	srcRef, err := c.Container().PublishAll(ctx, []string{registryRef("copy-image-src")}, dagger.ContainerPublishAllOpts{
+		PlatformVariants: variants,
+	})
+	require.NoError(t, err)
+	_, srcDigest, ok := strings.Cut(srcRef[0], "@")
+	require.True(t, ok)
+
+	destRefs := []string{
+		registryRef("copy-image-dest"),
+		registryRef("copy-image-dest"),
+	}
+	copiedRefs, err := c.CopyImage(ctx, srcRef[0], destRefs)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		destRefs[0] + "@" + srcDigest,
+		destRefs[1] + "@" + srcDigest,
+	}, copiedRefs)
+
+	for _, destRef := range destRefs {
+		parsedRef, err := name.ParseReference(destRef, name.Insecure)
+		require.NoError(t, err)
+		idx, err := remote.Index(parsedRef, remote.WithTransport(http.DefaultTransport))
+		require.NoError(t, err)
+		idxDigest, err := idx.Digest()
+		require.NoError(t, err)
+		require.Equal(t, srcDigest, idxDigest.String())
+		idxManifest, err := idx.IndexManifest()
+		require.NoError(t, err)
+		require.Len(t, idxManifest.Manifests, len(platformToUname))
+	}
+
+	for platform, uname := range platformToUname {
+		output, err := c.Container(dagger.ContainerOpts{Platform: platform}).
+			From(destRefs[0]).
+			WithExec(nil).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uname+"\n", output)
+	}
+
+	t.Run("to private registry", func(t *testing.T) {
+		destRef := privateRegistryRef("copy-image-dest")
+
+		_, err := c.CopyImage(ctx, srcRef[0], []string{destRef})
+		require.Error(t, err)
+
+		_, err = c.Container().
+			WithRegistryAuth(
+				privateRegistryHost,
+				"john",
+				c.SetSecret("this-secret", "xFlejaPdjrt25Dvr"),
+			).
+			Sync(ctx)
+		require.NoError(t, err)
+
+		copiedRefs, err := c.CopyImage(ctx, srcRef[0], []string{destRef})
+		require.NoError(t, err)
+		require.Equal(t, []string{destRef + "@" + srcDigest}, copiedRefs)
+	})
+}
+
+func TestImageMetadata(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	variants := make([]*dagger.Container, 0, len(platformToUname))
+	for platform := range platformToUname {
+		ctr := c.Container(dagger.ContainerOpts{Platform: platform}).
+			From(alpineImage).
+			WithLabel("org.opencontainers.image.title", "image-metadata")
+		variants = append(variants, ctr)
+	}
+
+	testRef := registryRef("image-metadata")
+	pushedRef, err := c.Container().Publish(ctx, testRef, dagger.ContainerPublishOpts{
+		PlatformVariants: variants,
+	})
+	require.NoError(t, err)
+	_, pushedDigest, ok := strings.Cut(pushedRef, "@")
+	require.True(t, ok)
+
+	md := c.ImageMetadata(testRef)
+
+	ref, err := md.Ref(ctx)
+	require.NoError(t, err)
+	require.Equal(t, pushedRef, ref)
+
+	mediaType, err := md.MediaType(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ocispecs.MediaTypeImageIndex, mediaType)
+
+	imgPlatforms, err := md.Platforms(ctx)
+	require.NoError(t, err)
+	require.Len(t, imgPlatforms, len(platformToUname))
+
+	manifests, err := md.Manifests(ctx)
+	require.NoError(t, err)
+	require.Len(t, manifests, len(platformToUname))
+
+	for platform := range platformToUname {
+		manifest := md.Manifest(dagger.ImageMetadataManifestOpts{Platform: platform})
+
+		actual, err := manifest.Platform(ctx)
+		require.NoError(t, err)
+		require.Equal(t, platform, actual)
+
+		labels, err := manifest.Labels(ctx)
+		require.NoError(t, err)
+		var found bool
+		for _, label := range labels {
+			name, err := label.Name(ctx)
+			require.NoError(t, err)
+			if name == "org.opencontainers.image.title" {
+				found = true
+			}
+		}
+		require.True(t, found)
+
+		layers, err := manifest.Layers(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, layers)
+		size, err := layers[0].Size(ctx)
+		require.NoError(t, err)
+		require.Greater(t, size, 0)
+
+		config, err := manifest.Config(ctx)
+		require.NoError(t, err)
+		require.Contains(t, string(config), `"rootfs"`)
+	}
+
+	t.Run("digest", func(t *testing.T) {
+		dgst, err := md.Digest(ctx)
+		require.NoError(t, err)
+		require.Equal(t, pushedDigest, dgst)
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		_, err := c.ImageMetadata(registryRef("image-metadata-missing")).Digest(ctx)
+		require.Error(t, err)
+	})
+}
+
+func TestContainerImageDigest(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	ctr := c.Container().
+		From(alpineImage).
+		WithExec([]string{"touch", "/image-digest"})
+
+	pushedRef, err := ctr.Publish(ctx, registryRef("container-image-digest"))
+	require.NoError(t, err)
+	_, pushedDigest, ok := strings.Cut(pushedRef, "@")
+	require.True(t, ok)
+
+	dgst, err := ctr.ImageDigest(ctx)
+	require.NoError(t, err)
+	require.Equal(t, pushedDigest, dgst)
+}
+
+func TestContainerAnnotations(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	hasAnnotation := func(t *testing.T, labels []dagger.Label, name, value string) bool {
+		t.Helper()
+		for _, label := range labels {
+			n, err := label.Name(ctx)
+			require.NoError(t, err)
+			if n != name {
+				continue
+			}
+			v, err := label.Value(ctx)
+			require.NoError(t, err)
+			return v == value
+		}
+		return false
+	}
+
+	t.Run("manifest", func(t *testing.T) {
+		testRef := registryRef("container-annotations")
+		_, err := c.Container().
+			From(alpineImage).
+			WithAnnotation("org.opencontainers.image.source", "https://example.com/repo").
+			WithAnnotation("com.example.removed", "yes").
+			WithoutAnnotation("com.example.removed").
+			Publish(ctx, testRef)
+		require.NoError(t, err)
+
+		md := c.ImageMetadata(testRef)
+		mediaType, err := md.MediaType(ctx)
+		require.NoError(t, err)
+		require.Equal(t, ocispecs.MediaTypeImageManifest, mediaType)
+
+		annotations, err := md.Manifest().Annotations(ctx)
+		require.NoError(t, err)
+		require.True(t, hasAnnotation(t, annotations, "org.opencontainers.image.source", "https://example.com/repo"))
+		require.False(t, hasAnnotation(t, annotations, "com.example.removed", "yes"))
+	})
+
+	t.Run("index", func(t *testing.T) {
+		testRef := registryRef("container-index-annotations")
+		_, err := c.Container().
+			From(alpineImage).
+			WithAnnotation("org.opencontainers.image.description", "single platform index", dagger.ContainerWithAnnotationOpts{
+				Index: true,
+			}).
+			WithAnnotation("org.opencontainers.image.title", "manifest").
+			Publish(ctx, testRef)
+		require.NoError(t, err)
+
+		md := c.ImageMetadata(testRef)
+		mediaType, err := md.MediaType(ctx)
+		require.NoError(t, err)
+		require.Equal(t, ocispecs.MediaTypeImageIndex, mediaType)
+
+		annotations, err := md.Annotations(ctx)
+		require.NoError(t, err)
+		require.True(t, hasAnnotation(t, annotations, "org.opencontainers.image.description", "single platform index"))
+
+		annotations, err = md.Manifest().Annotations(ctx)
+		require.NoError(t, err)
+		require.True(t, hasAnnotation(t, annotations, "org.opencontainers.image.title", "manifest"))
+	})
+
+	t.Run("export", func(t *testing.T) {
+		ctr := c.Container().
+			From(alpineImage).
+			WithAnnotation("org.opencontainers.image.title", "exported")
+
+		out, err := c.Container().From(alpineImage).
+			WithMountedFile("/image.tar", ctr.AsTarball()).
+			WithExec([]string{"sh", "-c", `mkdir /image && tar -xf /image.tar -C /image && grep -h -o '"org.opencontainers.image.title":"[a-z]*"' /image/blobs/sha256/*`}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Contains(t, out, `"org.opencontainers.image.title":"exported"`)
+	})
+}
+
+func TestContainerImageConfig(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	ctr := c.Container().
+		From(alpineImage).
+		WithImageConfig(`{
+			"StopSignal": "SIGQUIT",
+			"Volumes": {"/data": {}},
+			"Healthcheck": {"Test": ["CMD", "true"], "Interval": 5000000000},
+			"OnBuild": ["RUN echo hello"]
+		}`)
+
+	cfgJSON, err := ctr.ImageConfig(ctx)
+	require.NoError(t, err)
+
+	var cfg struct {
+		Env         []string
+		StopSignal  string
+		Volumes     map[string]struct{}
+		Healthcheck struct {
+			Test     []string
+			Interval time.Duration
+		}
+		OnBuild []string
+	}
+	require.NoError(t, json.Unmarshal([]byte(cfgJSON), &cfg))
+	require.Equal(t, "SIGQUIT", cfg.StopSignal)
+	require.Contains(t, cfg.Volumes, "/data")
+	require.Equal(t, []string{"CMD", "true"}, cfg.Healthcheck.Test)
+	require.Equal(t, 5*time.Second, cfg.Healthcheck.Interval)
+	require.Equal(t, []string{"RUN echo hello"}, cfg.OnBuild)
+	// fields not in the patch are kept
+	require.NotEmpty(t, cfg.Env)
+
+	t.Run("remove fields", func(t *testing.T) {
+		cfgJSON, err := ctr.WithImageConfig(`{"OnBuild": null, "Volumes": {"/data": null}}`).ImageConfig(ctx)
+		require.NoError(t, err)
+		require.NotContains(t, string(cfgJSON), "OnBuild")
+		require.NotContains(t, string(cfgJSON), "/data")
+		require.Contains(t, string(cfgJSON), "SIGQUIT")
+	})
+
+	t.Run("unknown fields", func(t *testing.T) {
+		_, err := ctr.WithImageConfig(`{"StopSignall": "SIGQUIT"}`).ImageConfig(ctx)
+		require.ErrorContains(t, err, "invalid image config")
+	})
+
+	t.Run("published", func(t *testing.T) {
+		testRef := registryRef("container-image-config")
+		pushedRef, err := ctr.Publish(ctx, testRef)
+		require.NoError(t, err)
+
+		config, err := c.ImageMetadata(pushedRef).Manifest().Config(ctx)
+		require.NoError(t, err)
+		require.Contains(t, string(config), `"StopSignal":"SIGQUIT"`)
+		require.Contains(t, string(config), `"OnBuild":["RUN echo hello"]`)
+
+		// the extended fields are kept when pulling the image back
+		cfgJSON, err := c.Container().From(pushedRef).ImageConfig(ctx)
+		require.NoError(t, err)
+		require.Contains(t, string(cfgJSON), `"Healthcheck":{"Test":["CMD","true"],"Interval":5000000000}`)
+	})
+}
+
+func TestContainerDiff(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	base := c.Container().
+		From(alpineImage).
+		WithExec([]string{"sh", "-c", "mkdir -p /data && echo old > /data/modified && echo bye > /data/deleted"})
+
+	ctr := base.
+		WithExec([]string{"sh", "-c", "printf hello > /data/added && echo newer > /data/modified && rm /data/deleted"})
+
+	changes, err := ctr.Diff(ctx, base)
+	require.NoError(t, err)
+
+	kinds := map[string]dagger.ChangeKind{}
+	sizes := map[string]int{}
+	for _, change := range changes {
+		path, err := change.Path(ctx)
+		require.NoError(t, err)
+		kind, err := change.Kind(ctx)
+		require.NoError(t, err)
+		size, err := change.Size(ctx)
+		require.NoError(t, err)
+		kinds[path] = kind
+		sizes[path] = size
+	}
+
+	require.Equal(t, dagger.Added, kinds["/data/added"])
+	require.Equal(t, 5, sizes["/data/added"])
+	require.Equal(t, dagger.Modified, kinds["/data/modified"])
+	require.Equal(t, 6, sizes["/data/modified"])
+	require.Equal(t, dagger.Deleted, kinds["/data/deleted"])
+	require.Equal(t, 0, sizes["/data/deleted"])
+	require.NotContains(t, kinds, "/etc/alpine-release")
+
+	t.Run("against itself", func(t *testing.T) {
+		changes, err := ctr.Diff(ctx, ctr)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+
+	t.Run("against scratch", func(t *testing.T) {
+		changes, err := base.Diff(ctx, c.Container())
+		require.NoError(t, err)
+
+		var found bool
+		for _, change := range changes {
+			path, err := change.Path(ctx)
+			require.NoError(t, err)
+			if path == "/etc/alpine-release" {
+				found = true
+				kind, err := change.Kind(ctx)
+				require.NoError(t, err)
+				require.Equal(t, dagger.Added, kind)
+			}
+		}
+		require.True(t, found)
+	})
+}
+
+func TestContainerLayers(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	base := c.Container().From(alpineImage)
+	baseLayers, err := base.Layers(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, baseLayers)
+
+	ctr := base.
+		WithExec([]string{"sh", "-c", "echo one > /one"}).
+		WithExec([]string{"sh", "-c", "echo two > /two"})
+
+	layers, err := ctr.Layers(ctx)
+	require.NoError(t, err)
+	require.Len(t, layers, len(baseLayers)+2)
+
+	for i, layer := range baseLayers {
+		baseDigest, err := layer.Digest(ctx)
+		require.NoError(t, err)
+		digest, err := layers[i].Digest(ctx)
+		require.NoError(t, err)
+		require.Equal(t, baseDigest, digest)
+	}
+
+	for i, cmd := range []string{"sh -c echo one > /one", "sh -c echo two > /two"} {
+		layer := layers[len(baseLayers)+i]
+
+		createdBy, err := layer.CreatedBy(ctx)
+		require.NoError(t, err)
+		require.Equal(t, cmd, createdBy)
+
+		digest, err := layer.Digest(ctx)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(digest, "sha256:"))
+
+		diffID, err := layer.DiffID(ctx)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(diffID, "sha256:"))
+
+		size, err := layer.Size(ctx)
+		require.NoError(t, err)
+		require.Greater(t, size, 0)
+	}
+
+	t.Run("match published image", func(t *testing.T) {
+		testRef := registryRef("container-layers")
+		_, err := ctr.Publish(ctx, testRef)
+		require.NoError(t, err)
+
+		published, err := c.ImageMetadata(testRef).Manifest().Layers(ctx)
+		require.NoError(t, err)
+		require.Len(t, published, len(layers))
+		for i, layer := range published {
+			publishedDigest, err := layer.Digest(ctx)
+			require.NoError(t, err)
+			digest, err := layers[i].Digest(ctx)
+			require.NoError(t, err)
+			require.Equal(t, publishedDigest, digest)
+		}
+	})
+}
+
+func TestContainerSquash(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	base := c.Container().From(alpineImage)
+	baseLayers, err := base.Layers(ctx)
+	require.NoError(t, err)
+
+	ctr := base.
+		WithExec([]string{"sh", "-c", "echo one > /one"}).
+		WithExec([]string{"sh", "-c", "echo two > /two"}).
+		WithExec([]string{"rm", "/etc/alpine-release"})
+
+	t.Run("from base", func(t *testing.T) {
+		squashed := ctr.Squash(dagger.ContainerSquashOpts{From: base})
+
+		layers, err := squashed.Layers(ctx)
+		require.NoError(t, err)
+		require.Len(t, layers, len(baseLayers)+1)
+
+		out, err := squashed.WithExec([]string{"sh", "-c", "cat /one /two; test ! -e /etc/alpine-release"}).Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "one\ntwo\n", out)
+
+		testRef := registryRef("container-squash")
+		_, err = squashed.Publish(ctx, testRef)
+		require.NoError(t, err)
+
+		published, err := c.ImageMetadata(testRef).Manifest().Layers(ctx)
+		require.NoError(t, err)
+		require.Len(t, published, len(baseLayers)+1)
+
+		// the deletion is part of the squashed layer
+		_, err = c.Container().From(testRef).File("/etc/alpine-release").Contents(ctx)
+		require.Error(t, err)
+	})
+
+	t.Run("everything", func(t *testing.T) {
+		layers, err := ctr.Squash().Layers(ctx)
+		require.NoError(t, err)
+		require.Len(t, layers, 1)
+	})
+}
+
+func TestContainerWithLayerBoundary(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	base := c.Container().From(alpineImage)
+	baseLayers, err := base.Layers(ctx)
+	require.NoError(t, err)
+
+	ctr := base.
+		WithLayerBoundary().
+		WithExec([]string{"sh", "-c", "echo one > /one"}).
+		WithExec([]string{"sh", "-c", "echo two > /two"}).
+		WithLayerBoundary().
+		WithExec([]string{"sh", "-c", "echo three > /three"}).
+		WithExec([]string{"rm", "/one"}).
+		WithLayerBoundary().
+		WithExec([]string{"sh", "-c", "echo four > /four"})
+
+	// the base layers, one layer per pair of boundaries, and the layer after
+	// the last boundary
+	expected := len(baseLayers) + 3
+
+	layers, err := ctr.Layers(ctx)
+	require.NoError(t, err)
+	require.Len(t, layers, expected)
+
+	testRef := registryRef("container-layer-boundary")
+	_, err = ctr.Publish(ctx, testRef)
+	require.NoError(t, err)
+
+	published, err := c.ImageMetadata(testRef).Manifest().Layers(ctx)
+	require.NoError(t, err)
+	require.Len(t, published, expected)
+
+	out, err := c.Container().From(testRef).
+		WithExec([]string{"sh", "-c", "cat /two /three /four; test ! -e /one"}).
+		Stdout(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "two\nthree\nfour\n", out)
+
+	t.Run("export", func(t *testing.T) {
+		out, err := c.Container().From(alpineImage).
+			WithMountedFile("/image.tar", ctr.AsTarball()).
+			WithExec([]string{"sh", "-c", "tar -tf /image.tar | grep -c '^blobs/sha256/.'"}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		// layers, the config and the manifest
+		require.Equal(t, strconv.Itoa(expected+2), strings.TrimSpace(out))
+	})
+
+	t.Run("reset when the filesystem is replaced", func(t *testing.T) {
+		layers, err := ctr.From(alpineImage).
+			WithExec([]string{"sh", "-c", "echo five > /five"}).
+			Layers(ctx)
+		require.NoError(t, err)
+		require.Len(t, layers, len(baseLayers)+1)
+
+		layers, err = ctr.WithRootFS(c.Directory().WithNewFile("five", "five")).Layers(ctx)
+		require.NoError(t, err)
+		require.Len(t, layers, 1)
+
+		src := c.Directory().
+			WithNewFile("Dockerfile",
+				`FROM `+alpineImage+`
+RUN echo five > /five
+`)
+		layers, err = ctr.Build(src).Layers(ctx)
+		require.NoError(t, err)
+		require.Len(t, layers, len(baseLayers)+1)
+	})
+}
+
 func TestContainerMultiPlatformImport(t *testing.T) {
 	c, ctx := connect(t)
 
@@ -2992,6 +3801,219 @@ func TestContainerExecError(t *testing.T) {
 	})
 }
 
+func TestContainerDebugContainer(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connectKeepingFailedExecs(t)
+
+	failing := c.Container().
+		From(alpineImage).
+		WithEnvVariable("FOO", "bar").
+		WithWorkdir("/src").
+		WithMountedDirectory("/mnt", c.Directory().WithNewFile("input", "hello")).
+		WithExec([]string{"sh", "-c", "echo rootfs > /out; echo mount > /mnt/output; exit 1"})
+
+	_, err := failing.Sync(ctx)
+	var exErr *dagger.ExecError
+	require.ErrorAs(t, err, &exErr)
+	require.NotEmpty(t, exErr.DebugID)
+
+	// the same failed exec is only kept once
+	_, err = failing.Stdout(ctx)
+	var againErr *dagger.ExecError
+	require.ErrorAs(t, err, &againErr)
+	require.Equal(t, exErr.DebugID, againErr.DebugID)
+
+	out, err := c.DebugContainer(exErr.DebugID).
+		WithExec([]string{"sh", "-c", "pwd; echo $FOO; cat /out /mnt/input /mnt/output"}).
+		Stdout(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "/src\nbar\nrootfs\nhellomount\n", out)
+
+	_, err = c.DebugContainer("bogus").Sync(ctx)
+	require.ErrorContains(t, err, "not found")
+
+	t.Run("not kept by default", func(t *testing.T) {
+		c, ctx := connect(t)
+
+		_, err := c.Container().
+			From(alpineImage).
+			WithExec([]string{"false"}).
+			Sync(ctx)
+		var exErr *dagger.ExecError
+		require.ErrorAs(t, err, &exErr)
+		require.Empty(t, exErr.DebugID)
+	})
+}
+
+func TestContainerExecExitCode(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	t.Run("zero exit code", func(t *testing.T) {
+		code, err := c.Container().
+			From(alpineImage).
+			WithExec([]string{"true"}).
+			ExitCode(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, code)
+	})
+
+	t.Run("non-zero exit code fails by default", func(t *testing.T) {
+		_, err := c.Container().
+			From(alpineImage).
+			WithExec([]string{"sh", "-c", "exit 3"}).
+			ExitCode(ctx)
+
+		var exErr *dagger.ExecError
+		require.ErrorAs(t, err, &exErr)
+		require.Equal(t, 3, exErr.ExitCode)
+	})
+
+	t.Run("non-zero exit code is recorded when expected", func(t *testing.T) {
+		ctr := c.Container().
+			From(alpineImage).
+			WithExec([]string{"sh", "-c", "echo out; sleep 0.5; echo err >&2; exit 3"}, dagger.ContainerWithExecOpts{
+				ExpectNonZeroExit: true,
+			})
+
+		code, err := ctr.ExitCode(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 3, code)
+
+		stdout, err := ctr.Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "out\n", stdout)
+
+		stderr, err := ctr.Stderr(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "err\n", stderr)
+
+		combined, err := ctr.CombinedOutput(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "out\nerr\n", combined)
+	})
+
+	t.Run("zero exit code is recorded when non-zero is expected", func(t *testing.T) {
+		code, err := c.Container().
+			From(alpineImage).
+			WithExec([]string{"true"}, dagger.ContainerWithExecOpts{
+				ExpectNonZeroExit: true,
+			}).
+			ExitCode(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, code)
+	})
+}
+
+func TestContainerExecTimeout(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	t.Run("command finishing in time succeeds", func(t *testing.T) {
+		out, err := c.Container().
+			From(alpineImage).
+			WithExec([]string{"echo", "hi"}, dagger.ContainerWithExecOpts{
+				Timeout: 30,
+			}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "hi\n", out)
+	})
+
+	t.Run("command running too long is killed", func(t *testing.T) {
+		_, err := c.Container().
+			From(alpineImage).
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExec([]string{"sh", "-c", "echo started; sleep 60"}, dagger.ContainerWithExecOpts{
+				Timeout:           2,
+				ExpectNonZeroExit: true,
+			}).
+			Sync(ctx)
+
+		var timeoutErr *dagger.ExecTimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		require.Equal(t, 2, timeoutErr.Timeout)
+		require.Equal(t, []string{"sh", "-c", "echo started; sleep 60"}, timeoutErr.Cmd)
+		require.Equal(t, "started\n", timeoutErr.Stdout)
+	})
+}
+
+func TestContainerExecResourceLimits(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	t.Run("ulimits", func(t *testing.T) {
+		out, err := c.Container().
+			From(alpineImage).
+			WithExec([]string{"sh", "-c", "ulimit -Sn; ulimit -Hn"}, dagger.ContainerWithExecOpts{
+				Ulimits: []string{"nofile=1024:2048"},
+			}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "1024\n2048\n", out)
+	})
+
+	t.Run("container defaults", func(t *testing.T) {
+		ctr := c.Container().
+			From(alpineImage).
+			WithResourceLimits(dagger.ContainerWithResourceLimitsOpts{
+				PidsLimit: 64,
+				Ulimits:   []string{"nofile=512"},
+			})
+
+		out, err := ctr.
+			WithExec([]string{"sh", "-c", "ulimit -n"}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "512\n", out)
+
+		// exec limits take precedence over the defaults; limits aren't part of
+		// the cache key, so use a different command
+		out, err = ctr.
+			WithExec([]string{"sh", "-c", "ulimit -Sn"}, dagger.ContainerWithExecOpts{
+				Ulimits: []string{"nofile=256"},
+			}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "256\n", out)
+	})
+
+	t.Run("invalid limits", func(t *testing.T) {
+		_, err := c.Container().
+			From(alpineImage).
+			WithExec([]string{"true"}, dagger.ContainerWithExecOpts{
+				MemoryLimit: "lots",
+			}).
+			Sync(ctx)
+		require.Error(t, err)
+
+		_, err = c.Container().
+			From(alpineImage).
+			WithExec([]string{"true"}, dagger.ContainerWithExecOpts{
+				Ulimits: []string{"nofile"},
+			}).
+			Sync(ctx)
+		require.Error(t, err)
+	})
+}
+
+func TestContainerFromImageNotFound(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	addr := "docker.io/library/alpine:dagger-does-not-exist"
+	_, err := c.Container().From(addr).Sync(ctx)
+
+	var notFoundErr *dagger.ImageNotFoundError
+	require.ErrorAs(t, err, &notFoundErr)
+	require.Equal(t, addr, notFoundErr.Address)
+}
+
 func TestContainerWithRegistryAuth(t *testing.T) {
 	t.Parallel()
 
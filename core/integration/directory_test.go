@@ -1,7 +1,9 @@
 package core
 
 import (
+	"io/fs"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -441,6 +443,22 @@ func TestDirectoryWithTimestamps(t *testing.T) {
 	})
 }
 
+func TestDirectoryPathNotFound(t *testing.T) {
+	t.Parallel()
+	c, ctx := connect(t)
+
+	dir := c.Directory().WithNewFile("some-file", "some-content")
+
+	_, err := dir.File("missing-file").Contents(ctx)
+	var notFoundErr *dagger.PathNotFoundError
+	require.ErrorAs(t, err, &notFoundErr)
+	require.Equal(t, "/missing-file", notFoundErr.Path)
+
+	_, err = c.Directory().File("missing-file").Contents(ctx)
+	require.ErrorAs(t, err, &notFoundErr)
+	require.Equal(t, "/missing-file", notFoundErr.Path)
+}
+
 func TestDirectoryWithoutDirectoryWithoutFile(t *testing.T) {
 	t.Parallel()
 	c, ctx := connect(t)
@@ -617,6 +635,163 @@ func TestDirectoryExport(t *testing.T) {
 	})
 }
 
+func TestDirectoryExportMirror(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	dir := c.Directory().
+		WithNewFile("main.go", "package main\n").
+		WithNewFile("gen/types.go", "package gen\n\ntype T struct{}\n").
+		WithNewFile("README.md", "# readme\n").
+		WithNewFile(".git/HEAD", "ref: refs/heads/other\n")
+
+	newDest := func(t *testing.T) string {
+		dest := t.TempDir()
+		for name, contents := range map[string]string{
+			"main.go":      "package main\n",
+			"gen/stale.go": "package gen\n\ntype Stale struct{}\n",
+			"notes.txt":    "notes\n",
+			".git/HEAD":    "ref: refs/heads/main\n",
+		} {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dest, name)), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dest, name), []byte(contents), 0o644))
+		}
+		return dest
+	}
+
+	walk := func(t *testing.T, root string) []string {
+		var paths []string
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || p == root {
+				return err
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, filepath.ToSlash(rel))
+			return nil
+		})
+		require.NoError(t, err)
+		return paths
+	}
+
+	summarize := func(t *testing.T, changes []dagger.FileChange) map[string]string {
+		summary := map[string]string{}
+		for _, change := range changes {
+			p, err := change.Path(ctx)
+			require.NoError(t, err)
+			kind, err := change.Kind(ctx)
+			require.NoError(t, err)
+			summary[p] = string(kind)
+		}
+		return summary
+	}
+
+	t.Run("export changes with mirror", func(t *testing.T) {
+		dest := newDest(t)
+		before := walk(t, dest)
+
+		changes, err := dir.ExportChanges(ctx, dest, dagger.DirectoryExportChangesOpts{
+			Mirror:  true,
+			Exclude: []string{".git"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"README.md":    "ADDED",
+			"gen/stale.go": "DELETED",
+			"gen/types.go": "ADDED",
+			"notes.txt":    "DELETED",
+		}, summarize(t, changes))
+
+		// nothing was written
+		require.Equal(t, before, walk(t, dest))
+	})
+
+	t.Run("export changes without mirror", func(t *testing.T) {
+		dest := newDest(t)
+
+		changes, err := dir.ExportChanges(ctx, dest, dagger.DirectoryExportChangesOpts{
+			Exclude: []string{".git"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"README.md":    "ADDED",
+			"gen/types.go": "ADDED",
+		}, summarize(t, changes))
+	})
+
+	t.Run("export changes to new dir", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "new")
+
+		changes, err := dir.ExportChanges(ctx, dest, dagger.DirectoryExportChangesOpts{
+			Mirror:  true,
+			Exclude: []string{".git"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"README.md":    "ADDED",
+			"gen":          "ADDED",
+			"gen/types.go": "ADDED",
+			"main.go":      "ADDED",
+		}, summarize(t, changes))
+
+		_, err = os.Stat(dest)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("mirror", func(t *testing.T) {
+		dest := newDest(t)
+
+		ok, err := dir.Export(ctx, dest, dagger.DirectoryExportOpts{
+			Mirror:  true,
+			Exclude: []string{".git"},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{
+			".git",
+			".git/HEAD",
+			"README.md",
+			"gen",
+			"gen/types.go",
+			"main.go",
+		}, walk(t, dest))
+
+		head, err := os.ReadFile(filepath.Join(dest, ".git", "HEAD"))
+		require.NoError(t, err)
+		require.Equal(t, "ref: refs/heads/main\n", string(head))
+
+		changes, err := dir.ExportChanges(ctx, dest, dagger.DirectoryExportChangesOpts{
+			Mirror:  true,
+			Exclude: []string{".git"},
+		})
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+
+	t.Run("without mirror", func(t *testing.T) {
+		dest := newDest(t)
+
+		ok, err := dir.Export(ctx, dest, dagger.DirectoryExportOpts{
+			Exclude: []string{".git"},
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{
+			".git",
+			".git/HEAD",
+			"README.md",
+			"gen",
+			"gen/stale.go",
+			"gen/types.go",
+			"main.go",
+			"notes.txt",
+		}, walk(t, dest))
+	})
+}
+
 func TestDirectoryDockerBuild(t *testing.T) {
 	t.Parallel()
 	c, ctx := connect(t)
@@ -928,6 +1103,133 @@ func TestDirectorySync(t *testing.T) {
 	})
 }
 
+func TestDirectoryStatAndWalk(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	srcDir := c.Directory().
+		WithNewFile("go.mod", "module foo\n").
+		WithNewFile("main.go", "package main\n", dagger.DirectoryWithNewFileOpts{
+			Permissions: 0o755,
+		}).
+		WithNewFile("node_modules/dep/index.js", "").
+		WithNewFile("pkg/util.go", "").
+		WithNewFile("pkg/sub/sub.go", "").
+		WithTimestamps(1234567890)
+
+	t.Run("stat", func(t *testing.T) {
+		entry := srcDir.Stat("main.go")
+
+		path, err := entry.Path(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "main.go", path)
+
+		typ, err := entry.Type(ctx)
+		require.NoError(t, err)
+		require.Equal(t, dagger.Regular, typ)
+
+		size, err := entry.Size(ctx)
+		require.NoError(t, err)
+		require.Equal(t, len("package main\n"), size)
+
+		perms, err := entry.Permissions(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0o755, perms)
+
+		modifiedAt, err := entry.ModifiedAt(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1234567890, modifiedAt)
+
+		typ, err = srcDir.Stat("pkg").Type(ctx)
+		require.NoError(t, err)
+		require.Equal(t, dagger.Dir, typ)
+
+		_, err = srcDir.Stat("nope").Type(ctx)
+		require.Error(t, err)
+	})
+
+	t.Run("stat absolute path", func(t *testing.T) {
+		path, err := srcDir.Stat("/go.mod").Path(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "go.mod", path)
+
+		path, err = srcDir.Stat("/pkg/sub/").Path(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "pkg/sub", path)
+	})
+
+	t.Run("symlinks", func(t *testing.T) {
+		dir := c.Container().
+			From(alpineImage).
+			WithExec([]string{"sh", "-c", "mkdir /out && echo hi > /out/file && ln -s file /out/link"}).
+			Directory("/out")
+
+		entry := dir.Stat("link")
+
+		typ, err := entry.Type(ctx)
+		require.NoError(t, err)
+		require.Equal(t, dagger.Symlink, typ)
+
+		target, err := entry.SymlinkTarget(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "file", target)
+	})
+
+	walkPaths := func(t *testing.T, opts dagger.DirectoryWalkOpts) []string {
+		entries, err := srcDir.Walk(ctx, opts)
+		require.NoError(t, err)
+		paths := []string{}
+		for _, entry := range entries {
+			path, err := entry.Path(ctx)
+			require.NoError(t, err)
+			paths = append(paths, path)
+		}
+		return paths
+	}
+
+	t.Run("walk", func(t *testing.T) {
+		require.ElementsMatch(t, []string{
+			"go.mod", "main.go",
+			"node_modules", "node_modules/dep", "node_modules/dep/index.js",
+			"pkg", "pkg/util.go", "pkg/sub", "pkg/sub/sub.go",
+		}, walkPaths(t, dagger.DirectoryWalkOpts{}))
+	})
+
+	t.Run("walk with filters", func(t *testing.T) {
+		require.ElementsMatch(t, []string{
+			"main.go", "pkg/util.go", "pkg/sub/sub.go",
+		}, walkPaths(t, dagger.DirectoryWalkOpts{
+			Include: []string{"**/*.go"},
+			Exclude: []string{"node_modules"},
+		}))
+	})
+
+	t.Run("walk subdirectory", func(t *testing.T) {
+		require.ElementsMatch(t, []string{
+			"pkg/util.go", "pkg/sub",
+		}, walkPaths(t, dagger.DirectoryWalkOpts{
+			Path:     "pkg",
+			MaxDepth: 1,
+		}))
+	})
+
+	t.Run("walk absolute path", func(t *testing.T) {
+		require.ElementsMatch(t, []string{
+			"pkg/util.go", "pkg/sub", "pkg/sub/sub.go",
+		}, walkPaths(t, dagger.DirectoryWalkOpts{
+			Path: "/pkg",
+		}))
+
+		require.ElementsMatch(t, []string{
+			"go.mod", "main.go", "node_modules", "pkg",
+		}, walkPaths(t, dagger.DirectoryWalkOpts{
+			Path:     "/",
+			MaxDepth: 1,
+		}))
+	})
+}
+
 func TestDirectoryGlob(t *testing.T) {
 	t.Parallel()
 
@@ -991,3 +1293,275 @@ func TestDirectoryGlob(t *testing.T) {
 		require.ElementsMatch(t, entries, []string{"foo/bar.md", "foo/bar.md/x.md"})
 	})
 }
+
+func TestDirectoryAsArchive(t *testing.T) {
+	t.Parallel()
+	c, ctx := connect(t)
+
+	dir := c.Directory().
+		WithNewFile("a.txt", "a").
+		WithNewFile("sub/b.txt", "b", dagger.DirectoryWithNewFileOpts{Permissions: 0o755})
+
+	tools := c.Container().
+		From(alpineImage).
+		WithExec([]string{"apk", "add", "zstd", "unzip"})
+
+	for _, tc := range []struct {
+		name string
+		opts dagger.DirectoryAsArchiveOpts
+		list string
+	}{
+		{"tar", dagger.DirectoryAsArchiveOpts{}, "tar -tf /archive"},
+		{"tar.gz", dagger.DirectoryAsArchiveOpts{Compression: dagger.Gzip}, "tar -tzf /archive"},
+		{"tar.zst", dagger.DirectoryAsArchiveOpts{Compression: dagger.Zstd}, "zstd -dc /archive | tar -tf -"},
+		{"zip", dagger.DirectoryAsArchiveOpts{Format: dagger.Zip}, "unzip -Z1 /archive"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			archive := dir.AsArchive(tc.opts)
+
+			out, err := tools.
+				WithMountedFile("/archive", archive).
+				WithExec([]string{"sh", "-c", tc.list}).
+				Stdout(ctx)
+			require.NoError(t, err)
+			require.Equal(t, "a.txt\nsub/\nsub/b.txt\n", out)
+
+			// the same contents produce the same archive, regardless of their
+			// timestamps
+			_, err = tools.
+				WithMountedFile("/archive", archive).
+				WithMountedFile("/same", dir.WithTimestamps(int(time.Now().Unix())).AsArchive(tc.opts)).
+				WithExec([]string{"cmp", "/archive", "/same"}).
+				Sync(ctx)
+			require.NoError(t, err)
+
+			extracted := archive.Extract()
+			ents, err := extracted.Entries(ctx)
+			require.NoError(t, err)
+			require.Equal(t, []string{"a.txt", "sub"}, ents)
+			b, err := extracted.File("sub/b.txt").Contents(ctx)
+			require.NoError(t, err)
+			require.Equal(t, "b", b)
+		})
+	}
+
+	t.Run("zip with compression", func(t *testing.T) {
+		_, err := dir.AsArchive(dagger.DirectoryAsArchiveOpts{
+			Format:      dagger.Zip,
+			Compression: dagger.Gzip,
+		}).Sync(ctx)
+		require.ErrorContains(t, err, "zip archives are always compressed")
+	})
+}
+
+func TestDirectoryDigest(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	dir := c.Directory().
+		WithNewFile("a.txt", "a").
+		WithNewFile("sub/b.txt", "b").
+		WithNewFile("node_modules/c.txt", "c")
+
+	dgst, err := dir.Digest(ctx)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(dgst, "sha256:"))
+
+	t.Run("ignores timestamps", func(t *testing.T) {
+		other, err := dir.WithTimestamps(int(time.Now().Unix())).Digest(ctx)
+		require.NoError(t, err)
+		require.Equal(t, dgst, other)
+	})
+
+	t.Run("changes with contents", func(t *testing.T) {
+		other, err := dir.WithNewFile("sub/b.txt", "changed").Digest(ctx)
+		require.NoError(t, err)
+		require.NotEqual(t, dgst, other)
+
+		other, err = dir.WithNewFile("sub/b.txt", "b", dagger.DirectoryWithNewFileOpts{
+			Permissions: 0o700,
+		}).Digest(ctx)
+		require.NoError(t, err)
+		require.NotEqual(t, dgst, other)
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		withoutModules, err := dir.WithoutDirectory("node_modules").Digest(ctx)
+		require.NoError(t, err)
+		excluded, err := dir.Digest(ctx, dagger.DirectoryDigestOpts{
+			Exclude: []string{"node_modules"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, withoutModules, excluded)
+
+		changed, err := dir.WithNewFile("node_modules/c.txt", "changed").Digest(ctx, dagger.DirectoryDigestOpts{
+			Exclude: []string{"node_modules"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, excluded, changed)
+	})
+
+	t.Run("include", func(t *testing.T) {
+		included, err := dir.Digest(ctx, dagger.DirectoryDigestOpts{
+			Include: []string{"sub"},
+		})
+		require.NoError(t, err)
+		changed, err := dir.WithNewFile("a.txt", "changed").Digest(ctx, dagger.DirectoryDigestOpts{
+			Include: []string{"sub"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, included, changed)
+	})
+}
+
+func TestDirectoryChanges(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	before := c.Directory().
+		WithNewFile("main.go", "package main\n\nfunc main() {}\n").
+		WithNewFile("old/moved.txt", "moved\n").
+		WithNewFile("deleted.txt", "deleted\n").
+		WithNewFile("script.sh", "#!/bin/sh\n")
+	after := before.
+		WithNewFile("main.go", "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n").
+		WithoutFile("old/moved.txt").
+		WithNewFile("new/moved.txt", "moved\n").
+		WithoutFile("deleted.txt").
+		WithNewFile("script.sh", "#!/bin/sh\n", dagger.DirectoryWithNewFileOpts{Permissions: 0o755}).
+		WithNewFile("added.txt", "added\n").
+		// only the timestamps change, which is not reported
+		WithTimestamps(int(time.Now().Unix()))
+
+	t.Run("changes", func(t *testing.T) {
+		changes, err := before.Changes(ctx, after)
+		require.NoError(t, err)
+
+		summary := map[string]string{}
+		for _, change := range changes {
+			p, err := change.Path(ctx)
+			require.NoError(t, err)
+			kind, err := change.Kind(ctx)
+			require.NoError(t, err)
+			oldPath, err := change.OldPath(ctx)
+			require.NoError(t, err)
+			summary[p] = string(kind) + " " + oldPath
+		}
+		require.Equal(t, map[string]string{
+			"added.txt":     "ADDED ",
+			"deleted.txt":   "DELETED ",
+			"main.go":       "MODIFIED ",
+			"new":           "ADDED ",
+			"new/moved.txt": "RENAMED old/moved.txt",
+			"old":           "DELETED ",
+			"script.sh":     "MODIFIED ",
+		}, summary)
+
+		for _, change := range changes {
+			p, err := change.Path(ctx)
+			require.NoError(t, err)
+			if p != "script.sh" {
+				continue
+			}
+			perms, err := change.Permissions(ctx)
+			require.NoError(t, err)
+			require.Equal(t, 0o755, perms)
+			oldPerms, err := change.OldPermissions(ctx)
+			require.NoError(t, err)
+			require.Equal(t, 0o644, oldPerms)
+		}
+	})
+
+	t.Run("as patch", func(t *testing.T) {
+		patch, err := before.AsPatch(after).Contents(ctx)
+		require.NoError(t, err)
+		require.Contains(t, patch, `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,5 @@
+ package main
+ 
+-func main() {}
++func main() {
++	println("hi")
++}
+`)
+		require.Contains(t, patch, "rename from old/moved.txt\nrename to new/moved.txt\n")
+		require.Contains(t, patch, "diff --git a/script.sh b/script.sh\nold mode 100644\nnew mode 100755\n")
+
+		// the patch applies with git
+		out, err := c.Container().
+			From(alpineImage).
+			WithExec([]string{"apk", "add", "git"}).
+			WithMountedDirectory("/src", before).
+			WithMountedFile("/changes.patch", before.AsPatch(after)).
+			WithWorkdir("/src").
+			WithExec([]string{"git", "apply", "--check", "-v", "/changes.patch"}).
+			Stderr(ctx)
+		require.NoError(t, err)
+		require.Contains(t, out, "Checking patch old/moved.txt => new/moved.txt...")
+	})
+
+	t.Run("with patch", func(t *testing.T) {
+		patched := before.WithPatch(before.AsPatch(after))
+
+		changes, err := patched.Changes(ctx, after)
+		require.NoError(t, err)
+		// directories themselves aren't part of patches
+		for _, change := range changes {
+			typ, err := change.Type(ctx)
+			require.NoError(t, err)
+			require.Equal(t, dagger.Dir, typ)
+		}
+
+		ents, err := patched.Entries(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"added.txt", "main.go", "new", "old", "script.sh"}, ents)
+		moved, err := patched.File("new/moved.txt").Contents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "moved\n", moved)
+	})
+
+	t.Run("with patch from diff -u", func(t *testing.T) {
+		patch := c.Directory().WithNewFile("fix.patch", `--- a/main.go	2023-01-01 00:00:00.000000000 +0000
++++ b/main.go	2023-01-01 00:00:00.000000000 +0000
+@@ -1,3 +1,3 @@
+ package main
+ 
+-func main() {}
++func main() { panic("oops") }
+`).File("fix.patch")
+
+		contents, err := c.Directory().
+			WithDirectory("src", before).
+			Directory("src").
+			WithPatch(patch).
+			File("main.go").
+			Contents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "package main\n\nfunc main() { panic(\"oops\") }\n", contents)
+	})
+
+	t.Run("with conflicting patch", func(t *testing.T) {
+		_, err := before.
+			WithNewFile("main.go", "package other\n").
+			WithPatch(before.AsPatch(after)).
+			Sync(ctx)
+		require.ErrorContains(t, err, "does not apply")
+	})
+
+	t.Run("with binary patch", func(t *testing.T) {
+		binary := before.WithNewFile("data.bin", "\x00old")
+		patch := binary.AsPatch(binary.WithNewFile("data.bin", "\x00new"))
+
+		contents, err := patch.Contents(ctx)
+		require.NoError(t, err)
+		require.Contains(t, contents, "Binary files a/data.bin and b/data.bin differ\n")
+
+		_, err = binary.WithPatch(patch).Sync(ctx)
+		require.ErrorContains(t, err, "data.bin: binary patches are not supported")
+	})
+}
@@ -316,3 +316,86 @@ func TestFileSync(t *testing.T) {
 		require.Equal(t, "bar", contents)
 	})
 }
+
+func TestFileExtract(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	archives := c.Container().
+		From(alpineImage).
+		WithExec([]string{"apk", "add", "zip"}).
+		WithWorkdir("/src").
+		WithExec([]string{"sh", "-c", `
+			mkdir -p sub
+			echo -n hello > sub/hello.txt
+			chown 1000:1000 sub/hello.txt
+			ln -s sub/hello.txt link
+			tar -cf /out.tar sub link
+			tar -czf /out.tar.gz sub link
+			zip -qry /out.zip sub link
+		`})
+
+	for _, name := range []string{"out.tar", "out.tar.gz", "out.zip"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			dir := archives.File("/" + name).Extract()
+
+			out, err := c.Container().
+				From(alpineImage).
+				WithMountedDirectory("/dir", dir).
+				WithExec([]string{"sh", "-c", "cat /dir/link; readlink /dir/link"}).
+				Stdout(ctx)
+			require.NoError(t, err)
+			require.Equal(t, "hellosub/hello.txt\n", out)
+		})
+	}
+
+	t.Run("preserves tar ownership", func(t *testing.T) {
+		out, err := c.Container().
+			From(alpineImage).
+			WithMountedDirectory("/dir", archives.File("/out.tar").Extract()).
+			WithExec([]string{"stat", "-c", "%u:%g", "/dir/sub/hello.txt"}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "1000:1000\n", out)
+	})
+
+	t.Run("not an archive", func(t *testing.T) {
+		_, err := c.Directory().
+			WithNewFile("foo", "not an archive").
+			File("foo").
+			Extract().
+			Sync(ctx)
+		require.Error(t, err)
+	})
+}
+
+func TestFileDigest(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	file := c.Directory().WithNewFile("hello.txt", "hello").File("hello.txt")
+
+	for _, tc := range []struct {
+		algorithm dagger.DigestAlgorithm
+		digest    string
+	}{
+		{dagger.Sha256, "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{dagger.Sha512, "sha512:9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043"},
+	} {
+		tc := tc
+		t.Run(string(tc.algorithm), func(t *testing.T) {
+			dgst, err := file.Digest(ctx, dagger.FileDigestOpts{Algorithm: tc.algorithm})
+			require.NoError(t, err)
+			require.Equal(t, tc.digest, dgst)
+		})
+	}
+
+	t.Run("defaults to sha256 and ignores timestamps", func(t *testing.T) {
+		dgst, err := file.WithTimestamps(0).Digest(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", dgst)
+	})
+}
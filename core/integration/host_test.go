@@ -267,6 +267,83 @@ func TestHostDirectoryExcludeInclude(t *testing.T) {
 	})
 }
 
+func TestHostDirectoryIgnoreFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		".gitignore":                "*.log\nnode_modules/\n/dist\n!keep.log\n",
+		".dockerignore":             "*.md\n!README.md\n",
+		"main.go":                   "package main",
+		"debug.log":                 "debug",
+		"keep.log":                  "keep",
+		"README.md":                 "readme",
+		"NOTES.md":                  "notes",
+		"dist/app":                  "app",
+		"node_modules/m/index.js":   "module",
+		"node_modules/m/.gitignore": "!*.log",
+		"node_modules/m/m.log":      "log",
+		"sub/.gitignore":            "*.txt\n",
+		"sub/a.txt":                 "a",
+		"sub/dist/app":              "app",
+		"sub/node_modules":          "not a dir",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600))
+	}
+
+	c, ctx := connect(t)
+
+	t.Run("gitignore", func(t *testing.T) {
+		entries, err := c.Host().Directory(dir, dagger.HostDirectoryOpts{
+			Gitignore: true,
+		}).Glob(ctx, "**/*")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{
+			".dockerignore", ".gitignore", "main.go", "keep.log", "README.md", "NOTES.md",
+			// directory-only patterns leave the directory behind, empty
+			"node_modules",
+			"sub", "sub/.gitignore", "sub/dist", "sub/dist/app", "sub/node_modules",
+		}, entries)
+	})
+
+	t.Run("ignore file", func(t *testing.T) {
+		entries, err := c.Host().Directory(dir, dagger.HostDirectoryOpts{
+			IgnoreFile: ".dockerignore",
+		}).Entries(ctx)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{
+			".dockerignore", ".gitignore", "main.go", "debug.log", "keep.log", "README.md",
+			"dist", "node_modules", "sub",
+		}, entries)
+	})
+
+	t.Run("exclude overrides ignore files", func(t *testing.T) {
+		entries, err := c.Host().Directory(dir, dagger.HostDirectoryOpts{
+			Gitignore: true,
+			Exclude:   []string{"keep.log", "node_modules", "sub"},
+		}).Entries(ctx)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{
+			".dockerignore", ".gitignore", "main.go", "README.md", "NOTES.md",
+		}, entries)
+	})
+
+	t.Run("missing ignore file", func(t *testing.T) {
+		_, err := c.Host().Directory(dir, dagger.HostDirectoryOpts{
+			IgnoreFile: ".nope",
+		}).ID(ctx)
+		require.ErrorContains(t, err, ".nope")
+	})
+
+	t.Run("ignore file outside directory", func(t *testing.T) {
+		_, err := c.Host().Directory(dir, dagger.HostDirectoryOpts{
+			IgnoreFile: "../.dockerignore",
+		}).ID(ctx)
+		require.Error(t, err)
+	})
+}
+
 func TestHostFile(t *testing.T) {
 	t.Parallel()
 
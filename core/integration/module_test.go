@@ -2021,6 +2021,49 @@ func (m *Test) Fn(dir *Directory, subpath Optional[string]) *Directory {
 	})
 }
 
+func TestModuleDebugFailedExec(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connectKeepingFailedExecs(t)
+
+	modGen := c.Container().From(golangImage).
+		WithMountedFile(testCLIBinPath, daggerCliFile(t, c)).
+		WithWorkdir("/work").
+		With(daggerExec("mod", "init", "--name=test", "--sdk=go")).
+		WithNewFile("main.go", dagger.ContainerWithNewFileOpts{
+			Contents: `package main
+
+import "context"
+
+type Test struct {}
+
+func (m *Test) Fail(ctx context.Context) (string, error) {
+	return dag.Container().
+		From("` + alpineImage + `").
+		WithEnvVariable("FOO", "bar").
+		WithExec([]string{"sh", "-c", "echo failed > /out; exit 1"}).
+		Stdout(ctx)
+}
+`,
+		})
+
+	_, err := modGen.Directory(".").AsModule().Serve(ctx)
+	require.NoError(t, err)
+
+	err = c.Do(ctx, &dagger.Request{Query: `{test{fail}}`}, &dagger.Response{})
+	var exErr *dagger.ExecError
+	require.ErrorAs(t, err, &exErr)
+	require.NotEmpty(t, exErr.DebugID)
+
+	// the debug ID is the one of the exec that failed in the function, not
+	// the one of the function's runtime container
+	out, err := c.DebugContainer(exErr.DebugID).
+		WithExec([]string{"sh", "-c", "echo $FOO; cat /out"}).
+		Stdout(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "bar\nfailed\n", out)
+}
+
 func TestModuleGoSyncDeps(t *testing.T) {
 	// verify that changes to deps result in a sync to the depender module
 	t.Parallel()
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	_ "embed"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/dagger/dagger/internal/testutil"
@@ -118,6 +120,59 @@ func TestSecretSet(t *testing.T) {
 	require.Equal(t, secretValue, plaintext)
 }
 
+func TestSecretSetURI(t *testing.T) {
+	t.Parallel()
+	c, ctx := connect(t)
+
+	t.Run("file", func(t *testing.T) {
+		secretPath := filepath.Join(t.TempDir(), "secret")
+		require.NoError(t, os.WriteFile(secretPath, []byte("file-secret-text"), 0o600))
+
+		s := c.SetSecretURI("file_key", "file://"+secretPath)
+
+		_, err := c.Container().From(alpineImage).
+			WithSecretVariable("FILE_KEY", s).
+			WithExec([]string{"sh", "-c", "test \"$FILE_KEY\" = \"file-secret-text\""}).
+			Sync(ctx)
+		require.NoError(t, err)
+
+		// references are resolved lazily, on each use
+		require.NoError(t, os.WriteFile(secretPath, []byte("rotated-secret-text"), 0o600))
+
+		plaintext, err := s.Plaintext(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "rotated-secret-text", plaintext)
+	})
+
+	t.Run("cmd", func(t *testing.T) {
+		s := c.SetSecretURI("cmd_key", "cmd://echo cmd-secret-text")
+
+		plaintext, err := s.Plaintext(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "cmd-secret-text", plaintext)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		s := c.SetSecretURI("missing_key", "file:///does/not/exist")
+
+		_, err := c.Container().From(alpineImage).
+			WithMountedSecret("/sekret", s).
+			WithExec([]string{"cat", "/sekret"}).
+			Sync(ctx)
+		require.Error(t, err)
+	})
+
+	t.Run("unknown scheme", func(t *testing.T) {
+		_, err := c.SetSecretURI("unknown_key", "nope://foo").Plaintext(ctx)
+		require.ErrorContains(t, err, "unsupported secret provider")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := c.SetSecretURI("invalid_key", "not-a-uri").ID(ctx)
+		require.ErrorContains(t, err, "invalid secret reference")
+	})
+}
+
 func TestSecretWhitespaceScrubbed(t *testing.T) {
 	t.Parallel()
 	c, ctx := connect(t)
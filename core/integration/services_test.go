@@ -432,6 +432,12 @@ func TestContainerExecServicesError(t *testing.T) {
 	_, err = client.Sync(ctx)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "start "+host+" (aliased as www): exited:")
+
+	var startErr *dagger.ServiceStartError
+	require.ErrorAs(t, err, &startErr)
+	require.Equal(t, host, startErr.Hostname)
+	require.Equal(t, 42, startErr.ExitCode)
+	require.Contains(t, startErr.Output, "nope")
 }
 
 func TestContainerServiceNoExec(t *testing.T) {
@@ -1206,6 +1212,345 @@ func TestServiceStartStop(t *testing.T) {
 	require.Empty(t, out)
 }
 
+func TestServiceHealthcheck(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	t.Run("waits for the health check to pass", func(t *testing.T) {
+		srv := c.Container().
+			From(alpineImage).
+			WithEnvVariable("BUST", identity.NewID()).
+			WithHealthcheck([]string{"test", "-f", "/tmp/ready"}).
+			WithExec([]string{"sh", "-c", "sleep 3 && touch /tmp/ready && sleep 3600"}).
+			AsService()
+
+		_, err := srv.Start(ctx)
+		require.NoError(t, err)
+		defer srv.Stop(ctx)
+
+		health, err := srv.Health(ctx)
+		require.NoError(t, err)
+		require.Equal(t, dagger.Healthy, health)
+	})
+
+	t.Run("fails after too many retries", func(t *testing.T) {
+		srv := c.Container().
+			From(alpineImage).
+			WithEnvVariable("BUST", identity.NewID()).
+			WithHealthcheck([]string{"false"}, dagger.ContainerWithHealthcheckOpts{
+				Retries: 2,
+			}).
+			WithExec([]string{"sleep", "3600"}).
+			AsService()
+
+		_, err := srv.Start(ctx)
+		require.Error(t, err)
+
+		var healthErr *dagger.ServiceHealthError
+		require.ErrorAs(t, err, &healthErr)
+	})
+
+	t.Run("is stored in the image config", func(t *testing.T) {
+		cfgJSON, err := c.Container().
+			From(alpineImage).
+			WithHealthcheck([]string{"test", "-f", "/tmp/ready"}, dagger.ContainerWithHealthcheckOpts{
+				Interval: 5,
+				Retries:  3,
+			}).
+			ImageConfig(ctx)
+		require.NoError(t, err)
+		require.Contains(t, string(cfgJSON), `"Healthcheck":{"Test":["CMD","test","-f","/tmp/ready"],"Interval":5000000000,"Retries":3}`)
+	})
+
+	t.Run("runs the image config health check", func(t *testing.T) {
+		srv := c.Container().
+			From(alpineImage).
+			WithEnvVariable("BUST", identity.NewID()).
+			WithImageConfig(`{"Healthcheck": {"Test": ["CMD-SHELL", "false"], "Retries": 2}}`).
+			WithExec([]string{"sleep", "3600"}).
+			AsService()
+
+		_, err := srv.Start(ctx)
+		require.Error(t, err)
+
+		var healthErr *dagger.ServiceHealthError
+		require.ErrorAs(t, err, &healthErr)
+	})
+
+	t.Run("replaces the image config health check", func(t *testing.T) {
+		srv := c.Container().
+			From(alpineImage).
+			WithEnvVariable("BUST", identity.NewID()).
+			WithImageConfig(`{"Healthcheck": {"Test": ["CMD", "false"]}}`).
+			WithHealthcheck([]string{"true"}).
+			WithExec([]string{"sleep", "3600"}).
+			AsService()
+
+		_, err := srv.Start(ctx)
+		require.NoError(t, err)
+		defer srv.Stop(ctx)
+	})
+
+	t.Run("skips a disabled image config health check", func(t *testing.T) {
+		srv := c.Container().
+			From(alpineImage).
+			WithEnvVariable("BUST", identity.NewID()).
+			WithHealthcheck([]string{"false"}).
+			WithImageConfig(`{"Healthcheck": {"Test": ["NONE"]}}`).
+			WithExec([]string{"sleep", "3600"}).
+			AsService()
+
+		_, err := srv.Start(ctx)
+		require.NoError(t, err)
+		defer srv.Stop(ctx)
+	})
+
+	t.Run("gates dependent containers on HTTP health checks", func(t *testing.T) {
+		srv := c.Container().
+			From("python").
+			WithWorkdir("/srv/www").
+			WithEnvVariable("BUST", identity.NewID()).
+			WithExposedPort(8000, dagger.ContainerWithExposedPortOpts{
+				HealthcheckPath: "/ready.txt",
+			}).
+			WithExec([]string{"sh", "-c", "(sleep 3 && echo ready > ready.txt) & python -m http.server"}).
+			AsService()
+
+		ports, err := c.Container().
+			WithExposedPort(8000, dagger.ContainerWithExposedPortOpts{
+				HealthcheckPath: "/ready.txt",
+			}).
+			ExposedPorts(ctx)
+		require.NoError(t, err)
+		require.Len(t, ports, 1)
+		path, err := ports[0].HealthcheckPath(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "/ready.txt", path)
+
+		out, err := c.Container().
+			From(alpineImage).
+			WithServiceBinding("www", srv).
+			WithExec([]string{"wget", "-O-", "http://www:8000/ready.txt"}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "ready\n", out)
+	})
+
+	t.Run("rejects health check paths on UDP ports", func(t *testing.T) {
+		_, err := c.Container().
+			WithExposedPort(53, dagger.ContainerWithExposedPortOpts{
+				Protocol:        dagger.Udp,
+				HealthcheckPath: "/healthz",
+			}).
+			ExposedPorts(ctx)
+		require.ErrorContains(t, err, "only supported for TCP ports")
+	})
+}
+
+func TestServiceLogsAndStatus(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	t.Run("captures output and exit code after exiting", func(t *testing.T) {
+		srv := c.Container().
+			From(alpineImage).
+			WithEnvVariable("BUST", identity.NewID()).
+			WithExec([]string{"sh", "-c", "echo hello; echo oh no >&2; sleep 3; exit 3"}).
+			AsService()
+
+		status, err := srv.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, dagger.Stopped, status)
+
+		_, err = srv.Stdout(ctx)
+		require.ErrorContains(t, err, "has not been started")
+
+		_, err = srv.Start(ctx)
+		require.NoError(t, err)
+		defer srv.Stop(ctx)
+
+		status, err = srv.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, dagger.Running, status)
+
+		require.Eventually(t, func() bool {
+			status, err := srv.Status(ctx)
+			require.NoError(t, err)
+			return status == dagger.Exited
+		}, time.Minute, time.Second)
+
+		code, err := srv.ExitCode(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 3, code)
+
+		stdout, err := srv.Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "hello\n", stdout)
+
+		stderr, err := srv.Stderr(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "oh no\n", stderr)
+	})
+
+	t.Run("retains output after stopping", func(t *testing.T) {
+		srv := c.Container().
+			From(alpineImage).
+			WithEnvVariable("BUST", identity.NewID()).
+			WithExec([]string{"sh", "-c", "echo hello; sleep 3600"}).
+			AsService()
+
+		_, err := srv.Start(ctx)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			stdout, err := srv.Stdout(ctx)
+			require.NoError(t, err)
+			return stdout == "hello\n"
+		}, time.Minute, time.Second)
+
+		_, err = srv.Stop(ctx)
+		require.NoError(t, err)
+
+		status, err := srv.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, dagger.Stopped, status)
+
+		stdout, err := srv.Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "hello\n", stdout)
+	})
+}
+
+func TestServiceBindingDependencies(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	t.Run("starts dependencies first", func(t *testing.T) {
+		shared := c.CacheVolume("service-deps-" + identity.NewID())
+
+		db := c.Container().
+			From(alpineImage).
+			WithMountedCache("/shared", shared).
+			WithHealthcheck([]string{"test", "-f", "/shared/db"}).
+			WithExec([]string{"sh", "-c", "sleep 3 && touch /shared/db && sleep 3600"}).
+			AsService()
+
+		// fails to start unless the db is already healthy
+		api := c.Container().
+			From(alpineImage).
+			WithMountedCache("/shared", shared).
+			WithHealthcheck([]string{"test", "-f", "/shared/api"}).
+			WithExec([]string{"sh", "-c", "test -f /shared/db && touch /shared/api && sleep 3600"}).
+			AsService()
+
+		_, err := c.Container().
+			From(alpineImage).
+			WithServiceBinding("db", db).
+			WithServiceBinding("api", api, dagger.ContainerWithServiceBindingOpts{
+				DependsOn: []string{"db"},
+			}).
+			WithEnvVariable("BUST", identity.NewID()).
+			WithExec([]string{"true"}).
+			Sync(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects unknown dependencies", func(t *testing.T) {
+		srv, _ := httpService(ctx, t, c, "hello")
+
+		_, err := c.Container().
+			From(alpineImage).
+			WithServiceBinding("www", srv, dagger.ContainerWithServiceBindingOpts{
+				DependsOn: []string{"db"},
+			}).
+			Sync(ctx)
+		require.ErrorContains(t, err, `depends on unknown service binding "db"`)
+	})
+
+	t.Run("rejects cycles", func(t *testing.T) {
+		srvA, _ := httpService(ctx, t, c, "a")
+		srvB, _ := httpService(ctx, t, c, "b")
+
+		_, err := c.Container().
+			From(alpineImage).
+			WithServiceBinding("a", srvA).
+			WithServiceBinding("b", srvB, dagger.ContainerWithServiceBindingOpts{
+				DependsOn: []string{"a"},
+			}).
+			WithServiceBinding("a", srvA, dagger.ContainerWithServiceBindingOpts{
+				DependsOn: []string{"b"},
+			}).
+			Sync(ctx)
+		require.ErrorContains(t, err, "service dependency cycle: a -> b -> a")
+	})
+}
+
+func TestContainerWithNetwork(t *testing.T) {
+	t.Parallel()
+
+	c, ctx := connect(t)
+
+	boundSrv, boundURL := httpService(ctx, t, c, "bound")
+	otherSrv, otherURL := httpService(ctx, t, c, "other")
+
+	otherHostname, err := otherSrv.Hostname(ctx)
+	require.NoError(t, err)
+
+	_, err = otherSrv.Start(ctx)
+	require.NoError(t, err)
+
+	fetch := func(ctr *dagger.Container, url string) (string, error) {
+		return ctr.
+			WithEnvVariable("BUST", identity.NewID()).
+			WithExec([]string{"wget", "-T", "5", "-O-", url}).
+			Stdout(ctx)
+	}
+
+	base := c.Container().
+		From(alpineImage).
+		WithServiceBinding("www", boundSrv)
+
+	t.Run("default", func(t *testing.T) {
+		out, err := fetch(base.WithNetwork(dagger.NetworkDefault), otherURL)
+		require.NoError(t, err)
+		require.Equal(t, "other", out)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		_, err := fetch(base.WithNetwork(dagger.NetworkNone), boundURL)
+		require.Error(t, err)
+	})
+
+	t.Run("services only", func(t *testing.T) {
+		ctr := base.WithNetwork(dagger.NetworkServicesOnly)
+
+		out, err := fetch(ctr, boundURL)
+		require.NoError(t, err)
+		require.Equal(t, "bound", out)
+
+		_, err = fetch(ctr, otherURL)
+		require.Error(t, err)
+	})
+
+	t.Run("services only with allowed destinations", func(t *testing.T) {
+		out, err := fetch(base.WithNetwork(dagger.NetworkServicesOnly, dagger.ContainerWithNetworkOpts{
+			Allow: []string{otherHostname},
+		}), otherURL)
+		require.NoError(t, err)
+		require.Equal(t, "other", out)
+	})
+
+	t.Run("allowed destinations require services only", func(t *testing.T) {
+		_, err := base.WithNetwork(dagger.NetworkDefault, dagger.ContainerWithNetworkOpts{
+			Allow: []string{"10.0.0.0/8"},
+		}).Sync(ctx)
+		require.ErrorContains(t, err, "allowed destinations require network mode NETWORK_SERVICES_ONLY")
+	})
+}
+
 // TestServiceNoCrossTalk shows that services spawned in one client cannot be
 // reached by another client.
 func TestServiceNoCrossTalk(t *testing.T) {
@@ -17,6 +17,8 @@ import (
 
 	"dagger.io/dagger"
 	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/engine"
+	"github.com/dagger/dagger/engine/client"
 	"github.com/dagger/dagger/internal/testutil"
 	"github.com/moby/buildkit/identity"
 	"github.com/stretchr/testify/require"
@@ -37,6 +39,22 @@ func connect(t *testing.T, opts ...dagger.ClientOpt) (*dagger.Client, context.Co
 	return client, ctx
 }
 
+// connectKeepingFailedExecs is like connect, but the session keeps the state
+// of failed execs around so that it can be loaded with debugContainer.
+func connectKeepingFailedExecs(t *testing.T) (*dagger.Client, context.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	engineClient, ctx, err := client.Connect(ctx, client.Params{
+		RunnerHost:      engine.RunnerHost(),
+		KeepFailedExecs: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { engineClient.Close() })
+
+	return engineClient.Dagger(), ctx
+}
+
 func newCache(t *testing.T) core.CacheVolumeID {
 	var res struct {
 		CacheVolume struct {
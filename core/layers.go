@@ -0,0 +1,269 @@
+package core
+
+import (
+	"context"
+	"strings"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/solver/pb"
+
+	"github.com/dagger/dagger/engine/buildkit"
+)
+
+// Change is a change to a path between two container filesystems.
+type Change struct {
+	// Path is the absolute path of the changed file or directory.
+	Path string `json:"path"`
+
+	Kind ChangeKind `json:"kind"`
+
+	// Size is the size of the path in the newer filesystem in bytes, or 0 if
+	// it was deleted or is not a regular file.
+	Size int `json:"size"`
+}
+
+type ChangeKind string
+
+const (
+	ChangeKindAdded    ChangeKind = "ADDED"
+	ChangeKindModified ChangeKind = "MODIFIED"
+	ChangeKindDeleted  ChangeKind = "DELETED"
+
+	// ChangeKindRenamed is only reported for changes between directories.
+	ChangeKindRenamed ChangeKind = "RENAMED"
+)
+
+func (kind ChangeKind) EnumName() string {
+	return string(kind)
+}
+
+// ContainerLayer is a layer of a container's root filesystem.
+type ContainerLayer struct {
+	// Digest is the digest of the compressed layer, as it would be published.
+	Digest string `json:"digest"`
+
+	// DiffID is the digest of the uncompressed layer.
+	DiffID string `json:"diffID"`
+
+	MediaType string `json:"mediaType"`
+
+	// Size is the size of the compressed layer in bytes.
+	Size int `json:"size"`
+
+	// CreatedBy is the command that produced the layer, or a description of
+	// where it came from if it was not produced by a command (e.g., a pulled
+	// image layer).
+	CreatedBy string `json:"createdBy"`
+}
+
+// Diff returns the changes to the container's root filesystem relative to the
+// other container's root filesystem (e.g., its base image).
+func (container *Container) Diff(ctx context.Context, bk *buildkit.Client, svcs *Services, other *Container) ([]Change, error) {
+	detach, _, err := svcs.StartBindings(ctx, bk, container.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	detachOther, _, err := svcs.StartBindings(ctx, bk, other.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detachOther()
+
+	lower, err := other.fsDefinition(ctx)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := container.fsDefinition(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fsChanges, err := bk.FilesystemChanges(ctx, lower, upper)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0, len(fsChanges))
+	for _, fsChange := range fsChanges {
+		change := Change{
+			Path: fsChange.Path,
+			Size: int(fsChange.Size),
+		}
+		switch fsChange.Kind {
+		case fs.ChangeKindAdd:
+			change.Kind = ChangeKindAdded
+		case fs.ChangeKindModify:
+			change.Kind = ChangeKindModified
+		case fs.ChangeKindDelete:
+			change.Kind = ChangeKindDeleted
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// Layers returns the layers of the container's root filesystem, from the
+// bottom up, as they would be published.
+func (container *Container) Layers(ctx context.Context, bk *buildkit.Client, svcs *Services) ([]ContainerLayer, error) {
+	detach, _, err := svcs.StartBindings(ctx, bk, container.Services)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	st, err := container.imageFSState()
+	if err != nil {
+		return nil, err
+	}
+	def, err := st.Marshal(ctx, llb.Platform(container.Platform))
+	if err != nil {
+		return nil, err
+	}
+
+	bkLayers, err := bk.Layers(ctx, def.ToPB())
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([]ContainerLayer, 0, len(bkLayers))
+	for _, layer := range bkLayers {
+		layers = append(layers, ContainerLayer{
+			Digest:    layer.Descriptor.Digest.String(),
+			DiffID:    layer.DiffID.String(),
+			MediaType: layer.Descriptor.MediaType,
+			Size:      int(layer.Descriptor.Size),
+			CreatedBy: layerCreatedBy(layer.Description),
+		})
+	}
+	return layers, nil
+}
+
+// Squash collapses the layers of the container's root filesystem that are not
+// in the base container's root filesystem into a single layer, which includes
+// the removal of any paths deleted since base. If base is nil, the whole root
+// filesystem is collapsed into a single layer.
+//
+// Layer boundaries are removed, since the layers they separate no longer
+// exist.
+func (container *Container) Squash(ctx context.Context, base *Container) (*Container, error) {
+	container = container.Clone()
+
+	upper, err := container.FSState()
+	if err != nil {
+		return nil, err
+	}
+
+	var st llb.State
+	if base == nil {
+		st = flattenState(upper)
+	} else {
+		lower, err := base.FSState()
+		if err != nil {
+			return nil, err
+		}
+		st = llb.Merge(
+			[]llb.State{lower, squashedLayer(lower, upper)},
+			llb.WithCustomName(buildkit.InternalPrefix+"squash"),
+		)
+		container.Services.Merge(base.Services)
+	}
+
+	def, err := st.Marshal(ctx, llb.Platform(container.Platform))
+	if err != nil {
+		return nil, err
+	}
+	container.FS = def.ToPB()
+	container.LayerBoundaries = nil
+
+	// the image is no longer the one that was pulled
+	container.ImageRef = ""
+
+	return container, nil
+}
+
+// WithLayerBoundary marks the end of a layer: when the image is published or
+// exported, the layers added since the previous boundary are squashed into one.
+func (container *Container) WithLayerBoundary(ctx context.Context) (*Container, error) {
+	container = container.Clone()
+	container.LayerBoundaries = append(container.LayerBoundaries, container.FS)
+	return container, nil
+}
+
+// imageFSState returns the root filesystem to publish or export, honoring the
+// container's layer boundaries: the layers below the first boundary and above
+// the last one are kept as they are, and the layers between each pair of
+// boundaries are squashed into one.
+func (container *Container) imageFSState() (llb.State, error) {
+	st, err := container.FSState()
+	if err != nil {
+		return llb.State{}, err
+	}
+	if len(container.LayerBoundaries) == 0 {
+		return st, nil
+	}
+
+	boundaries := make([]llb.State, 0, len(container.LayerBoundaries))
+	for _, def := range container.LayerBoundaries {
+		boundary := llb.Scratch()
+		if def != nil {
+			boundary, err = defToState(def)
+			if err != nil {
+				return llb.State{}, err
+			}
+		}
+		boundaries = append(boundaries, boundary)
+	}
+
+	layers := []llb.State{boundaries[0]}
+	for i := 1; i < len(boundaries); i++ {
+		layers = append(layers, squashedLayer(boundaries[i-1], boundaries[i]))
+	}
+	// a diff with an ancestor keeps its layers as they are
+	layers = append(layers, llb.Diff(boundaries[len(boundaries)-1], st))
+
+	return llb.Merge(layers, llb.WithCustomName(buildkit.InternalPrefix+"layer boundaries")), nil
+}
+
+// squashedLayer returns the changes from lower to upper as a single layer.
+//
+// Buildkit keeps the layers of a diff against an ancestor as they are, so the
+// diff is taken against a flattened copy of upper instead.
+func squashedLayer(lower, upper llb.State) llb.State {
+	return llb.Diff(lower, flattenState(upper), llb.WithCustomName(buildkit.InternalPrefix+"squash"))
+}
+
+// flattenState returns a copy of st in a single layer.
+func flattenState(st llb.State) llb.State {
+	return llb.Scratch().File(
+		llb.Copy(st, "/", "/", &llb.CopyInfo{
+			CopyDirContentsOnly: true,
+		}),
+		llb.WithCustomName(buildkit.InternalPrefix+"flatten"),
+	)
+}
+
+func (container *Container) fsDefinition(ctx context.Context) (*pb.Definition, error) {
+	st, err := container.FSState()
+	if err != nil {
+		return nil, err
+	}
+	def, err := st.Marshal(ctx, llb.Platform(container.Platform))
+	if err != nil {
+		return nil, err
+	}
+	return def.ToPB(), nil
+}
+
+// layerCreatedBy extracts the command from the description buildkit gives to
+// the outputs of an exec, e.g. "mount / from exec sh -c make".
+func layerCreatedBy(description string) string {
+	if _, cmd, ok := strings.Cut(description, " from exec "); ok && strings.HasPrefix(description, "mount ") {
+		return cmd
+	}
+	return description
+}
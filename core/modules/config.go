@@ -32,6 +32,13 @@ type Config struct {
 	// Exclude these file globs when loading the module root.
 	Exclude []string `json:"exclude,omitempty"`
 
+	// Exclude the files ignored by .gitignore files when loading the module root.
+	Gitignore bool `json:"gitignore,omitempty"`
+
+	// Exclude the files ignored by this .dockerignore-style file, relative to
+	// the module root, when loading the module root.
+	IgnoreFile string `json:"ignoreFile,omitempty"`
+
 	// Modules that this module depends on.
 	Dependencies []string `json:"dependencies,omitempty"`
 }
@@ -136,8 +136,10 @@ func (ref *Ref) AsModule(ctx context.Context, c *dagger.Client) (*dagger.Module,
 		}
 
 		return c.Host().Directory(modRootDir, dagger.HostDirectoryOpts{
-			Include: cfg.Include,
-			Exclude: cfg.Exclude,
+			Include:    cfg.Include,
+			Exclude:    cfg.Exclude,
+			Gitignore:  cfg.Gitignore,
+			IgnoreFile: cfg.IgnoreFile,
 		}).AsModule(dagger.DirectoryAsModuleOpts{
 			SourceSubpath: subdirRelPath,
 		}), nil
@@ -7,6 +7,10 @@ type Port struct {
 	Port        int             `json:"port"`
 	Protocol    NetworkProtocol `json:"protocol"`
 	Description *string         `json:"description,omitempty"`
+
+	// HealthcheckPath is an HTTP path that must respond successfully for the
+	// service to be considered healthy.
+	HealthcheckPath string `json:"healthcheckPath,omitempty"`
 }
 
 // NetworkProtocol is a string deriving from NetworkProtocol enum
@@ -28,6 +32,27 @@ func (proto NetworkProtocol) Network() string {
 	return strings.ToLower(string(proto))
 }
 
+// NetworkMode determines which network destinations a container's commands
+// may reach.
+type NetworkMode string
+
+const (
+	// NetworkModeDefault allows unrestricted network access.
+	NetworkModeDefault NetworkMode = "NETWORK_DEFAULT"
+
+	// NetworkModeNone allows no network access apart from the loopback
+	// interface.
+	NetworkModeNone NetworkMode = "NETWORK_NONE"
+
+	// NetworkModeServicesOnly only allows network access to the container's
+	// bound services, DNS, and any explicitly allowed destinations.
+	NetworkModeServicesOnly NetworkMode = "NETWORK_SERVICES_ONLY"
+)
+
+func (mode NetworkMode) EnumName() string {
+	return string(mode)
+}
+
 type PortForward struct {
 	Frontend int             `json:"frontend"`
 	Backend  int             `json:"backend"`
@@ -23,11 +23,19 @@ func (s *cacheSchema) Schema() string {
 func (s *cacheSchema) Resolvers() Resolvers {
 	rs := Resolvers{
 		"Query": ObjectResolver{
-			"cacheVolume": ToResolver(s.cacheVolume),
+			"cacheVolume":  ToResolver(s.cacheVolume),
+			"cacheVolumes": ToResolver(s.cacheVolumes),
 		},
 	}
 
-	ResolveIDable[core.CacheVolume](rs, "CacheVolume", ObjectResolver{})
+	ResolveIDable[core.CacheVolume](rs, "CacheVolume", ObjectResolver{
+		"key":          ToResolver(s.key),
+		"size":         ToResolver(s.size),
+		"lastUsed":     ToResolver(s.lastUsed),
+		"prune":        ToResolver(s.prune),
+		"withContents": ToResolver(s.withContents),
+		"snapshot":     ToResolver(s.snapshot),
+	})
 
 	return rs
 }
@@ -47,3 +55,51 @@ func (s *cacheSchema) cacheVolume(ctx context.Context, parent any, args cacheArg
 	// we have to inject something so we can tell it's a valid ID
 	return core.NewCache(args.Key), nil
 }
+
+func (s *cacheSchema) cacheVolumes(ctx context.Context, parent any, args any) ([]*core.CacheVolume, error) {
+	return core.CacheVolumes(ctx, s.bk)
+}
+
+func (s *cacheSchema) key(ctx context.Context, parent *core.CacheVolume, args any) (string, error) {
+	return parent.Key(), nil
+}
+
+func (s *cacheSchema) size(ctx context.Context, parent *core.CacheVolume, args any) (int64, error) {
+	usage, err := parent.Usage(ctx, s.bk)
+	if err != nil {
+		return 0, err
+	}
+	return usage.Size, nil
+}
+
+func (s *cacheSchema) lastUsed(ctx context.Context, parent *core.CacheVolume, args any) (*int, error) {
+	usage, err := parent.Usage(ctx, s.bk)
+	if err != nil {
+		return nil, err
+	}
+	if usage.LastUsedAt == nil {
+		return nil, nil
+	}
+	lastUsed := int(usage.LastUsedAt.Unix())
+	return &lastUsed, nil
+}
+
+func (s *cacheSchema) prune(ctx context.Context, parent *core.CacheVolume, args any) (int64, error) {
+	return parent.Prune(ctx, s.bk)
+}
+
+type cacheWithContentsArgs struct {
+	Source core.DirectoryID
+}
+
+func (s *cacheSchema) withContents(ctx context.Context, parent *core.CacheVolume, args cacheWithContentsArgs) (*core.CacheVolume, error) {
+	dir, err := args.Source.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return parent.WithContents(ctx, s.bk, s.services, dir)
+}
+
+func (s *cacheSchema) snapshot(ctx context.Context, parent *core.CacheVolume, args any) (*core.Directory, error) {
+	return parent.Snapshot(ctx, s.bk, nil, s.platform)
+}
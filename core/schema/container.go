@@ -2,9 +2,11 @@ package schema
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/dagger/dagger/core/pipeline"
 	"github.com/dagger/dagger/core/socket"
 
+	dockerspec "github.com/moby/buildkit/exporter/containerimage/image"
 	"github.com/moby/buildkit/frontend/dockerfile/shell"
 	"github.com/moby/buildkit/util/leaseutil"
 )
@@ -44,7 +47,22 @@ func (s *containerSchema) Schema() string {
 func (s *containerSchema) Resolvers() Resolvers {
 	rs := Resolvers{
 		"Query": ObjectResolver{
-			"container": ToResolver(s.container),
+			"container":      ToResolver(s.container),
+			"copyImage":      ToResolver(s.copyImage),
+			"imageMetadata":  ToResolver(s.imageMetadata),
+			"debugContainer": ToResolver(s.debugContainer),
+		},
+		"ImageMetadata": ObjectResolver{
+			"annotations": ToResolver(s.imageMetadataAnnotations),
+			"platforms":   ToResolver(s.imageMetadataPlatforms),
+			"manifest":    ToResolver(s.imageMetadataManifest),
+		},
+		"ImageManifest": ObjectResolver{
+			"annotations": ToResolver(s.imageManifestAnnotations),
+			"labels":      ToResolver(s.imageManifestLabels),
+		},
+		"Change": ObjectResolver{
+			"kind": ToResolver(s.changeKind),
 		},
 	}
 
@@ -70,6 +88,10 @@ func (s *containerSchema) Resolvers() Resolvers {
 		"label":                   ToResolver(s.label),
 		"labels":                  ToResolver(s.labels),
 		"withoutLabel":            ToResolver(s.withoutLabel),
+		"withAnnotation":          ToResolver(s.withAnnotation),
+		"withoutAnnotation":       ToResolver(s.withoutAnnotation),
+		"imageConfig":             ToResolver(s.imageConfig),
+		"withImageConfig":         ToResolver(s.withImageConfig),
 		"entrypoint":              ToResolver(s.entrypoint),
 		"withEntrypoint":          ToResolver(s.withEntrypoint),
 		"defaultArgs":             ToResolver(s.defaultArgs),
@@ -87,18 +109,30 @@ func (s *containerSchema) Resolvers() Resolvers {
 		"withNewFile":             ToResolver(s.withNewFile),
 		"withDirectory":           ToResolver(s.withDirectory),
 		"withExec":                ToResolver(s.withExec),
+		"withResourceLimits":      ToResolver(s.withResourceLimits),
+		"withNetwork":             ToResolver(s.withNetwork),
 		"stdout":                  ToResolver(s.stdout),
 		"stderr":                  ToResolver(s.stderr),
+		"combinedOutput":          ToResolver(s.combinedOutput),
+		"exitCode":                ToResolver(s.exitCode),
 		"publish":                 ToResolver(s.publish),
+		"publishAll":              ToResolver(s.publishAll),
 		"platform":                ToResolver(s.platform),
 		"export":                  ToResolver(s.export),
 		"asTarball":               ToResolver(s.asTarball),
+		"provenance":              ToResolver(s.provenance),
 		"import":                  ToResolver(s.import_),
 		"withRegistryAuth":        ToResolver(s.withRegistryAuth),
 		"withoutRegistryAuth":     ToResolver(s.withoutRegistryAuth),
 		"imageRef":                ToResolver(s.imageRef),
+		"imageDigest":             ToResolver(s.imageDigest),
+		"diff":                    ToResolver(s.diff),
+		"layers":                  ToResolver(s.layers),
+		"squash":                  ToResolver(s.squash),
+		"withLayerBoundary":       ToResolver(s.withLayerBoundary),
 		"withExposedPort":         ToResolver(s.withExposedPort),
 		"withoutExposedPort":      ToResolver(s.withoutExposedPort),
+		"withHealthcheck":         ToResolver(s.withHealthcheck),
 		"exposedPorts":            ToResolver(s.exposedPorts),
 		"withServiceBinding":      ToResolver(s.withServiceBinding),
 		"withFocus":               ToResolver(s.withFocus),
@@ -144,11 +178,12 @@ func (s *containerSchema) sync(ctx context.Context, parent *core.Container, _ an
 }
 
 type containerFromArgs struct {
-	Address string
+	Address    string
+	VerifyWith string
 }
 
 func (s *containerSchema) from(ctx context.Context, parent *core.Container, args containerFromArgs) (*core.Container, error) {
-	return parent.From(ctx, s.bk, args.Address)
+	return parent.From(ctx, s.bk, args.Address, args.VerifyWith)
 }
 
 type containerBuildArgs struct {
@@ -219,6 +254,31 @@ func (s *containerSchema) stderr(ctx context.Context, parent *core.Container, _
 	return parent.MetaFileContents(ctx, s.bk, s.svcs, s.progSockPath, "stderr")
 }
 
+func (s *containerSchema) combinedOutput(ctx context.Context, parent *core.Container, _ any) (string, error) {
+	return parent.MetaFileContents(ctx, s.bk, s.svcs, s.progSockPath, "combined")
+}
+
+func (s *containerSchema) exitCode(ctx context.Context, parent *core.Container, _ any) (int, error) {
+	return parent.ExitCode(ctx, s.bk, s.svcs, s.progSockPath)
+}
+
+type containerWithResourceLimitsArgs struct {
+	core.ContainerResourceLimitOpts
+}
+
+func (s *containerSchema) withResourceLimits(ctx context.Context, parent *core.Container, args containerWithResourceLimitsArgs) (*core.Container, error) {
+	return parent.WithResourceLimits(args.ContainerResourceLimitOpts)
+}
+
+type containerWithNetworkArgs struct {
+	Mode  core.NetworkMode
+	Allow []string
+}
+
+func (s *containerSchema) withNetwork(ctx context.Context, parent *core.Container, args containerWithNetworkArgs) (*core.Container, error) {
+	return parent.WithNetwork(args.Mode, args.Allow)
+}
+
 type containerGpuArgs struct {
 	core.ContainerGPUOpts
 }
@@ -236,7 +296,7 @@ type containerWithEntrypointArgs struct {
 }
 
 func (s *containerSchema) withEntrypoint(ctx context.Context, parent *core.Container, args containerWithEntrypointArgs) (*core.Container, error) {
-	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+	return parent.UpdateImageConfig(ctx, func(cfg dockerspec.ImageConfig) dockerspec.ImageConfig {
 		cfg.Entrypoint = args.Args
 		return cfg
 	})
@@ -256,7 +316,7 @@ type containerWithDefaultArgs struct {
 }
 
 func (s *containerSchema) withDefaultArgs(ctx context.Context, parent *core.Container, args containerWithDefaultArgs) (*core.Container, error) {
-	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+	return parent.UpdateImageConfig(ctx, func(cfg dockerspec.ImageConfig) dockerspec.ImageConfig {
 		if args.Args == nil {
 			cfg.Cmd = []string{}
 			return cfg
@@ -281,7 +341,7 @@ type containerWithUserArgs struct {
 }
 
 func (s *containerSchema) withUser(ctx context.Context, parent *core.Container, args containerWithUserArgs) (*core.Container, error) {
-	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+	return parent.UpdateImageConfig(ctx, func(cfg dockerspec.ImageConfig) dockerspec.ImageConfig {
 		cfg.User = args.Name
 		return cfg
 	})
@@ -301,7 +361,7 @@ type containerWithWorkdirArgs struct {
 }
 
 func (s *containerSchema) withWorkdir(ctx context.Context, parent *core.Container, args containerWithWorkdirArgs) (*core.Container, error) {
-	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+	return parent.UpdateImageConfig(ctx, func(cfg dockerspec.ImageConfig) dockerspec.ImageConfig {
 		cfg.WorkingDir = absPath(cfg.WorkingDir, args.Path)
 		return cfg
 	})
@@ -323,7 +383,7 @@ type containerWithVariableArgs struct {
 }
 
 func (s *containerSchema) withEnvVariable(ctx context.Context, parent *core.Container, args containerWithVariableArgs) (*core.Container, error) {
-	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+	return parent.UpdateImageConfig(ctx, func(cfg dockerspec.ImageConfig) dockerspec.ImageConfig {
 		value := args.Value
 
 		if args.Expand {
@@ -344,7 +404,7 @@ type containerWithoutVariableArgs struct {
 }
 
 func (s *containerSchema) withoutEnvVariable(ctx context.Context, parent *core.Container, args containerWithoutVariableArgs) (*core.Container, error) {
-	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+	return parent.UpdateImageConfig(ctx, func(cfg dockerspec.ImageConfig) dockerspec.ImageConfig {
 		newEnv := []string{}
 
 		core.WalkEnv(cfg.Env, func(k, _, env string) {
@@ -420,6 +480,20 @@ func (s *containerSchema) labels(ctx context.Context, parent *core.Container, ar
 	return labels, nil
 }
 
+func sortedLabels(m map[string]string) []Label {
+	labels := make([]Label, 0, len(m))
+	for name, value := range m {
+		labels = append(labels, Label{
+			Name:  name,
+			Value: value,
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	return labels
+}
+
 type containerLabelArgs struct {
 	Name string
 }
@@ -456,10 +530,115 @@ type containerPublishArgs struct {
 	PlatformVariants  []core.ContainerID
 	ForcedCompression core.ImageLayerCompression
 	MediaTypes        core.ImageMediaTypes
+	core.ContainerAttestationOpts
+	SignWith core.SecretID
 }
 
 func (s *containerSchema) publish(ctx context.Context, parent *core.Container, args containerPublishArgs) (string, error) {
-	return parent.Publish(ctx, s.bk, s.svcs, args.Address, args.PlatformVariants, args.ForcedCompression, args.MediaTypes)
+	refs, err := s.publishAll(ctx, parent, containerPublishAllArgs{
+		Addresses:                []string{args.Address},
+		PlatformVariants:         args.PlatformVariants,
+		ForcedCompression:        args.ForcedCompression,
+		MediaTypes:               args.MediaTypes,
+		ContainerAttestationOpts: args.ContainerAttestationOpts,
+		SignWith:                 args.SignWith,
+	})
+	if err != nil {
+		return "", err
+	}
+	return refs[0], nil
+}
+
+type containerPublishAllArgs struct {
+	Addresses         []string
+	PlatformVariants  []core.ContainerID
+	ForcedCompression core.ImageLayerCompression
+	MediaTypes        core.ImageMediaTypes
+	core.ContainerAttestationOpts
+	SignWith core.SecretID
+}
+
+func (s *containerSchema) publishAll(ctx context.Context, parent *core.Container, args containerPublishAllArgs) ([]string, error) {
+	refs, err := parent.Publish(ctx, s.bk, s.svcs, args.Addresses, args.PlatformVariants, args.ForcedCompression, args.MediaTypes, args.ContainerAttestationOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.SignWith != "" {
+		key, err := s.secrets.GetSecret(ctx, args.SignWith.String())
+		if err != nil {
+			return nil, err
+		}
+		if err := core.SignImages(ctx, s.bk, refs, key); err != nil {
+			return nil, err
+		}
+	}
+
+	return refs, nil
+}
+
+type copyImageArgs struct {
+	Source       string
+	Destinations []string
+}
+
+func (s *containerSchema) copyImage(ctx context.Context, parent *core.Query, args copyImageArgs) ([]string, error) {
+	return core.CopyImage(ctx, s.bk, args.Source, args.Destinations)
+}
+
+type imageMetadataArgs struct {
+	Address string
+}
+
+func (s *containerSchema) imageMetadata(ctx context.Context, parent *core.Query, args imageMetadataArgs) (*core.ImageMetadata, error) {
+	return core.ResolveImageMetadata(ctx, s.bk, args.Address)
+}
+
+type debugContainerArgs struct {
+	ID string
+}
+
+func (s *containerSchema) debugContainer(ctx context.Context, parent *core.Query, args debugContainerArgs) (*core.Container, error) {
+	return core.DebugContainer(ctx, s.bk, parent.PipelinePath(), args.ID)
+}
+
+func (s *containerSchema) imageMetadataAnnotations(ctx context.Context, parent *core.ImageMetadata, args any) ([]Label, error) {
+	return sortedLabels(parent.Annotations), nil
+}
+
+func (s *containerSchema) imageMetadataPlatforms(ctx context.Context, parent *core.ImageMetadata, args any) ([]specs.Platform, error) {
+	platforms := make([]specs.Platform, 0, len(parent.Manifests))
+	for _, manifest := range parent.Manifests {
+		platforms = append(platforms, manifest.Platform)
+	}
+	return platforms, nil
+}
+
+type imageMetadataManifestArgs struct {
+	Platform *specs.Platform
+}
+
+func (s *containerSchema) imageMetadataManifest(ctx context.Context, parent *core.ImageMetadata, args imageMetadataManifestArgs) (core.ImageManifest, error) {
+	if args.Platform == nil && len(parent.Manifests) == 1 {
+		return parent.Manifests[0], nil
+	}
+	platform := s.MergedSchemas.platform
+	if args.Platform != nil {
+		platform = *args.Platform
+	}
+	return parent.Manifest(platform)
+}
+
+func (s *containerSchema) imageManifestAnnotations(ctx context.Context, parent core.ImageManifest, args any) ([]Label, error) {
+	return sortedLabels(parent.Annotations), nil
+}
+
+func (s *containerSchema) imageManifestLabels(ctx context.Context, parent core.ImageManifest, args any) ([]Label, error) {
+	img, err := parent.ImageConfig()
+	if err != nil {
+		return nil, err
+	}
+	return sortedLabels(img.Config.Labels), nil
 }
 
 type containerWithMountedFileArgs struct {
@@ -528,7 +707,7 @@ type containerWithLabelArgs struct {
 }
 
 func (s *containerSchema) withLabel(ctx context.Context, parent *core.Container, args containerWithLabelArgs) (*core.Container, error) {
-	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+	return parent.UpdateImageConfig(ctx, func(cfg dockerspec.ImageConfig) dockerspec.ImageConfig {
 		if cfg.Labels == nil {
 			cfg.Labels = make(map[string]string)
 		}
@@ -542,12 +721,43 @@ type containerWithoutLabelArgs struct {
 }
 
 func (s *containerSchema) withoutLabel(ctx context.Context, parent *core.Container, args containerWithoutLabelArgs) (*core.Container, error) {
-	return parent.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+	return parent.UpdateImageConfig(ctx, func(cfg dockerspec.ImageConfig) dockerspec.ImageConfig {
 		delete(cfg.Labels, args.Name)
 		return cfg
 	})
 }
 
+type containerWithAnnotationArgs struct {
+	Name  string
+	Value string
+	Index bool
+}
+
+func (s *containerSchema) withAnnotation(ctx context.Context, parent *core.Container, args containerWithAnnotationArgs) (*core.Container, error) {
+	return parent.WithAnnotation(ctx, args.Name, args.Value, args.Index)
+}
+
+type containerWithoutAnnotationArgs struct {
+	Name  string
+	Index bool
+}
+
+func (s *containerSchema) withoutAnnotation(ctx context.Context, parent *core.Container, args containerWithoutAnnotationArgs) (*core.Container, error) {
+	return parent.WithoutAnnotation(ctx, args.Name, args.Index)
+}
+
+func (s *containerSchema) imageConfig(ctx context.Context, parent *core.Container, args any) (dockerspec.ImageConfig, error) {
+	return parent.ImageConfig(ctx)
+}
+
+type containerWithImageConfigArgs struct {
+	Patch json.RawMessage
+}
+
+func (s *containerSchema) withImageConfig(ctx context.Context, parent *core.Container, args containerWithImageConfigArgs) (*core.Container, error) {
+	return parent.WithImageConfig(ctx, args.Patch)
+}
+
 type containerDirectoryArgs struct {
 	Path string
 }
@@ -670,10 +880,11 @@ type containerExportArgs struct {
 	PlatformVariants  []core.ContainerID
 	ForcedCompression core.ImageLayerCompression
 	MediaTypes        core.ImageMediaTypes
+	core.ContainerAttestationOpts
 }
 
 func (s *containerSchema) export(ctx context.Context, parent *core.Container, args containerExportArgs) (bool, error) {
-	if err := parent.Export(ctx, s.bk, s.svcs, args.Path, args.PlatformVariants, args.ForcedCompression, args.MediaTypes); err != nil {
+	if err := parent.Export(ctx, s.bk, s.svcs, args.Path, args.PlatformVariants, args.ForcedCompression, args.MediaTypes, args.ContainerAttestationOpts); err != nil {
 		return false, err
 	}
 
@@ -690,6 +901,14 @@ func (s *containerSchema) asTarball(ctx context.Context, parent *core.Container,
 	return parent.AsTarball(ctx, s.bk, s.MergedSchemas.platform, s.svcs, args.PlatformVariants, args.ForcedCompression, args.MediaTypes)
 }
 
+type containerProvenanceArgs struct {
+	Mode core.ProvenanceMode
+}
+
+func (s *containerSchema) provenance(ctx context.Context, parent *core.Container, args containerProvenanceArgs) (*core.File, error) {
+	return parent.ProvenanceFile(ctx, s.bk, s.svcs, args.Mode)
+}
+
 type containerImportArgs struct {
 	Source core.FileID
 	Tag    string
@@ -740,13 +959,59 @@ func (s *containerSchema) withoutRegistryAuth(_ context.Context, parents *core.C
 	return parents, nil
 }
 
+func (s *containerSchema) imageDigest(ctx context.Context, parent *core.Container, args any) (string, error) {
+	return parent.ImageDigest(ctx, s.bk, s.svcs)
+}
+
+type containerDiffArgs struct {
+	Other core.ContainerID
+}
+
+func (s *containerSchema) diff(ctx context.Context, parent *core.Container, args containerDiffArgs) ([]core.Change, error) {
+	other, err := args.Other.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return parent.Diff(ctx, s.bk, s.svcs, other)
+}
+
+func (s *containerSchema) changeKind(ctx context.Context, change core.Change, args any) (string, error) {
+	// NB: return the enum name so the resolver layer can look up the value
+	return change.Kind.EnumName(), nil
+}
+
+func (s *containerSchema) layers(ctx context.Context, parent *core.Container, args any) ([]core.ContainerLayer, error) {
+	return parent.Layers(ctx, s.bk, s.svcs)
+}
+
+type containerSquashArgs struct {
+	From core.ContainerID
+}
+
+func (s *containerSchema) squash(ctx context.Context, parent *core.Container, args containerSquashArgs) (*core.Container, error) {
+	var base *core.Container
+	if args.From != "" {
+		var err error
+		base, err = args.From.Decode()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parent.Squash(ctx, base)
+}
+
+func (s *containerSchema) withLayerBoundary(ctx context.Context, parent *core.Container, args any) (*core.Container, error) {
+	return parent.WithLayerBoundary(ctx)
+}
+
 func (s *containerSchema) imageRef(ctx context.Context, parent *core.Container, args containerWithVariableArgs) (string, error) {
 	return parent.ImageRefOrErr(ctx, s.bk)
 }
 
 type containerWithServiceBindingArgs struct {
-	Service core.ServiceID
-	Alias   string
+	Service   core.ServiceID
+	Alias     string
+	DependsOn []string
 }
 
 func (s *containerSchema) withServiceBinding(ctx context.Context, parent *core.Container, args containerWithServiceBindingArgs) (*core.Container, error) {
@@ -755,20 +1020,22 @@ func (s *containerSchema) withServiceBinding(ctx context.Context, parent *core.C
 		return nil, err
 	}
 
-	return parent.WithServiceBinding(ctx, s.svcs, svc, args.Alias)
+	return parent.WithServiceBinding(ctx, s.svcs, svc, args.Alias, args.DependsOn)
 }
 
 type containerWithExposedPortArgs struct {
-	Protocol    core.NetworkProtocol
-	Port        int
-	Description *string
+	Protocol        core.NetworkProtocol
+	Port            int
+	Description     *string
+	HealthcheckPath string
 }
 
 func (s *containerSchema) withExposedPort(ctx context.Context, parent *core.Container, args containerWithExposedPortArgs) (*core.Container, error) {
 	return parent.WithExposedPort(core.Port{
-		Protocol:    args.Protocol,
-		Port:        args.Port,
-		Description: args.Description,
+		Protocol:        args.Protocol,
+		Port:            args.Port,
+		Description:     args.Description,
+		HealthcheckPath: args.HealthcheckPath,
 	})
 }
 
@@ -781,12 +1048,31 @@ func (s *containerSchema) withoutExposedPort(ctx context.Context, parent *core.C
 	return parent.WithoutExposedPort(args.Port, args.Protocol)
 }
 
+type containerWithHealthcheckArgs struct {
+	Args        []string
+	Interval    int
+	Timeout     int
+	Retries     int
+	StartPeriod int
+}
+
+func (s *containerSchema) withHealthcheck(ctx context.Context, parent *core.Container, args containerWithHealthcheckArgs) (*core.Container, error) {
+	return parent.WithHealthcheck(core.ContainerHealthcheck{
+		Args:        args.Args,
+		Interval:    args.Interval,
+		Timeout:     args.Timeout,
+		Retries:     args.Retries,
+		StartPeriod: args.StartPeriod,
+	})
+}
+
 // NB(vito): we have to use a different type with a regular string Protocol
 // field so that the enum mapping works.
 type ExposedPort struct {
-	Port        int     `json:"port"`
-	Protocol    string  `json:"protocol"`
-	Description *string `json:"description,omitempty"`
+	Port            int     `json:"port"`
+	Protocol        string  `json:"protocol"`
+	Description     *string `json:"description,omitempty"`
+	HealthcheckPath string  `json:"healthcheckPath,omitempty"`
 }
 
 func (s *containerSchema) exposedPorts(ctx context.Context, parent *core.Container, args any) ([]ExposedPort, error) {
@@ -795,9 +1081,10 @@ func (s *containerSchema) exposedPorts(ctx context.Context, parent *core.Contain
 	for _, p := range parent.Ports {
 		ociPort := fmt.Sprintf("%d/%s", p.Port, p.Protocol.Network())
 		ports[ociPort] = ExposedPort{
-			Port:        p.Port,
-			Protocol:    string(p.Protocol),
-			Description: p.Description,
+			Port:            p.Port,
+			Protocol:        string(p.Protocol),
+			Description:     p.Description,
+			HealthcheckPath: p.HealthcheckPath,
 		}
 	}
 
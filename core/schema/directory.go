@@ -33,6 +33,15 @@ func (s *directorySchema) Resolvers() Resolvers {
 		"Query": ObjectResolver{
 			"directory": ToResolver(s.directory),
 		},
+		"DirectoryEntry": ObjectResolver{
+			"type":          ToResolver(s.entryType),
+			"symlinkTarget": ToResolver(s.entrySymlinkTarget),
+		},
+		"FileChange": ObjectResolver{
+			"kind":    ToResolver(s.fileChangeKind),
+			"type":    ToResolver(s.fileChangeType),
+			"oldPath": ToResolver(s.fileChangeOldPath),
+		},
 	}
 
 	ResolveIDable[core.Directory](rs, "Directory", ObjectResolver{
@@ -40,6 +49,8 @@ func (s *directorySchema) Resolvers() Resolvers {
 		"pipeline":         ToResolver(s.pipeline),
 		"entries":          ToResolver(s.entries),
 		"glob":             ToResolver(s.glob),
+		"stat":             ToResolver(s.stat),
+		"walk":             ToResolver(s.walk),
 		"file":             ToResolver(s.file),
 		"withFile":         ToResolver(s.withFile),
 		"withNewFile":      ToResolver(s.withNewFile),
@@ -47,10 +58,16 @@ func (s *directorySchema) Resolvers() Resolvers {
 		"directory":        ToResolver(s.subdirectory),
 		"withDirectory":    ToResolver(s.withDirectory),
 		"withTimestamps":   ToResolver(s.withTimestamps),
+		"asArchive":        ToResolver(s.asArchive),
+		"digest":           ToResolver(s.digest),
 		"withNewDirectory": ToResolver(s.withNewDirectory),
 		"withoutDirectory": ToResolver(s.withoutDirectory),
 		"diff":             ToResolver(s.diff),
+		"changes":          ToResolver(s.changes),
+		"asPatch":          ToResolver(s.asPatch),
+		"withPatch":        ToResolver(s.withPatch),
 		"export":           ToResolver(s.export),
+		"exportChanges":    ToResolver(s.exportChanges),
 		"dockerBuild":      ToResolver(s.dockerBuild),
 	})
 
@@ -131,6 +148,28 @@ func (s *directorySchema) withTimestamps(ctx context.Context, parent *core.Direc
 	return parent.WithTimestamps(ctx, args.Timestamp)
 }
 
+type asArchiveArgs struct {
+	Format      core.ArchiveFormat
+	Compression core.ImageLayerCompression
+}
+
+func (s *directorySchema) asArchive(ctx context.Context, parent *core.Directory, args asArchiveArgs) (*core.File, error) {
+	return parent.AsArchive(ctx, s.bk, s.svcs, args.Format, args.Compression)
+}
+
+type dirDigestArgs struct {
+	Include []string
+	Exclude []string
+}
+
+func (s *directorySchema) digest(ctx context.Context, parent *core.Directory, args dirDigestArgs) (string, error) {
+	dgst, err := parent.ContentDigest(ctx, s.bk, s.svcs, args.Include, args.Exclude)
+	if err != nil {
+		return "", err
+	}
+	return dgst.String(), nil
+}
+
 type entriesArgs struct {
 	Path string
 }
@@ -147,6 +186,41 @@ func (s *directorySchema) glob(ctx context.Context, parent *core.Directory, args
 	return parent.Glob(ctx, s.bk, s.svcs, ".", args.Pattern)
 }
 
+type dirStatArgs struct {
+	Path string
+}
+
+func (s *directorySchema) stat(ctx context.Context, parent *core.Directory, args dirStatArgs) (*core.DirectoryEntry, error) {
+	return parent.StatEntry(ctx, s.bk, s.svcs, args.Path)
+}
+
+type dirWalkArgs struct {
+	Path     string
+	Include  []string
+	Exclude  []string
+	MaxDepth int
+}
+
+func (s *directorySchema) walk(ctx context.Context, parent *core.Directory, args dirWalkArgs) ([]*core.DirectoryEntry, error) {
+	filter := core.CopyFilter{
+		Include: args.Include,
+		Exclude: args.Exclude,
+	}
+	return parent.Walk(ctx, s.bk, s.svcs, args.Path, filter, args.MaxDepth)
+}
+
+func (s *directorySchema) entryType(ctx context.Context, entry core.DirectoryEntry, args any) (string, error) {
+	// NB: return the enum name so the resolver layer can look up the value
+	return entry.Type.EnumName(), nil
+}
+
+func (s *directorySchema) entrySymlinkTarget(ctx context.Context, entry core.DirectoryEntry, args any) (*string, error) {
+	if entry.SymlinkTarget == "" {
+		return nil, nil
+	}
+	return &entry.SymlinkTarget, nil
+}
+
 type dirFileArgs struct {
 	Path string
 }
@@ -208,12 +282,59 @@ func (s *directorySchema) diff(ctx context.Context, parent *core.Directory, args
 	return parent.Diff(ctx, dir)
 }
 
+func (s *directorySchema) changes(ctx context.Context, parent *core.Directory, args diffArgs) ([]core.FileChange, error) {
+	other, err := args.Other.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return parent.Changes(ctx, s.bk, s.svcs, other)
+}
+
+func (s *directorySchema) fileChangeKind(ctx context.Context, change core.FileChange, args any) (string, error) {
+	// NB: return the enum name so the resolver layer can look up the value
+	return change.Kind.EnumName(), nil
+}
+
+func (s *directorySchema) fileChangeType(ctx context.Context, change core.FileChange, args any) (string, error) {
+	// NB: return the enum name so the resolver layer can look up the value
+	return change.Type.EnumName(), nil
+}
+
+func (s *directorySchema) fileChangeOldPath(ctx context.Context, change core.FileChange, args any) (*string, error) {
+	if change.OldPath == "" {
+		return nil, nil
+	}
+	return &change.OldPath, nil
+}
+
+func (s *directorySchema) asPatch(ctx context.Context, parent *core.Directory, args diffArgs) (*core.File, error) {
+	other, err := args.Other.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return parent.AsPatch(ctx, s.bk, s.svcs, other)
+}
+
+type withPatchArgs struct {
+	Patch core.FileID
+}
+
+func (s *directorySchema) withPatch(ctx context.Context, parent *core.Directory, args withPatchArgs) (*core.Directory, error) {
+	patch, err := args.Patch.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return parent.WithPatch(ctx, s.bk, s.svcs, patch)
+}
+
 type dirExportArgs struct {
-	Path string
+	Path    string
+	Mirror  bool
+	Exclude []string
 }
 
 func (s *directorySchema) export(ctx context.Context, parent *core.Directory, args dirExportArgs) (bool, error) {
-	err := parent.Export(ctx, s.bk, s.host, s.svcs, args.Path)
+	err := parent.Export(ctx, s.bk, s.host, s.svcs, args.Path, args.Mirror, args.Exclude)
 	if err != nil {
 		return false, err
 	}
@@ -221,6 +342,10 @@ func (s *directorySchema) export(ctx context.Context, parent *core.Directory, ar
 	return true, nil
 }
 
+func (s *directorySchema) exportChanges(ctx context.Context, parent *core.Directory, args dirExportArgs) ([]core.FileChange, error) {
+	return parent.ExportChanges(ctx, s.bk, s.host, s.svcs, args.Path, args.Mirror, args.Exclude)
+}
+
 type dirDockerBuildArgs struct {
 	Platform   *specs.Platform
 	Dockerfile string
@@ -1,6 +1,11 @@
 package schema
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/graphql/gqlerrors"
+)
 
 var (
 	ErrMergeTypeConflict   = errors.New("object type re-defined")
@@ -19,3 +24,38 @@ func (e InvalidInputError) Error() string {
 func (e InvalidInputError) Unwrap() error {
 	return e.Err
 }
+
+func (e InvalidInputError) Extensions() map[string]any {
+	return map[string]any{
+		"_type": core.ErrorTypeInvalidInput,
+	}
+}
+
+// extendedError carries the extensions of a typed error that has been
+// wrapped with extra context, since the GraphQL library only inspects the
+// outermost error.
+type extendedError struct {
+	error
+	extensions map[string]any
+}
+
+func (e extendedError) Unwrap() error {
+	return e.error
+}
+
+func (e extendedError) Extensions() map[string]any {
+	return e.extensions
+}
+
+// withExtensions ensures the extensions of any typed error in err's chain are
+// reported to the client.
+func withExtensions(err error) error {
+	if _, ok := err.(gqlerrors.ExtendedError); ok {
+		return err
+	}
+	var ext gqlerrors.ExtendedError
+	if errors.As(err, &ext) {
+		return extendedError{err, ext.Extensions()}
+	}
+	return err
+}
@@ -36,6 +36,8 @@ func (s *fileSchema) Resolvers() Resolvers {
 		"size":           ToResolver(s.size),
 		"export":         ToResolver(s.export),
 		"withTimestamps": ToResolver(s.withTimestamps),
+		"extract":        ToResolver(s.extract),
+		"digest":         ToResolver(s.digest),
 	})
 
 	return rs
@@ -100,3 +102,19 @@ type fileWithTimestampsArgs struct {
 func (s *fileSchema) withTimestamps(ctx context.Context, parent *core.File, args fileWithTimestampsArgs) (*core.File, error) {
 	return parent.WithTimestamps(ctx, args.Timestamp)
 }
+
+func (s *fileSchema) extract(ctx context.Context, parent *core.File, args any) (*core.Directory, error) {
+	return parent.Extract(ctx, s.bk, s.svcs)
+}
+
+type fileDigestArgs struct {
+	Algorithm core.DigestAlgorithm
+}
+
+func (s *fileSchema) digest(ctx context.Context, parent *core.File, args fileDigestArgs) (string, error) {
+	dgst, err := parent.ContentDigest(ctx, s.bk, s.svcs, args.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	return dgst.String(), nil
+}
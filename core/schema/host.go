@@ -69,10 +69,11 @@ type hostDirectoryArgs struct {
 	Path string
 
 	core.CopyFilter
+	core.IgnoreFilter
 }
 
 func (s *hostSchema) directory(ctx context.Context, parent *core.Query, args hostDirectoryArgs) (*core.Directory, error) {
-	return s.host.Directory(ctx, s.bk, args.Path, parent.PipelinePath(), "host.directory", s.platform, args.CopyFilter)
+	return s.host.Directory(ctx, s.bk, args.Path, parent.PipelinePath(), "host.directory", s.platform, args.CopyFilter, args.IgnoreFilter)
 }
 
 type hostSocketArgs struct {
@@ -36,7 +36,8 @@ func (s *querySchema) Resolvers() Resolvers {
 			"checkVersionCompatibility": ToResolver(s.checkVersionCompatibility),
 		},
 		"Port": ObjectResolver{
-			"protocol": ToResolver(s.portProtocolHack),
+			"protocol":        ToResolver(s.portProtocolHack),
+			"healthcheckPath": ToResolver(s.portHealthcheckPath),
 		},
 	}
 }
@@ -120,3 +121,10 @@ func (s *querySchema) portProtocolHack(ctx context.Context, port core.Port, args
 	// lookup the enum value by name.
 	return port.Protocol.EnumName(), nil
 }
+
+func (s *querySchema) portHealthcheckPath(ctx context.Context, port core.Port, args any) (*string, error) {
+	if port.HealthcheckPath == "" {
+		return nil, nil
+	}
+	return &port.HealthcheckPath, nil
+}
@@ -108,7 +108,7 @@ func ToResolver[P any, A any, R any](f func(context.Context, P, A) (R, error)) g
 		res, err := f(ctx, parent, args)
 		if err != nil {
 			vtx.Done(err)
-			return nil, err
+			return nil, withExtensions(err)
 		}
 
 		if edible, ok := any(res).(resourceid.Digestible); ok {
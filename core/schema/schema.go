@@ -284,20 +284,23 @@ func (s *schemaView) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 			msg := "Internal Server Error"
 			code := http.StatusInternalServerError
+			var extensions map[string]any
 			switch v := v.(type) {
 			case error:
 				msg = v.Error()
-				if errors.As(v, &InvalidInputError{}) {
+				var inputErr InvalidInputError
+				if errors.As(v, &inputErr) {
 					// panics can happen on invalid input in scalar serde
 					code = http.StatusBadRequest
+					extensions = inputErr.Extensions()
 				}
 			case string:
 				msg = v
 			}
+			formatted := gqlerrors.NewFormattedError(msg)
+			formatted.Extensions = extensions
 			res := graphql.Result{
-				Errors: []gqlerrors.FormattedError{
-					gqlerrors.NewFormattedError(msg),
-				},
+				Errors: []gqlerrors.FormattedError{formatted},
 			}
 			bytes, err := json.Marshal(res)
 			if err != nil {
@@ -9,7 +9,7 @@ import (
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/core/modules"
 	ciconsts "github.com/dagger/dagger/internal/mage/consts"
-	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	dockerspec "github.com/moby/buildkit/exporter/containerimage/image"
 	"github.com/vito/progrock"
 )
 
@@ -381,7 +381,7 @@ func (sdk *goSDK) Runtime(ctx context.Context, mod *core.Module) (*core.Containe
 		return nil, fmt.Errorf("failed to exec go build in go module sdk container runtime: %w", err)
 	}
 
-	ctr, err = ctr.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+	ctr, err = ctr.UpdateImageConfig(ctx, func(cfg dockerspec.ImageConfig) dockerspec.ImageConfig {
 		cfg.Entrypoint = []string{goSDKRuntimePath}
 		return cfg
 	})
@@ -430,7 +430,7 @@ func (sdk *goSDK) baseWithCodegen(ctx context.Context, mod *core.Module) (*core.
 	if err != nil {
 		return nil, fmt.Errorf("failed to mount module source into go module sdk container codegen: %w", err)
 	}
-	ctr, err = ctr.UpdateImageConfig(ctx, func(cfg specs.ImageConfig) specs.ImageConfig {
+	ctr, err = ctr.UpdateImageConfig(ctx, func(cfg dockerspec.ImageConfig) dockerspec.ImageConfig {
 		cfg.WorkingDir = filepath.Join(goSDKUserModSourceDirPath, mod.SourceDirectorySubpath)
 		cfg.Cmd = nil
 		return cfg
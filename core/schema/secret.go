@@ -23,8 +23,9 @@ func (s *secretSchema) Schema() string {
 func (s *secretSchema) Resolvers() Resolvers {
 	rs := Resolvers{
 		"Query": ObjectResolver{
-			"secret":    ToResolver(s.secret),
-			"setSecret": ToResolver(s.setSecret),
+			"secret":       ToResolver(s.secret),
+			"setSecret":    ToResolver(s.setSecret),
+			"setSecretURI": ToResolver(s.setSecretURI),
 		},
 	}
 
@@ -68,6 +69,20 @@ func (s *secretSchema) setSecret(ctx context.Context, parent any, args setSecret
 	return secretID.Decode()
 }
 
+type setSecretURIArgs struct {
+	Name string
+	URI  string
+}
+
+func (s *secretSchema) setSecretURI(ctx context.Context, parent any, args setSecretURIArgs) (*core.Secret, error) {
+	secretID, err := s.secrets.AddSecretRef(ctx, s.bk, args.Name, args.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	return secretID.Decode()
+}
+
 func (s *secretSchema) plaintext(ctx context.Context, parent *core.Secret, args any) (string, error) {
 	id, err := parent.ID()
 	if err != nil {
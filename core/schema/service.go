@@ -34,6 +34,11 @@ func (s *serviceSchema) Resolvers() Resolvers {
 		"hostname": ToResolver(s.hostname),
 		"ports":    ToResolver(s.ports),
 		"endpoint": ToResolver(s.endpoint),
+		"health":   ToResolver(s.health),
+		"status":   ToResolver(s.status),
+		"exitCode": ToResolver(s.exitCode),
+		"stdout":   ToResolver(s.stdout),
+		"stderr":   ToResolver(s.stderr),
 		"start":    ToResolver(s.start),
 		"stop":     ToResolver(s.stop),
 	})
@@ -66,6 +71,42 @@ func (s *serviceSchema) endpoint(ctx context.Context, parent *core.Service, args
 	return parent.Endpoint(ctx, s.svcs, args.Port, args.Scheme)
 }
 
+func (s *serviceSchema) health(ctx context.Context, parent *core.Service, args any) (string, error) {
+	status, err := parent.Health(ctx, s.svcs)
+	if err != nil {
+		return "", err
+	}
+	// NB: return the enum name so the resolver layer can look up the value
+	return status.EnumName(), nil
+}
+
+func (s *serviceSchema) status(ctx context.Context, parent *core.Service, args any) (string, error) {
+	status, err := parent.Status(ctx, s.svcs)
+	if err != nil {
+		return "", err
+	}
+	return status.EnumName(), nil
+}
+
+func (s *serviceSchema) exitCode(ctx context.Context, parent *core.Service, args any) (*int, error) {
+	code, exited, err := parent.ExitCode(ctx, s.svcs)
+	if err != nil {
+		return nil, err
+	}
+	if !exited {
+		return nil, nil
+	}
+	return &code, nil
+}
+
+func (s *serviceSchema) stdout(ctx context.Context, parent *core.Service, args any) (string, error) {
+	return parent.Stdout(ctx, s.svcs)
+}
+
+func (s *serviceSchema) stderr(ctx context.Context, parent *core.Service, args any) (string, error) {
+	return parent.Stderr(ctx, s.svcs)
+}
+
 func (s *serviceSchema) start(ctx context.Context, parent *core.Service, args any) (core.ServiceID, error) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -2,9 +2,13 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/dagger/dagger/core/resourceid"
+	"github.com/dagger/dagger/engine"
+	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/moby/buildkit/session/secrets"
 	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
@@ -38,6 +42,7 @@ func (secret *Secret) Digest() (digest.Digest, error) {
 func NewSecretStore() *SecretStore {
 	return &SecretStore{
 		secrets: map[string][]byte{},
+		refs:    map[string]secretRef{},
 	}
 }
 
@@ -46,6 +51,15 @@ var _ secrets.SecretStore = &SecretStore{}
 type SecretStore struct {
 	mu      sync.Mutex
 	secrets map[string][]byte
+	refs    map[string]secretRef
+}
+
+// secretRef is a secret whose value is held by a client and resolved through
+// its session each time the secret is read.
+type secretRef struct {
+	bk            *buildkit.Client
+	ownerClientID string
+	uri           string
 }
 
 // AddSecret adds the secret identified by user defined name with its plaintext
@@ -58,6 +72,36 @@ func (store *SecretStore) AddSecret(_ context.Context, name string, plaintext []
 
 	// add the plaintext to the map
 	store.secrets[secret.Name] = plaintext
+	delete(store.refs, secret.Name)
+
+	return secret.ID()
+}
+
+// AddSecretRef adds the secret identified by user defined name with a URI
+// reference to its value (e.g. env://NAME). The reference is resolved by the
+// calling client only when the secret is actually read, so the plaintext never
+// needs to be sent to the server up front.
+func (store *SecretStore) AddSecretRef(ctx context.Context, bk *buildkit.Client, name string, uri string) (SecretID, error) {
+	if _, _, ok := strings.Cut(uri, "://"); !ok {
+		return "", fmt.Errorf("invalid secret reference %q: expected <scheme>://<ref>", uri)
+	}
+
+	clientMetadata, err := engine.ClientMetadataFromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	secret := NewDynamicSecret(name)
+
+	store.refs[secret.Name] = secretRef{
+		bk:            bk,
+		ownerClientID: clientMetadata.ClientID,
+		uri:           uri,
+	}
+	delete(store.secrets, secret.Name)
 
 	return secret.ID()
 }
@@ -72,9 +116,6 @@ func (store *SecretStore) AddSecret(_ context.Context, name string, plaintext []
 //
 // In all other cases, a SecretID is expected.
 func (store *SecretStore) GetSecret(ctx context.Context, idOrName string) ([]byte, error) {
-	store.mu.Lock()
-	defer store.mu.Unlock()
-
 	var name string
 	if secret, err := SecretID(idOrName).Decode(); err == nil {
 		name = secret.Name
@@ -82,10 +123,34 @@ func (store *SecretStore) GetSecret(ctx context.Context, idOrName string) ([]byt
 		name = idOrName
 	}
 
+	store.mu.Lock()
 	plaintext, ok := store.secrets[name]
-	if !ok {
+	ref, isRef := store.refs[name]
+	store.mu.Unlock()
+
+	if ok {
+		return plaintext, nil
+	}
+
+	if !isRef {
 		return nil, errors.Wrapf(secrets.ErrNotFound, "secret %s", name)
 	}
 
+	// NB: the resolved value is deliberately not cached so that it never
+	// outlives the operation that needed it.
+	plaintext, err := ref.resolve(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "secret %s", name)
+	}
+
 	return plaintext, nil
 }
+
+func (ref secretRef) resolve(ctx context.Context) ([]byte, error) {
+	caller, err := ref.bk.GetSessionCaller(ctx, ref.ownerClientID)
+	if err != nil {
+		return nil, fmt.Errorf("get session of client %s: %w", ref.ownerClientID, err)
+	}
+
+	return secrets.GetSecret(ctx, caller, ref.uri)
+}
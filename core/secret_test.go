@@ -21,3 +21,9 @@ func TestSecretStoreNotFound(t *testing.T) {
 	_, err := store.GetSecret(context.Background(), "foo")
 	require.ErrorIs(t, err, secrets.ErrNotFound)
 }
+
+func TestSecretStoreInvalidRef(t *testing.T) {
+	store := NewSecretStore()
+	_, err := store.AddSecretRef(context.Background(), nil, "foo", "not-a-uri")
+	require.ErrorContains(t, err, "invalid secret reference")
+}
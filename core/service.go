@@ -17,6 +17,8 @@ import (
 	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/dagger/dagger/network"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	bkgwpb "github.com/moby/buildkit/frontend/gateway/pb"
+	"github.com/moby/buildkit/identity"
 	"github.com/moby/buildkit/solver/pb"
 	"github.com/opencontainers/go-digest"
 	"github.com/vito/progrock"
@@ -24,6 +26,24 @@ import (
 
 const (
 	ShimEnableTTYEnvVar = "_DAGGER_ENABLE_TTY"
+
+	// ShimExpectNonZeroExitEnvVar tells the shim to succeed even if the
+	// command exits non-zero, recording the exit code instead.
+	ShimExpectNonZeroExitEnvVar = "_DAGGER_EXPECT_NON_ZERO_EXIT"
+
+	// ShimTimeoutEnvVar tells the shim to kill the command after the given
+	// number of seconds. It is set when setting up the container bundle rather
+	// than by the exec itself, so that the timeout doesn't bust the cache.
+	ShimTimeoutEnvVar = "_DAGGER_EXEC_TIMEOUT"
+
+	// ShimNetworkModeEnvVar tells the shim to restrict the container's network
+	// access according to a NetworkMode.
+	ShimNetworkModeEnvVar = "_DAGGER_NETWORK_MODE"
+
+	// ShimNetworkAllowEnvVar is a comma-separated list of hostnames, IPs and
+	// CIDRs that the shim allows in addition to DNS when the network mode is
+	// NETWORK_SERVICES_ONLY.
+	ShimNetworkAllowEnvVar = "_DAGGER_NETWORK_ALLOW"
 )
 
 type Service struct {
@@ -200,6 +220,75 @@ func (svc *Service) Endpoint(ctx context.Context, svcs *Services, port int, sche
 	return endpoint, nil
 }
 
+// Health returns the current health of the service, which must be running.
+func (svc *Service) Health(ctx context.Context, svcs *Services) (ServiceHealthStatus, error) {
+	running, err := svcs.Get(ctx, svc)
+	if err != nil {
+		return "", err
+	}
+	return running.HealthStatus(), nil
+}
+
+// Status returns the lifecycle state of the service.
+func (svc *Service) Status(ctx context.Context, svcs *Services) (ServiceStatus, error) {
+	status, _, err := svcs.Inspect(ctx, svc)
+	return status, err
+}
+
+// ExitCode returns the exit code of the service's process and true if it has
+// exited, either on its own or because it was stopped.
+func (svc *Service) ExitCode(ctx context.Context, svcs *Services) (int, bool, error) {
+	running, err := svc.lastRun(ctx, svcs)
+	if err != nil {
+		return 0, false, err
+	}
+	code, exited := running.Exited()
+	return code, exited, nil
+}
+
+// Stdout returns the most recent stdout of the service's process, including
+// its last run if it has since been stopped.
+func (svc *Service) Stdout(ctx context.Context, svcs *Services) (string, error) {
+	running, err := svc.lastRun(ctx, svcs)
+	if err != nil {
+		return "", err
+	}
+	if running.Stdout == nil {
+		return "", nil
+	}
+	return running.Stdout.String(), nil
+}
+
+// Stderr returns the most recent stderr of the service's process, including
+// its last run if it has since been stopped.
+func (svc *Service) Stderr(ctx context.Context, svcs *Services) (string, error) {
+	running, err := svc.lastRun(ctx, svcs)
+	if err != nil {
+		return "", err
+	}
+	if running.Stderr == nil {
+		return "", nil
+	}
+	return running.Stderr.String(), nil
+}
+
+// lastRun returns the running service, or the last one to run if it has been
+// stopped.
+func (svc *Service) lastRun(ctx context.Context, svcs *Services) (*RunningService, error) {
+	_, running, err := svcs.Inspect(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+	if running == nil {
+		dig, err := svc.Digest()
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("service %s has not been started", network.HostHash(dig))
+	}
+	return running, nil
+}
+
 func (svc *Service) Start(
 	ctx context.Context,
 	bk *buildkit.Client,
@@ -271,6 +360,11 @@ func (svc *Service) startContainer(
 		return nil, fmt.Errorf("service container must be result of withExec (expected exec op, got %T)", dag.GetOp())
 	}
 
+	healthcheck, err := imageHealthcheck(ctr.Config)
+	if err != nil {
+		return nil, fmt.Errorf("health check: %w", err)
+	}
+
 	detachDeps, _, err := svcs.StartBindings(ctx, bk, ctr.Services)
 	if err != nil {
 		return nil, fmt.Errorf("start dependent services: %w", err)
@@ -354,11 +448,16 @@ func (svc *Service) startContainer(
 		execOp.Meta.ProxyEnv = &pb.ProxyEnv{}
 	}
 
-	execOp.Meta.ProxyEnv.FtpProxy, err = buildkit.ContainerExecUncachedMetadata{
-		ParentClientIDs: clientMetadata.ClientIDs(),
-		ServerID:        clientMetadata.ServerID,
-		ProgSockPath:    bk.ProgSockPath,
-	}.ToPBFtpProxyVal()
+	// keep any settings specific to the exec, e.g. its resource limits
+	var execMD buildkit.ContainerExecUncachedMetadata
+	if err := execMD.FromPBFtpProxyVal(execOp.Meta.ProxyEnv.FtpProxy); err != nil {
+		return nil, err
+	}
+	execMD.ParentClientIDs = clientMetadata.ClientIDs()
+	execMD.ServerID = clientMetadata.ServerID
+	execMD.ProgSockPath = bk.ProgSockPath
+
+	execOp.Meta.ProxyEnv.FtpProxy, err = execMD.ToPBFtpProxyVal()
 	if err != nil {
 		return nil, err
 	}
@@ -370,6 +469,8 @@ func (svc *Service) startContainer(
 	}
 
 	outBuf := new(bytes.Buffer)
+	stdoutBuf := NewOutputBuffer(ServiceOutputLimit)
+	stderrBuf := NewOutputBuffer(ServiceOutputLimit)
 	var stdinCtr, stdoutClient, stderrClient io.ReadCloser
 	var stdinClient, stdoutCtr, stderrCtr io.WriteCloser
 	if forwardStdin != nil {
@@ -379,16 +480,16 @@ func (svc *Service) startContainer(
 	if forwardStdout != nil {
 		stdoutClient, stdoutCtr = io.Pipe()
 	} else {
-		stdoutCtr = nopCloser{io.MultiWriter(vtx.Stdout(), outBuf)}
+		stdoutCtr = nopCloser{io.MultiWriter(vtx.Stdout(), outBuf, stdoutBuf)}
 	}
 
 	if forwardStderr != nil {
 		stderrClient, stderrCtr = io.Pipe()
 	} else {
-		stderrCtr = nopCloser{io.MultiWriter(vtx.Stderr(), outBuf)}
+		stderrCtr = nopCloser{io.MultiWriter(vtx.Stderr(), outBuf, stderrBuf)}
 	}
 
-	svcProc, err := gc.Start(ctx, bkgw.StartRequest{
+	startReq := bkgw.StartRequest{
 		Args:         execOp.Meta.Args,
 		Env:          env,
 		Cwd:          execOp.Meta.Cwd,
@@ -399,11 +500,47 @@ func (svc *Service) startContainer(
 		Stdout:       stdoutCtr,
 		Stderr:       stderrCtr,
 		SecurityMode: execOp.Security,
-	})
+	}
+	svcProc, err := gc.Start(ctx, startReq)
 	if err != nil {
 		return nil, fmt.Errorf("start container: %w", err)
 	}
 
+	// stops monitoring the service's health once it's stopped
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	defer func() {
+		if err != nil {
+			stopHealth()
+		}
+	}()
+
+	var execHealth *execHealthChecker
+	healthy := make(chan error, 1)
+	if healthcheck != nil {
+		healthVtx := rec.Vertex(
+			digest.Digest(identity.NewID()),
+			"health check "+strings.Join(healthcheck.Args, " "),
+			progrock.Internal(),
+		)
+		execHealth = newExecHealth(gc, *healthcheck, startReq, healthVtx)
+		go func() {
+			defer healthVtx.Done(nil)
+			if err := <-checked; err != nil {
+				healthy <- err
+				return
+			}
+			err := execHealth.WaitHealthy(healthCtx)
+			healthy <- err
+			if err == nil {
+				execHealth.Monitor(healthCtx)
+			}
+		}()
+	} else {
+		go func() {
+			healthy <- <-checked
+		}()
+	}
+
 	if forwardStdin != nil {
 		forwardStdin(stdinClient, svcProc)
 	}
@@ -415,9 +552,17 @@ func (svc *Service) startContainer(
 	}
 
 	exited := make(chan error, 1)
+	processDone := make(chan struct{})
+	var exitCode int
 	go func() {
 		defer close(exited)
-		exited <- svcProc.Wait()
+		err := svcProc.Wait()
+		exitCode = processExitCode(err)
+		close(processDone)
+		exited <- err
+
+		// nothing left to check once the process exits
+		stopHealth()
 
 		// detach dependent services when process exits
 		detachDeps()
@@ -428,6 +573,8 @@ func (svc *Service) startContainer(
 			vtx.Done(stopErr)
 		}()
 
+		stopHealth()
+
 		// TODO(vito): graceful shutdown?
 		if err := svcProc.Signal(ctx, syscall.SIGKILL); err != nil {
 			return fmt.Errorf("signal: %w", err)
@@ -444,10 +591,19 @@ func (svc *Service) startContainer(
 		return nil
 	}
 
+	var healthStatus func() ServiceHealthStatus
+	if execHealth != nil {
+		healthStatus = execHealth.Status
+	}
+
 	select {
-	case err := <-checked:
+	case err := <-healthy:
 		if err != nil {
-			return nil, fmt.Errorf("health check errored: %w", err)
+			return nil, &ServiceHealthError{
+				original: fmt.Errorf("health check errored: %w", err),
+				Hostname: host,
+				Ports:    ctr.Ports,
+			}
 		}
 
 		return &RunningService{
@@ -458,7 +614,8 @@ func (svc *Service) startContainer(
 				Digest:   dig,
 				ClientID: clientMetadata.ClientID,
 			},
-			Stop: stopSvc,
+			Stop:   stopSvc,
+			Health: healthStatus,
 			Wait: func(ctx context.Context) error {
 				select {
 				case <-ctx.Done():
@@ -467,14 +624,43 @@ func (svc *Service) startContainer(
 					return err
 				}
 			},
+			Stdout: stdoutBuf,
+			Stderr: stderrBuf,
+			ExitCode: func() (int, bool) {
+				select {
+				case <-processDone:
+					return exitCode, true
+				default:
+					return 0, false
+				}
+			},
 		}, nil
 	case err := <-exited:
+		startErr := &ServiceStartError{
+			original: fmt.Errorf("service exited before healthcheck"),
+			Hostname: host,
+			Cmd:      execOp.Meta.Args,
+			Output:   outBuf.String(),
+		}
 		if err != nil {
-			return nil, fmt.Errorf("exited: %w\noutput: %s", err, outBuf.String())
+			startErr.original = fmt.Errorf("exited: %w\noutput: %s", err, outBuf.String())
+			startErr.ExitCode = processExitCode(err)
 		}
+		return nil, startErr
+	}
+}
 
-		return nil, fmt.Errorf("service exited before healthcheck")
+// processExitCode returns the exit code for the error returned by a
+// container process, or -1 if it did not exit normally.
+func processExitCode(err error) int {
+	if err == nil {
+		return 0
 	}
+	var exitErr *bkgwpb.ExitError
+	if errors.As(err, &exitErr) {
+		return int(exitErr.ExitCode)
+	}
+	return -1
 }
 
 func proxyEnvList(p *pb.ProxyEnv) []string {
@@ -644,7 +830,11 @@ func (svc *Service) startReverseTunnel(ctx context.Context, bk *buildkit.Client,
 	case err := <-checked:
 		if err != nil {
 			stop()
-			return nil, fmt.Errorf("health check errored: %w", err)
+			return nil, &ServiceHealthError{
+				original: fmt.Errorf("health check errored: %w", err),
+				Hostname: host,
+				Ports:    checkPorts,
+			}
 		}
 
 		return &RunningService{
@@ -672,6 +862,19 @@ type ServiceBinding struct {
 	Service  *Service `json:"service"`
 	Hostname string   `json:"hostname"`
 	Aliases  AliasSet `json:"aliases"`
+
+	// DependsOn lists the hostnames of other bindings that must be started and
+	// healthy before this one is started.
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// Name returns a human-readable name for the binding, preferring its alias
+// over its generated hostname.
+func (bnd ServiceBinding) Name() string {
+	if len(bnd.Aliases) > 0 {
+		return bnd.Aliases[0]
+	}
+	return bnd.Hostname
 }
 
 type AliasSet []string
@@ -720,7 +923,92 @@ func (bndp *ServiceBindings) Merge(other ServiceBindings) {
 		}
 
 		merged[i].Aliases = merged[i].Aliases.Union(bnd.Aliases)
+		merged[i].DependsOn = AliasSet(cloneSlice(merged[i].DependsOn)).Union(bnd.DependsOn)
 	}
 
 	*bndp = merged
 }
+
+// Lookup returns the binding with the given hostname or alias.
+func (bnds ServiceBindings) Lookup(name string) (ServiceBinding, bool) {
+	for _, bnd := range bnds {
+		if bnd.Hostname == name {
+			return bnd, true
+		}
+		for _, alias := range bnd.Aliases {
+			if alias == name {
+				return bnd, true
+			}
+		}
+	}
+	return ServiceBinding{}, false
+}
+
+// StartOrder groups the bindings into stages such that every binding comes
+// after the bindings it depends on. The bindings within a stage can be started
+// in parallel, and the stages should be stopped in reverse order.
+//
+// An error is returned if the dependencies form a cycle.
+func (bnds ServiceBindings) StartOrder() ([]ServiceBindings, error) {
+	indices := map[string]int{}
+	for i, bnd := range bnds {
+		indices[bnd.Hostname] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make([]int, len(bnds))
+	stages := make([]int, len(bnds))
+	var path []string
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch states[i] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := []string{bnds[i].Name()}
+			for j := len(path) - 1; j >= 0; j-- {
+				cycle = append([]string{path[j]}, cycle...)
+				if path[j] == bnds[i].Name() {
+					break
+				}
+			}
+			return fmt.Errorf("service dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		states[i] = visiting
+		path = append(path, bnds[i].Name())
+		for _, dep := range bnds[i].DependsOn {
+			j, found := indices[dep]
+			if !found {
+				return fmt.Errorf("service %s depends on unknown service %s", bnds[i].Name(), dep)
+			}
+			if err := visit(j); err != nil {
+				return err
+			}
+			if stages[j]+1 > stages[i] {
+				stages[i] = stages[j] + 1
+			}
+		}
+		path = path[:len(path)-1]
+		states[i] = visited
+		return nil
+	}
+
+	var order []ServiceBindings
+	for i, bnd := range bnds {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+		for len(order) <= stages[i] {
+			order = append(order, ServiceBindings{})
+		}
+		order[stages[i]] = append(order[stages[i]], bnd)
+	}
+
+	return order, nil
+}
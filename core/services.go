@@ -22,14 +22,24 @@ import (
 // stopping and re-starting of the same service in rapid succession.
 const DetachGracePeriod = 10 * time.Second
 
+// MaxStoppedServices is the number of stopped services kept around per client
+// so that their output and exit code can still be inspected. Once exceeded,
+// the services that stopped first are forgotten, along with their output.
+const MaxStoppedServices = 10
+
 // Services manages the lifecycle of services, ensuring the same service only
 // runs once per client.
 type Services struct {
 	bk       *buildkit.Client
 	starting map[ServiceKey]*sync.WaitGroup
 	running  map[ServiceKey]*RunningService
+	stopped  map[ServiceKey]*RunningService
 	bindings map[ServiceKey]int
-	l        sync.Mutex
+
+	// stoppedOrder lists the keys of stopped services in the order they
+	// stopped, oldest first
+	stoppedOrder []ServiceKey
+	l            sync.Mutex
 }
 
 // RunningService represents a service that is actively running.
@@ -60,6 +70,101 @@ type RunningService struct {
 
 	// Block until the service has exited or the provided context is canceled.
 	Wait func(context.Context) error
+
+	// Health returns the current health of the service. If nil, the service is
+	// always healthy once started.
+	Health func() ServiceHealthStatus
+
+	// Stdout and Stderr retain the most recent output of the service's
+	// process. They are nil for services that don't run a process of their
+	// own, i.e. tunnels and host services.
+	Stdout, Stderr *OutputBuffer
+
+	// ExitCode returns the exit code of the service's process and true once it
+	// has exited. If nil, the service has no process of its own.
+	ExitCode func() (int, bool)
+}
+
+// HealthStatus returns the current health of the service.
+func (running *RunningService) HealthStatus() ServiceHealthStatus {
+	if running.Health == nil {
+		return ServiceHealthy
+	}
+	return running.Health()
+}
+
+// Exited returns the exit code of the service's process and true if it has
+// exited.
+func (running *RunningService) Exited() (int, bool) {
+	if running.ExitCode == nil {
+		return 0, false
+	}
+	return running.ExitCode()
+}
+
+// ServiceStatus is the lifecycle state of a service.
+type ServiceStatus string
+
+const (
+	// ServiceStopped means the service is not running, either because it was
+	// never started or because it has been stopped.
+	ServiceStopped ServiceStatus = "STOPPED"
+
+	// ServiceStarting means the service has been started and is waiting for
+	// its health checks to pass.
+	ServiceStarting ServiceStatus = "STARTING"
+
+	// ServiceRunning means the service has started and is still running.
+	ServiceRunning ServiceStatus = "RUNNING"
+
+	// ServiceExited means the service's process exited on its own after it
+	// started.
+	ServiceExited ServiceStatus = "EXITED"
+)
+
+func (status ServiceStatus) EnumName() string {
+	return string(status)
+}
+
+// ServiceOutputLimit is the number of bytes of stdout and stderr retained for
+// each running service.
+const ServiceOutputLimit = 1024 * 1024
+
+// OutputBuffer is an io.Writer that retains only the last limit bytes written
+// to it.
+type OutputBuffer struct {
+	limit int
+	buf   []byte
+	l     sync.Mutex
+}
+
+// NewOutputBuffer returns an OutputBuffer retaining up to limit bytes.
+func NewOutputBuffer(limit int) *OutputBuffer {
+	return &OutputBuffer{limit: limit}
+}
+
+func (buf *OutputBuffer) Write(p []byte) (int, error) {
+	buf.l.Lock()
+	defer buf.l.Unlock()
+
+	n := len(p)
+	if n >= buf.limit {
+		buf.buf = append(buf.buf[:0], p[n-buf.limit:]...)
+		return n, nil
+	}
+
+	if overflow := len(buf.buf) + n - buf.limit; overflow > 0 {
+		buf.buf = append(buf.buf[:0], buf.buf[overflow:]...)
+	}
+	buf.buf = append(buf.buf, p...)
+	return n, nil
+}
+
+// String returns the retained output.
+func (buf *OutputBuffer) String() string {
+	buf.l.Lock()
+	defer buf.l.Unlock()
+	return string(buf.buf)
 }
 
 // ServiceKey is a unique identifier for a service.
@@ -74,6 +179,7 @@ func NewServices(bk *buildkit.Client) *Services {
 		bk:       bk,
 		starting: map[ServiceKey]*sync.WaitGroup{},
 		running:  map[ServiceKey]*RunningService{},
+		stopped:  map[ServiceKey]*RunningService{},
 		bindings: map[ServiceKey]int{},
 	}
 }
@@ -117,6 +223,43 @@ func (ss *Services) Get(ctx context.Context, svc Startable) (*RunningService, er
 	}
 }
 
+// Inspect returns the status of the given service along with the running
+// service, or the last one to run if it has since been stopped. Unlike Get, it
+// does not wait for a starting service. The running service is nil if the
+// service has not been started.
+func (ss *Services) Inspect(ctx context.Context, svc Startable) (ServiceStatus, *RunningService, error) {
+	clientMetadata, err := engine.ClientMetadataFromContext(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dig, err := svc.Digest()
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := ServiceKey{
+		Digest:   dig,
+		ClientID: clientMetadata.ClientID,
+	}
+
+	ss.l.Lock()
+	defer ss.l.Unlock()
+
+	if running, isRunning := ss.running[key]; isRunning {
+		if _, exited := running.Exited(); exited {
+			return ServiceExited, running, nil
+		}
+		return ServiceRunning, running, nil
+	}
+
+	if _, isStarting := ss.starting[key]; isStarting {
+		return ServiceStarting, ss.stopped[key], nil
+	}
+
+	return ServiceStopped, ss.stopped[key], nil
+}
+
 type Startable interface {
 	Digest() (digest.Digest, error)
 
@@ -194,6 +337,7 @@ dance:
 
 	ss.l.Lock()
 	delete(ss.starting, key)
+	ss.forgetStopped(key)
 	ss.running[key] = running
 	ss.bindings[key] = 1
 	ss.l.Unlock()
@@ -203,15 +347,25 @@ dance:
 	return running, nil
 }
 
-// StartBindings starts each of the bound services in parallel and returns a
-// function that will detach from all of them after 10 seconds.
+// StartBindings starts the bound services and returns a function that will
+// detach from all of them after 10 seconds.
+//
+// Services are started in stages according to their dependencies, with each
+// stage starting in parallel once all of the services in the previous stage
+// are healthy. Services are detached in the reverse order.
 func (ss *Services) StartBindings(ctx context.Context, bk *buildkit.Client, bindings ServiceBindings) (_ func(), _ []*RunningService, err error) {
+	stages, err := bindings.StartOrder()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	running := []*RunningService{}
 	detach := func() {
 		go func() {
 			<-time.After(DetachGracePeriod)
-			for _, svc := range running {
-				ss.Detach(ctx, svc)
+			// detach dependents before the services they depend on
+			for i := len(running) - 1; i >= 0; i-- {
+				ss.Detach(ctx, running[i])
 			}
 		}()
 	}
@@ -222,32 +376,34 @@ func (ss *Services) StartBindings(ctx context.Context, bk *buildkit.Client, bind
 		}
 	}()
 
-	// NB: don't use errgroup.WithCancel; we don't want to cancel on Wait
-	eg := new(errgroup.Group)
-
-	started := make(chan *RunningService, len(bindings))
-	for _, bnd := range bindings {
-		bnd := bnd
-		eg.Go(func() error {
-			runningSvc, err := ss.Start(ctx, bnd.Service)
-			if err != nil {
-				return fmt.Errorf("start %s (%s): %w", bnd.Hostname, bnd.Aliases, err)
-			}
-			started <- runningSvc
-			return nil
-		})
-	}
-
-	startErr := eg.Wait()
+	for _, stage := range stages {
+		// NB: don't use errgroup.WithCancel; we don't want to cancel on Wait
+		eg := new(errgroup.Group)
+
+		started := make([]*RunningService, len(stage))
+		for i, bnd := range stage {
+			i, bnd := i, bnd
+			eg.Go(func() error {
+				runningSvc, err := ss.Start(ctx, bnd.Service)
+				if err != nil {
+					return fmt.Errorf("start %s (%s): %w", bnd.Hostname, bnd.Aliases, err)
+				}
+				started[i] = runningSvc
+				return nil
+			})
+		}
 
-	close(started)
+		startErr := eg.Wait()
 
-	if startErr != nil {
-		return nil, nil, startErr
-	}
+		for _, svc := range started {
+			if svc != nil {
+				running = append(running, svc)
+			}
+		}
 
-	for svc := range started {
-		running = append(running, svc)
+		if startErr != nil {
+			return nil, nil, startErr
+		}
 	}
 
 	return detach, running, nil
@@ -305,6 +461,12 @@ func (ss *Services) StopClientServices(ctx context.Context, client *engine.Clien
 	ss.l.Lock()
 	defer ss.l.Unlock()
 
+	for key := range ss.stopped {
+		if key.ClientID == client.ClientID {
+			ss.forgetStopped(key)
+		}
+	}
+
 	eg := new(errgroup.Group)
 	for _, svc := range ss.running {
 		if svc.Key.ClientID != client.ClientID {
@@ -355,5 +517,38 @@ func (ss *Services) stop(ctx context.Context, running *RunningService) error {
 	delete(ss.bindings, running.Key)
 	delete(ss.running, running.Key)
 
+	// keep it around so its output and exit code can still be inspected
+	ss.forgetStopped(running.Key)
+	ss.stopped[running.Key] = running
+	ss.stoppedOrder = append(ss.stoppedOrder, running.Key)
+
+	// forget the client's services that stopped first, beyond the limit
+	var kept int
+	for i := len(ss.stoppedOrder) - 1; i >= 0; i-- {
+		key := ss.stoppedOrder[i]
+		if key.ClientID != running.Key.ClientID {
+			continue
+		}
+		kept++
+		if kept > MaxStoppedServices {
+			ss.forgetStopped(key)
+		}
+	}
+
 	return nil
 }
+
+// forgetStopped removes the stopped service with the given key, if any. The
+// caller must hold ss.l.
+func (ss *Services) forgetStopped(key ServiceKey) {
+	if _, found := ss.stopped[key]; !found {
+		return
+	}
+	delete(ss.stopped, key)
+	for i, k := range ss.stoppedOrder {
+		if k == key {
+			ss.stoppedOrder = append(ss.stoppedOrder[:i], ss.stoppedOrder[i+1:]...)
+			break
+		}
+	}
+}
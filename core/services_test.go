@@ -2,6 +2,7 @@ package core_test
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"runtime"
 	"sync/atomic"
@@ -278,6 +279,83 @@ func TestServicesStartConcurrentSadThenHappy(t *testing.T) {
 	require.Equal(t, 2, stub.Starts())
 }
 
+func TestServicesStoppedLimit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = engine.ContextWithClientMetadata(ctx, &engine.ClientMetadata{
+		ClientID: "fake-client",
+	})
+
+	stubClient := new(buildkit.Client)
+	services := core.NewServices(stubClient)
+
+	stubs := make([]*fakeStartable, core.MaxStoppedServices+2)
+	for i := range stubs {
+		stub := newStartable(fmt.Sprintf("fake-%d", i))
+		stubs[i] = stub
+
+		stub.startResults <- startResult{
+			Started: &core.RunningService{
+				Key: core.ServiceKey{
+					Digest:   stub.digest,
+					ClientID: "fake-client",
+				},
+				Host: stub.id + "-host",
+				Stop: func(context.Context) error { return nil },
+			},
+		}
+
+		running, err := services.Start(ctx, stub)
+		require.NoError(t, err)
+		require.NoError(t, services.Detach(ctx, running))
+	}
+
+	for i, stub := range stubs {
+		status, running, err := services.Inspect(ctx, stub)
+		require.NoError(t, err)
+		require.Equal(t, core.ServiceStopped, status)
+		if i < 2 {
+			// the services that stopped first are forgotten
+			require.Nil(t, running)
+		} else {
+			require.NotNil(t, running)
+		}
+	}
+}
+
+func TestServiceBindingsStartOrder(t *testing.T) {
+	t.Parallel()
+
+	db := core.ServiceBinding{Hostname: "db-host", Aliases: core.AliasSet{"db"}}
+	cache := core.ServiceBinding{Hostname: "cache-host", Aliases: core.AliasSet{"cache"}}
+	migrations := core.ServiceBinding{Hostname: "migrations-host", Aliases: core.AliasSet{"migrations"}, DependsOn: []string{"db-host"}}
+	api := core.ServiceBinding{Hostname: "api-host", Aliases: core.AliasSet{"api"}, DependsOn: []string{"migrations-host", "cache-host"}}
+
+	t.Run("orders by dependencies", func(t *testing.T) {
+		stages, err := core.ServiceBindings{api, migrations, cache, db}.StartOrder()
+		require.NoError(t, err)
+		require.Equal(t, []core.ServiceBindings{
+			{cache, db},
+			{migrations},
+			{api},
+		}, stages)
+	})
+
+	t.Run("detects cycles", func(t *testing.T) {
+		cyclicDB := db
+		cyclicDB.DependsOn = []string{"api-host"}
+
+		_, err := core.ServiceBindings{api, migrations, cache, cyclicDB}.StartOrder()
+		require.ErrorContains(t, err, "service dependency cycle: api -> migrations -> db -> api")
+	})
+
+	t.Run("rejects unknown dependencies", func(t *testing.T) {
+		_, err := core.ServiceBindings{migrations}.StartOrder()
+		require.ErrorContains(t, err, "depends on unknown service db-host")
+	})
+}
+
 type fakeStartable struct {
 	id     string
 	digest digest.Digest
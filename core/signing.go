@@ -0,0 +1,241 @@
+package core
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/dagger/dagger/engine/buildkit"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+)
+
+// simpleSigningType is the type cosign gives to container image signatures.
+const simpleSigningType = "cosign container image signature"
+
+// simpleSigningPayload is the payload signed by cosign for an image:
+// https://github.com/containers/image/blob/main/docs/containers-signature.5.md
+type simpleSigningPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]any `json:"optional"`
+}
+
+// SignImages signs the images at the given digested refs with the PEM-encoded
+// private key, pushing a cosign-compatible signature to each image's
+// repository.
+func SignImages(ctx context.Context, bk *buildkit.Client, refs []string, privateKey []byte) error {
+	signer, err := parseSigningKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	signed := map[string]struct{}{}
+	for _, ref := range refs {
+		named, dgst, err := parseDigestedRef(ref)
+		if err != nil {
+			return err
+		}
+
+		// tags of the same repository share their signatures
+		key := named.Name() + "@" + dgst.String()
+		if _, ok := signed[key]; ok {
+			continue
+		}
+		signed[key] = struct{}{}
+
+		var payload simpleSigningPayload
+		payload.Critical.Identity.DockerReference = named.Name()
+		payload.Critical.Image.DockerManifestDigest = dgst.String()
+		payload.Critical.Type = simpleSigningType
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		sig, err := signPayload(signer, payloadBytes)
+		if err != nil {
+			return fmt.Errorf("sign %s: %w", ref, err)
+		}
+
+		err = bk.PushImageSignature(ctx, named.Name(), dgst, buildkit.ImageSignature{
+			Payload:   payloadBytes,
+			Signature: base64.StdEncoding.EncodeToString(sig),
+		})
+		if err != nil {
+			return fmt.Errorf("push signature of %s: %w", ref, err)
+		}
+	}
+	return nil
+}
+
+// VerifyImageSignature checks that the image at the given digested ref has a
+// cosign signature made with the PEM-encoded public key.
+func VerifyImageSignature(ctx context.Context, bk *buildkit.Client, ref string, publicKey []byte) error {
+	named, dgst, err := parseDigestedRef(ref)
+	if err != nil {
+		return err
+	}
+
+	pub, err := parseVerificationKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	sigs, err := bk.ImageSignatures(ctx, named.Name(), dgst)
+	if err != nil {
+		return fmt.Errorf("fetch signatures of %s: %w", ref, err)
+	}
+	if len(sigs) == 0 {
+		return fmt.Errorf("image %s is not signed", ref)
+	}
+
+	for _, sig := range sigs {
+		rawSig, err := base64.StdEncoding.DecodeString(sig.Signature)
+		if err != nil {
+			continue
+		}
+		if err := verifyPayload(pub, sig.Payload, rawSig); err != nil {
+			continue
+		}
+
+		if !payloadSignsImage(sig.Payload, named, dgst) {
+			continue
+		}
+		return nil
+	}
+
+	return fmt.Errorf("image %s has no valid signature for the given key", ref)
+}
+
+// payloadSignsImage reports whether the simple signing payload is for the
+// image with the given digest in the given repository. A signature copied from
+// another repository must not be accepted, even if the digest matches.
+func payloadSignsImage(dt []byte, named reference.Named, dgst digest.Digest) bool {
+	var payload simpleSigningPayload
+	if err := json.Unmarshal(dt, &payload); err != nil {
+		return false
+	}
+	if payload.Critical.Type != simpleSigningType {
+		return false
+	}
+	if payload.Critical.Image.DockerManifestDigest != dgst.String() {
+		return false
+	}
+	signedRef, err := reference.ParseNormalizedNamed(payload.Critical.Identity.DockerReference)
+	if err != nil {
+		return false
+	}
+	// normalizing maps the legacy index.docker.io host cosign uses to docker.io
+	return signedRef.Name() == named.Name()
+}
+
+func parseDigestedRef(ref string) (reference.Named, digest.Digest, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return nil, "", err
+	}
+	canonical, ok := named.(reference.Canonical)
+	if !ok {
+		return nil, "", fmt.Errorf("image ref %s has no digest", ref)
+	}
+	return named, canonical.Digest(), nil
+}
+
+func parseSigningKey(dt []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(dt)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "ENCRYPTED COSIGN PRIVATE KEY", "ENCRYPTED SIGSTORE PRIVATE KEY":
+		return nil, errors.New("encrypted signing keys are not supported, export the key unencrypted (e.g. with openssl) first")
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse signing key: %w", err)
+	}
+
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		return key, nil
+	case *rsa.PrivateKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing key %T", key)
+	}
+}
+
+func parseVerificationKey(dt []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(dt)
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("unsupported public key type %q", block.Type)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	return pub, nil
+}
+
+// signPayload signs the payload the same way cosign does: SHA-256 with ECDSA
+// and RSA (PKCS #1 v1.5) keys, and the raw payload with Ed25519 keys.
+func signPayload(signer crypto.Signer, payload []byte) ([]byte, error) {
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		return signer.Sign(rand.Reader, payload, crypto.Hash(0))
+	}
+	sum := sha256.Sum256(payload)
+	return signer.Sign(rand.Reader, sum[:], crypto.SHA256)
+}
+
+func verifyPayload(pub crypto.PublicKey, payload, sig []byte) error {
+	sum := sha256.Sum256(payload)
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, sum[:], sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, payload, sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key %T", pub)
+	}
+}
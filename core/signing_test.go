@@ -0,0 +1,81 @@
+package core
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignPayload(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	payload := []byte(`{"critical":{}}`)
+	for name, key := range map[string]crypto.Signer{
+		"ecdsa":   ecKey,
+		"rsa":     rsaKey,
+		"ed25519": edKey,
+	} {
+		key := key
+		t.Run(name, func(t *testing.T) {
+			privDER, err := x509.MarshalPKCS8PrivateKey(key)
+			require.NoError(t, err)
+			pubDER, err := x509.MarshalPKIXPublicKey(key.Public())
+			require.NoError(t, err)
+
+			signer, err := parseSigningKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+			require.NoError(t, err)
+			pub, err := parseVerificationKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+			require.NoError(t, err)
+
+			sig, err := signPayload(signer, payload)
+			require.NoError(t, err)
+			require.NoError(t, verifyPayload(pub, payload, sig))
+			require.Error(t, verifyPayload(pub, []byte(`{"critical":null}`), sig))
+		})
+	}
+}
+
+func TestParseSigningKeyEncrypted(t *testing.T) {
+	_, err := parseSigningKey(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED COSIGN PRIVATE KEY", Bytes: []byte("{}")}))
+	require.ErrorContains(t, err, "encrypted signing keys are not supported")
+
+	_, err = parseSigningKey([]byte("not a key"))
+	require.ErrorContains(t, err, "not PEM encoded")
+}
+
+func TestPayloadSignsImage(t *testing.T) {
+	dgst := digest.FromString("manifest")
+	named, _, err := parseDigestedRef("alpine@" + dgst.String())
+	require.NoError(t, err)
+
+	payload := func(ref string, dgst digest.Digest) []byte {
+		var payload simpleSigningPayload
+		payload.Critical.Identity.DockerReference = ref
+		payload.Critical.Image.DockerManifestDigest = dgst.String()
+		payload.Critical.Type = simpleSigningType
+		dt, err := json.Marshal(payload)
+		require.NoError(t, err)
+		return dt
+	}
+
+	require.True(t, payloadSignsImage(payload("docker.io/library/alpine", dgst), named, dgst))
+	require.True(t, payloadSignsImage(payload("index.docker.io/library/alpine", dgst), named, dgst))
+	require.False(t, payloadSignsImage(payload("docker.io/library/alpine", digest.FromString("other")), named, dgst))
+	require.False(t, payloadSignsImage(payload("registry.example.com/alpine", dgst), named, dgst))
+	require.False(t, payloadSignsImage(payload("", dgst), named, dgst))
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/fs"
@@ -12,6 +13,7 @@ import (
 	"github.com/dagger/dagger/core/reffs"
 	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/moby/buildkit/client/llb"
+	dockerspec "github.com/moby/buildkit/exporter/containerimage/image"
 	"github.com/moby/buildkit/frontend/dockerfile/shell"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/solver/llbsolver/provenance"
@@ -253,7 +255,7 @@ func mergeMap[T any](dst, src map[string]T) map[string]T {
 // Only the configurations that have corresponding `WithXXX` and `WithoutXXX`
 // methods in `Container` are added or updated (i.e., `Env`, `Labels` and
 // `ExposedPorts`). Everything else is replaced.
-func mergeImageConfig(dst, src specs.ImageConfig) specs.ImageConfig {
+func mergeImageConfig(dst, src dockerspec.ImageConfig) dockerspec.ImageConfig {
 	res := src
 
 	res.Env = mergeEnv(dst.Env, src.Env)
@@ -263,6 +265,38 @@ func mergeImageConfig(dst, src specs.ImageConfig) specs.ImageConfig {
 	return res
 }
 
+// applyMergePatch applies a JSON merge patch (RFC 7386) to the JSON document
+// in target.
+func applyMergePatch(target, patch []byte) ([]byte, error) {
+	var targetVal, patchVal any
+	if err := json.Unmarshal(target, &targetVal); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &patchVal); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergePatchValue(targetVal, patchVal))
+}
+
+func mergePatchValue(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = mergePatchValue(targetObj[k], v)
+	}
+	return targetObj
+}
+
 type nopCloser struct {
 	io.Writer
 }
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		target string
+		patch  string
+		want   string
+	}{
+		{
+			name:   "add",
+			target: `{"User":"root"}`,
+			patch:  `{"StopSignal":"SIGQUIT"}`,
+			want:   `{"User":"root","StopSignal":"SIGQUIT"}`,
+		},
+		{
+			name:   "replace",
+			target: `{"User":"root","Cmd":["sh"]}`,
+			patch:  `{"Cmd":["bash","-l"]}`,
+			want:   `{"User":"root","Cmd":["bash","-l"]}`,
+		},
+		{
+			name:   "remove",
+			target: `{"User":"root","StopSignal":"SIGQUIT"}`,
+			patch:  `{"StopSignal":null}`,
+			want:   `{"User":"root"}`,
+		},
+		{
+			name:   "nested",
+			target: `{"Volumes":{"/data":{}},"Labels":{"a":"1","b":"2"}}`,
+			patch:  `{"Volumes":{"/cache":{}},"Labels":{"a":null}}`,
+			want:   `{"Volumes":{"/data":{},"/cache":{}},"Labels":{"b":"2"}}`,
+		},
+		{
+			name:   "replace non-object",
+			target: `{"Healthcheck":null}`,
+			patch:  `{"Healthcheck":{"Test":["CMD","true"]}}`,
+			want:   `{"Healthcheck":{"Test":["CMD","true"]}}`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := applyMergePatch([]byte(tc.target), []byte(tc.patch))
+			require.NoError(t, err)
+			require.JSONEq(t, tc.want, string(res))
+		})
+	}
+
+	_, err := applyMergePatch([]byte(`{}`), []byte(`{`))
+	require.Error(t, err)
+}
@@ -0,0 +1,539 @@
+package buildkit
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/klauspost/compress/zstd"
+	bksolverpb "github.com/moby/buildkit/solver/pb"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/vito/progrock"
+)
+
+// ArchiveFormat is the format of an archive, named after its file extension.
+type ArchiveFormat string
+
+const (
+	ArchiveTar    ArchiveFormat = "tar"
+	ArchiveTarGz  ArchiveFormat = "tar.gz"
+	ArchiveTarZst ArchiveFormat = "tar.zst"
+	ArchiveZip    ArchiveFormat = "zip"
+)
+
+// archiveModTime is the modification time of every entry written to an
+// archive, so that archiving the same contents always produces the same
+// bytes. It's the earliest time that can be represented in a zip archive.
+var archiveModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ArchiveDirectory writes the contents of dir in the filesystem of def to an
+// archive, returning a definition containing only the archive at fileName.
+func (c *Client) ArchiveDirectory(
+	ctx context.Context,
+	engineHostPlatform specs.Platform,
+	def *bksolverpb.Definition,
+	dir string,
+	fileName string,
+	format ArchiveFormat,
+) (*bksolverpb.Definition, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	mountPath, unmount, err := c.mountDefinition(ctx, def)
+	if err != nil {
+		return nil, err
+	}
+	defer unmount()
+
+	root, err := fs.RootPath(mountPath, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "dagger-archive")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir for archive: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archivePath := filepath.Join(tmpDir, fileName)
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeArchive(f, root, format); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write %s archive: %w", format, err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Chtimes(archivePath, archiveModTime, archiveModTime); err != nil {
+		return nil, err
+	}
+
+	ctx, recorder := progrock.WithGroup(ctx, "archive directory")
+	pbDef, err := c.EngineContainerLocalImport(ctx, recorder, engineHostPlatform, tmpDir, nil, []string{fileName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import archive from engine container filesystem: %w", err)
+	}
+	return pbDef, nil
+}
+
+// ExtractArchive extracts the archive at filePath in the filesystem of def,
+// returning a definition of the extracted contents. The format of the archive
+// is detected from its contents.
+func (c *Client) ExtractArchive(
+	ctx context.Context,
+	engineHostPlatform specs.Platform,
+	def *bksolverpb.Definition,
+	filePath string,
+) (*bksolverpb.Definition, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	mountPath, unmount, err := c.mountDefinition(ctx, def)
+	if err != nil {
+		return nil, err
+	}
+	defer unmount()
+
+	archivePath, err := fs.RootPath(mountPath, filePath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tmpDir, err := os.MkdirTemp("", "dagger-extract")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir for extraction: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := extractArchive(f, tmpDir); err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %w", filePath, err)
+	}
+
+	ctx, recorder := progrock.WithGroup(ctx, "extract archive")
+	pbDef, err := c.EngineContainerLocalImport(ctx, recorder, engineHostPlatform, tmpDir, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import extracted archive from engine container filesystem: %w", err)
+	}
+	return pbDef, nil
+}
+
+func writeArchive(w io.Writer, root string, format ArchiveFormat) error {
+	switch format {
+	case ArchiveTar:
+		return writeTar(w, root)
+	case ArchiveTarGz:
+		zw := gzip.NewWriter(w)
+		if err := writeTar(zw, root); err != nil {
+			return err
+		}
+		return zw.Close()
+	case ArchiveTarZst:
+		zw, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return err
+		}
+		if err := writeTar(zw, root); err != nil {
+			zw.Close()
+			return err
+		}
+		return zw.Close()
+	case ArchiveZip:
+		return writeZip(w, root)
+	default:
+		return fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+// walkArchiveEntries calls fn for every path under root, in lexical order,
+// with its slash-separated path relative to root.
+func walkArchiveEntries(root string, fn func(name, src string, fi os.FileInfo) error) error {
+	return filepath.WalkDir(root, func(src string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, src)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), src, fi)
+	})
+}
+
+func writeTar(w io.Writer, root string) error {
+	tw := tar.NewWriter(w)
+	err := walkArchiveEntries(root, func(name, src string, fi os.FileInfo) error {
+		if fi.Mode()&os.ModeSocket != 0 {
+			// not representable in a tar archive
+			return nil
+		}
+
+		var link string
+		if fi.Mode()&os.ModeSymlink != 0 {
+			var err error
+			link, err = os.Readlink(src)
+			if err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.ModTime = archiveModTime
+		hdr.AccessTime = time.Time{}
+		hdr.ChangeTime = time.Time{}
+		hdr.Uname = ""
+		hdr.Gname = ""
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			return copyFileTo(tw, src)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func writeZip(w io.Writer, root string) error {
+	zw := zip.NewWriter(w)
+	err := walkArchiveEntries(root, func(name, src string, fi os.FileInfo) error {
+		mode := fi.Mode()
+		if !mode.IsRegular() && !mode.IsDir() && mode&os.ModeSymlink == 0 {
+			// not representable in a zip archive
+			return nil
+		}
+
+		hdr, err := zip.FileInfoHeader(fi)
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.Modified = archiveModTime
+		if mode.IsRegular() {
+			hdr.Method = zip.Deflate
+		} else {
+			hdr.Method = zip.Store
+		}
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		switch {
+		case mode.IsRegular():
+			return copyFileTo(fw, src)
+		case mode&os.ModeSymlink != 0:
+			// zip stores the target of a symlink as its contents
+			link, err := os.Readlink(src)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(fw, link)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func copyFileTo(w io.Writer, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+var (
+	gzipMagic     = []byte{0x1f, 0x8b}
+	zstdMagic     = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic      = []byte("PK\x03\x04")
+	zipEmptyMagic = []byte("PK\x05\x06")
+)
+
+// extractArchive extracts the tar (optionally gzip or zstd compressed) or zip
+// archive f into dest.
+func extractArchive(f *os.File, dest string) error {
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, zipMagic), bytes.HasPrefix(magic, zipEmptyMagic):
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return extractZip(f, fi.Size(), dest)
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		return extractTar(zr, dest)
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		return extractTar(zr, dest)
+	default:
+		return extractTar(br, dest)
+	}
+}
+
+// archiveDir is a directory extracted from an archive, whose metadata is
+// applied once all of its contents have been written.
+type archiveDir struct {
+	path    string
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func extractTar(r io.Reader, dest string) error {
+	var dirs []archiveDir
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		dst, err := archiveEntryPath(dest, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if dst == dest {
+			continue
+		}
+		if err := prepareArchiveEntry(dst, hdr.Typeflag == tar.TypeDir); err != nil {
+			return err
+		}
+
+		mode := hdr.FileInfo().Mode()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dst, 0o755); err != nil {
+				return err
+			}
+			dirs = append(dirs, archiveDir{dst, mode, hdr.ModTime})
+		case tar.TypeReg, tar.TypeRegA: //nolint:staticcheck // TypeRegA is still written by some tools
+			if err := writeArchiveFile(dst, tr, mode); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, dst); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			target, err := fs.RootPath(dest, hdr.Linkname)
+			if err != nil {
+				return err
+			}
+			if err := os.Link(target, dst); err != nil {
+				return err
+			}
+		default:
+			// devices, fifos, etc. are skipped
+			continue
+		}
+
+		if err := os.Lchown(dst, hdr.Uid, hdr.Gid); err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeDir && hdr.Typeflag != tar.TypeSymlink {
+			if err := os.Chtimes(dst, hdr.ModTime, hdr.ModTime); err != nil {
+				return err
+			}
+		}
+	}
+
+	return applyDirMetadata(dirs)
+}
+
+func extractZip(r io.ReaderAt, size int64, dest string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	var dirs []archiveDir
+	for _, zf := range zr.File {
+		dst, err := archiveEntryPath(dest, zf.Name)
+		if err != nil {
+			return err
+		}
+		if dst == dest {
+			continue
+		}
+		mode := zf.Mode()
+		if err := prepareArchiveEntry(dst, mode.IsDir()); err != nil {
+			return err
+		}
+
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(dst, 0o755); err != nil {
+				return err
+			}
+			dirs = append(dirs, archiveDir{dst, mode, zf.Modified})
+			continue
+		case mode&os.ModeSymlink != 0:
+			link, err := readZipFile(zf)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(string(link), dst); err != nil {
+				return err
+			}
+			continue
+		case !mode.IsRegular():
+			continue
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = writeArchiveFile(dst, rc, mode)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := os.Chtimes(dst, zf.Modified, zf.Modified); err != nil {
+			return err
+		}
+	}
+
+	return applyDirMetadata(dirs)
+}
+
+// prepareArchiveEntry creates the parent directories of dst and removes
+// anything already at dst that an entry would replace.
+func prepareArchiveEntry(dst string, isDir bool) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	fi, err := os.Lstat(dst)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if isDir && fi.IsDir() {
+		return nil
+	}
+	return os.RemoveAll(dst)
+}
+
+func readZipFile(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func writeArchiveFile(dst string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// chmod explicitly, since the mode passed to open is subject to umask
+	return os.Chmod(dst, mode.Perm()|mode&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky))
+}
+
+// applyDirMetadata sets the mode and modification time of extracted
+// directories, deepest first so that setting them isn't undone by changes to
+// their children.
+func applyDirMetadata(dirs []archiveDir) error {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dir := dirs[i]
+		if err := os.Chmod(dir.path, dir.mode.Perm()|dir.mode&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+			return err
+		}
+		if err := os.Chtimes(dir.path, dir.modTime, dir.modTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveEntryPath returns the path to extract the archive entry with the
+// given name to. Parent directories are resolved within dest so that entries
+// can't escape it, but the entry itself is not followed if it's a symlink.
+func archiveEntryPath(dest, name string) (string, error) {
+	name = path.Clean("/" + filepath.ToSlash(name))
+	if name == "/" {
+		return dest, nil
+	}
+	parent, err := fs.RootPath(dest, path.Dir(name))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, path.Base(name)), nil
+}
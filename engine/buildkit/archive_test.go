@@ -0,0 +1,104 @@
+package buildkit
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "sub", "empty"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "run.sh"), []byte("#!/bin/sh"), 0o755))
+	require.NoError(t, os.Symlink("sub/run.sh", filepath.Join(src, "link")))
+
+	for _, format := range []ArchiveFormat{ArchiveTar, ArchiveTarGz, ArchiveTarZst, ArchiveZip} {
+		format := format
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, writeArchive(&buf, src, format))
+
+			// the output doesn't depend on the modification times of the
+			// contents
+			later := time.Now().Add(time.Hour)
+			require.NoError(t, os.Chtimes(filepath.Join(src, "a.txt"), later, later))
+			var again bytes.Buffer
+			require.NoError(t, writeArchive(&again, src, format))
+			require.Equal(t, buf.Bytes(), again.Bytes())
+
+			archivePath := filepath.Join(t.TempDir(), "archive")
+			require.NoError(t, os.WriteFile(archivePath, buf.Bytes(), 0o600))
+			f, err := os.Open(archivePath)
+			require.NoError(t, err)
+			defer f.Close()
+
+			dest := t.TempDir()
+			require.NoError(t, extractArchive(f, dest))
+
+			dt, err := os.ReadFile(filepath.Join(dest, "a.txt"))
+			require.NoError(t, err)
+			require.Equal(t, "a", string(dt))
+
+			fi, err := os.Stat(filepath.Join(dest, "sub", "run.sh"))
+			require.NoError(t, err)
+			require.Equal(t, os.FileMode(0o755), fi.Mode().Perm())
+			require.True(t, fi.ModTime().Equal(archiveModTime))
+
+			fi, err = os.Stat(filepath.Join(dest, "sub", "empty"))
+			require.NoError(t, err)
+			require.True(t, fi.IsDir())
+
+			target, err := os.Readlink(filepath.Join(dest, "link"))
+			require.NoError(t, err)
+			require.Equal(t, "sub/run.sh", target)
+		})
+	}
+}
+
+func TestExtractArchiveStaysInDest(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     "escape",
+		Linkname: "/",
+		Uid:      os.Getuid(),
+		Gid:      os.Getgid(),
+	}))
+	for _, name := range []string{"../evil", "escape/evil"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     4,
+			Uid:      os.Getuid(),
+			Gid:      os.Getgid(),
+		}))
+		_, err := tw.Write([]byte("evil"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.tar")
+	require.NoError(t, os.WriteFile(archivePath, buf.Bytes(), 0o600))
+	f, err := os.Open(archivePath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, os.Mkdir(dest, 0o755))
+	require.NoError(t, extractArchive(f, dest))
+
+	_, err = os.Stat(filepath.Join(dir, "evil"))
+	require.True(t, os.IsNotExist(err))
+	dt, err := os.ReadFile(filepath.Join(dest, "evil"))
+	require.NoError(t, err)
+	require.Equal(t, "evil", string(dt))
+}
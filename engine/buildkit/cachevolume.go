@@ -0,0 +1,325 @@
+package buildkit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/containerd/containerd/mount"
+	bkcache "github.com/moby/buildkit/cache"
+	bkclient "github.com/moby/buildkit/client"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	bksession "github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/solver/llbsolver/mounts"
+	bksolverpb "github.com/moby/buildkit/solver/pb"
+	fscopy "github.com/tonistiigi/fsutil/copy"
+	"golang.org/x/sync/errgroup"
+)
+
+// CacheVolumeUsage describes the disk usage of a cache volume on the engine.
+//
+// A single cache volume may be backed by several cache records, e.g. one per
+// source directory it was initialized from.
+type CacheVolumeUsage struct {
+	// ID is the cache mount ID of the volume, as passed to llb.AsPersistentCacheDir.
+	ID string
+
+	// Size is the total disk usage of the volume in bytes.
+	Size int64
+
+	// InUse is true if the volume is currently mounted by a running exec.
+	InUse bool
+
+	// LastUsedAt is the last time the volume was mounted, if ever.
+	LastUsedAt *time.Time
+
+	recordIDs []string
+}
+
+// cacheMountIDRegexp extracts the cache mount ID from the description that
+// buildkit's mount manager gives the records it creates, i.e.
+// `cached mount <dest> from <manager> with id "<id>"`.
+var cacheMountIDRegexp = regexp.MustCompile(`^cached mount .* with id ("(?:[^"\\]|\\.)*")$`)
+
+// CacheVolumes returns the disk usage of every cache volume on the engine,
+// sorted by ID.
+func (c *Client) CacheVolumes(ctx context.Context) ([]*CacheVolumeUsage, error) {
+	records, err := c.Worker.DiskUsage(ctx, bkclient.DiskUsageInfo{
+		Filter: []string{"type==" + string(bkclient.UsageRecordTypeCacheMount)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache disk usage: %w", err)
+	}
+
+	volumes := map[string]*CacheVolumeUsage{}
+	for _, record := range records {
+		match := cacheMountIDRegexp.FindStringSubmatch(record.Description)
+		if match == nil {
+			continue
+		}
+		id, err := strconv.Unquote(match[1])
+		if err != nil {
+			continue
+		}
+
+		volume, ok := volumes[id]
+		if !ok {
+			volume = &CacheVolumeUsage{ID: id}
+			volumes[id] = volume
+		}
+		volume.Size += record.Size
+		volume.InUse = volume.InUse || record.InUse
+		if record.LastUsedAt != nil && (volume.LastUsedAt == nil || record.LastUsedAt.After(*volume.LastUsedAt)) {
+			volume.LastUsedAt = record.LastUsedAt
+		}
+		volume.recordIDs = append(volume.recordIDs, record.ID)
+	}
+
+	usages := make([]*CacheVolumeUsage, 0, len(volumes))
+	for _, volume := range volumes {
+		usages = append(usages, volume)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].ID < usages[j].ID
+	})
+	return usages, nil
+}
+
+// PruneCacheVolume wipes the cache volume with the given cache mount ID,
+// returning the number of bytes that were reclaimed.
+//
+// Subsequent uses of the volume start from an empty directory. Records that
+// are still mounted by a running exec are detached immediately but only
+// reclaimed by the engine's garbage collector once released.
+func (c *Client) PruneCacheVolume(ctx context.Context, id string) (int64, error) {
+	volumes, err := c.CacheVolumes(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// detach the volume's records so that new mounts don't pick them up
+	if err := c.Worker.PruneCacheMounts(ctx, []string{id}); err != nil {
+		return 0, fmt.Errorf("failed to prune cache mount: %w", err)
+	}
+
+	var filters []string
+	for _, volume := range volumes {
+		if volume.ID != id {
+			continue
+		}
+		for _, recordID := range volume.recordIDs {
+			filters = append(filters, "id=="+recordID)
+		}
+	}
+	if len(filters) == 0 {
+		return 0, nil
+	}
+
+	// NB: multiple filters match any of them
+	ch := make(chan bkclient.UsageInfo)
+	var reclaimed int64
+	eg, egctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		defer close(ch)
+		return c.Worker.Prune(egctx, ch, bkclient.PruneInfo{
+			Filter: filters,
+			All:    true,
+		})
+	})
+	eg.Go(func() error {
+		for info := range ch {
+			reclaimed += info.Size
+		}
+		return nil
+	})
+	if err := eg.Wait(); err != nil {
+		return 0, fmt.Errorf("failed to prune cache volume: %w", err)
+	}
+	return reclaimed, nil
+}
+
+// SetCacheVolumeContents replaces the contents of the cache volume with the
+// given cache mount ID with the contents of dir in def. It blocks until no
+// execs have the volume mounted.
+func (c *Client) SetCacheVolumeContents(ctx context.Context, id string, def *bksolverpb.Definition, dir string) error {
+	res, err := c.Solve(ctx, bkgw.SolveRequest{
+		Definition: def,
+		Evaluate:   true,
+	})
+	if err != nil {
+		return err
+	}
+	ref, err := res.SingleRef()
+	if err != nil {
+		return fmt.Errorf("failed to get single ref: %w", err)
+	}
+
+	// a nil ref is an empty directory, i.e. llb.Scratch()
+	var srcMounts []mount.Mount
+	if ref != nil {
+		cacheRef, err := ref.CacheRef(ctx)
+		if err != nil {
+			return err
+		}
+		mountable, err := cacheRef.Mount(ctx, true, bksession.NewGroup(c.ID()))
+		if err != nil {
+			return fmt.Errorf("failed to get source mount: %w", err)
+		}
+		var release func() error
+		srcMounts, release, err = mountable.Mount()
+		if err != nil {
+			return fmt.Errorf("failed to get source mounts: %w", err)
+		}
+		defer release()
+	}
+
+	// lock the volume so that the contents aren't replaced underneath any
+	// execs that are using it
+	return withLockedCacheMount(ctx, c.cacheMountManager(), id, func(ctx context.Context, mnt mount.Mount) error {
+		if err := RemoveAllUnderDir(mnt.Source); err != nil {
+			return err
+		}
+		if srcMounts == nil {
+			return nil
+		}
+		return mount.WithTempMount(ctx, srcMounts, func(root string) error {
+			return fscopy.Copy(ctx, root, dir, mnt.Source, "/", fscopy.WithCopyInfo(fscopy.CopyInfo{
+				CopyDirContents: true,
+			}))
+		})
+	})
+}
+
+// CacheVolumeSnapshot copies the current contents of the cache volume with the
+// given cache mount ID into a new, immutable snapshot.
+func (c *Client) CacheVolumeSnapshot(ctx context.Context, id string) (*bksolverpb.Definition, error) {
+	group := bksession.NewGroup(c.ID())
+
+	mutableRef, err := c.Worker.CacheManager().New(ctx, nil, group,
+		bkcache.WithDescription(fmt.Sprintf("snapshot of cache volume %q", id)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot ref: %w", err)
+	}
+	defer func() {
+		if mutableRef != nil {
+			mutableRef.Release(context.Background())
+		}
+	}()
+
+	mountable, err := mutableRef.Mount(ctx, false, group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot mount: %w", err)
+	}
+	destMounts, release, err := mountable.Mount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot mounts: %w", err)
+	}
+	err = WithCacheMount(ctx, c.cacheMountManager(), id, func(ctx context.Context, mnt mount.Mount) error {
+		return mount.WithTempMount(ctx, destMounts, func(root string) error {
+			return fscopy.Copy(ctx, mnt.Source, "/", root, "/", fscopy.WithCopyInfo(fscopy.CopyInfo{
+				CopyDirContents: true,
+			}))
+		})
+	})
+	release()
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy cache volume: %w", err)
+	}
+
+	immutableRef, err := mutableRef.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit snapshot: %w", err)
+	}
+	defer immutableRef.Release(context.Background())
+
+	// the mutable ref must be released before the snapshot can be finalized
+	mutableRef.Release(ctx)
+	mutableRef = nil
+
+	return c.blobDefinition(ctx, immutableRef)
+}
+
+func (c *Client) cacheMountManager() *mounts.MountManager {
+	return mounts.NewMountManager("dagger-cache", c.Worker.CacheManager(), c.SessionManager)
+}
+
+// WithCacheMount mounts the cache volume with the given cache mount ID and
+// calls cb with the mount, which is always a bind mount.
+func WithCacheMount(ctx context.Context, mountManager *mounts.MountManager, cacheKey string, cb func(ctx context.Context, mnt mount.Mount) error) error {
+	// this should never block in theory since the mount is shared, but put a
+	// timeout on this out of an abundance of caution
+	getRefCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return withCacheMount(ctx, getRefCtx, mountManager, cacheKey, bksolverpb.CacheSharingOpt_SHARED, cb)
+}
+
+// withLockedCacheMount is like WithCacheMount, but mounts the cache volume
+// LOCKED, waiting for any execs that currently have it mounted to finish
+// first. Execs that mount it SHARED in the meantime get a separate, empty
+// instance of the volume, as with any LOCKED cache mount.
+func withLockedCacheMount(ctx context.Context, mountManager *mounts.MountManager, cacheKey string, cb func(ctx context.Context, mnt mount.Mount) error) error {
+	return withCacheMount(ctx, ctx, mountManager, cacheKey, bksolverpb.CacheSharingOpt_LOCKED, cb)
+}
+
+func withCacheMount(
+	ctx context.Context,
+	getRefCtx context.Context,
+	mountManager *mounts.MountManager,
+	cacheKey string,
+	sharing bksolverpb.CacheSharingOpt,
+	cb func(ctx context.Context, mnt mount.Mount) error,
+) error {
+	ref, err := mountManager.MountableCache(getRefCtx, &bksolverpb.Mount{
+		CacheOpt: &bksolverpb.CacheOpt{
+			ID:      cacheKey,
+			Sharing: sharing,
+		},
+	}, nil, nil)
+	defer func() {
+		if ref != nil {
+			ref.Release(context.Background())
+		}
+	}()
+	if err != nil {
+		return fmt.Errorf("failed to get cache mount ref: %w", err)
+	}
+
+	mountable, err := ref.Mount(ctx, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get cache mount: %w", err)
+	}
+	mounts, releaseMounts, err := mountable.Mount()
+	if err != nil {
+		return fmt.Errorf("failed to get cache mount mounts: %w", err)
+	}
+	defer releaseMounts()
+	if len(mounts) != 1 {
+		return fmt.Errorf("expected 1 mount, got %d", len(mounts))
+	}
+	mnt := mounts[0]
+	if mnt.Type != "bind" && mnt.Type != "rbind" {
+		// TODO: we could support overlay (when there's a parent ref to the cache mount)
+		// by just mounting to a tempdir
+		return fmt.Errorf("expected bind mount, got %s", mnt.Type)
+	}
+	return cb(ctx, mnt)
+}
+
+// RemoveAllUnderDir removes the contents of dir, but not dir itself.
+func RemoveAllUnderDir(dir string) error {
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read dir %s: %w", dir, err)
+	}
+	for _, dirent := range dirents {
+		if err := os.RemoveAll(filepath.Join(dir, dirent.Name())); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", dirent.Name(), err)
+		}
+	}
+	return nil
+}
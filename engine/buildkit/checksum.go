@@ -0,0 +1,95 @@
+package buildkit
+
+import (
+	"context"
+	_ "crypto/sha512" // register sha384 and sha512 for digest.Algorithm
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/containerd/continuity/fs"
+	bkcache "github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/cache/contenthash"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	bksession "github.com/moby/buildkit/session"
+	bksolverpb "github.com/moby/buildkit/solver/pb"
+	"github.com/opencontainers/go-digest"
+)
+
+// DirectoryDigest returns the content hash of dir in the result of def, using
+// the same checksums buildkit computes for cache keys. The hash covers the
+// names, contents, permissions and ownership of the directory's entries, but
+// not their modification times.
+//
+// Include and exclude patterns filter the entries that are part of the hash.
+func (c *Client) DirectoryDigest(ctx context.Context, def *bksolverpb.Definition, dir string, include, exclude []string) (digest.Digest, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+
+	res, err := c.Solve(ctx, bkgw.SolveRequest{Definition: def, Evaluate: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to solve: %w", err)
+	}
+	ref, err := res.SingleRef()
+	if err != nil {
+		return "", fmt.Errorf("failed to get single ref: %w", err)
+	}
+
+	// a nil ref is an empty directory, which contenthash handles
+	var cacheRef bkcache.ImmutableRef
+	if ref != nil {
+		cacheRef, err = ref.CacheRef(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	dgst, err := contenthash.Checksum(ctx, cacheRef, path.Join("/", dir), contenthash.ChecksumOpts{
+		IncludePatterns: include,
+		ExcludePatterns: exclude,
+	}, bksession.NewGroup(c.ID()))
+	if err != nil {
+		return "", fmt.Errorf("failed to compute checksum: %w", err)
+	}
+	return dgst, nil
+}
+
+// FileDigest returns the digest of the contents of filePath in the result of
+// def, computed with the given algorithm.
+func (c *Client) FileDigest(ctx context.Context, def *bksolverpb.Definition, filePath string, algorithm digest.Algorithm) (digest.Digest, error) {
+	if !algorithm.Available() {
+		return "", fmt.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+
+	mountPath, unmount, err := c.mountDefinition(ctx, def)
+	if err != nil {
+		return "", err
+	}
+	defer unmount()
+
+	src, err := fs.RootPath(mountPath, filePath)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	digester := algorithm.Digester()
+	if _, err := io.Copy(digester.Hash(), f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	return digester.Digest(), nil
+}
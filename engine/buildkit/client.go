@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/containerd/containerd/remotes/docker"
 	"github.com/dagger/dagger/auth"
 	"github.com/dagger/dagger/engine"
 	"github.com/dagger/dagger/engine/session"
@@ -58,6 +59,10 @@ type Opts struct {
 	// not any nested clients (may change in future).
 	MainClientCaller bksession.Caller
 	DNSConfig        *oci.DNSConfig
+	RegistryHosts    docker.RegistryHosts
+	// KeepFailedExecs keeps the state of the most recent failed execs around
+	// until the client is closed, so that they can be debugged.
+	KeepFailedExecs bool
 }
 
 type ResolveCacheExporterFunc func(ctx context.Context, g bksession.Group) (remotecache.Exporter, error)
@@ -77,6 +82,11 @@ type Client struct {
 	containers   map[bkgw.Container]struct{}
 	containersMu sync.Mutex
 
+	failedExecs      map[string]*failedExec
+	failedExecOrder  []string
+	failedExecsInMod map[digest.Digest]string
+	failedExecsMu    sync.Mutex
+
 	dialer *net.Dialer
 
 	closeCtx context.Context
@@ -91,6 +101,8 @@ func NewClient(ctx context.Context, opts Opts) (*Client, error) {
 		clientIDToSecretToken: make(map[string]string),
 		refs:                  make(map[*ref]struct{}),
 		containers:            make(map[bkgw.Container]struct{}),
+		failedExecs:           make(map[string]*failedExec),
+		failedExecsInMod:      make(map[digest.Digest]string),
 		closeCtx:              closeCtx,
 		cancel:                cancel,
 	}
@@ -173,6 +185,15 @@ func (c *Client) Close() error {
 	c.refs = nil
 	c.refsMu.Unlock()
 
+	c.failedExecsMu.Lock()
+	for _, exec := range c.failedExecs {
+		exec.release()
+	}
+	c.failedExecs = nil
+	c.failedExecOrder = nil
+	c.failedExecsInMod = nil
+	c.failedExecsMu.Unlock()
+
 	c.containersMu.Lock()
 	var containerReleaseGroup errgroup.Group
 	for ctr := range c.containers {
@@ -248,12 +269,17 @@ func (c *Client) Solve(ctx context.Context, req bkgw.SolveRequest) (_ *Result, r
 			if execOp.Meta.ProxyEnv == nil {
 				execOp.Meta.ProxyEnv = &bksolverpb.ProxyEnv{}
 			}
+			// keep any settings specific to the exec, e.g. its timeout
+			var md ContainerExecUncachedMetadata
+			if err := md.FromPBFtpProxyVal(execOp.Meta.ProxyEnv.FtpProxy); err != nil {
+				return err
+			}
+			md.ParentClientIDs = clientMetadata.ClientIDs()
+			md.ServerID = clientMetadata.ServerID
+			md.ProgSockPath = c.ProgSockPath
+
 			var err error
-			execOp.Meta.ProxyEnv.FtpProxy, err = ContainerExecUncachedMetadata{
-				ParentClientIDs: clientMetadata.ClientIDs(),
-				ServerID:        clientMetadata.ServerID,
-				ProgSockPath:    c.ProgSockPath,
-			}.ToPBFtpProxyVal()
+			execOp.Meta.ProxyEnv.FtpProxy, err = md.ToPBFtpProxyVal()
 			if err != nil {
 				return err
 			}
@@ -270,7 +296,7 @@ func (c *Client) Solve(ctx context.Context, req bkgw.SolveRequest) (_ *Result, r
 
 	llbRes, err := c.llbBridge.Solve(ctx, req, c.ID())
 	if err != nil {
-		return nil, wrapError(ctx, err, c.ID())
+		return nil, c.wrapError(ctx, err)
 	}
 	res, err := solverresult.ConvertResult(llbRes, func(rp bksolver.ResultProxy) (*ref, error) {
 		return newRef(rp, c), nil
@@ -687,6 +713,71 @@ type ContainerExecUncachedMetadata struct {
 	ParentClientIDs []string `json:"parentClientIDs,omitempty"`
 	ServerID        string   `json:"serverID,omitempty"`
 	ProgSockPath    string   `json:"progSockPath,omitempty"`
+
+	// Timeout is the number of seconds after which the exec is killed, if
+	// non-zero.
+	Timeout int `json:"timeout,omitempty"`
+
+	// ResourceLimits constrains the resources available to the exec.
+	ResourceLimits *ExecResourceLimits `json:"resourceLimits,omitempty"`
+}
+
+// ExecResourceLimits constrains the resources available to an exec. Zero
+// values are unlimited.
+type ExecResourceLimits struct {
+	// CPUs is the number of CPUs the exec may use, e.g. 1.5.
+	CPUs float64 `json:"cpus,omitempty"`
+
+	// Memory is the maximum memory the exec may use, in bytes.
+	Memory int64 `json:"memory,omitempty"`
+
+	// Pids is the maximum number of processes the exec may run at once.
+	Pids int64 `json:"pids,omitempty"`
+
+	// Ulimits are the exec's per-process resource limits, as set by ulimit(1).
+	Ulimits []ExecUlimit `json:"ulimits,omitempty"`
+}
+
+// ExecUlimit is a per-process resource limit, e.g. "nofile".
+type ExecUlimit struct {
+	Name string `json:"name"`
+	Soft int64  `json:"soft"`
+	Hard int64  `json:"hard"`
+}
+
+// Merge returns the limits with any limits set in other taking precedence.
+func (limits ExecResourceLimits) Merge(other ExecResourceLimits) ExecResourceLimits {
+	if other.CPUs != 0 {
+		limits.CPUs = other.CPUs
+	}
+	if other.Memory != 0 {
+		limits.Memory = other.Memory
+	}
+	if other.Pids != 0 {
+		limits.Pids = other.Pids
+	}
+	if len(other.Ulimits) > 0 {
+		merged := []ExecUlimit{}
+		for _, ulimit := range limits.Ulimits {
+			overridden := false
+			for _, o := range other.Ulimits {
+				if o.Name == ulimit.Name {
+					overridden = true
+					break
+				}
+			}
+			if !overridden {
+				merged = append(merged, ulimit)
+			}
+		}
+		limits.Ulimits = append(merged, other.Ulimits...)
+	}
+	return limits
+}
+
+// IsZero returns true if no limits are set.
+func (limits ExecResourceLimits) IsZero() bool {
+	return limits.CPUs == 0 && limits.Memory == 0 && limits.Pids == 0 && len(limits.Ulimits) == 0
 }
 
 func (md ContainerExecUncachedMetadata) ToPBFtpProxyVal() (string, error) {
@@ -697,6 +788,17 @@ func (md ContainerExecUncachedMetadata) ToPBFtpProxyVal() (string, error) {
 	return string(b), nil
 }
 
+// FromPBFtpProxyVal loads the metadata set on an exec op, if any.
+func (md *ContainerExecUncachedMetadata) FromPBFtpProxyVal(val string) error {
+	if val == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(val), md); err != nil {
+		return fmt.Errorf("invalid exec metadata: %w", err)
+	}
+	return nil
+}
+
 func (md *ContainerExecUncachedMetadata) FromEnv(envKV string) (bool, error) {
 	_, val, ok := strings.Cut(envKV, "ftp_proxy=")
 	if !ok {
@@ -13,7 +13,10 @@ import (
 	bkcache "github.com/moby/buildkit/cache"
 	bkclient "github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	dockerspec "github.com/moby/buildkit/exporter/containerimage/image"
+	"github.com/moby/buildkit/frontend/attestations/sbom"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	bkgwpb "github.com/moby/buildkit/frontend/gateway/pb"
 	bksolverpb "github.com/moby/buildkit/solver/pb"
 	solverresult "github.com/moby/buildkit/solver/result"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
@@ -21,8 +24,26 @@ import (
 )
 
 type ContainerExport struct {
-	Definition *bksolverpb.Definition
-	Config     specs.ImageConfig
+	Definition   *bksolverpb.Definition
+	Config       dockerspec.ImageConfig
+	Attestations []ContainerAttestation
+
+	// Annotations are set on the image manifest.
+	Annotations map[string]string
+	// IndexAnnotations are set on the image index. Setting any forces an
+	// index to be created, even when exporting a single platform.
+	IndexAnnotations map[string]string
+}
+
+// ContainerAttestation is an in-toto attestation to attach to an exported
+// image, either given as a single predicate or as a bundle of statements
+// produced by solving Definition (e.g., the output of an SBOM scanner).
+type ContainerAttestation struct {
+	// Reason is why the attestation was generated, e.g. "sbom" or "provenance".
+	Reason        string
+	PredicateType string
+	Predicate     []byte
+	Definition    *bksolverpb.Definition
 }
 
 func (c *Client) PublishContainerImage(
@@ -189,6 +210,16 @@ func (c *Client) getContainerResult(
 	expPlatforms := &exptypes.Platforms{
 		Platforms: make([]exptypes.Platform, len(inputByPlatform)),
 	}
+
+	// index annotations can only be set on an image index, so export a
+	// single platform as a one-element index if there are any
+	useIndex := len(inputByPlatform) > 1
+	for _, input := range inputByPlatform {
+		if len(input.IndexAnnotations) > 0 {
+			useIndex = true
+		}
+	}
+
 	// TODO: probably faster to do this in parallel for each platform
 	for platformString, input := range inputByPlatform {
 		res, err := c.Solve(ctx, bkgw.SolveRequest{
@@ -211,12 +242,14 @@ func (c *Client) getContainerResult(
 		if err != nil {
 			return nil, err
 		}
-		cfgBytes, err := json.Marshal(specs.Image{
-			Platform: specs.Platform{
-				Architecture: platform.Architecture,
-				OS:           platform.OS,
-				OSVersion:    platform.OSVersion,
-				OSFeatures:   platform.OSFeatures,
+		cfgBytes, err := json.Marshal(dockerspec.Image{
+			Image: specs.Image{
+				Platform: specs.Platform{
+					Architecture: platform.Architecture,
+					OS:           platform.OS,
+					OSVersion:    platform.OSVersion,
+					OSFeatures:   platform.OSFeatures,
+				},
 			},
 			Config: input.Config,
 		})
@@ -224,9 +257,13 @@ func (c *Client) getContainerResult(
 			return nil, err
 		}
 		combinedResult.AddMeta(fmt.Sprintf("%s/%s", exptypes.ExporterImageConfigKey, platformString), cfgBytes)
-		if len(inputByPlatform) == 1 {
+		if !useIndex {
 			combinedResult.AddMeta(exptypes.ExporterImageConfigKey, cfgBytes)
 			combinedResult.SetRef(ref)
+			expPlatforms.Platforms[0] = exptypes.Platform{
+				ID:       platformString,
+				Platform: platform,
+			}
 		} else {
 			expPlatforms.Platforms[len(combinedResult.Refs)] = exptypes.Platform{
 				ID:       platformString,
@@ -234,9 +271,31 @@ func (c *Client) getContainerResult(
 			}
 			combinedResult.AddRef(platformString, ref)
 		}
+
+		// manifest annotations of a single image apply regardless of platform
+		annotationPlatform := &platform
+		if !useIndex {
+			annotationPlatform = nil
+		}
+		for k, v := range input.Annotations {
+			combinedResult.AddMeta(exptypes.AnnotationManifestKey(annotationPlatform, k), []byte(v))
+		}
+		for k, v := range input.IndexAnnotations {
+			combinedResult.AddMeta(exptypes.AnnotationIndexKey(k), []byte(v))
+		}
+
+		for _, att := range input.Attestations {
+			bkAtt, err := c.containerAttestation(ctx, att)
+			if err != nil {
+				return nil, err
+			}
+			combinedResult.AddAttestation(platformString, bkAtt)
+		}
 	}
 
-	if len(combinedResult.Refs) > 1 {
+	// NB: the exporter needs to know the platforms to match attestations to
+	// their image manifest, even when exporting a single platform
+	if len(combinedResult.Refs) > 0 || len(combinedResult.Attestations) > 0 {
 		platformBytes, err := json.Marshal(expPlatforms)
 		if err != nil {
 			return nil, err
@@ -246,3 +305,51 @@ func (c *Client) getContainerResult(
 
 	return combinedResult, nil
 }
+
+func (c *Client) containerAttestation(
+	ctx context.Context,
+	att ContainerAttestation,
+) (solverresult.Attestation[bkcache.ImmutableRef], error) {
+	bkAtt := solverresult.Attestation[bkcache.ImmutableRef]{
+		Metadata: map[string][]byte{
+			solverresult.AttestationReasonKey: []byte(att.Reason),
+		},
+		InToto: solverresult.InTotoAttestation{
+			PredicateType: att.PredicateType,
+		},
+	}
+
+	if att.Definition == nil {
+		predicate := att.Predicate
+		bkAtt.Kind = bkgwpb.AttestationKindInToto
+		bkAtt.Path = att.Reason + ".json"
+		bkAtt.ContentFunc = func() ([]byte, error) {
+			return predicate, nil
+		}
+		return bkAtt, nil
+	}
+
+	res, err := c.Solve(ctx, bkgw.SolveRequest{
+		Definition: att.Definition,
+		Evaluate:   true,
+	})
+	if err != nil {
+		return bkAtt, fmt.Errorf("failed to solve %s attestation: %s", att.Reason, err)
+	}
+	cacheRes, err := ConvertToWorkerCacheResult(ctx, res)
+	if err != nil {
+		return bkAtt, fmt.Errorf("failed to convert result: %s", err)
+	}
+	ref, err := cacheRes.SingleRef()
+	if err != nil {
+		return bkAtt, err
+	}
+
+	bkAtt.Kind = bkgwpb.AttestationKindBundle
+	bkAtt.Ref = ref
+	if att.Reason == solverresult.AttestationReasonSBOM {
+		// lets the exporter add the image's layers to the SBOM
+		bkAtt.Metadata[solverresult.AttestationSBOMCore] = []byte(sbom.CoreSBOMName)
+	}
+	return bkAtt, nil
+}
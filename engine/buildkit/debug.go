@@ -0,0 +1,223 @@
+package buildkit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dagger/dagger/engine"
+	"github.com/moby/buildkit/identity"
+	bksolver "github.com/moby/buildkit/solver"
+	llberror "github.com/moby/buildkit/solver/llbsolver/errdefs"
+	bksolverpb "github.com/moby/buildkit/solver/pb"
+	bkworker "github.com/moby/buildkit/worker"
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// FailedExec is the state of an exec at the time it failed.
+type FailedExec struct {
+	Args     []string
+	Env      []string
+	Cwd      string
+	User     string
+	Platform ocispecs.Platform
+
+	// Mounts are the exec's filesystem mounts, including the rootfs. Mounts
+	// with no content to debug (e.g. cache mounts, tmpfs and secrets) are
+	// omitted.
+	Mounts []FailedExecMount
+}
+
+// FailedExecMount is a filesystem mount of a failed exec.
+type FailedExecMount struct {
+	Dest       string
+	SourcePath string
+	Readonly   bool
+
+	// Definition is the content of the mount when the exec failed.
+	Definition *bksolverpb.Definition
+}
+
+// MaxFailedExecs is the number of failed execs kept around by a client to be
+// debugged. The oldest ones are released first.
+const MaxFailedExecs = 10
+
+// failedExec holds on to the results of a failed exec's mounts until it's
+// evicted or the client is closed.
+type failedExec struct {
+	op       *bksolverpb.Op
+	opDigest digest.Digest
+	results  []bksolver.Result
+}
+
+func (exec *failedExec) release() {
+	for _, res := range exec.results {
+		if res != nil {
+			res.Release(context.Background())
+		}
+	}
+}
+
+// registerFailedExec keeps the mounts of the exec that returned execErr
+// around so that they can be debugged, returning the ID to retrieve them with.
+// It returns an empty ID if the client doesn't keep failed execs.
+//
+// If the exec ran a module function, the ID of the last exec that failed
+// during the function call is returned instead, since the function's runtime
+// container is rarely what needs debugging.
+func (c *Client) registerFailedExec(ctx context.Context, op *bksolverpb.Op, execErr *llberror.ExecError) string {
+	if !c.KeepFailedExecs {
+		return ""
+	}
+	if execErr.OwnerBorrowed {
+		// the results were released by a previous wrapping of the same error
+		return ""
+	}
+
+	dt, err := op.Marshal()
+	if err != nil {
+		return ""
+	}
+	opDigest := digest.FromBytes(dt)
+
+	// the module function call that ran the exec, if any
+	var callerDigest digest.Digest
+	if clientMetadata, err := engine.ClientMetadataFromContext(ctx); err == nil {
+		callerDigest = clientMetadata.ModuleContextDigest
+	}
+
+	c.failedExecsMu.Lock()
+	defer c.failedExecsMu.Unlock()
+	if c.failedExecs == nil {
+		// client is closed
+		return ""
+	}
+
+	id := c.failedExecIn(execModuleContextDigest(op.GetExec()))
+	if id == "" {
+		id = c.addFailedExec(op, opDigest, execErr)
+	}
+	if callerDigest != "" {
+		c.failedExecsInMod[callerDigest] = id
+	}
+	return id
+}
+
+// failedExecIn returns the ID of the last exec that failed during the module
+// function call with the given module context digest, if it's still kept.
+//
+// failedExecsMu must be held.
+func (c *Client) failedExecIn(moduleContextDigest digest.Digest) string {
+	if moduleContextDigest == "" {
+		return ""
+	}
+	id := c.failedExecsInMod[moduleContextDigest]
+	if _, ok := c.failedExecs[id]; !ok {
+		return ""
+	}
+	return id
+}
+
+// addFailedExec keeps the mounts of a failed exec, unless the same op has
+// already failed, evicting the oldest failed exec if there are too many.
+//
+// failedExecsMu must be held.
+func (c *Client) addFailedExec(op *bksolverpb.Op, opDigest digest.Digest, execErr *llberror.ExecError) string {
+	for _, id := range c.failedExecOrder {
+		if c.failedExecs[id].opDigest == opDigest {
+			return id
+		}
+	}
+
+	exec := &failedExec{
+		op:       op,
+		opDigest: opDigest,
+		results:  make([]bksolver.Result, len(execErr.Mounts)),
+	}
+	for i, res := range execErr.Mounts {
+		if res != nil {
+			exec.results[i] = res.Clone()
+		}
+	}
+
+	id := identity.NewID()
+	c.failedExecs[id] = exec
+	c.failedExecOrder = append(c.failedExecOrder, id)
+
+	for len(c.failedExecOrder) > MaxFailedExecs {
+		evicted := c.failedExecOrder[0]
+		c.failedExecOrder = c.failedExecOrder[1:]
+		c.failedExecs[evicted].release()
+		delete(c.failedExecs, evicted)
+		for mod, modID := range c.failedExecsInMod {
+			if modID == evicted {
+				delete(c.failedExecsInMod, mod)
+			}
+		}
+	}
+
+	return id
+}
+
+// execModuleContextDigest returns the module context digest of the module
+// function call run by an exec, if any.
+func execModuleContextDigest(execOp *bksolverpb.ExecOp) digest.Digest {
+	if execOp == nil || execOp.Meta == nil {
+		return ""
+	}
+	for _, env := range execOp.Meta.Env {
+		if v, ok := strings.CutPrefix(env, "_DAGGER_MODULE_CONTEXT_DIGEST="); ok {
+			return digest.Digest(v)
+		}
+	}
+	return ""
+}
+
+// FailedExec returns the state of the failed exec with the given ID, as
+// reported by ExecError.DebugID.
+func (c *Client) FailedExec(ctx context.Context, id string) (*FailedExec, error) {
+	c.failedExecsMu.Lock()
+	exec, ok := c.failedExecs[id]
+	c.failedExecsMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("failed exec %q not found", id)
+	}
+
+	execOp := exec.op.GetExec()
+	state := &FailedExec{
+		Args: execOp.Meta.Args,
+		Env:  execOp.Meta.Env,
+		Cwd:  execOp.Meta.Cwd,
+		User: execOp.Meta.User,
+	}
+	if exec.op.Platform != nil {
+		state.Platform = exec.op.Platform.Spec()
+	}
+
+	for i, mnt := range execOp.Mounts {
+		res := exec.results[i]
+		if res == nil || mnt.MountType != bksolverpb.MountType_BIND || mnt.Dest == MetaMountDestPath {
+			continue
+		}
+		workerRef, ok := res.Sys().(*bkworker.WorkerRef)
+		if !ok {
+			return nil, fmt.Errorf("invalid ref type: %T", res.Sys())
+		}
+		if workerRef.ImmutableRef == nil {
+			// empty mount
+			continue
+		}
+		def, err := c.blobDefinition(ctx, workerRef.ImmutableRef)
+		if err != nil {
+			return nil, fmt.Errorf("mount %s: %w", mnt.Dest, err)
+		}
+		state.Mounts = append(state.Mounts, FailedExecMount{
+			Dest:       mnt.Dest,
+			SourcePath: mnt.Selector,
+			Readonly:   mnt.Readonly,
+			Definition: def,
+		})
+	}
+	return state, nil
+}
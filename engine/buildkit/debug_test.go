@@ -0,0 +1,83 @@
+package buildkit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dagger/dagger/engine"
+	llberror "github.com/moby/buildkit/solver/llbsolver/errdefs"
+	bksolverpb "github.com/moby/buildkit/solver/pb"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFailedExec(t *testing.T) {
+	newClient := func(keep bool) *Client {
+		return &Client{
+			Opts:             Opts{KeepFailedExecs: keep},
+			failedExecs:      make(map[string]*failedExec),
+			failedExecsInMod: make(map[digest.Digest]string),
+		}
+	}
+
+	execOp := func(env ...string) *bksolverpb.Op {
+		return &bksolverpb.Op{
+			Op: &bksolverpb.Op_Exec{
+				Exec: &bksolverpb.ExecOp{
+					Meta: &bksolverpb.Meta{Args: []string{"false"}, Env: env},
+				},
+			},
+		}
+	}
+
+	ctx := context.Background()
+
+	t.Run("opt-in", func(t *testing.T) {
+		c := newClient(false)
+		require.Empty(t, c.registerFailedExec(ctx, execOp(), &llberror.ExecError{}))
+	})
+
+	t.Run("dedupes by op", func(t *testing.T) {
+		c := newClient(true)
+		id := c.registerFailedExec(ctx, execOp("A=1"), &llberror.ExecError{})
+		require.NotEmpty(t, id)
+		require.Equal(t, id, c.registerFailedExec(ctx, execOp("A=1"), &llberror.ExecError{}))
+		require.NotEqual(t, id, c.registerFailedExec(ctx, execOp("A=2"), &llberror.ExecError{}))
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		c := newClient(true)
+		ids := make([]string, MaxFailedExecs+2)
+		for i := range ids {
+			ids[i] = c.registerFailedExec(ctx, execOp(fmt.Sprintf("I=%d", i)), &llberror.ExecError{})
+		}
+		require.Len(t, c.failedExecs, MaxFailedExecs)
+		for i, id := range ids {
+			_, kept := c.failedExecs[id]
+			// the execs that failed first are released
+			require.Equal(t, i >= 2, kept)
+		}
+	})
+
+	t.Run("module function call", func(t *testing.T) {
+		c := newClient(true)
+		fnDigest := digest.FromString("fn")
+
+		fnCtx := engine.ContextWithClientMetadata(ctx, &engine.ClientMetadata{
+			ModuleContextDigest: fnDigest,
+		})
+		nested := c.registerFailedExec(fnCtx, execOp("NESTED=1"), &llberror.ExecError{})
+		require.NotEmpty(t, nested)
+
+		// the runtime container reports the exec that failed in the function
+		runtime := execOp("_DAGGER_MODULE_CONTEXT_DIGEST=" + fnDigest.String())
+		require.Equal(t, nested, c.registerFailedExec(ctx, runtime, &llberror.ExecError{}))
+
+		// unless no exec failed in it
+		other := execOp("_DAGGER_MODULE_CONTEXT_DIGEST=" + digest.FromString("other").String())
+		id := c.registerFailedExec(ctx, other, &llberror.ExecError{})
+		require.NotEmpty(t, id)
+		require.NotEqual(t, nested, id)
+	})
+}
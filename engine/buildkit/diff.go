@@ -0,0 +1,386 @@
+package buildkit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/continuity/fs"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/snapshot"
+	bksolverpb "github.com/moby/buildkit/solver/pb"
+	"github.com/tonistiigi/fsutil"
+)
+
+// FileChange is a change to a path between two filesystems.
+type FileChange struct {
+	Kind fs.ChangeKind
+	Path string
+
+	// Mode and Size describe the path in the upper filesystem. They are zero
+	// for deleted paths.
+	Mode os.FileMode
+	Size int64
+}
+
+// FilesystemChanges returns the changes that turn the filesystem of lower
+// into the filesystem of upper, in lexical path order.
+func (c *Client) FilesystemChanges(ctx context.Context, lower, upper *bksolverpb.Definition) ([]FileChange, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	lowerPath, unmountLower, err := c.mountDefinition(ctx, lower)
+	if err != nil {
+		return nil, err
+	}
+	defer unmountLower()
+
+	upperPath, unmountUpper, err := c.mountDefinition(ctx, upper)
+	if err != nil {
+		return nil, err
+	}
+	defer unmountUpper()
+
+	var changes []FileChange
+	err = fs.Changes(ctx, lowerPath, upperPath, func(kind fs.ChangeKind, path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		change := FileChange{
+			Kind: kind,
+			Path: path,
+		}
+		if kind != fs.ChangeKindDelete && fi != nil {
+			change.Mode = fi.Mode()
+			if fi.Mode().IsRegular() {
+				change.Size = fi.Size()
+			}
+		}
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute changes: %w", err)
+	}
+	return changes, nil
+}
+
+// DirectoryChange is a change to a path between two directories.
+type DirectoryChange struct {
+	FileChange
+
+	// OldPath is the path of the entry in the lower directory if it was
+	// renamed, in which case Kind is fs.ChangeKindAdd.
+	OldPath string
+
+	// OldMode describes the path in the lower directory. It is zero for added
+	// paths.
+	OldMode os.FileMode
+}
+
+// DirectoryChanges returns the changes that turn lowerDir in the filesystem of
+// lower into upperDir in the filesystem of upper. Paths are relative to the
+// directories.
+//
+// Unlike FilesystemChanges, paths that only differ by their modification
+// time are not reported, the contents of deleted directories are reported
+// along with the directories, and regular files that were moved without
+// changing their contents are reported as renames.
+func (c *Client) DirectoryChanges(
+	ctx context.Context,
+	lower *bksolverpb.Definition,
+	lowerDir string,
+	upper *bksolverpb.Definition,
+	upperDir string,
+) ([]DirectoryChange, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	lowerRoot, unmountLower, err := c.mountDirectory(ctx, lower, lowerDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unmountLower()
+
+	upperRoot, unmountUpper, err := c.mountDirectory(ctx, upper, upperDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unmountUpper()
+
+	return directoryChanges(ctx, lowerRoot, upperRoot)
+}
+
+func directoryChanges(ctx context.Context, lowerRoot, upperRoot string) ([]DirectoryChange, error) {
+	var changes []DirectoryChange
+	addDeleted := func(p string, oldFi os.FileInfo) error {
+		changes = append(changes, DirectoryChange{
+			FileChange: FileChange{Kind: fs.ChangeKindDelete, Path: p},
+			OldMode:    oldFi.Mode(),
+		})
+		if !oldFi.IsDir() {
+			return nil
+		}
+		// fs.Changes only reports the deleted directory itself
+		root := filepath.Join(lowerRoot, p)
+		return filepath.Walk(root, func(src string, fi os.FileInfo, err error) error {
+			if err != nil || src == root {
+				return err
+			}
+			rel, err := filepath.Rel(lowerRoot, src)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, DirectoryChange{
+				FileChange: FileChange{Kind: fs.ChangeKindDelete, Path: rel},
+				OldMode:    fi.Mode(),
+			})
+			return nil
+		})
+	}
+
+	err := fs.Changes(ctx, lowerRoot, upperRoot, func(kind fs.ChangeKind, p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		p = strings.TrimPrefix(p, string(os.PathSeparator))
+
+		var oldFi os.FileInfo
+		if kind != fs.ChangeKindAdd {
+			oldFi, err = os.Lstat(filepath.Join(lowerRoot, p))
+			if err != nil {
+				return err
+			}
+		}
+
+		switch kind {
+		case fs.ChangeKindDelete:
+			return addDeleted(p, oldFi)
+		case fs.ChangeKindModify:
+			if oldFi.IsDir() && !fi.IsDir() {
+				// the directory's contents were deleted along with it
+				if err := addDeleted(p, oldFi); err != nil {
+					return err
+				}
+				kind = fs.ChangeKindAdd
+				oldFi = nil
+				break
+			}
+			same, err := sameEntry(filepath.Join(lowerRoot, p), filepath.Join(upperRoot, p), oldFi, fi)
+			if err != nil {
+				return err
+			}
+			if same {
+				return nil
+			}
+		}
+
+		change := DirectoryChange{
+			FileChange: FileChange{
+				Kind: kind,
+				Path: p,
+				Mode: fi.Mode(),
+			},
+		}
+		if fi.Mode().IsRegular() {
+			change.Size = fi.Size()
+		}
+		if oldFi != nil {
+			change.OldMode = oldFi.Mode()
+		}
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute changes: %w", err)
+	}
+
+	return detectRenames(lowerRoot, upperRoot, changes)
+}
+
+// sameEntry returns whether the entries at the given paths have the same
+// type, permissions, ownership and contents.
+func sameEntry(oldPath, newPath string, oldFi, newFi os.FileInfo) (bool, error) {
+	if oldFi.Mode() != newFi.Mode() {
+		return false, nil
+	}
+	oldStat, err := fsutil.Stat(oldPath)
+	if err != nil {
+		return false, err
+	}
+	newStat, err := fsutil.Stat(newPath)
+	if err != nil {
+		return false, err
+	}
+	if oldStat.Uid != newStat.Uid || oldStat.Gid != newStat.Gid {
+		return false, nil
+	}
+
+	switch {
+	case oldFi.Mode().IsRegular():
+		if oldFi.Size() != newFi.Size() {
+			return false, nil
+		}
+		oldDgst, err := fileChecksum(oldPath)
+		if err != nil {
+			return false, err
+		}
+		newDgst, err := fileChecksum(newPath)
+		if err != nil {
+			return false, err
+		}
+		return bytes.Equal(oldDgst, newDgst), nil
+	case oldFi.Mode()&os.ModeSymlink != 0:
+		oldTarget, err := os.Readlink(oldPath)
+		if err != nil {
+			return false, err
+		}
+		newTarget, err := os.Readlink(newPath)
+		if err != nil {
+			return false, err
+		}
+		return oldTarget == newTarget, nil
+	default:
+		return true, nil
+	}
+}
+
+// detectRenames pairs deleted regular files with added regular files that
+// have the same contents, replacing them with renames.
+func detectRenames(lowerRoot, upperRoot string, changes []DirectoryChange) ([]DirectoryChange, error) {
+	deletedBySize := map[int64][]int{}
+	for i, change := range changes {
+		if change.Kind == fs.ChangeKindDelete && change.OldMode.IsRegular() {
+			fi, err := os.Lstat(filepath.Join(lowerRoot, change.Path))
+			if err != nil {
+				return nil, err
+			}
+			// empty files are all alike, don't guess which one went where
+			if fi.Size() > 0 {
+				deletedBySize[fi.Size()] = append(deletedBySize[fi.Size()], i)
+			}
+		}
+	}
+	if len(deletedBySize) == 0 {
+		return changes, nil
+	}
+
+	checksums := map[string][]byte{}
+	checksum := func(p string) ([]byte, error) {
+		if dgst, ok := checksums[p]; ok {
+			return dgst, nil
+		}
+		dgst, err := fileChecksum(p)
+		if err != nil {
+			return nil, err
+		}
+		checksums[p] = dgst
+		return dgst, nil
+	}
+
+	renamed := map[int]bool{}
+	for i, change := range changes {
+		if change.Kind != fs.ChangeKindAdd || !change.Mode.IsRegular() {
+			continue
+		}
+		candidates := deletedBySize[change.Size]
+		for j, candidate := range candidates {
+			newDgst, err := checksum(filepath.Join(upperRoot, change.Path))
+			if err != nil {
+				return nil, err
+			}
+			oldDgst, err := checksum(filepath.Join(lowerRoot, changes[candidate].Path))
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(oldDgst, newDgst) {
+				continue
+			}
+			changes[i].OldPath = changes[candidate].Path
+			changes[i].OldMode = changes[candidate].OldMode
+			renamed[candidate] = true
+			deletedBySize[change.Size] = append(candidates[:j:j], candidates[j+1:]...)
+			break
+		}
+	}
+
+	filtered := make([]DirectoryChange, 0, len(changes)-len(renamed))
+	for i, change := range changes {
+		if !renamed[i] {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered, nil
+}
+
+func fileChecksum(p string) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// mountDefinition solves def and mounts the result read-only, returning the
+// mount path and a function to unmount it. An empty result is mounted as an
+// empty directory.
+func (c *Client) mountDefinition(ctx context.Context, def *bksolverpb.Definition) (string, func() error, error) {
+	res, err := c.Solve(ctx, bkgw.SolveRequest{Definition: def, Evaluate: true})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to solve: %w", err)
+	}
+	ref, err := res.SingleRef()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get single ref: %w", err)
+	}
+
+	mountable, err := ref.getMountable(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get mountable: %w", err)
+	}
+	if mountable == nil {
+		dir, err := os.MkdirTemp("", "dagger-scratch")
+		if err != nil {
+			return "", nil, err
+		}
+		return dir, func() error { return os.RemoveAll(dir) }, nil
+	}
+
+	mounter := snapshot.LocalMounter(mountable)
+	mountPath, err := mounter.Mount()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to mount: %w", err)
+	}
+	return mountPath, mounter.Unmount, nil
+}
+
+// mountDirectory mounts the result of def like mountDefinition, returning the
+// path of dir within the mount.
+func (c *Client) mountDirectory(ctx context.Context, def *bksolverpb.Definition, dir string) (string, func() error, error) {
+	mountPath, unmount, err := c.mountDefinition(ctx, def)
+	if err != nil {
+		return "", nil, err
+	}
+	root, err := fs.RootPath(mountPath, dir)
+	if err != nil {
+		unmount()
+		return "", nil, err
+	}
+	return root, unmount, nil
+}
@@ -7,6 +7,8 @@ type ExecError struct {
 	ExitCode int
 	Stdout   string
 	Stderr   string
+	// DebugID identifies the state of the failed exec, see Client.FailedExec.
+	DebugID string
 }
 
 func (e *ExecError) Error() string {
@@ -24,5 +26,38 @@ func (e *ExecError) Extensions() map[string]interface{} {
 		"exitCode": e.ExitCode,
 		"stdout":   e.Stdout,
 		"stderr":   e.Stderr,
+		"debugID":  e.DebugID,
+	}
+}
+
+// ExecTimeoutError is an error returned when an `Op_Exec` is killed after
+// exceeding its timeout.
+type ExecTimeoutError struct {
+	original error
+	Cmd      []string
+	// Timeout is the timeout that was exceeded, in seconds.
+	Timeout int
+	Stdout  string
+	Stderr  string
+	// DebugID identifies the state of the failed exec, see Client.FailedExec.
+	DebugID string
+}
+
+func (e *ExecTimeoutError) Error() string {
+	return e.original.Error()
+}
+
+func (e *ExecTimeoutError) Unwrap() error {
+	return e.original
+}
+
+func (e *ExecTimeoutError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"_type":   "EXEC_TIMEOUT_ERROR",
+		"cmd":     e.Cmd,
+		"timeout": e.Timeout,
+		"stdout":  e.Stdout,
+		"stderr":  e.Stderr,
+		"debugID": e.DebugID,
 	}
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/containerd/continuity/fs"
 	"github.com/dagger/dagger/engine"
 	"github.com/dagger/dagger/engine/sources/blob"
+	bkcache "github.com/moby/buildkit/cache"
 	cacheconfig "github.com/moby/buildkit/cache/config"
 	bkclient "github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/client/llb"
@@ -27,6 +28,10 @@ import (
 	"github.com/vito/progrock"
 )
 
+// LocalImport imports srcPath from the caller's host. If gitignore is set, the
+// paths ignored by the .gitignore files in srcPath and its subdirectories are
+// excluded, and if ignoreFile is set, so are the paths ignored by that
+// .dockerignore-style file, relative to srcPath.
 func (c *Client) LocalImport(
 	ctx context.Context,
 	recorder *progrock.Recorder,
@@ -34,6 +39,8 @@ func (c *Client) LocalImport(
 	srcPath string,
 	excludePatterns []string,
 	includePatterns []string,
+	gitignore bool,
+	ignoreFile string,
 ) (*bksolverpb.Definition, error) {
 	srcPath = path.Clean(srcPath)
 	if srcPath == ".." || strings.HasPrefix(srcPath, "../") {
@@ -53,6 +60,20 @@ func (c *Client) LocalImport(
 	localName := fmt.Sprintf("upload %s from %s (client id: %s)", srcPath, clientMetadata.ClientHostname, clientMetadata.ClientID)
 	if len(excludePatterns) > 0 {
 		localName += fmt.Sprintf(" (exclude: %s)", strings.Join(excludePatterns, ", "))
+	}
+	if gitignore {
+		localName += " (gitignore)"
+	}
+	if ignoreFile != "" {
+		localName += fmt.Sprintf(" (ignore file: %s)", ignoreFile)
+	}
+	ignorePatterns, err := c.localIgnorePatterns(ctx, srcPath, excludePatterns, gitignore, ignoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ignore files: %w", err)
+	}
+	// the explicit patterns come last so they take precedence
+	excludePatterns = append(ignorePatterns, excludePatterns...)
+	if len(excludePatterns) > 0 {
 		localOpts = append(localOpts, llb.ExcludePatterns(excludePatterns))
 	}
 	if len(includePatterns) > 0 {
@@ -90,20 +111,24 @@ func (c *Client) LocalImport(
 	}
 	cachedRes, err := resultProxy.Result(ctx)
 	if err != nil {
-		return nil, wrapError(ctx, err, c.ID())
+		return nil, c.wrapError(ctx, err)
 	}
 	workerRef, ok := cachedRes.Sys().(*bkworker.WorkerRef)
 	if !ok {
 		return nil, fmt.Errorf("invalid ref: %T", cachedRes.Sys())
 	}
-	ref := workerRef.ImmutableRef
+	return c.blobDefinition(ctx, workerRef.ImmutableRef)
+}
 
+// blobDefinition returns a blob source definition for the contents of ref, so
+// that the result no longer depends on ref being held.
+func (c *Client) blobDefinition(ctx context.Context, ref bkcache.ImmutableRef) (*bksolverpb.Definition, error) {
 	// Force an unlazy of the copy in case it was lazy due to remote caching; we
 	// need it to exist locally or else blob source won't work.
 	// NOTE: in theory we could keep it lazy if we could get the descriptor handlers
 	// for the remote over to the blob source code, but the plumbing to accomplish that
 	// is tricky and ultimately only result in a marginal performance optimization.
-	err = ref.Extract(ctx, nil)
+	err := ref.Extract(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract ref: %s", err)
 	}
@@ -135,14 +160,14 @@ func (c *Client) LocalImport(
 	}
 	blobPB := blobDef.ToPB()
 
-	// do a sync solve right now so we can release the cache ref for the local import
-	// without giving up the lease on the blob
+	// do a sync solve right now so we can release the cache ref without giving up
+	// the lease on the blob
 	_, err = c.Solve(ctx, bkgw.SolveRequest{
 		Definition: blobPB,
 		Evaluate:   true,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to solve blobsource: %w", wrapError(ctx, err, c.ID()))
+		return nil, fmt.Errorf("failed to solve blobsource: %w", c.wrapError(ctx, err))
 	}
 
 	return blobPB, nil
@@ -166,7 +191,7 @@ func (c *Client) EngineContainerLocalImport(
 		ClientHostname: hostname,
 	})
 
-	return c.LocalImport(ctx, recorder, platform, srcPath, excludePatterns, includePatterns)
+	return c.LocalImport(ctx, recorder, platform, srcPath, excludePatterns, includePatterns, false, "")
 }
 
 func (c *Client) ReadCallerHostFile(ctx context.Context, path string) ([]byte, error) {
@@ -205,10 +230,15 @@ func (c *Client) ReadCallerHostFile(ctx context.Context, path string) ([]byte, e
 	return msg.Data, nil
 }
 
+// LocalDirExport writes the result of def to destPath on the client's host.
+// If mirror is set, any paths under destPath that aren't part of the result
+// are removed, except for those matching excludePatterns.
 func (c *Client) LocalDirExport(
 	ctx context.Context,
 	def *bksolverpb.Definition,
 	destPath string,
+	mirror bool,
+	excludePatterns []string,
 ) error {
 	ctx, cancel, err := c.withClientCloseCancel(ctx)
 	if err != nil {
@@ -246,8 +276,10 @@ func (c *Client) LocalDirExport(
 	}
 
 	ctx = engine.LocalExportOpts{
-		DestClientID: clientMetadata.ClientID,
-		Path:         destPath,
+		DestClientID:    clientMetadata.ClientID,
+		Path:            destPath,
+		Mirror:          mirror,
+		ExcludePatterns: excludePatterns,
 	}.AppendToOutgoingContext(ctx)
 
 	_, descRef, err := expInstance.Export(ctx, cacheRes, clientMetadata.ClientID)
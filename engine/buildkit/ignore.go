@@ -0,0 +1,229 @@
+package buildkit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dagger/dagger/engine"
+	"github.com/moby/buildkit/session/filesync"
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+	"github.com/tonistiigi/fsutil"
+)
+
+const gitignoreFileName = ".gitignore"
+
+// localIgnorePatterns returns exclude patterns for the paths in srcPath on the
+// caller's host that are ignored by its .gitignore files, if gitignore is set,
+// and by the .dockerignore-style ignoreFile, if set. Paths matching
+// excludePatterns are not searched for ignore files.
+func (c *Client) localIgnorePatterns(
+	ctx context.Context,
+	srcPath string,
+	excludePatterns []string,
+	gitignore bool,
+	ignoreFile string,
+) ([]string, error) {
+	var includePatterns []string
+	if gitignore {
+		includePatterns = append(includePatterns, "**/"+gitignoreFileName)
+	}
+	if ignoreFile != "" {
+		ignoreFile = path.Clean(ignoreFile)
+		if path.IsAbs(ignoreFile) || ignoreFile == ".." || strings.HasPrefix(ignoreFile, "../") {
+			return nil, fmt.Errorf("ignore file %q must be a relative path under %q", ignoreFile, srcPath)
+		}
+		includePatterns = append(includePatterns, ignoreFile)
+	}
+	if len(includePatterns) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	clientMetadata, err := engine.ClientMetadataFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get requester session ID: %s", err)
+	}
+
+	ctx = engine.LocalImportOpts{
+		OwnerClientID:   clientMetadata.ClientID,
+		Path:            srcPath,
+		IncludePatterns: includePatterns,
+		ExcludePatterns: excludePatterns,
+	}.AppendToOutgoingContext(ctx)
+
+	clientCaller, err := c.SessionManager.Get(ctx, clientMetadata.ClientID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get requester session: %s", err)
+	}
+	diffCopyClient, err := filesync.NewFileSyncClient(clientCaller.Conn()).DiffCopy(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create diff copy client: %s", err)
+	}
+	defer diffCopyClient.CloseSend()
+
+	// only the ignore files are synced, so this is cheap to do on every import
+	tmpDir, err := os.MkdirTemp("", "dagger-ignore")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	err = fsutil.Receive(ctx, diffCopyClient, tmpDir, fsutil.ReceiveOpt{Merge: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive ignore files: %w", err)
+	}
+
+	var patterns []string
+	if gitignore {
+		patterns, err = gitignorePatterns(tmpDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ignoreFile != "" {
+		f, err := os.Open(filepath.Join(tmpDir, filepath.FromSlash(ignoreFile)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open ignore file %s: %w", ignoreFile, err)
+		}
+		defer f.Close()
+		ignorePatterns, err := ignorefile.ReadAll(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ignore file %s: %w", ignoreFile, err)
+		}
+		patterns = append(patterns, ignorePatterns...)
+	}
+	return patterns, nil
+}
+
+// gitignorePatterns returns exclude patterns for the .gitignore files under
+// root. Files in deeper directories come later, so that their patterns take
+// precedence, and files in ignored directories are skipped like git does.
+func gitignorePatterns(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != gitignoreFileName {
+			return nil
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s files: %w", gitignoreFileName, err)
+	}
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return pathDepth(dirs[i]) < pathDepth(dirs[j])
+	})
+
+	var patterns []string
+	for _, dir := range dirs {
+		if dir != "." && len(patterns) > 0 {
+			pm, err := patternmatcher.New(patterns)
+			if err != nil {
+				return nil, err
+			}
+			ignored, err := pm.MatchesOrParentMatches(dir)
+			if err != nil {
+				return nil, err
+			}
+			if ignored {
+				continue
+			}
+		}
+
+		f, err := os.Open(filepath.Join(root, filepath.FromSlash(dir), gitignoreFileName))
+		if err != nil {
+			return nil, err
+		}
+		dirPatterns, err := parseGitignore(dir, f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path.Join(dir, gitignoreFileName), err)
+		}
+		patterns = append(patterns, dirPatterns...)
+	}
+	return patterns, nil
+}
+
+// parseGitignore converts the patterns of a .gitignore file in dir to exclude
+// patterns relative to the root of the import.
+//
+// Directory-only patterns (e.g. "build/") exclude the contents of the matching
+// directories, so that files with the same name are kept, but leave the empty
+// directories themselves in place.
+func parseGitignore(dir string, r io.Reader) ([]string, error) {
+	var patterns []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		// trailing spaces are ignored unless they're escaped
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+			line = line[:len(line)-1]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var negate bool
+		switch {
+		case strings.HasPrefix(line, "!"):
+			negate = true
+			line = line[1:]
+		case strings.HasPrefix(line, `\#`), strings.HasPrefix(line, `\!`):
+			line = line[1:]
+		}
+
+		dirOnly := strings.HasSuffix(line, "/")
+		line = strings.TrimRight(line, "/")
+		if line == "" {
+			continue
+		}
+
+		// a pattern with a separator at the start or in the middle is
+		// relative to the directory of the .gitignore; otherwise it matches
+		// at any depth below it
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		pattern := path.Join(dir, line)
+		if dirOnly {
+			pattern += "/**"
+		}
+		if negate {
+			pattern = "!" + pattern
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+func pathDepth(p string) int {
+	if p == "." {
+		return 0
+	}
+	return strings.Count(p, "/") + 1
+}
@@ -0,0 +1,88 @@
+package buildkit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tonistiigi/fsutil"
+)
+
+func TestGitignorePatterns(t *testing.T) {
+	root := t.TempDir()
+	for name, contents := range map[string]string{
+		".gitignore":                "*.log\n/dist\nbuild/\nnode_modules/\n!keep.log\n# comment\n\\#notes\n",
+		"#notes":                    "",
+		"a.log":                     "",
+		"keep.log":                  "",
+		"dist/out":                  "",
+		"build/out":                 "",
+		"node_modules/m/.gitignore": "!*.log\n",
+		"node_modules/m/m.log":      "",
+		"src/main.go":               "",
+		"src/debug.log":             "",
+		"sub/.gitignore":            "*.txt\n!important.txt\n",
+		"sub/build":                 "",
+		"sub/dist/out":              "",
+		"sub/notes.txt":             "",
+		"sub/important.txt":         "",
+		"notes.txt":                 "",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(contents), 0o644))
+	}
+
+	patterns, err := gitignorePatterns(root)
+	require.NoError(t, err)
+
+	var paths []string
+	err = fsutil.Walk(context.Background(), root, &fsutil.WalkOpt{
+		ExcludePatterns: patterns,
+	}, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(p))
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		".gitignore",
+		// directory-only patterns leave the directories behind, empty
+		"build",
+		"keep.log",
+		"node_modules",
+		"notes.txt",
+		"src",
+		"src/main.go",
+		"sub",
+		"sub/.gitignore",
+		// only directories are matched by build/
+		"sub/build",
+		// /dist is relative to the root
+		"sub/dist",
+		"sub/dist/out",
+		"sub/important.txt",
+	}, paths)
+}
+
+func TestParseGitignore(t *testing.T) {
+	patterns, err := parseGitignore("sub", strings.NewReader(
+		"# comment\n\n*.o\n/bin\ndocs/*.md\nout/\n!keep.o\n\\!bang\ntrailing \\ \nspaces   \r\n",
+	))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"sub/**/*.o",
+		"sub/bin",
+		"sub/docs/*.md",
+		"sub/**/out/**",
+		"!sub/**/keep.o",
+		"sub/**/!bang",
+		`sub/**/trailing \ `,
+		"sub/**/spaces",
+	}, patterns)
+}
@@ -0,0 +1,89 @@
+package buildkit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/containerd/containerd/labels"
+	cacheconfig "github.com/moby/buildkit/cache/config"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	bksession "github.com/moby/buildkit/session"
+	bksolverpb "github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/compression"
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Layer is a layer of a filesystem, as it would be exported in an image.
+type Layer struct {
+	// Descriptor is the descriptor of the compressed layer blob.
+	Descriptor ocispecs.Descriptor
+
+	// DiffID is the digest of the uncompressed layer.
+	DiffID digest.Digest
+
+	// Description is how the layer was created, e.g. "mount / from exec
+	// sh -c make".
+	Description string
+
+	CreatedAt time.Time
+}
+
+// Layers returns the layers of the filesystem of def, from the bottom up. Any
+// layer blobs that have not been computed yet are created with the default
+// compression, the same as when publishing an image.
+func (c *Client) Layers(ctx context.Context, def *bksolverpb.Definition) ([]Layer, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	res, err := c.Solve(ctx, bkgw.SolveRequest{Definition: def, Evaluate: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to solve: %w", err)
+	}
+	ref, err := res.SingleRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get single ref: %w", err)
+	}
+	if ref == nil {
+		return nil, nil
+	}
+	cacheRef, err := ref.CacheRef(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cacheRef == nil {
+		return nil, nil
+	}
+
+	remotes, err := cacheRef.GetRemotes(ctx, true, cacheconfig.RefConfig{
+		Compression: compression.New(compression.Default),
+	}, false, bksession.NewGroup(c.ID()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remotes: %w", err)
+	}
+	if len(remotes) != 1 {
+		return nil, fmt.Errorf("expected 1 remote, got %d", len(remotes))
+	}
+	descs := remotes[0].Descriptors
+
+	chain := cacheRef.LayerChain()
+	defer chain.Release(context.Background())
+	if len(chain) != len(descs) {
+		return nil, fmt.Errorf("expected %d layers, got %d", len(chain), len(descs))
+	}
+
+	layers := make([]Layer, 0, len(descs))
+	for i, desc := range descs {
+		layers = append(layers, Layer{
+			Descriptor:  desc,
+			DiffID:      digest.Digest(desc.Annotations[labels.LabelUncompressed]),
+			Description: chain[i].GetDescription(),
+			CreatedAt:   chain[i].GetCreatedAt(),
+		})
+	}
+	return layers, nil
+}
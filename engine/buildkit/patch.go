@@ -0,0 +1,906 @@
+package buildkit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/continuity/fs"
+	bksolverpb "github.com/moby/buildkit/solver/pb"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/tonistiigi/fsutil"
+	"github.com/vito/progrock"
+)
+
+// patchContextLines is the number of unchanged lines around each hunk of a
+// patch, as in the default output of git diff.
+const patchContextLines = 3
+
+// DirectoryPatch writes a unified diff of the changes that turn lowerDir in
+// the filesystem of lower into upperDir in the filesystem of upper, returning
+// a definition containing only the patch at fileName.
+//
+// The patch is in the format of git diff, including renames and permission
+// changes, so that it can be applied with git apply as well as ApplyPatch.
+func (c *Client) DirectoryPatch(
+	ctx context.Context,
+	engineHostPlatform specs.Platform,
+	lower *bksolverpb.Definition,
+	lowerDir string,
+	upper *bksolverpb.Definition,
+	upperDir string,
+	fileName string,
+) (*bksolverpb.Definition, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	lowerRoot, unmountLower, err := c.mountDirectory(ctx, lower, lowerDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unmountLower()
+
+	upperRoot, unmountUpper, err := c.mountDirectory(ctx, upper, upperDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unmountUpper()
+
+	changes, err := directoryChanges(ctx, lowerRoot, upperRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "dagger-patch")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir for patch: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	patchPath := filepath.Join(tmpDir, fileName)
+	f, err := os.Create(patchPath)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	if err := writePatch(w, lowerRoot, upperRoot, changes); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write patch: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Chtimes(patchPath, archiveModTime, archiveModTime); err != nil {
+		return nil, err
+	}
+
+	ctx, recorder := progrock.WithGroup(ctx, "create patch")
+	pbDef, err := c.EngineContainerLocalImport(ctx, recorder, engineHostPlatform, tmpDir, nil, []string{fileName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import patch from engine container filesystem: %w", err)
+	}
+	return pbDef, nil
+}
+
+// ApplyPatch applies the unified diff at patchPath in the filesystem of
+// patchDef to dir in the filesystem of def.
+//
+// It returns a definition containing the files created or changed by the
+// patch, relative to dir, along with the paths removed by the patch. The
+// parent directories of changed files have the permissions and ownership of
+// the directories they replace, so that the result can be copied onto dir.
+func (c *Client) ApplyPatch(
+	ctx context.Context,
+	engineHostPlatform specs.Platform,
+	def *bksolverpb.Definition,
+	dir string,
+	patchDef *bksolverpb.Definition,
+	patchPath string,
+) (*bksolverpb.Definition, []string, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cancel()
+
+	root, unmount, err := c.mountDirectory(ctx, def, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unmount()
+
+	patchMount, unmountPatch, err := c.mountDefinition(ctx, patchDef)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unmountPatch()
+
+	src, err := fs.RootPath(patchMount, patchPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	tmpDir, err := os.MkdirTemp("", "dagger-apply-patch")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temp dir for patch: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	removed, err := applyPatch(f, root, tmpDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to apply %s: %w", patchPath, err)
+	}
+
+	ctx, recorder := progrock.WithGroup(ctx, "apply patch")
+	pbDef, err := c.EngineContainerLocalImport(ctx, recorder, engineHostPlatform, tmpDir, nil, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to import patched files from engine container filesystem: %w", err)
+	}
+	return pbDef, removed, nil
+}
+
+// writePatch writes the changes between lowerRoot and upperRoot as a git
+// style unified diff. Directories are omitted, since only their contents
+// can be patched.
+func writePatch(w io.Writer, lowerRoot, upperRoot string, changes []DirectoryChange) error {
+	for _, change := range changes {
+		oldPath, newPath := change.Path, change.Path
+		oldMode, newMode := change.OldMode, change.Mode
+		switch {
+		case change.Kind == fs.ChangeKindDelete:
+			newPath, newMode = "", 0
+		case change.Kind == fs.ChangeKindAdd && change.OldPath != "":
+			oldPath = change.OldPath
+		case change.Kind == fs.ChangeKindAdd:
+			oldPath, oldMode = "", 0
+		case !patchable(oldMode) || !patchable(newMode) || oldMode.Type() != newMode.Type():
+			// changing the type of an entry is a deletion and an addition
+			if patchable(oldMode) {
+				if err := writeFilePatch(w, lowerRoot, upperRoot, oldPath, "", oldMode, 0); err != nil {
+					return err
+				}
+			}
+			oldPath, oldMode = "", 0
+		}
+		if (oldPath != "" && !patchable(oldMode)) || (newPath != "" && !patchable(newMode)) {
+			continue
+		}
+		if err := writeFilePatch(w, lowerRoot, upperRoot, oldPath, newPath, oldMode, newMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchable returns whether an entry with the given mode can be represented
+// in a patch.
+func patchable(mode os.FileMode) bool {
+	return mode.IsRegular() || mode&os.ModeSymlink != 0
+}
+
+// writeFilePatch writes the patch for a single file. An empty oldPath or
+// newPath means the file was added or deleted, respectively.
+func writeFilePatch(w io.Writer, lowerRoot, upperRoot, oldPath, newPath string, oldMode, newMode os.FileMode) error {
+	var oldContent, newContent []byte
+	var err error
+	if oldPath != "" {
+		oldContent, err = readPatchContent(filepath.Join(lowerRoot, oldPath), oldMode)
+		if err != nil {
+			return err
+		}
+	}
+	if newPath != "" {
+		newContent, err = readPatchContent(filepath.Join(upperRoot, newPath), newMode)
+		if err != nil {
+			return err
+		}
+	}
+
+	aName, bName := "/dev/null", "/dev/null"
+	if oldPath != "" {
+		aName = "a/" + oldPath
+	}
+	if newPath != "" {
+		bName = "b/" + newPath
+	}
+
+	var hdr bytes.Buffer
+	switch {
+	case oldPath == "":
+		fmt.Fprintf(&hdr, "diff --git a/%s b/%s\n", newPath, newPath)
+		fmt.Fprintf(&hdr, "new file mode %s\n", gitMode(newMode))
+	case newPath == "":
+		fmt.Fprintf(&hdr, "diff --git a/%s b/%s\n", oldPath, oldPath)
+		fmt.Fprintf(&hdr, "deleted file mode %s\n", gitMode(oldMode))
+	default:
+		fmt.Fprintf(&hdr, "diff --git a/%s b/%s\n", oldPath, newPath)
+		if oldMode != newMode {
+			fmt.Fprintf(&hdr, "old mode %s\n", gitMode(oldMode))
+			fmt.Fprintf(&hdr, "new mode %s\n", gitMode(newMode))
+		}
+		if oldPath != newPath {
+			fmt.Fprintf(&hdr, "similarity index 100%%\n")
+			fmt.Fprintf(&hdr, "rename from %s\n", oldPath)
+			fmt.Fprintf(&hdr, "rename to %s\n", newPath)
+		}
+	}
+
+	contentChanged := !bytes.Equal(oldContent, newContent)
+	if oldPath != "" && newPath != "" && oldPath == newPath && oldMode == newMode && !contentChanged {
+		// e.g. only the ownership changed, which patches can't express
+		return nil
+	}
+	if _, err := w.Write(hdr.Bytes()); err != nil {
+		return err
+	}
+	if !contentChanged {
+		return nil
+	}
+
+	if bytes.IndexByte(oldContent, 0) != -1 || bytes.IndexByte(newContent, 0) != -1 {
+		_, err := fmt.Fprintf(w, "Binary files %s and %s differ\n", aName, bName)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", aName, bName); err != nil {
+		return err
+	}
+	return writeHunks(w, splitLines(string(oldContent)), splitLines(string(newContent)))
+}
+
+// readPatchContent returns the content of a file as it appears in a patch:
+// its contents for a regular file, or its target for a symlink.
+func readPatchContent(p string, mode os.FileMode) ([]byte, error) {
+	if mode&os.ModeSymlink != 0 {
+		target, err := os.Readlink(p)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(target), nil
+	}
+	return os.ReadFile(p)
+}
+
+func writeHunks(w io.Writer, a, b []string) error {
+	m := difflib.NewMatcher(a, b)
+	for _, group := range m.GetGroupedOpCodes(patchContextLines) {
+		first, last := group[0], group[len(group)-1]
+		if _, err := fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(first.I1, last.I2), hunkRange(first.J1, last.J2)); err != nil {
+			return err
+		}
+		for _, op := range group {
+			if op.Tag == 'e' {
+				if err := writeHunkLines(w, ' ', a[op.I1:op.I2]); err != nil {
+					return err
+				}
+				continue
+			}
+			if op.Tag == 'r' || op.Tag == 'd' {
+				if err := writeHunkLines(w, '-', a[op.I1:op.I2]); err != nil {
+					return err
+				}
+			}
+			if op.Tag == 'r' || op.Tag == 'i' {
+				if err := writeHunkLines(w, '+', b[op.J1:op.J2]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func writeHunkLines(w io.Writer, prefix byte, lines []string) error {
+	for _, line := range lines {
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n\\ No newline at end of file\n"
+		}
+		if _, err := fmt.Fprintf(w, "%c%s", prefix, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hunkRange formats the range of lines [start, end) of a hunk header.
+func hunkRange(start, end int) string {
+	switch n := end - start; n {
+	case 0:
+		// an empty range refers to the line before it
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return strconv.Itoa(start + 1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+}
+
+// splitLines splits s into lines, keeping their line endings.
+func splitLines(s string) []string {
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n')
+		if i == -1 {
+			lines = append(lines, s)
+			break
+		}
+		lines = append(lines, s[:i+1])
+		s = s[i+1:]
+	}
+	return lines
+}
+
+func gitMode(mode os.FileMode) string {
+	if mode&os.ModeSymlink != 0 {
+		return "120000"
+	}
+	return fmt.Sprintf("100%03o", mode.Perm())
+}
+
+func parseGitMode(s string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(strings.TrimSpace(s), 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mode %q", s)
+	}
+	switch m &^ 0o777 {
+	case 0o120000:
+		return os.ModeSymlink | 0o777, nil
+	case 0o100000:
+		return os.FileMode(m & 0o777), nil
+	default:
+		return 0, fmt.Errorf("unsupported mode %q", s)
+	}
+}
+
+// filePatch is the patch of a single file.
+type filePatch struct {
+	// oldPath and newPath are empty for added and deleted files,
+	// respectively.
+	oldPath, newPath string
+
+	// oldMode and newMode are zero unless they're set by the patch.
+	oldMode, newMode os.FileMode
+
+	binary bool
+	hunks  []*hunk
+}
+
+type hunk struct {
+	oldStart, oldLines int
+	newStart, newLines int
+
+	// lines are prefixed with ' ', '-' or '+' and keep their line ending.
+	lines []string
+}
+
+// parsePatch parses a unified diff, as written by git diff or diff -u. The
+// first component of the paths in the patch is stripped, like patch -p1.
+func parsePatch(r io.Reader) ([]*filePatch, error) {
+	var (
+		patches []*filePatch
+		cur     *filePatch
+
+		// the number of lines left to read in the current hunk
+		oldLeft, newLeft int
+
+		// headers of the current patch, resolved into its paths once it's
+		// complete
+		sawOld, sawNew, isNew, isDelete bool
+		gitOld, gitNew                  string
+		renameFrom, renameTo            string
+	)
+
+	finish := func() error {
+		if cur == nil {
+			return nil
+		}
+		if oldLeft > 0 || newLeft > 0 {
+			return errors.New("truncated hunk")
+		}
+		if !sawOld {
+			cur.oldPath = gitOld
+			if renameFrom != "" {
+				cur.oldPath = renameFrom
+			}
+			if isNew {
+				cur.oldPath = ""
+			}
+		}
+		if !sawNew {
+			cur.newPath = gitNew
+			if renameTo != "" {
+				cur.newPath = renameTo
+			}
+			if isDelete {
+				cur.newPath = ""
+			}
+		}
+		if cur.oldPath == "" && cur.newPath == "" {
+			return errors.New("missing file name")
+		}
+		patches = append(patches, cur)
+		cur = nil
+		sawOld, sawNew, isNew, isDelete = false, false, false, false
+		gitOld, gitNew, renameFrom, renameTo = "", "", "", ""
+		return nil
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, readErr := br.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+		if line == "" {
+			break
+		}
+
+		if oldLeft > 0 || newLeft > 0 {
+			if line == "\n" {
+				// some editors strip the trailing space of empty context lines
+				line = " \n"
+			}
+			switch line[0] {
+			case ' ':
+				oldLeft--
+				newLeft--
+			case '-':
+				oldLeft--
+			case '+':
+				newLeft--
+			case '\\':
+				trimLastNewline(cur)
+				continue
+			default:
+				return nil, fmt.Errorf("invalid line in hunk: %q", line)
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("hunk is longer than its header: %q", line)
+			}
+			h := cur.hunks[len(cur.hunks)-1]
+			h.lines = append(h.lines, line)
+			continue
+		}
+
+		var err error
+		header := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(header, "diff --git "):
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			cur = &filePatch{}
+			gitOld, gitNew = parseGitDiffNames(strings.TrimPrefix(header, "diff --git "))
+		case strings.HasPrefix(header, "--- "):
+			if cur == nil || sawOld || len(cur.hunks) > 0 {
+				// a patch without a diff --git line
+				if err := finish(); err != nil {
+					return nil, err
+				}
+				cur = &filePatch{}
+			}
+			cur.oldPath = patchFileName(strings.TrimPrefix(header, "--- "))
+			sawOld = true
+		case strings.HasPrefix(header, "+++ ") && cur != nil:
+			cur.newPath = patchFileName(strings.TrimPrefix(header, "+++ "))
+			sawNew = true
+		case strings.HasPrefix(header, "@@ ") && cur != nil:
+			h, err := parseHunkHeader(header)
+			if err != nil {
+				return nil, err
+			}
+			cur.hunks = append(cur.hunks, h)
+			oldLeft, newLeft = h.oldLines, h.newLines
+		case strings.HasPrefix(header, "\\") && cur != nil:
+			trimLastNewline(cur)
+		case strings.HasPrefix(header, "new file mode ") && cur != nil:
+			isNew = true
+			cur.newMode, err = parseGitMode(strings.TrimPrefix(header, "new file mode "))
+		case strings.HasPrefix(header, "deleted file mode ") && cur != nil:
+			isDelete = true
+			cur.oldMode, err = parseGitMode(strings.TrimPrefix(header, "deleted file mode "))
+		case strings.HasPrefix(header, "old mode ") && cur != nil:
+			cur.oldMode, err = parseGitMode(strings.TrimPrefix(header, "old mode "))
+		case strings.HasPrefix(header, "new mode ") && cur != nil:
+			cur.newMode, err = parseGitMode(strings.TrimPrefix(header, "new mode "))
+		case strings.HasPrefix(header, "rename from ") && cur != nil:
+			renameFrom = strings.TrimPrefix(header, "rename from ")
+		case strings.HasPrefix(header, "rename to ") && cur != nil:
+			renameTo = strings.TrimPrefix(header, "rename to ")
+		case strings.HasPrefix(header, "copy from ") && cur != nil:
+			err = errors.New("copies are not supported")
+		case (strings.HasPrefix(header, "Binary files ") || header == "GIT binary patch") && cur != nil:
+			cur.binary = true
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return patches, nil
+}
+
+// parseGitDiffNames returns the paths of a "diff --git a/old b/new" line.
+func parseGitDiffNames(names string) (string, string) {
+	// the names are the same unless the file is renamed, in which case
+	// the rename headers have them
+	if n := len(names); n%2 == 1 {
+		a, b := names[:n/2], names[n/2+1:]
+		if names[n/2] == ' ' && stripPatchPrefix(a) == stripPatchPrefix(b) {
+			return stripPatchPrefix(a), stripPatchPrefix(b)
+		}
+	}
+	a, b, _ := strings.Cut(names, " ")
+	return stripPatchPrefix(a), stripPatchPrefix(b)
+}
+
+// patchFileName returns the path of a ---/+++ line, or "" for /dev/null.
+func patchFileName(name string) string {
+	// diff -u follows the name with a tab and a timestamp
+	name, _, _ = strings.Cut(name, "\t")
+	if name == "/dev/null" {
+		return ""
+	}
+	return stripPatchPrefix(name)
+}
+
+func stripPatchPrefix(name string) string {
+	_, name, _ = strings.Cut(name, "/")
+	return name
+}
+
+func parseHunkHeader(header string) (*hunk, error) {
+	// @@ -oldStart[,oldLines] +newStart[,newLines] @@ [section]
+	fields := strings.Fields(header)
+	if len(fields) < 4 || fields[3] != "@@" || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return nil, fmt.Errorf("invalid hunk header: %q", header)
+	}
+	h := &hunk{}
+	var err error
+	if h.oldStart, h.oldLines, err = parseHunkRange(fields[1][1:]); err != nil {
+		return nil, fmt.Errorf("invalid hunk header: %q", header)
+	}
+	if h.newStart, h.newLines, err = parseHunkRange(fields[2][1:]); err != nil {
+		return nil, fmt.Errorf("invalid hunk header: %q", header)
+	}
+	return h, nil
+}
+
+func parseHunkRange(s string) (int, int, error) {
+	startStr, linesStr, hasLines := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !hasLines {
+		return start, 1, nil
+	}
+	lines, err := strconv.Atoi(linesStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, lines, nil
+}
+
+// trimLastNewline handles a "\ No newline at end of file" line, which refers
+// to the line before it.
+func trimLastNewline(fp *filePatch) {
+	if len(fp.hunks) == 0 {
+		return
+	}
+	h := fp.hunks[len(fp.hunks)-1]
+	if len(h.lines) == 0 {
+		return
+	}
+	h.lines[len(h.lines)-1] = strings.TrimSuffix(h.lines[len(h.lines)-1], "\n")
+}
+
+// applyHunks applies hunks to the lines of a file. Hunks are matched exactly,
+// but may be offset from the line numbers in their headers.
+func applyHunks(lines []string, hunks []*hunk) ([]string, error) {
+	var result []string
+	next := 0   // the first line not yet copied to result
+	offset := 0 // how far the previous hunk was from its header's position
+	for i, h := range hunks {
+		var from, to []string
+		for _, line := range h.lines {
+			switch line[0] {
+			case ' ':
+				from = append(from, line[1:])
+				to = append(to, line[1:])
+			case '-':
+				from = append(from, line[1:])
+			case '+':
+				to = append(to, line[1:])
+			}
+		}
+
+		// an empty range starts after the line in the header
+		want := h.oldStart + offset
+		if h.oldLines > 0 {
+			want--
+		}
+		pos := -1
+		for delta := 0; want-delta >= next || want+delta+len(from) <= len(lines); delta++ {
+			if p := want - delta; p >= next && p+len(from) <= len(lines) && linesEqual(lines[p:p+len(from)], from) {
+				pos = p
+				break
+			}
+			if p := want + delta; delta > 0 && p >= next && p+len(from) <= len(lines) && linesEqual(lines[p:p+len(from)], from) {
+				pos = p
+				break
+			}
+		}
+		if pos == -1 {
+			return nil, fmt.Errorf("hunk #%d does not apply", i+1)
+		}
+
+		result = append(result, lines[next:pos]...)
+		result = append(result, to...)
+		next = pos + len(from)
+		offset += pos - want
+	}
+	return append(result, lines[next:]...), nil
+}
+
+func linesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// applyPatch applies the patch read from r to the directory at root, writing
+// the files it creates or changes to dest and returning the paths it removes.
+// The root directory is left untouched.
+func applyPatch(r io.Reader, root, dest string) ([]string, error) {
+	patches, err := parsePatch(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patch: %w", err)
+	}
+	if len(patches) == 0 {
+		return nil, errors.New("patch is empty")
+	}
+
+	// the state of files changed by previous patches
+	written := map[string]bool{}
+	removed := map[string]bool{}
+
+	for _, fp := range patches {
+		name := fp.newPath
+		if name == "" {
+			name = fp.oldPath
+		}
+		if fp.binary {
+			return nil, fmt.Errorf("%s: binary patches are not supported", name)
+		}
+
+		oldPath, err := cleanPatchPath(fp.oldPath)
+		if err != nil {
+			return nil, err
+		}
+		newPath, err := cleanPatchPath(fp.newPath)
+		if err != nil {
+			return nil, err
+		}
+
+		var lines []string
+		var mode os.FileMode
+		uid, gid := -1, -1
+		if oldPath != "" {
+			target, err := readPatchTarget(root, dest, oldPath, written, removed)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", oldPath, err)
+			}
+			if fp.oldMode != 0 && fp.oldMode.Type() != target.mode.Type() {
+				return nil, fmt.Errorf("%s: type does not match patch", oldPath)
+			}
+			lines = splitLines(string(target.content))
+			mode, uid, gid = target.mode, target.uid, target.gid
+		} else if exists, err := patchPathExists(root, newPath, written, removed); err != nil {
+			return nil, err
+		} else if exists {
+			return nil, fmt.Errorf("%s: already exists", newPath)
+		}
+
+		lines, err = applyHunks(lines, fp.hunks)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		if oldPath != "" && oldPath != newPath {
+			if written[oldPath] {
+				if err := os.Remove(filepath.Join(dest, oldPath)); err != nil {
+					return nil, err
+				}
+				delete(written, oldPath)
+			}
+			removed[oldPath] = true
+		}
+		if newPath == "" {
+			if len(lines) > 0 {
+				return nil, fmt.Errorf("%s: deleted file is not empty after patching", oldPath)
+			}
+			continue
+		}
+
+		if fp.newMode != 0 {
+			mode = fp.newMode
+		} else if mode == 0 {
+			mode = 0o644
+		}
+		if err := writePatchedFile(root, dest, newPath, strings.Join(lines, ""), mode, uid, gid); err != nil {
+			return nil, fmt.Errorf("%s: %w", newPath, err)
+		}
+		written[newPath] = true
+		delete(removed, newPath)
+	}
+
+	removedPaths := make([]string, 0, len(removed))
+	for p := range removed {
+		removedPaths = append(removedPaths, p)
+	}
+	sort.Strings(removedPaths)
+	return removedPaths, nil
+}
+
+// cleanPatchPath validates a path from a patch, which must be relative and
+// stay within the patched directory.
+func cleanPatchPath(p string) (string, error) {
+	if p == "" {
+		return "", nil
+	}
+	clean := path.Clean(p)
+	if path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("invalid path in patch: %q", p)
+	}
+	return clean, nil
+}
+
+func patchPathExists(root, p string, written, removed map[string]bool) (bool, error) {
+	if written[p] {
+		return true, nil
+	}
+	if removed[p] {
+		return false, nil
+	}
+	src, err := fs.RootPath(root, p)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Lstat(src); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// patchTarget is a file to be patched.
+type patchTarget struct {
+	content  []byte
+	mode     os.FileMode
+	uid, gid int
+}
+
+// readPatchTarget reads the file at p in root, or in dest if it was written by
+// a previous patch.
+func readPatchTarget(root, dest, p string, written, removed map[string]bool) (*patchTarget, error) {
+	if removed[p] {
+		return nil, os.ErrNotExist
+	}
+	src := filepath.Join(dest, p)
+	if !written[p] {
+		var err error
+		src, err = fs.RootPath(root, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	st, err := fsutil.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	mode := os.FileMode(st.Mode)
+	if !patchable(mode) {
+		return nil, errors.New("not a regular file or symlink")
+	}
+	content, err := readPatchContent(src, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &patchTarget{
+		content: content,
+		mode:    mode,
+		uid:     int(st.Uid),
+		gid:     int(st.Gid),
+	}, nil
+}
+
+// writePatchedFile writes a file to dest, creating its parent directories with
+// the permissions and ownership of the same directories in root.
+func writePatchedFile(root, dest, p, content string, mode os.FileMode, uid, gid int) error {
+	dir := path.Dir(p)
+	var parents []string
+	for d := dir; d != "."; d = path.Dir(d) {
+		parents = append(parents, d)
+	}
+	for i := len(parents) - 1; i >= 0; i-- {
+		parent := parents[i]
+		target := filepath.Join(dest, parent)
+		if _, err := os.Lstat(target); err == nil {
+			continue
+		}
+		perm, dirUID, dirGID := os.FileMode(0o755), -1, -1
+		if src, err := fs.RootPath(root, parent); err == nil {
+			if st, err := fsutil.Stat(src); err == nil {
+				if !os.FileMode(st.Mode).IsDir() {
+					return fmt.Errorf("%s is not a directory", parent)
+				}
+				perm = os.FileMode(st.Mode).Perm()
+				dirUID, dirGID = int(st.Uid), int(st.Gid)
+			}
+		}
+		if err := os.Mkdir(target, perm); err != nil {
+			return err
+		}
+		if err := os.Chmod(target, perm); err != nil {
+			return err
+		}
+		if err := os.Lchown(target, dirUID, dirGID); err != nil {
+			return err
+		}
+	}
+
+	target := filepath.Join(dest, p)
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	if mode&os.ModeSymlink != 0 {
+		if err := os.Symlink(content, target); err != nil {
+			return err
+		}
+	} else {
+		if err := os.WriteFile(target, []byte(content), mode.Perm()); err != nil {
+			return err
+		}
+		if err := os.Chmod(target, mode.Perm()); err != nil {
+			return err
+		}
+	}
+	return os.Lchown(target, uid, gid)
+}
@@ -0,0 +1,157 @@
+package buildkit
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchRoundTrip(t *testing.T) {
+	lower := t.TempDir()
+	upper := t.TempDir()
+	writeFiles := func(root string, files map[string]string) {
+		for name, content := range files {
+			p := filepath.Join(root, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+			require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+		}
+	}
+	writeFiles(lower, map[string]string{
+		"modified.txt":   "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\n",
+		"deleted.txt":    "deleted\n",
+		"gone/a.txt":     "a\n",
+		"old/moved.txt":  "moved contents\n",
+		"touched.txt":    "touched\n",
+		"script.sh":      "#!/bin/sh\n",
+		"no-newline.txt": "no newline",
+	})
+	writeFiles(upper, map[string]string{
+		"modified.txt":   "one\ntwo\nTHREE\nfour\nfive\nsix\nseven\neight\nnine\n",
+		"added.txt":      "added\n",
+		"new/moved.txt":  "moved contents\n",
+		"touched.txt":    "touched\n",
+		"script.sh":      "#!/bin/sh\n",
+		"no-newline.txt": "no newline\n",
+		"sub/dir/x.txt":  "x",
+	})
+	require.NoError(t, os.Chmod(filepath.Join(upper, "script.sh"), 0o755))
+	require.NoError(t, os.Symlink("added.txt", filepath.Join(upper, "link")))
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(upper, "touched.txt"), later, later))
+
+	changes, err := directoryChanges(context.Background(), lower, upper)
+	require.NoError(t, err)
+
+	summary := map[string]string{}
+	for _, change := range changes {
+		kind := change.Kind.String()
+		if change.OldPath != "" {
+			kind = "rename from " + change.OldPath
+		}
+		summary[change.Path] = kind
+	}
+	require.Equal(t, map[string]string{
+		"added.txt":      "add",
+		"deleted.txt":    "delete",
+		"gone":           "delete",
+		"gone/a.txt":     "delete",
+		"link":           "add",
+		"modified.txt":   "modify",
+		"new":            "add",
+		"new/moved.txt":  "rename from old/moved.txt",
+		"no-newline.txt": "modify",
+		"old":            "delete",
+		"script.sh":      "modify",
+		"sub":            "add",
+		"sub/dir":        "add",
+		"sub/dir/x.txt":  "add",
+	}, summary)
+
+	var patch bytes.Buffer
+	require.NoError(t, writePatch(&patch, lower, upper, changes))
+	t.Log(patch.String())
+	require.Contains(t, patch.String(), `diff --git a/modified.txt b/modified.txt
+--- a/modified.txt
++++ b/modified.txt
+@@ -1,8 +1,9 @@
+ one
+ two
+-three
++THREE
+ four
+ five
+ six
+ seven
+ eight
++nine
+`)
+	require.Contains(t, patch.String(), `diff --git a/old/moved.txt b/new/moved.txt
+similarity index 100%
+rename from old/moved.txt
+rename to new/moved.txt
+`)
+	require.Contains(t, patch.String(), `diff --git a/script.sh b/script.sh
+old mode 100644
+new mode 100755
+`)
+	require.Contains(t, patch.String(), `-no newline
+\ No newline at end of file
++no newline
+`)
+
+	// applying the patch to lower produces upper
+	dest := t.TempDir()
+	removed, err := applyPatch(bytes.NewReader(patch.Bytes()), lower, dest)
+	require.NoError(t, err)
+	require.Equal(t, []string{"deleted.txt", "gone/a.txt", "old/moved.txt"}, removed)
+
+	result := t.TempDir()
+	require.NoError(t, fs.CopyDir(result, lower))
+	for _, p := range removed {
+		require.NoError(t, os.RemoveAll(filepath.Join(result, p)))
+	}
+	require.NoError(t, fs.CopyDir(result, dest))
+	// directories aren't part of patches
+	require.NoError(t, os.Remove(filepath.Join(result, "gone")))
+	require.NoError(t, os.Remove(filepath.Join(result, "old")))
+
+	changes, err = directoryChanges(context.Background(), result, upper)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("a\nb\nc\n"), 0o644))
+
+	for name, patch := range map[string]string{
+		"conflict": "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n a\n-x\n+y\n",
+		"missing":  "--- a/missing.txt\n+++ b/missing.txt\n@@ -1 +1 @@\n-a\n+b\n",
+		"exists":   "--- /dev/null\n+++ b/a.txt\n@@ -0,0 +1 @@\n+a\n",
+		"escape":   "--- /dev/null\n+++ b/../evil\n@@ -0,0 +1 @@\n+evil\n",
+		"empty":    "",
+	} {
+		patch := patch
+		t.Run(name, func(t *testing.T) {
+			_, err := applyPatch(strings.NewReader(patch), root, t.TempDir())
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("offset", func(t *testing.T) {
+		dest := t.TempDir()
+		patch := "--- a/a.txt\n+++ b/a.txt\n@@ -5,2 +5,2 @@\n b\n-c\n+C\n"
+		_, err := applyPatch(strings.NewReader(patch), root, dest)
+		require.NoError(t, err)
+		dt, err := os.ReadFile(filepath.Join(dest, "a.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "a\nb\nC\n", string(dt))
+	})
+}
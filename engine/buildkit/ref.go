@@ -193,7 +193,7 @@ func (r *ref) Result(ctx context.Context) (bksolver.CachedResult, error) {
 	ctx = withOutgoingContext(ctx)
 	res, err := r.resultProxy.Result(ctx)
 	if err != nil {
-		return nil, wrapError(ctx, err, r.c.ID())
+		return nil, r.c.wrapError(ctx, err)
 	}
 	return res, nil
 }
@@ -239,7 +239,7 @@ func ConvertToWorkerCacheResult(ctx context.Context, res *solverresult.Result[*r
 	})
 }
 
-func wrapError(ctx context.Context, baseErr error, sessionID string) error {
+func (c *Client) wrapError(ctx context.Context, baseErr error) error {
 	var slowCacheErr *bksolver.SlowCacheError
 	if errors.As(baseErr, &slowCacheErr) {
 		if slowCacheErr.Result != nil {
@@ -298,7 +298,7 @@ func wrapError(ctx context.Context, baseErr error, sessionID string) error {
 	if !ok {
 		return errors.Join(baseErr, fmt.Errorf("invalid ref type: %T", metaMountResult.Sys()))
 	}
-	mntable, err := workerRef.ImmutableRef.Mount(ctx, true, bksession.NewGroup(sessionID))
+	mntable, err := workerRef.ImmutableRef.Mount(ctx, true, bksession.NewGroup(c.ID()))
 	if err != nil {
 		return errors.Join(err, baseErr)
 	}
@@ -324,12 +324,36 @@ func wrapError(ctx context.Context, baseErr error, sessionID string) error {
 		}
 	}
 
+	timeoutBytes, err := getExecMetaFile(ctx, mntable, "timeout")
+	if err != nil {
+		return errors.Join(err, baseErr)
+	}
+
+	// keep the state of the exec's mounts around so that it can be debugged
+	debugID := c.registerFailedExec(ctx, op, execErr)
+
+	if len(timeoutBytes) > 0 {
+		timeout, err := strconv.Atoi(string(timeoutBytes))
+		if err != nil {
+			return errors.Join(err, baseErr)
+		}
+		return &ExecTimeoutError{
+			original: fmt.Errorf("timed out after %ds: %w", timeout, baseErr),
+			Cmd:      execOp.Exec.Meta.Args,
+			Timeout:  timeout,
+			Stdout:   strings.TrimSpace(string(stdoutBytes)),
+			Stderr:   strings.TrimSpace(string(stderrBytes)),
+			DebugID:  debugID,
+		}
+	}
+
 	return &ExecError{
 		original: baseErr,
 		Cmd:      execOp.Exec.Meta.Args,
 		ExitCode: exitCode,
 		Stdout:   strings.TrimSpace(string(stdoutBytes)),
 		Stderr:   strings.TrimSpace(string(stderrBytes)),
+		DebugID:  debugID,
 	}
 }
 
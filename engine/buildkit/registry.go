@@ -0,0 +1,344 @@
+package buildkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	bksession "github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/util/resolver"
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxManifestSize is the largest manifest (or signature payload) read into
+// memory from a registry.
+const maxManifestSize = 4 << 20
+
+// CopyImage copies the image at src to each of the destinations, including
+// every platform of a multi-platform image. Blobs and manifests are streamed
+// directly between the registries without being unpacked.
+//
+// It returns the digest of the copied image, which is the same in every
+// destination.
+func (c *Client) CopyImage(ctx context.Context, src string, dests []string) (digest.Digest, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+
+	srcNamed, err := reference.ParseNormalizedNamed(src)
+	if err != nil {
+		return "", err
+	}
+	srcRef := reference.TagNameOnly(srcNamed).String()
+
+	srcResolver := c.registryResolver(srcRef, "pull")
+	_, desc, err := srcResolver.Resolve(ctx, srcRef)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", src, err)
+	}
+	fetcher, err := srcResolver.Fetcher(ctx, srcRef)
+	if err != nil {
+		return "", err
+	}
+
+	eg, egctx := errgroup.WithContext(ctx)
+	for _, dest := range dests {
+		dest := dest
+		eg.Go(func() error {
+			destNamed, err := reference.ParseNormalizedNamed(dest)
+			if err != nil {
+				return err
+			}
+			if _, ok := destNamed.(reference.Canonical); ok {
+				return fmt.Errorf("destination %s must not include a digest", dest)
+			}
+			// push the root manifest to the tag and every other manifest by digest
+			destRef, err := reference.WithDigest(reference.TagNameOnly(destNamed), desc.Digest)
+			if err != nil {
+				return err
+			}
+
+			pusher, err := c.registryResolver(destRef.String(), "push").Pusher(egctx, destRef.String())
+			if err != nil {
+				return err
+			}
+
+			cp := &imageCopier{
+				fetcher: fetcher,
+				pusher:  pusher,
+			}
+			if reference.Domain(srcNamed) == reference.Domain(destNamed) {
+				// let the registry mount blobs from the source repository
+				// rather than uploading them again
+				cp.mountFrom = reference.Domain(srcNamed)
+				cp.mountRepo = reference.Path(srcNamed)
+			}
+			if err := cp.copy(egctx, desc); err != nil {
+				return fmt.Errorf("copy to %s: %w", dest, err)
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return "", err
+	}
+
+	return desc.Digest, nil
+}
+
+type imageCopier struct {
+	fetcher remotes.Fetcher
+	pusher  remotes.Pusher
+
+	mountFrom string
+	mountRepo string
+}
+
+// copy copies desc and everything it references, children first so that
+// the registry never sees a manifest referencing missing content.
+func (cp *imageCopier) copy(ctx context.Context, desc ocispecs.Descriptor) error {
+	switch desc.MediaType {
+	case images.MediaTypeDockerSchema2ManifestList, ocispecs.MediaTypeImageIndex,
+		images.MediaTypeDockerSchema2Manifest, ocispecs.MediaTypeImageManifest:
+		dt, err := fetchManifestBytes(ctx, cp.fetcher, desc)
+		if err != nil {
+			return err
+		}
+		// manifests and indexes are parsed together, only one of them is set
+		var manifest struct {
+			Config    ocispecs.Descriptor   `json:"config"`
+			Layers    []ocispecs.Descriptor `json:"layers"`
+			Manifests []ocispecs.Descriptor `json:"manifests"`
+		}
+		if err := json.Unmarshal(dt, &manifest); err != nil {
+			return fmt.Errorf("parse manifest %s: %w", desc.Digest, err)
+		}
+		children := manifest.Manifests
+		if manifest.Config.Digest != "" {
+			children = append(children, manifest.Config)
+		}
+		children = append(children, manifest.Layers...)
+		for _, child := range children {
+			if images.IsNonDistributable(child.MediaType) {
+				continue
+			}
+			if err := cp.copy(ctx, child); err != nil {
+				return err
+			}
+		}
+		return pushBlob(ctx, cp.pusher, desc, dt)
+	default:
+		return cp.copyBlob(ctx, desc)
+	}
+}
+
+func (cp *imageCopier) copyBlob(ctx context.Context, desc ocispecs.Descriptor) error {
+	if cp.mountFrom != "" {
+		desc.Annotations = map[string]string{
+			"containerd.io/distribution.source." + cp.mountFrom: cp.mountRepo,
+		}
+	}
+
+	w, err := cp.pusher.Push(ctx, desc)
+	if err != nil {
+		if errdefs.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	defer w.Close()
+
+	rc, err := cp.fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := content.Copy(ctx, w, rc, desc.Size, desc.Digest); err != nil && !errdefs.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// RemoteImage is the metadata of an image in a registry.
+type RemoteImage struct {
+	// Ref is the fully qualified ref of the image, including its digest.
+	Ref        string
+	Descriptor ocispecs.Descriptor
+	// Annotations of the image's index or manifest.
+	Annotations map[string]string
+	// Manifests has one entry per platform of the image.
+	Manifests []RemoteImageManifest
+}
+
+// RemoteImageManifest is the metadata of a single platform of an image.
+type RemoteImageManifest struct {
+	Descriptor  ocispecs.Descriptor
+	Platform    ocispecs.Platform
+	Annotations map[string]string
+	Layers      []ocispecs.Descriptor
+	// Config is the raw image config.
+	Config []byte
+}
+
+// ResolveRemoteImage fetches the manifests and configs of the image at ref,
+// without pulling any of its layers.
+func (c *Client) ResolveRemoteImage(ctx context.Context, ref string) (*RemoteImage, error) {
+	ctx, cancel, err := c.withClientCloseCancel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return nil, err
+	}
+	ref = reference.TagNameOnly(named).String()
+
+	res := c.registryResolver(ref, "pull")
+	_, desc, err := res.Resolve(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", ref, err)
+	}
+	fetcher, err := res.Fetcher(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	digested, err := reference.WithDigest(named, desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	img := &RemoteImage{
+		Ref:        digested.String(),
+		Descriptor: desc,
+	}
+
+	switch desc.MediaType {
+	case images.MediaTypeDockerSchema2ManifestList, ocispecs.MediaTypeImageIndex:
+		dt, err := fetchManifestBytes(ctx, fetcher, desc)
+		if err != nil {
+			return nil, err
+		}
+		var idx ocispecs.Index
+		if err := json.Unmarshal(dt, &idx); err != nil {
+			return nil, fmt.Errorf("parse index %s: %w", desc.Digest, err)
+		}
+		img.Annotations = idx.Annotations
+
+		for _, mdesc := range idx.Manifests {
+			switch mdesc.MediaType {
+			case images.MediaTypeDockerSchema2Manifest, ocispecs.MediaTypeImageManifest:
+			default:
+				continue
+			}
+			if mdesc.Annotations["vnd.docker.reference.type"] == "attestation-manifest" {
+				continue
+			}
+			manifest, err := fetchRemoteImageManifest(ctx, fetcher, mdesc)
+			if err != nil {
+				return nil, err
+			}
+			img.Manifests = append(img.Manifests, *manifest)
+		}
+	case images.MediaTypeDockerSchema2Manifest, ocispecs.MediaTypeImageManifest:
+		manifest, err := fetchRemoteImageManifest(ctx, fetcher, desc)
+		if err != nil {
+			return nil, err
+		}
+		img.Annotations = manifest.Annotations
+		img.Manifests = append(img.Manifests, *manifest)
+	default:
+		return nil, fmt.Errorf("unsupported image media type %q", desc.MediaType)
+	}
+
+	return img, nil
+}
+
+func fetchRemoteImageManifest(ctx context.Context, fetcher remotes.Fetcher, desc ocispecs.Descriptor) (*RemoteImageManifest, error) {
+	dt, err := fetchManifestBytes(ctx, fetcher, desc)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ocispecs.Manifest
+	if err := json.Unmarshal(dt, &manifest); err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", desc.Digest, err)
+	}
+
+	config, err := fetchManifestBytes(ctx, fetcher, manifest.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var platform ocispecs.Platform
+	if desc.Platform != nil {
+		platform = *desc.Platform
+	} else if err := json.Unmarshal(config, &platform); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", manifest.Config.Digest, err)
+	}
+
+	return &RemoteImageManifest{
+		Descriptor:  desc,
+		Platform:    platforms.Normalize(platform),
+		Annotations: manifest.Annotations,
+		Layers:      manifest.Layers,
+		Config:      config,
+	}, nil
+}
+
+// registryResolver returns a resolver for ref using the engine's registry
+// configuration, authenticating with the credentials of the client's session.
+func (c *Client) registryResolver(ref, scope string) *resolver.Resolver {
+	return resolver.DefaultPool.GetResolver(c.RegistryHosts, ref, scope, c.SessionManager, bksession.NewGroup(c.ID()))
+}
+
+func fetchManifestBytes(ctx context.Context, fetcher remotes.Fetcher, desc ocispecs.Descriptor) ([]byte, error) {
+	if desc.Size > maxManifestSize {
+		return nil, fmt.Errorf("%s is too large: %d bytes", desc.Digest, desc.Size)
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	dt, err := io.ReadAll(io.LimitReader(rc, maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if digest.FromBytes(dt) != desc.Digest {
+		return nil, fmt.Errorf("digest mismatch for %s", desc.Digest)
+	}
+	return dt, nil
+}
+
+func pushBlob(ctx context.Context, pusher remotes.Pusher, desc ocispecs.Descriptor, dt []byte) error {
+	w, err := pusher.Push(ctx, desc)
+	if err != nil {
+		if errdefs.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	defer w.Close()
+
+	if _, err := w.Write(dt); err != nil {
+		return err
+	}
+	if err := w.Commit(ctx, desc.Size, desc.Digest); err != nil && !errdefs.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
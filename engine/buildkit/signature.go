@@ -0,0 +1,200 @@
+package buildkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// Media type and annotation used by cosign for simple signing payloads:
+	// https://github.com/sigstore/cosign/blob/main/specs/SIGNATURE_SPEC.md
+	simpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	signatureAnnotation    = "dev.cosignproject.cosign/signature"
+)
+
+// ImageSignature is a signed payload stored alongside an image using the
+// cosign signature format.
+type ImageSignature struct {
+	Payload []byte
+	// Signature is the base64 encoded signature of the payload.
+	Signature string
+}
+
+// ImageSignatures fetches the cosign signatures of the image with the given
+// digest in the repository of ref. It returns no signatures if there are none.
+func (c *Client) ImageSignatures(ctx context.Context, ref string, dgst digest.Digest) ([]ImageSignature, error) {
+	sigRef, err := signatureRef(ref, dgst)
+	if err != nil {
+		return nil, err
+	}
+
+	res := c.registryResolver(sigRef, "pull")
+	_, desc, err := res.Resolve(ctx, sigRef)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("resolve %s: %w", sigRef, err)
+	}
+	fetcher, err := res.Fetcher(ctx, sigRef)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := fetchSignatureManifest(ctx, fetcher, desc)
+	if err != nil {
+		return nil, err
+	}
+
+	var sigs []ImageSignature
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != simpleSigningMediaType {
+			continue
+		}
+		sig, ok := layer.Annotations[signatureAnnotation]
+		if !ok {
+			continue
+		}
+		payload, err := fetchManifestBytes(ctx, fetcher, layer)
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, ImageSignature{
+			Payload:   payload,
+			Signature: sig,
+		})
+	}
+	return sigs, nil
+}
+
+// PushImageSignature attaches a cosign signature to the image with the given
+// digest in the repository of ref, keeping any existing signatures.
+func (c *Client) PushImageSignature(ctx context.Context, ref string, dgst digest.Digest, sig ImageSignature) error {
+	sigRef, err := signatureRef(ref, dgst)
+	if err != nil {
+		return err
+	}
+
+	res := c.registryResolver(sigRef, "push")
+
+	var layers []ocispecs.Descriptor
+	if _, desc, err := res.Resolve(ctx, sigRef); err == nil {
+		fetcher, err := res.Fetcher(ctx, sigRef)
+		if err != nil {
+			return err
+		}
+		existing, err := fetchSignatureManifest(ctx, fetcher, desc)
+		if err != nil {
+			return err
+		}
+		layers = existing.Layers
+	} else if !errdefs.IsNotFound(err) {
+		return fmt.Errorf("resolve %s: %w", sigRef, err)
+	}
+
+	layer := ocispecs.Descriptor{
+		MediaType: simpleSigningMediaType,
+		Digest:    digest.FromBytes(sig.Payload),
+		Size:      int64(len(sig.Payload)),
+		Annotations: map[string]string{
+			signatureAnnotation: sig.Signature,
+		},
+	}
+	for _, existing := range layers {
+		if existing.Digest == layer.Digest && existing.Annotations[signatureAnnotation] == sig.Signature {
+			// already signed
+			return nil
+		}
+	}
+	layers = append(layers, layer)
+
+	diffIDs := make([]digest.Digest, 0, len(layers))
+	for _, l := range layers {
+		diffIDs = append(diffIDs, l.Digest)
+	}
+	configBytes, err := json.Marshal(ocispecs.Image{
+		RootFS: ocispecs.RootFS{
+			Type:    "layers",
+			DiffIDs: diffIDs,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	configDesc := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageConfig,
+		Digest:    digest.FromBytes(configBytes),
+		Size:      int64(len(configBytes)),
+	}
+
+	manifestBytes, err := json.Marshal(ocispecs.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispecs.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    layers,
+	})
+	if err != nil {
+		return err
+	}
+	manifestDesc := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifestBytes),
+		Size:      int64(len(manifestBytes)),
+	}
+
+	pusher, err := res.Pusher(ctx, sigRef)
+	if err != nil {
+		return err
+	}
+	if err := pushBlob(ctx, pusher, layer, sig.Payload); err != nil {
+		return fmt.Errorf("push signature payload: %w", err)
+	}
+	if err := pushBlob(ctx, pusher, configDesc, configBytes); err != nil {
+		return fmt.Errorf("push signature config: %w", err)
+	}
+	if err := pushBlob(ctx, pusher, manifestDesc, manifestBytes); err != nil {
+		return fmt.Errorf("push signature manifest: %w", err)
+	}
+	return nil
+}
+
+// signatureRef returns the tag cosign uses for the signatures of the image
+// with the given digest, e.g. registry/repo:sha256-<hex>.sig.
+func signatureRef(ref string, dgst digest.Digest) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", err
+	}
+	if err := dgst.Validate(); err != nil {
+		return "", err
+	}
+	tagged, err := reference.WithTag(
+		reference.TrimNamed(named),
+		strings.Replace(dgst.String(), ":", "-", 1)+".sig",
+	)
+	if err != nil {
+		return "", err
+	}
+	return tagged.String(), nil
+}
+
+func fetchSignatureManifest(ctx context.Context, fetcher remotes.Fetcher, desc ocispecs.Descriptor) (*ocispecs.Manifest, error) {
+	dt, err := fetchManifestBytes(ctx, fetcher, desc)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ocispecs.Manifest
+	if err := json.Unmarshal(dt, &manifest); err != nil {
+		return nil, fmt.Errorf("parse signature manifest: %w", err)
+	}
+	return &manifest, nil
+}
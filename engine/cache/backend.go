@@ -0,0 +1,598 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	remotecache "github.com/moby/buildkit/cache/remotecache/v1"
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+/*
+A Backend is a plain object store (e.g. a shared directory or an S3 bucket) that ephemeral
+engines can share their cache through without a cache service. The bookkeeping the cache
+service would otherwise do is implemented by backendService on top of it:
+  - Layer blobs are stored under "blobs/<algorithm>/<encoded digest>"
+  - Cache records are stored in a single index at "index.json", which every engine merges
+    its own records into on export and turns into a cache config on import
+  - Cache mounts are stored as blobs too, with "cachemounts/<name digest>.json" pointing at
+    the latest blob for each cache mount name
+
+Cache keys in an engine's key store are only partially content-addressed (keys with inputs
+get random IDs), so records in the index are instead identified by a digest of their op
+digest and inputs, which is the same across engines.
+
+Concurrent exports from different engines may overwrite each other's index updates. Records
+lost that way are missing from the index on the next export and thus simply exported again.
+*/
+type Backend interface {
+	// Get returns the contents of the object with the given key. If there is no such object,
+	// the returned error wraps fs.ErrNotExist.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Put replaces the contents of the object with the given key.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Exists returns whether there is an object with the given key.
+	Exists(ctx context.Context, key string) (bool, error)
+
+	// List returns the keys of all the objects whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+
+	// DownloadURL returns a URL that the object with the given key can be read from with
+	// (possibly ranged) GET requests.
+	DownloadURL(ctx context.Context, key string) (string, error)
+
+	// UploadURL returns a URL and headers that the object with the given key can be written
+	// to with a PUT request.
+	UploadURL(ctx context.Context, key string) (string, map[string]string, error)
+
+	// Transport returns the transport to use for requests to the URLs returned by
+	// DownloadURL and UploadURL.
+	Transport() http.RoundTripper
+}
+
+const (
+	defaultBackendImportPeriod  = 5 * time.Minute
+	defaultBackendExportPeriod  = 5 * time.Minute
+	defaultBackendExportTimeout = 10 * time.Minute
+
+	backendIndexKey          = "index.json"
+	backendBlobsPrefix       = "blobs/"
+	backendCacheMountsPrefix = "cachemounts/"
+)
+
+// isBackendURL returns whether the cache service URL points at a Backend rather than at a
+// cache service.
+func isBackendURL(u *url.URL) bool {
+	switch u.Scheme {
+	case "file", "s3":
+		return true
+	default:
+		return false
+	}
+}
+
+// newBackend returns the Backend for the given URL, which is one of:
+//   - file:///path/to/dir
+//   - s3://bucket/optional/prefix?region=...&endpoint_url=...&use_path_style=true
+func newBackend(ctx context.Context, u *url.URL) (Backend, error) {
+	switch u.Scheme {
+	case "file":
+		return newFilesystemBackend(u.Path)
+	case "s3":
+		return newS3Backend(ctx, u)
+	default:
+		return nil, fmt.Errorf("unsupported cache backend %q", u.Scheme)
+	}
+}
+
+type backendService struct {
+	backend Backend
+	config  Config
+
+	mu sync.Mutex
+	// index records of the last UpdateCacheRecords call, waiting for their layers
+	pending map[digest.Digest]*indexRecord
+	// creation time of the results the pending records are being exported with
+	pendingCreatedAt map[digest.Digest]time.Time
+}
+
+var _ Service = &backendService{}
+
+func newBackendService(ctx context.Context, u *url.URL) (*backendService, error) {
+	backend, err := newBackend(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	config := Config{
+		ImportPeriod:  defaultBackendImportPeriod,
+		ExportPeriod:  defaultBackendExportPeriod,
+		ExportTimeout: defaultBackendExportTimeout,
+	}
+	for param, period := range map[string]*time.Duration{
+		"import_period":  &config.ImportPeriod,
+		"export_period":  &config.ExportPeriod,
+		"export_timeout": &config.ExportTimeout,
+	} {
+		v := u.Query().Get(param)
+		if v == "" {
+			continue
+		}
+		*period, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", param, v, err)
+		}
+	}
+
+	return &backendService{
+		backend: backend,
+		config:  config,
+	}, nil
+}
+
+// cacheIndex is the format of the index of cache records stored in a Backend.
+type cacheIndex struct {
+	// Records by their index digest, see recordIndexDigests
+	Records map[digest.Digest]*indexRecord
+}
+
+type indexRecord struct {
+	// Digest is the record digest, as in the remote cache config
+	Digest  digest.Digest
+	Inputs  [][]indexInput `json:",omitempty"`
+	Results []indexResult  `json:",omitempty"`
+}
+
+type indexInput struct {
+	Selector string `json:",omitempty"`
+	// Record is the index digest of the input record
+	Record digest.Digest
+}
+
+type indexResult struct {
+	Layers    []ocispecs.Descriptor
+	CreatedAt time.Time
+}
+
+type indexCacheMount struct {
+	Name      string
+	Digest    digest.Digest
+	Size      int64
+	MediaType string
+}
+
+func (s *backendService) GetConfig(context.Context, GetConfigRequest) (*Config, error) {
+	config := s.config
+	return &config, nil
+}
+
+func (s *backendService) UpdateCacheRecords(
+	ctx context.Context,
+	req UpdateCacheRecordsRequest,
+) (*UpdateCacheRecordsResponse, error) {
+	index, err := s.loadIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	indexDigests, records, err := recordIndexDigests(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &UpdateCacheRecordsResponse{}
+	createdAt := map[digest.Digest]time.Time{}
+	for _, cacheKey := range req.CacheKeys {
+		dgst, ok := indexDigests[cacheKey.ID]
+		if !ok || len(cacheKey.Results) == 0 {
+			continue
+		}
+		if _, ok := createdAt[dgst]; ok {
+			continue
+		}
+		if existing, ok := index.Records[dgst]; ok && len(existing.Results) > 0 {
+			// another engine (or an earlier export) already stored a result
+			continue
+		}
+		// any result will do, use the most recent one
+		result := cacheKey.Results[0]
+		for _, r := range cacheKey.Results[1:] {
+			if r.CreatedAt.After(result.CreatedAt) {
+				result = r
+			}
+		}
+		createdAt[dgst] = result.CreatedAt
+		resp.ExportRecords = append(resp.ExportRecords, ExportRecord{
+			Digest:     dgst,
+			CacheRefID: result.ID,
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = records
+	s.pendingCreatedAt = createdAt
+	return resp, nil
+}
+
+func (s *backendService) UpdateCacheLayers(ctx context.Context, req UpdateCacheLayersRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending == nil {
+		return fmt.Errorf("no pending cache records")
+	}
+
+	withResults := map[digest.Digest]*indexRecord{}
+	for _, updated := range req.UpdatedRecords {
+		rec, ok := s.pending[updated.RecordDigest]
+		if !ok {
+			continue
+		}
+		rec.Results = []indexResult{{
+			Layers:    updated.Layers,
+			CreatedAt: s.pendingCreatedAt[updated.RecordDigest],
+		}}
+		withResults[updated.RecordDigest] = rec
+	}
+
+	// only store the records with results and the records they depend on
+	toStore := map[digest.Digest]*indexRecord{}
+	var add func(dgst digest.Digest, rec *indexRecord)
+	add = func(dgst digest.Digest, rec *indexRecord) {
+		if _, ok := toStore[dgst]; ok {
+			return
+		}
+		toStore[dgst] = rec
+		for _, inputs := range rec.Inputs {
+			for _, input := range inputs {
+				if inputRec, ok := s.pending[input.Record]; ok {
+					add(input.Record, inputRec)
+				}
+			}
+		}
+	}
+	for dgst, rec := range withResults {
+		add(dgst, rec)
+	}
+
+	index, err := s.loadIndex(ctx)
+	if err != nil {
+		return err
+	}
+	for dgst, rec := range toStore {
+		existing, ok := index.Records[dgst]
+		if !ok {
+			index.Records[dgst] = rec
+			continue
+		}
+		if len(existing.Results) == 0 {
+			existing.Results = rec.Results
+		}
+	}
+
+	s.pending = nil
+	s.pendingCreatedAt = nil
+	return s.saveIndex(ctx, index)
+}
+
+func (s *backendService) ImportCache(ctx context.Context) (*remotecache.CacheConfig, error) {
+	index, err := s.loadIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return index.cacheConfig(), nil
+}
+
+func (s *backendService) GetLayerDownloadURL(ctx context.Context, req GetLayerDownloadURLRequest) (*GetLayerDownloadURLResponse, error) {
+	u, err := s.backend.DownloadURL(ctx, blobKey(req.Digest))
+	if err != nil {
+		return nil, err
+	}
+	return &GetLayerDownloadURLResponse{URL: u}, nil
+}
+
+func (s *backendService) GetLayerUploadURL(ctx context.Context, req GetLayerUploadURLRequest) (*GetLayerUploadURLResponse, error) {
+	u, headers, err := s.backend.UploadURL(ctx, blobKey(req.Digest))
+	if err != nil {
+		return nil, err
+	}
+	return &GetLayerUploadURLResponse{URL: u, Headers: headers}, nil
+}
+
+func (s *backendService) GetCacheMountConfig(ctx context.Context, req GetCacheMountConfigRequest) (*GetCacheMountConfigResponse, error) {
+	keys, err := s.backend.List(ctx, backendCacheMountsPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache mounts: %w", err)
+	}
+
+	resp := &GetCacheMountConfigResponse{}
+	for _, key := range keys {
+		if !strings.HasSuffix(key, ".json") {
+			continue
+		}
+		bs, err := s.backend.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cache mount %s: %w", key, err)
+		}
+		var cacheMount indexCacheMount
+		if err := json.Unmarshal(bs, &cacheMount); err != nil {
+			return nil, fmt.Errorf("failed to decode cache mount %s: %w", key, err)
+		}
+
+		config := SyncedCacheMountConfig{
+			Name:      cacheMount.Name,
+			Digest:    cacheMount.Digest,
+			Size:      cacheMount.Size,
+			MediaType: cacheMount.MediaType,
+		}
+		// the upload may have failed after the cache mount was recorded, in which case
+		// it starts out empty
+		exists, err := s.backend.Exists(ctx, blobKey(cacheMount.Digest))
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			config.URL, err = s.backend.DownloadURL(ctx, blobKey(cacheMount.Digest))
+			if err != nil {
+				return nil, err
+			}
+		}
+		resp.SyncedCacheMounts = append(resp.SyncedCacheMounts, config)
+	}
+	return resp, nil
+}
+
+func (s *backendService) GetCacheMountUploadURL(ctx context.Context, req GetCacheMountUploadURLRequest) (*GetCacheMountUploadURLResponse, error) {
+	bs, err := json.Marshal(indexCacheMount{
+		Name:      req.CacheName,
+		Digest:    req.Digest,
+		Size:      req.Size,
+		MediaType: ocispecs.MediaTypeImageLayerZstd,
+	})
+	if err != nil {
+		return nil, err
+	}
+	key := backendCacheMountsPrefix + digest.FromString(req.CacheName).Encoded() + ".json"
+	if err := s.backend.Put(ctx, key, bs); err != nil {
+		return nil, fmt.Errorf("failed to record cache mount %q: %w", req.CacheName, err)
+	}
+
+	u, headers, err := s.backend.UploadURL(ctx, blobKey(req.Digest))
+	if err != nil {
+		return nil, err
+	}
+	return &GetCacheMountUploadURLResponse{URL: u, Headers: headers}, nil
+}
+
+func (s *backendService) loadIndex(ctx context.Context) (*cacheIndex, error) {
+	index := &cacheIndex{}
+	bs, err := s.backend.Get(ctx, backendIndexKey)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to get cache index: %w", err)
+	default:
+		if err := json.Unmarshal(bs, index); err != nil {
+			return nil, fmt.Errorf("failed to decode cache index: %w", err)
+		}
+	}
+	if index.Records == nil {
+		index.Records = map[digest.Digest]*indexRecord{}
+	}
+	return index, nil
+}
+
+func (s *backendService) saveIndex(ctx context.Context, index *cacheIndex) error {
+	bs, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := s.backend.Put(ctx, backendIndexKey, bs); err != nil {
+		return fmt.Errorf("failed to save cache index: %w", err)
+	}
+	return nil
+}
+
+func blobKey(dgst digest.Digest) string {
+	return backendBlobsPrefix + dgst.Algorithm().String() + "/" + dgst.Encoded()
+}
+
+// recordIndexDigests converts the cache keys of an engine's key store into index records,
+// returning the index digest of each cache key ID along with the records by index digest.
+//
+// Root keys (without inputs) already have content-addressed IDs, the digest of any other
+// key is the digest of its record digest and inputs. Keys that can't be shared, such as
+// those with random digests or inputs, are skipped.
+func recordIndexDigests(req UpdateCacheRecordsRequest) (map[string]digest.Digest, map[digest.Digest]*indexRecord, error) {
+	backlinks := map[string][]Link{}
+	for _, link := range req.Links {
+		backlinks[link.ID] = append(backlinks[link.ID], link)
+	}
+
+	digests := map[string]digest.Digest{}
+	records := map[digest.Digest]*indexRecord{}
+	visiting := map[string]bool{}
+
+	var resolve func(id string) (digest.Digest, bool, error)
+	resolve = func(id string) (digest.Digest, bool, error) {
+		if dgst, ok := digests[id]; ok {
+			return dgst, dgst != "", nil
+		}
+		if visiting[id] {
+			// should never happen, but don't recurse forever on a corrupt key store
+			return "", false, nil
+		}
+		visiting[id] = true
+		defer delete(visiting, id)
+
+		links := backlinks[id]
+		if len(links) == 0 {
+			dgst, err := digest.Parse(id)
+			if err != nil {
+				digests[id] = ""
+				return "", false, nil
+			}
+			digests[id] = dgst
+			records[dgst] = &indexRecord{Digest: dgst}
+			return dgst, true, nil
+		}
+
+		rec := &indexRecord{}
+		for _, link := range links {
+			if strings.HasPrefix(link.Digest.String(), "random:") {
+				digests[id] = ""
+				return "", false, nil
+			}
+			rec.Digest = outputKey(link.Digest, link.Output)
+			linkedDigest, ok, err := resolve(link.LinkedID)
+			if err != nil {
+				return "", false, err
+			}
+			if !ok {
+				continue
+			}
+			for len(rec.Inputs) <= link.Input {
+				rec.Inputs = append(rec.Inputs, nil)
+			}
+			rec.Inputs[link.Input] = append(rec.Inputs[link.Input], indexInput{
+				Selector: link.Selector.String(),
+				Record:   linkedDigest,
+			})
+		}
+		for _, inputs := range rec.Inputs {
+			if len(inputs) == 0 {
+				// can't be matched without all of its inputs
+				digests[id] = ""
+				return "", false, nil
+			}
+			sort.Slice(inputs, func(i, j int) bool {
+				if inputs[i].Record != inputs[j].Record {
+					return inputs[i].Record < inputs[j].Record
+				}
+				return inputs[i].Selector < inputs[j].Selector
+			})
+		}
+
+		bs, err := json.Marshal(rec)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to marshal cache record %s: %w", id, err)
+		}
+		dgst := digest.FromBytes(bs)
+		digests[id] = dgst
+		records[dgst] = rec
+		return dgst, true, nil
+	}
+
+	for _, cacheKey := range req.CacheKeys {
+		if _, _, err := resolve(cacheKey.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	for id, dgst := range digests {
+		if dgst == "" {
+			delete(digests, id)
+		}
+	}
+	return digests, records, nil
+}
+
+// cacheConfig converts the index into a cache config that remotecache can parse.
+func (index *cacheIndex) cacheConfig() *remotecache.CacheConfig {
+	config := &remotecache.CacheConfig{}
+
+	layerIndexes := map[digest.Digest]int{}
+	addLayer := func(desc ocispecs.Descriptor) (int, bool) {
+		if i, ok := layerIndexes[desc.Digest]; ok {
+			return i, true
+		}
+		annotations := &remotecache.LayerAnnotations{
+			MediaType: desc.MediaType,
+			DiffID:    digest.Digest(desc.Annotations["containerd.io/uncompressed"]),
+			Size:      desc.Size,
+		}
+		if annotations.DiffID == "" {
+			// the layer can't be imported without it
+			return 0, false
+		}
+		if createdAt, ok := desc.Annotations["buildkit/createdat"]; ok {
+			if err := annotations.CreatedAt.UnmarshalText([]byte(createdAt)); err != nil {
+				return 0, false
+			}
+		}
+		layerIndexes[desc.Digest] = len(config.Layers)
+		config.Layers = append(config.Layers, remotecache.CacheLayer{
+			Blob:        desc.Digest,
+			ParentIndex: -1,
+			Annotations: annotations,
+		})
+		return layerIndexes[desc.Digest], true
+	}
+
+	// sort for a stable config
+	dgsts := make([]digest.Digest, 0, len(index.Records))
+	for dgst := range index.Records {
+		dgsts = append(dgsts, dgst)
+	}
+	sort.Slice(dgsts, func(i, j int) bool {
+		return dgsts[i] < dgsts[j]
+	})
+	recordIndexes := map[digest.Digest]int{}
+	for i, dgst := range dgsts {
+		recordIndexes[dgst] = i
+	}
+
+	for _, dgst := range dgsts {
+		rec := index.Records[dgst]
+		cacheRec := remotecache.CacheRecord{
+			Digest: rec.Digest,
+		}
+		for _, inputs := range rec.Inputs {
+			var cacheInputs []remotecache.CacheInput
+			for _, input := range inputs {
+				linkIndex, ok := recordIndexes[input.Record]
+				if !ok {
+					continue
+				}
+				cacheInputs = append(cacheInputs, remotecache.CacheInput{
+					Selector:  input.Selector,
+					LinkIndex: linkIndex,
+				})
+			}
+			cacheRec.Inputs = append(cacheRec.Inputs, cacheInputs)
+		}
+		for _, result := range rec.Results {
+			chained := remotecache.ChainedResult{CreatedAt: result.CreatedAt}
+			for _, layer := range result.Layers {
+				layerIndex, ok := addLayer(layer)
+				if !ok {
+					chained.LayerIndexes = nil
+					break
+				}
+				chained.LayerIndexes = append(chained.LayerIndexes, layerIndex)
+			}
+			if len(chained.LayerIndexes) > 0 {
+				cacheRec.ChainedResults = append(cacheRec.ChainedResults, chained)
+			}
+		}
+		config.Records = append(config.Records, cacheRec)
+	}
+	return config
+}
+
+// outputKey is the record digest that buildkit uses for an output of an op.
+func outputKey(dgst digest.Digest, output int) digest.Digest {
+	return digest.FromBytes([]byte(fmt.Sprintf("%s@%d", dgst, output)))
+}
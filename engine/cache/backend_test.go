@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesystemBackend(t *testing.T) {
+	ctx := context.Background()
+	backend, err := newFilesystemBackend(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = backend.Get(ctx, "missing")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	exists, err := backend.Exists(ctx, "missing")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, backend.Put(ctx, "foo/bar.json", []byte("{}")))
+	bs, err := backend.Get(ctx, "foo/bar.json")
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(bs))
+
+	_, err = backend.Get(ctx, "../escape")
+	require.ErrorContains(t, err, "invalid cache key")
+
+	client := &http.Client{Transport: backend.Transport()}
+
+	uploadURL, headers, err := backend.UploadURL(ctx, "blobs/sha256/abc")
+	require.NoError(t, err)
+	require.Empty(t, headers)
+	req, err := http.NewRequest(http.MethodPut, uploadURL, strings.NewReader("hello world"))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	downloadURL, err := backend.DownloadURL(ctx, "blobs/sha256/abc")
+	require.NoError(t, err)
+	req, err = http.NewRequest(http.MethodGet, downloadURL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Range", "bytes=6-")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, "world", string(body))
+
+	req, err = http.NewRequest(http.MethodGet, "file:///etc/passwd", nil)
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	keys, err := backend.List(ctx, "blobs/")
+	require.NoError(t, err)
+	require.Equal(t, []string{"blobs/sha256/abc"}, keys)
+}
+
+func TestRecordIndexDigests(t *testing.T) {
+	root := digest.FromString("root").String()
+	opDigest := digest.FromString("op")
+
+	// the same graph in two engines, with different random IDs for the non-root key
+	digestsA, recordsA, err := recordIndexDigests(UpdateCacheRecordsRequest{
+		CacheKeys: []CacheKey{{ID: root}, {ID: "random-a"}},
+		Links:     []Link{{ID: "random-a", LinkedID: root, Digest: opDigest}},
+	})
+	require.NoError(t, err)
+	digestsB, _, err := recordIndexDigests(UpdateCacheRecordsRequest{
+		CacheKeys: []CacheKey{{ID: root}, {ID: "random-b"}},
+		Links:     []Link{{ID: "random-b", LinkedID: root, Digest: opDigest}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, digest.Digest(root), digestsA[root])
+	require.Equal(t, digestsA["random-a"], digestsB["random-b"])
+
+	rec := recordsA[digestsA["random-a"]]
+	require.Equal(t, outputKey(opDigest, 0), rec.Digest)
+	require.Equal(t, [][]indexInput{{{Record: digest.Digest(root)}}}, rec.Inputs)
+
+	// keys that aren't content-addressed can't be shared
+	digests, _, err := recordIndexDigests(UpdateCacheRecordsRequest{
+		CacheKeys: []CacheKey{{ID: "not-a-digest"}, {ID: "child"}},
+		Links:     []Link{{ID: "child", LinkedID: "not-a-digest", Digest: opDigest}},
+	})
+	require.NoError(t, err)
+	require.Empty(t, digests)
+}
+
+func TestBackendServiceExportImport(t *testing.T) {
+	ctx := context.Background()
+	backend, err := newFilesystemBackend(t.TempDir())
+	require.NoError(t, err)
+	svc := &backendService{backend: backend}
+
+	root := digest.FromString("root").String()
+	req := UpdateCacheRecordsRequest{
+		CacheKeys: []CacheKey{
+			{ID: root},
+			{ID: "child", Results: []Result{{ID: "ref", CreatedAt: time.Now()}}},
+		},
+		Links: []Link{{ID: "child", LinkedID: root, Digest: digest.FromString("op")}},
+	}
+	resp, err := svc.UpdateCacheRecords(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, resp.ExportRecords, 1)
+	require.Equal(t, "ref", resp.ExportRecords[0].CacheRefID)
+
+	layer := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageLayerZstd,
+		Digest:    digest.FromString("layer"),
+		Size:      5,
+		Annotations: map[string]string{
+			"containerd.io/uncompressed": digest.FromString("diff").String(),
+		},
+	}
+	require.NoError(t, svc.UpdateCacheLayers(ctx, UpdateCacheLayersRequest{
+		UpdatedRecords: []RecordLayers{{
+			RecordDigest: resp.ExportRecords[0].Digest,
+			Layers:       []ocispecs.Descriptor{layer},
+		}},
+	}))
+
+	config, err := svc.ImportCache(ctx)
+	require.NoError(t, err)
+	require.Len(t, config.Layers, 1)
+	require.Equal(t, layer.Digest, config.Layers[0].Blob)
+	require.Len(t, config.Records, 2)
+
+	// already exported records aren't exported again
+	resp, err = svc.UpdateCacheRecords(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, resp.ExportRecords)
+}
@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// filesystemBackend is a Backend that stores objects as files under a directory, which is
+// typically a network filesystem shared by several engines.
+type filesystemBackend struct {
+	root string
+}
+
+var _ Backend = &filesystemBackend{}
+
+func newFilesystemBackend(root string) (*filesystemBackend, error) {
+	if !filepath.IsAbs(root) {
+		return nil, fmt.Errorf("cache directory %q must be an absolute path", root)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &filesystemBackend{root: filepath.Clean(root)}, nil
+}
+
+func (b *filesystemBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	p, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(p)
+}
+
+func (b *filesystemBackend) Put(ctx context.Context, key string, data []byte) error {
+	p, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p, bytes.NewReader(data))
+}
+
+func (b *filesystemBackend) Exists(ctx context.Context, key string) (bool, error) {
+	p, err := b.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	case err != nil:
+		return false, err
+	default:
+		return true, nil
+	}
+}
+
+func (b *filesystemBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(b.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			// skip temporary files of in-progress writes
+			return nil
+		}
+		rel, err := filepath.Rel(b.root, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (b *filesystemBackend) DownloadURL(ctx context.Context, key string) (string, error) {
+	return b.url(key)
+}
+
+func (b *filesystemBackend) UploadURL(ctx context.Context, key string) (string, map[string]string, error) {
+	u, err := b.url(key)
+	return u, nil, err
+}
+
+func (b *filesystemBackend) Transport() http.RoundTripper {
+	return b
+}
+
+// RoundTrip implements http.RoundTripper for the file:// URLs returned by DownloadURL and
+// UploadURL, supporting GET requests (with an open-ended range) and PUT requests.
+func (b *filesystemBackend) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	if req.URL.Scheme != "file" {
+		return nil, fmt.Errorf("unsupported URL scheme %q", req.URL.Scheme)
+	}
+	p := filepath.Clean(filepath.FromSlash(req.URL.Path))
+	if rel, err := filepath.Rel(b.root, p); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fileResponse(req, http.StatusForbidden, nil), nil
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		f, err := os.Open(p)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return fileResponse(req, http.StatusNotFound, nil), nil
+			}
+			return nil, err
+		}
+		rangeHeader := req.Header.Get("Range")
+		if rangeHeader == "" {
+			return fileResponse(req, http.StatusOK, f), nil
+		}
+		offset, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"), 10, 64)
+		if err != nil {
+			f.Close()
+			return fileResponse(req, http.StatusRequestedRangeNotSatisfiable, nil), nil
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return fileResponse(req, http.StatusPartialContent, f), nil
+	case http.MethodPut:
+		if err := writeFileAtomic(p, req.Body); err != nil {
+			return nil, err
+		}
+		return fileResponse(req, http.StatusOK, nil), nil
+	default:
+		return fileResponse(req, http.StatusMethodNotAllowed, nil), nil
+	}
+}
+
+func (b *filesystemBackend) path(key string) (string, error) {
+	p := filepath.Join(b.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, b.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid cache key %q", key)
+	}
+	return p, nil
+}
+
+func (b *filesystemBackend) url(key string) (string, error) {
+	p, err := b.path(key)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String(), nil
+}
+
+func fileResponse(req *http.Request, status int, body io.ReadCloser) *http.Response {
+	if body == nil {
+		body = http.NoBody
+	}
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode: status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       body,
+		Request:    req,
+	}
+}
+
+// writeFileAtomic writes the file at p via a temporary file in the same directory, so that
+// other engines never see a partially written file.
+func writeFileAtomic(p string, r io.Reader) (rerr error) {
+	dir := filepath.Dir(p)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(p)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rerr != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err := io.Copy(tmp, r); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -56,16 +57,30 @@ func NewManager(ctx context.Context, managerConfig ManagerConfig) (Manager, erro
 		httpClient:    &http.Client{},
 	}
 
-	if managerConfig.Token == "" {
-		return defaultCacheManager{m.localCache}, nil
-	}
-	bklog.G(ctx).Debugf("using cache service at %s", managerConfig.ServiceURL)
-
-	serviceClient, err := newClient(managerConfig.ServiceURL, managerConfig.Token)
+	serviceURL, err := url.Parse(managerConfig.ServiceURL)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid cache service URL: %w", err)
+	}
+	switch {
+	case isBackendURL(serviceURL):
+		// self-hosted cache, no token needed
+		bklog.G(ctx).Debugf("using cache backend at %s", serviceURL.Redacted())
+		backendService, err := newBackendService(ctx, serviceURL)
+		if err != nil {
+			return nil, err
+		}
+		m.cacheClient = backendService
+		m.httpClient.Transport = backendService.backend.Transport()
+	case managerConfig.Token == "":
+		return defaultCacheManager{m.localCache}, nil
+	default:
+		bklog.G(ctx).Debugf("using cache service at %s", managerConfig.ServiceURL)
+		serviceClient, err := newClient(managerConfig.ServiceURL, managerConfig.Token)
+		if err != nil {
+			return nil, err
+		}
+		m.cacheClient = serviceClient
 	}
-	m.cacheClient = serviceClient
 	m.layerProvider = &layerProvider{
 		httpClient:  m.httpClient,
 		cacheClient: m.cacheClient,
@@ -158,6 +173,7 @@ func (m *manager) Export(ctx context.Context) error {
 				ID:       id,
 				LinkedID: linkedID,
 				Input:    int(linkInfo.Input),
+				Output:   int(linkInfo.Output),
 				Digest:   linkInfo.Digest,
 				Selector: linkInfo.Selector,
 			}
@@ -8,8 +8,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path/filepath"
-	"time"
 
 	"github.com/containerd/containerd/archive"
 	"github.com/containerd/containerd/content"
@@ -17,9 +15,8 @@ import (
 	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/mount"
 	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/klauspost/compress/zstd"
-	"github.com/moby/buildkit/solver/llbsolver/mounts"
-	solverpb "github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/leaseutil"
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
@@ -36,6 +33,8 @@ func (m *manager) StartCacheMountSynchronization(ctx context.Context) error {
 	var eg errgroup.Group
 	for _, syncedCacheMount := range syncedCacheMounts {
 		syncedCacheMount := syncedCacheMount
+		// record the name so the volume can be listed by key
+		core.SeenCacheKeys.Store(syncedCacheMount.Name, struct{}{})
 		if syncedCacheMount.URL == "" {
 			// nothing to download, have to start fresh, skip it until we sync back to cloud at shutdown
 			continue
@@ -43,8 +42,8 @@ func (m *manager) StartCacheMountSynchronization(ctx context.Context) error {
 		eg.Go(func() error {
 			bklog.G(ctx).Debugf("syncing cache mount locally %s", syncedCacheMount.Name)
 			cacheKey := cacheKeyFromMountName(syncedCacheMount.Name)
-			return withCacheMount(ctx, m.MountManager, cacheKey, func(ctx context.Context, mnt mount.Mount) error {
-				cacheMountDir := mnt.Source // relies on our check that this is a bind mount in withCacheMount
+			return buildkit.WithCacheMount(ctx, m.MountManager, cacheKey, func(ctx context.Context, mnt mount.Mount) error {
+				cacheMountDir := mnt.Source // relies on our check that this is a bind mount in WithCacheMount
 
 				// if there's any existing data in the cache mount, we'll just leave it alone
 				// NOTE: there's cases in which this heuristic isn't ideal, such as when a
@@ -69,7 +68,7 @@ func (m *manager) StartCacheMountSynchronization(ctx context.Context) error {
 					MediaType: syncedCacheMount.MediaType,
 				}, []mount.Mount{mnt})
 				if err != nil {
-					if removeErr := removeAllUnderDir(cacheMountDir); removeErr != nil {
+					if removeErr := buildkit.RemoveAllUnderDir(cacheMountDir); removeErr != nil {
 						err = errors.Join(err, fmt.Errorf("failed to empty out cache mount dir after failure %q: %w", cacheMountDir, removeErr))
 					}
 					return fmt.Errorf("failed to apply cache mount: %w", err)
@@ -104,7 +103,7 @@ func (m *manager) StartCacheMountSynchronization(ctx context.Context) error {
 				bklog.G(ctx).Debugf("syncing cache mount remotely %s", cacheMountName)
 				cacheKey := cacheKeyFromMountName(cacheMountName)
 
-				return withCacheMount(ctx, m.MountManager, cacheKey, func(ctx context.Context, mnt mount.Mount) error {
+				return buildkit.WithCacheMount(ctx, m.MountManager, cacheKey, func(ctx context.Context, mnt mount.Mount) error {
 					// First compress the mount into the content store. We can't stream direct to S3 because we want
 					// to tell S3 the checksum of the whole thing when we open the request there. Apparently there
 					// is a way to include the checksum as a trailer, but it is poorly documented and seems to require
@@ -130,7 +129,7 @@ func (m *manager) StartCacheMountSynchronization(ctx context.Context) error {
 						return fmt.Errorf("failed to create compressor: %w", err)
 					}
 					defer compressor.Close()
-					// mnt.Source relies on our check that this is a bind mount in withCacheMount
+					// mnt.Source relies on our check that this is a bind mount in WithCacheMount
 					err = archive.WriteDiff(ctx, compressor, "", mnt.Source)
 					if err != nil {
 						return fmt.Errorf("failed to write diff: %w", err)
@@ -202,57 +201,3 @@ func cacheKeyFromMountName(name string) string {
 	// problem though too, so just accepting it for now.
 	return core.NewCache(name).Sum()
 }
-
-func withCacheMount(ctx context.Context, mountManager *mounts.MountManager, cacheKey string, cb func(ctx context.Context, mnt mount.Mount) error) error {
-	// this should never block in theory since we have exclusive access at
-	// engine startup, but put a timeout on this out of an abundance of caution
-	getRefCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-	ref, err := mountManager.MountableCache(getRefCtx, &solverpb.Mount{
-		CacheOpt: &solverpb.CacheOpt{
-			ID:      cacheKey,
-			Sharing: solverpb.CacheSharingOpt_SHARED,
-		},
-	}, nil, nil)
-	defer func() {
-		if ref != nil {
-			ref.Release(context.Background())
-		}
-	}()
-	if err != nil {
-		return fmt.Errorf("failed to get cache mount ref: %w", err)
-	}
-
-	mountable, err := ref.Mount(ctx, false, nil)
-	if err != nil {
-		return fmt.Errorf("failed to get cache mount: %w", err)
-	}
-	mounts, releaseMounts, err := mountable.Mount()
-	if err != nil {
-		return fmt.Errorf("failed to get cache mount mounts: %w", err)
-	}
-	defer releaseMounts()
-	if len(mounts) != 1 {
-		return fmt.Errorf("expected 1 mount, got %d", len(mounts))
-	}
-	mnt := mounts[0]
-	if mnt.Type != "bind" && mnt.Type != "rbind" {
-		// TODO: we could support overlay (when there's a parent ref to the cache mount)
-		// by just mounting to a tempdir
-		return fmt.Errorf("expected bind mount, got %s", mnt.Type)
-	}
-	return cb(ctx, mnt)
-}
-
-func removeAllUnderDir(dir string) error {
-	dirents, err := os.ReadDir(dir)
-	if err != nil {
-		return fmt.Errorf("failed to read dir %s: %w", dir, err)
-	}
-	for _, dirent := range dirents {
-		if err := os.RemoveAll(filepath.Join(dir, dirent.Name())); err != nil {
-			return fmt.Errorf("failed to remove %s: %w", dirent.Name(), err)
-		}
-	}
-	return nil
-}
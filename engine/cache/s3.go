@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// s3PresignExpiry is how long the URLs returned by an s3Backend are valid for. They are
+// only requested right before use, but pushing a large layer can take a while.
+const s3PresignExpiry = time.Hour
+
+// s3Backend is a Backend that stores objects in an S3-compatible bucket, such as AWS S3 or
+// MinIO. Credentials are taken from the standard AWS environment variables and config files.
+type s3Backend struct {
+	client    *s3.Client
+	presigner *s3.PresignClient
+	bucket    string
+	prefix    string
+}
+
+var _ Backend = &s3Backend{}
+
+// newS3Backend returns an s3Backend for a URL of the form s3://bucket/optional/prefix, with
+// the following optional query parameters:
+//   - region: the bucket's region, defaults to $AWS_REGION
+//   - endpoint_url: the URL of an S3-compatible endpoint, e.g. for MinIO
+//   - use_path_style: whether to use path-style addressing with endpoint_url
+func newS3Backend(ctx context.Context, u *url.URL) (*s3Backend, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing bucket in S3 cache URL")
+	}
+	query := u.Query()
+
+	region := query.Get("region")
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	var usePathStyle bool
+	if v := query.Get("use_path_style"); v != "" {
+		usePathStyle, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid use_path_style %q: %w", v, err)
+		}
+	}
+	client := s3.NewFromConfig(cfg, func(options *s3.Options) {
+		if endpointURL := query.Get("endpoint_url"); endpointURL != "" {
+			options.UsePathStyle = usePathStyle
+			options.EndpointResolver = s3.EndpointResolverFromURL(endpointURL)
+		}
+	})
+
+	prefix := strings.Trim(u.Path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &s3Backend{
+		client:    client,
+		presigner: s3.NewPresignClient(client, s3.WithPresignExpires(s3PresignExpiry)),
+		bucket:    u.Host,
+		prefix:    prefix,
+	}, nil
+}
+
+func (b *s3Backend) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.prefix + key),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, fmt.Errorf("%s: %w", key, fs.ErrNotExist)
+		}
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (b *s3Backend) Put(ctx context.Context, key string, data []byte) error {
+	_, err := b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(b.bucket),
+		Key:           aws.String(b.prefix + key),
+		Body:          bytes.NewReader(data),
+		ContentLength: int64(len(data)),
+	})
+	return err
+}
+
+func (b *s3Backend) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.prefix + key),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *s3Backend) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(b.prefix + prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			keys = append(keys, strings.TrimPrefix(aws.ToString(obj.Key), b.prefix))
+		}
+	}
+	return keys, nil
+}
+
+func (b *s3Backend) DownloadURL(ctx context.Context, key string) (string, error) {
+	req, err := b.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.prefix + key),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download of %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+func (b *s3Backend) UploadURL(ctx context.Context, key string) (string, map[string]string, error) {
+	req, err := b.presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.prefix + key),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to presign upload of %s: %w", key, err)
+	}
+	headers := map[string]string{}
+	for k, v := range req.SignedHeader {
+		// the host header is set by the HTTP client from the URL
+		if strings.EqualFold(k, "host") || len(v) == 0 {
+			continue
+		}
+		headers[k] = v[0]
+	}
+	return req.URL, headers, nil
+}
+
+func (b *s3Backend) Transport() http.RoundTripper {
+	return http.DefaultTransport
+}
+
+func isS3NotFound(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "NoSuchKey", "NotFound":
+		return true
+	default:
+		return false
+	}
+}
@@ -119,6 +119,7 @@ type Link struct {
 	ID       string
 	LinkedID string
 	Input    int
+	Output   int
 	Digest   digest.Digest
 	Selector digest.Digest
 }
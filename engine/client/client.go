@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -31,6 +32,7 @@ import (
 	"github.com/moby/buildkit/session/auth/authprovider"
 	"github.com/moby/buildkit/session/filesync"
 	"github.com/moby/buildkit/session/grpchijack"
+	"github.com/moby/patternmatcher"
 	"github.com/opencontainers/go-digest"
 	"github.com/tonistiigi/fsutil"
 	fstypes "github.com/tonistiigi/fsutil/types"
@@ -67,10 +69,19 @@ type Params struct {
 	EngineNameCallback func(string)
 	CloudURLCallback   func(string)
 
+	// Secret providers keyed by URI scheme, used to resolve secret references
+	// (see Query.setSecretURI) in addition to the defaults.
+	SecretProviders map[string]SecretProvider
+
 	// If this client is for a module function, this digest will be set in the
 	// grpc context metadata for any api requests back to the engine. It's used by the API
 	// server to determine which schema to serve and other module context metadata.
 	ModuleContextDigest digest.Digest
+
+	// Keep the state of failed execs around so that a debug shell can be
+	// opened in them, e.g. for dagger call --debug-on-failure. Ignored by
+	// nested clients, which share the setting of their server.
+	KeepFailedExecs bool
 }
 
 type Client struct {
@@ -257,6 +268,16 @@ func Connect(ctx context.Context, params Params) (_ *Client, _ context.Context,
 		EnableHostNetworkAccess: !c.DisableHostRW,
 	})
 
+	// secret references
+	secretProviders := DefaultSecretProviders()
+	for scheme, provider := range c.SecretProviders {
+		secretProviders[scheme] = provider
+	}
+	bkSession.Allow(SecretURIStore{
+		EnableHostAccess: !c.DisableHostRW,
+		Providers:        secretProviders,
+	})
+
 	// registry auth
 	bkSession.Allow(authprovider.NewDockerAuthProvider(config.LoadDefaultConfigFile(os.Stderr), nil))
 
@@ -279,6 +300,7 @@ func Connect(ctx context.Context, params Params) (_ *Client, _ context.Context,
 				UpstreamCacheImportConfig: c.upstreamCacheImportOptions,
 				Labels:                    c.labels,
 				ModuleContextDigest:       c.ModuleContextDigest,
+				KeepFailedExecs:           c.KeepFailedExecs,
 			}.AppendToMD(meta))
 		})
 	})
@@ -648,17 +670,29 @@ func (AnyDirTarget) DiffCopy(stream filesync.FileSend_DiffCopyServer) (rerr erro
 			return fmt.Errorf("failed to create synctarget dest dir %s: %w", opts.Path, err)
 		}
 
+		var receivedMu sync.Mutex
+		received := map[string]struct{}{}
 		err := fsutil.Receive(stream.Context(), stream, opts.Path, fsutil.ReceiveOpt{
 			Merge: true,
 			Filter: func(path string, stat *fstypes.Stat) bool {
 				stat.Uid = uint32(os.Getuid())
 				stat.Gid = uint32(os.Getgid())
+				receivedMu.Lock()
+				received[filepath.ToSlash(path)] = struct{}{}
+				receivedMu.Unlock()
 				return true
 			},
 		})
 		if err != nil {
 			return fmt.Errorf("failed to receive fs changes: %w", err)
 		}
+		if opts.Mirror {
+			// fsutil can only mirror by diffing against the whole destination,
+			// which would remove excluded paths too, so prune separately
+			if err := pruneExportDir(opts.Path, received, opts.ExcludePatterns); err != nil {
+				return fmt.Errorf("failed to prune synctarget dest dir %s: %w", opts.Path, err)
+			}
+		}
 		return nil
 	}
 
@@ -731,6 +765,69 @@ func (AnyDirTarget) DiffCopy(stream filesync.FileSend_DiffCopyServer) (rerr erro
 	}
 }
 
+// pruneExportDir removes everything under root that isn't in keep and doesn't
+// match any of the exclude patterns. Directories that still contain excluded
+// paths are kept.
+func pruneExportDir(root string, keep map[string]struct{}, exclude []string) error {
+	pm, err := patternmatcher.New(exclude)
+	if err != nil {
+		return fmt.Errorf("invalid exclude patterns: %w", err)
+	}
+
+	var staleDirs []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		excluded, err := pm.MatchesOrParentMatches(rel)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			if d.IsDir() && !pm.Exclusions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if _, ok := keep[rel]; ok {
+			return nil
+		}
+		if d.IsDir() {
+			// remove it once its contents have been pruned
+			staleDirs = append(staleDirs, path)
+			return nil
+		}
+		return os.Remove(path)
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(staleDirs) - 1; i >= 0; i-- {
+		entries, err := os.ReadDir(staleDirs[i])
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			continue
+		}
+		if err := os.Remove(staleDirs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type progRockAttachable struct {
 	writer progrock.Writer
 }
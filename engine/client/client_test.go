@@ -0,0 +1,47 @@
+package client
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneExportDir(t *testing.T) {
+	root := t.TempDir()
+	for _, p := range []string{"a.txt", "stale/x", "keep/y", "keep/z", ".git/HEAD", "vendor/.git/HEAD", "vendor/old"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, p)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, p), nil, 0o644))
+	}
+
+	keep := map[string]struct{}{"a.txt": {}, "keep": {}, "keep/y": {}}
+	require.NoError(t, pruneExportDir(root, keep, []string{"**/.git"}))
+
+	var paths []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == root {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	require.NoError(t, err)
+
+	// vendor isn't kept, but is left behind for the excluded path in it
+	require.Equal(t, []string{
+		".git",
+		".git/HEAD",
+		"a.txt",
+		"keep",
+		"keep/y",
+		"vendor",
+		"vendor/.git",
+		"vendor/.git/HEAD",
+	}, paths)
+}
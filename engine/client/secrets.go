@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SecretProvider resolves the plaintext of a secret reference. The ref is the
+// part of the secret URI following "<scheme>://".
+type SecretProvider interface {
+	GetSecret(ctx context.Context, ref string) ([]byte, error)
+}
+
+// SecretProviderFunc adapts a function to a SecretProvider.
+type SecretProviderFunc func(ctx context.Context, ref string) ([]byte, error)
+
+func (f SecretProviderFunc) GetSecret(ctx context.Context, ref string) ([]byte, error) {
+	return f(ctx, ref)
+}
+
+// DefaultSecretProviders returns the providers for the built-in secret URI
+// schemes.
+func DefaultSecretProviders() map[string]SecretProvider {
+	return map[string]SecretProvider{
+		"env":   SecretProviderFunc(envSecret),
+		"file":  SecretProviderFunc(fileSecret),
+		"cmd":   SecretProviderFunc(cmdSecret),
+		"vault": VaultSecretProvider{},
+	}
+}
+
+// SecretURIStore resolves secret references of the form <scheme>://<ref>
+// on behalf of the engine, which only asks for them when a secret is actually
+// mounted or read.
+type SecretURIStore struct {
+	EnableHostAccess bool
+
+	Providers map[string]SecretProvider
+}
+
+var _ secrets.SecretStore = SecretURIStore{}
+
+func (s SecretURIStore) Register(server *grpc.Server) {
+	secrets.RegisterSecretsServer(server, secretsprovider.NewSecretProvider(s))
+}
+
+func (s SecretURIStore) GetSecret(ctx context.Context, uri string) ([]byte, error) {
+	if !s.EnableHostAccess {
+		return nil, status.Errorf(codes.PermissionDenied, "host access is disabled")
+	}
+
+	scheme, ref, ok := strings.Cut(uri, "://")
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid secret reference %q", uri)
+	}
+
+	provider, ok := s.Providers[scheme]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported secret provider %q", scheme)
+	}
+
+	plaintext, err := provider.GetSecret(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s secret provider: %w", scheme, err)
+	}
+
+	return plaintext, nil
+}
+
+func envSecret(_ context.Context, name string) ([]byte, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %q is not set", name)
+	}
+	return []byte(v), nil
+}
+
+func fileSecret(_ context.Context, path string) ([]byte, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = home + path[1:]
+	}
+	return os.ReadFile(path)
+}
+
+// cmdSecret runs the reference as a shell command and returns its stdout,
+// minus a single trailing newline.
+func cmdSecret(ctx context.Context, command string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	out, _ = strings.CutSuffix(string(out), "\n")
+	return []byte(out), nil
+}
+
+// VaultSecretProvider reads secrets from a Vault-compatible KV HTTP API.
+//
+// References are of the form PATH?field=KEY, e.g. secret/data/ci?field=token,
+// which reads the key "token" from GET $VAULT_ADDR/v1/secret/data/ci. Both KV
+// v1 and v2 response shapes are supported. The field defaults to "value".
+type VaultSecretProvider struct {
+	// Address of the server; defaults to $VAULT_ADDR.
+	Addr string
+	// Token sent as X-Vault-Token; defaults to $VAULT_TOKEN.
+	Token string
+	// HTTP client to use; defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+func (p VaultSecretProvider) GetSecret(ctx context.Context, ref string) ([]byte, error) {
+	addr := p.Addr
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("vault address is not configured (set VAULT_ADDR)")
+	}
+	token := p.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	secretPath, query, _ := strings.Cut(ref, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("parse reference: %w", err)
+	}
+	field := params.Get("field")
+	if field == "" {
+		field = "value"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(secretPath, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("read %s: unexpected status %s", secretPath, resp.Status)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	data := body.Data
+	// KV v2 nests the secret data under data.data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, isMeta := data["metadata"]; isMeta {
+			data = nested
+		}
+	}
+
+	v, ok := data[field]
+	if !ok {
+		return nil, fmt.Errorf("read %s: field %q not found", secretPath, field)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("read %s: field %q is not a string", secretPath, field)
+	}
+	return []byte(s), nil
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretURIStore(t *testing.T) {
+	t.Setenv("DAGGER_TEST_SECRET", "env-secret")
+
+	store := SecretURIStore{
+		EnableHostAccess: true,
+		Providers:        DefaultSecretProviders(),
+	}
+
+	plaintext, err := store.GetSecret(context.Background(), "env://DAGGER_TEST_SECRET")
+	require.NoError(t, err)
+	require.Equal(t, "env-secret", string(plaintext))
+
+	plaintext, err = store.GetSecret(context.Background(), "cmd://echo cmd-secret")
+	require.NoError(t, err)
+	require.Equal(t, "cmd-secret", string(plaintext))
+
+	_, err = store.GetSecret(context.Background(), "env://DAGGER_TEST_SECRET_UNSET")
+	require.ErrorContains(t, err, "is not set")
+
+	_, err = store.GetSecret(context.Background(), "nope://foo")
+	require.ErrorContains(t, err, "unsupported secret provider")
+
+	store.EnableHostAccess = false
+	_, err = store.GetSecret(context.Background(), "env://DAGGER_TEST_SECRET")
+	require.ErrorContains(t, err, "host access is disabled")
+}
+
+func TestVaultSecretProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/ci":
+			w.Write([]byte(`{"data":{"data":{"token":"v2-secret"},"metadata":{"version":1}}}`))
+		case "/v1/kv/ci":
+			w.Write([]byte(`{"data":{"value":"v1-secret"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	provider := VaultSecretProvider{Addr: srv.URL, Token: "test-token"}
+
+	plaintext, err := provider.GetSecret(context.Background(), "secret/data/ci?field=token")
+	require.NoError(t, err)
+	require.Equal(t, "v2-secret", string(plaintext))
+
+	plaintext, err = provider.GetSecret(context.Background(), "kv/ci")
+	require.NoError(t, err)
+	require.Equal(t, "v1-secret", string(plaintext))
+
+	_, err = provider.GetSecret(context.Background(), "secret/data/missing")
+	require.ErrorContains(t, err, "404")
+
+	_, err = VaultSecretProvider{Addr: srv.URL}.GetSecret(context.Background(), "kv/ci")
+	require.ErrorContains(t, err, "403")
+}
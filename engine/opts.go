@@ -68,6 +68,11 @@ type ClientMetadata struct {
 
 	// Import configuration for Buildkit's remote cache
 	UpstreamCacheImportConfig []*controlapi.CacheOptionsEntry
+
+	// If KeepFailedExecs is true, the server keeps the state of the most
+	// recent failed execs so that they can be debugged. Only read from the
+	// client that initializes the server.
+	KeepFailedExecs bool `json:"keep_failed_execs"`
 }
 
 // ClientIDs returns the ClientID followed by ParentClientIDs.
@@ -169,6 +174,10 @@ type LocalExportOpts struct {
 	FileOriginalName   string      `json:"file_original_name"`
 	AllowParentDirPath bool        `json:"allow_parent_dir_path"`
 	FileMode           os.FileMode `json:"file_mode"`
+	// Mirror removes any paths under Path that weren't exported, other than
+	// those matching ExcludePatterns.
+	Mirror          bool     `json:"mirror"`
+	ExcludePatterns []string `json:"exclude_patterns"`
 }
 
 func (o LocalExportOpts) ToGRPCMD() metadata.MD {
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/containerd/containerd/remotes/docker"
 	"github.com/dagger/dagger/auth"
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/core/pipeline"
@@ -75,6 +76,7 @@ type BuildkitControllerOpts struct {
 	UpstreamCacheExporters map[string]remotecache.ResolveCacheExporterFunc
 	UpstreamCacheImporters map[string]remotecache.ResolveCacheImporterFunc
 	DNSConfig              *oci.DNSConfig
+	RegistryHosts          docker.RegistryHosts
 }
 
 func NewBuildkitController(opts BuildkitControllerOpts) (*BuildkitController, error) {
@@ -246,6 +248,8 @@ func (e *BuildkitController) Session(stream controlapi.Control_SessionServer) (r
 			ProgSockPath:          progSockPath,
 			MainClientCaller:      caller,
 			DNSConfig:             e.DNSConfig,
+			RegistryHosts:         e.RegistryHosts,
+			KeepFailedExecs:       opts.KeepFailedExecs,
 		})
 		if err != nil {
 			e.serverMu.Unlock()
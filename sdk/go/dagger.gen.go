@@ -157,7 +157,11 @@ type CacheVolume struct {
 	q *querybuilder.Selection
 	c graphql.Client
 
-	id *CacheVolumeID
+	id       *CacheVolumeID
+	key      *string
+	lastUsed *int
+	prune    *int
+	size     *int
 }
 
 func (r *CacheVolume) ID(ctx context.Context) (CacheVolumeID, error) {
@@ -199,24 +203,170 @@ func (r *CacheVolume) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
+// The key identifying this cache volume.
+//
+// Empty if the key is not known, e.g. for a volume that has not been used
+// since the engine restarted.
+func (r *CacheVolume) Key(ctx context.Context) (string, error) {
+	if r.key != nil {
+		return *r.key, nil
+	}
+	q := r.q.Select("key")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The last time this cache volume was mounted, as a Unix timestamp.
+//
+// Null if the volume has never been used.
+func (r *CacheVolume) LastUsed(ctx context.Context) (int, error) {
+	if r.lastUsed != nil {
+		return *r.lastUsed, nil
+	}
+	q := r.q.Select("lastUsed")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Wipes the contents of this cache volume, returning the number of bytes
+// reclaimed.
+//
+// Subsequent uses of the volume start from an empty directory.
+func (r *CacheVolume) Prune(ctx context.Context) (int, error) {
+	if r.prune != nil {
+		return *r.prune, nil
+	}
+	q := r.q.Select("prune")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The disk space used by this cache volume on the engine, in bytes.
+func (r *CacheVolume) Size(ctx context.Context) (int, error) {
+	if r.size != nil {
+		return *r.size, nil
+	}
+	q := r.q.Select("size")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Returns a directory containing a copy of the current contents of this cache
+// volume.
+func (r *CacheVolume) Snapshot() *Directory {
+	q := r.q.Select("snapshot")
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Replaces the contents of this cache volume with the contents of the given
+// directory, e.g. to pre-populate a package manager cache.
+//
+// This is a side effect: the contents are replaced on the engine when this
+// field is resolved (e.g. when the ID of the returned volume is requested),
+// affecting all pipelines using this cache volume, and again every time it is
+// resolved. The returned cache volume is the same volume, with the same ID.
+//
+// Replacing the contents waits for any commands currently using this cache
+// volume to finish.
+func (r *CacheVolume) WithContents(source *Directory) *CacheVolume {
+	assertNotNil("source", source)
+	q := r.q.Select("withContents")
+	q = q.Arg("source", source)
+
+	return &CacheVolume{
+		q: q,
+		c: r.c,
+	}
+}
+
+// A change to a path between two container filesystems.
+type Change struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	kind *ChangeKind
+	path *string
+	size *int
+}
+
+// The kind of change.
+func (r *Change) Kind(ctx context.Context) (ChangeKind, error) {
+	if r.kind != nil {
+		return *r.kind, nil
+	}
+	q := r.q.Select("kind")
+
+	var response ChangeKind
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The absolute path of the changed file or directory.
+func (r *Change) Path(ctx context.Context) (string, error) {
+	if r.path != nil {
+		return *r.path, nil
+	}
+	q := r.q.Select("path")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The size of the path in the newer filesystem in bytes, or 0 if it was
+// deleted or is not a regular file.
+func (r *Change) Size(ctx context.Context) (int, error) {
+	if r.size != nil {
+		return *r.size, nil
+	}
+	q := r.q.Select("size")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // An OCI-compatible container, also known as a docker container.
 type Container struct {
 	q *querybuilder.Selection
 	c graphql.Client
 
-	envVariable   *string
-	export        *bool
-	id            *ContainerID
-	imageRef      *string
-	label         *string
-	platform      *Platform
-	publish       *string
-	shellEndpoint *string
-	stderr        *string
-	stdout        *string
-	sync          *ContainerID
-	user          *string
-	workdir       *string
+	combinedOutput *string
+	envVariable    *string
+	exitCode       *int
+	export         *bool
+	id             *ContainerID
+	imageConfig    *JSON
+	imageDigest    *string
+	imageRef       *string
+	label          *string
+	platform       *Platform
+	publish        *string
+	shellEndpoint  *string
+	stderr         *string
+	stdout         *string
+	sync           *ContainerID
+	user           *string
+	workdir        *string
 }
 type WithContainerFunc func(r *Container) *Container
 
@@ -330,6 +480,22 @@ func (r *Container) Build(context *Directory, opts ...ContainerBuildOpts) *Conta
 	}
 }
 
+// The output and error streams of the last executed command, interleaved in
+// the order they were written.
+//
+// Will execute default command if none is set, or error if there's no default.
+func (r *Container) CombinedOutput(ctx context.Context) (string, error) {
+	if r.combinedOutput != nil {
+		return *r.combinedOutput, nil
+	}
+	q := r.q.Select("combinedOutput")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // Retrieves default arguments for future commands.
 func (r *Container) DefaultArgs(ctx context.Context) ([]string, error) {
 	q := r.q.Select("defaultArgs")
@@ -340,6 +506,43 @@ func (r *Container) DefaultArgs(ctx context.Context) ([]string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// The changes to this container's root filesystem relative to another
+// container's root filesystem (e.g., its base image).
+func (r *Container) Diff(ctx context.Context, other *Container) ([]Change, error) {
+	assertNotNil("other", other)
+	q := r.q.Select("diff")
+	q = q.Arg("other", other)
+
+	q = q.Select("kind path size")
+
+	type diff struct {
+		Kind ChangeKind
+		Path string
+		Size int
+	}
+
+	convert := func(fields []diff) []Change {
+		out := []Change{}
+
+		for i := range fields {
+			val := Change{kind: &fields[i].Kind, path: &fields[i].Path, size: &fields[i].Size}
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []diff
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
 // Retrieves a directory at the given path.
 //
 // Mounts are included.
@@ -410,6 +613,21 @@ func (r *Container) EnvVariables(ctx context.Context) ([]EnvVariable, error) {
 	return convert(response), nil
 }
 
+// The exit code of the last executed command.
+//
+// Will execute default command if none is set, or error if there's no default.
+func (r *Container) ExitCode(ctx context.Context) (int, error) {
+	if r.exitCode != nil {
+		return *r.exitCode, nil
+	}
+	q := r.q.Select("exitCode")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // EXPERIMENTAL API! Subject to change/removal at any time.
 //
 // experimentalWithAllGPUs configures all available GPUs on the host to be accessible to this container.
@@ -452,6 +670,13 @@ type ContainerExportOpts struct {
 	// is largely compatible with most recent container runtimes, but Docker may be needed
 	// for older runtimes without OCI support.
 	MediaTypes ImageMediaTypes
+	// Container used to generate an SPDX SBOM attestation for each platform.
+	//
+	// It must implement the BuildKit SBOM scanner protocol
+	// (e.g., docker/buildkit-syft-scanner).
+	SbomScanner *Container
+	// Attach SLSA provenance attestations with the given level of detail.
+	Provenance ProvenanceMode
 }
 
 // Writes the container as an OCI tarball to the destination file path on the host for the specified platform variants.
@@ -476,6 +701,14 @@ func (r *Container) Export(ctx context.Context, path string, opts ...ContainerEx
 		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
 			q = q.Arg("mediaTypes", opts[i].MediaTypes)
 		}
+		// `sbomScanner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SbomScanner) {
+			q = q.Arg("sbomScanner", opts[i].SbomScanner)
+		}
+		// `provenance` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Provenance) {
+			q = q.Arg("provenance", opts[i].Provenance)
+		}
 	}
 	q = q.Arg("path", path)
 
@@ -492,19 +725,20 @@ func (r *Container) Export(ctx context.Context, path string, opts ...ContainerEx
 func (r *Container) ExposedPorts(ctx context.Context) ([]Port, error) {
 	q := r.q.Select("exposedPorts")
 
-	q = q.Select("description port protocol")
+	q = q.Select("description healthcheckPath port protocol")
 
 	type exposedPorts struct {
-		Description string
-		Port        int
-		Protocol    NetworkProtocol
+		Description     string
+		HealthcheckPath string
+		Port            int
+		Protocol        NetworkProtocol
 	}
 
 	convert := func(fields []exposedPorts) []Port {
 		out := []Port{}
 
 		for i := range fields {
-			val := Port{description: &fields[i].Description, port: &fields[i].Port, protocol: &fields[i].Protocol}
+			val := Port{description: &fields[i].Description, healthcheckPath: &fields[i].HealthcheckPath, port: &fields[i].Port, protocol: &fields[i].Protocol}
 			out = append(out, val)
 		}
 
@@ -535,9 +769,23 @@ func (r *Container) File(path string) *File {
 	}
 }
 
+// ContainerFromOpts contains options for Container.From
+type ContainerFromOpts struct {
+	// PEM-encoded public key the image must have a cosign signature for.
+	//
+	// If set, unsigned images and images signed with another key are refused.
+	VerifyWith string
+}
+
 // Initializes this container from a pulled base image.
-func (r *Container) From(address string) *Container {
+func (r *Container) From(address string, opts ...ContainerFromOpts) *Container {
 	q := r.q.Select("from")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `verifyWith` optional argument
+		if !querybuilder.IsZeroValue(opts[i].VerifyWith) {
+			q = q.Arg("verifyWith", opts[i].VerifyWith)
+		}
+	}
 	q = q.Arg("address", address)
 
 	return &Container{
@@ -586,6 +834,36 @@ func (r *Container) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
+// The container's image config, as a JSON object using the Docker image
+// config field names (e.g., "StopSignal", "Volumes", "Healthcheck").
+func (r *Container) ImageConfig(ctx context.Context) (JSON, error) {
+	if r.imageConfig != nil {
+		return *r.imageConfig, nil
+	}
+	q := r.q.Select("imageConfig")
+
+	var response JSON
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The digest of this container's image, as published with the default options.
+//
+// The image is exported to the engine without being pushed, so this is cheap
+// once the container has been published.
+func (r *Container) ImageDigest(ctx context.Context) (string, error) {
+	if r.imageDigest != nil {
+		return *r.imageDigest, nil
+	}
+	q := r.q.Select("imageDigest")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // The unique image reference which can only be retrieved immediately after the 'Container.From' call.
 func (r *Container) ImageRef(ctx context.Context) (string, error) {
 	if r.imageRef != nil {
@@ -674,6 +952,43 @@ func (r *Container) Labels(ctx context.Context) ([]Label, error) {
 	return convert(response), nil
 }
 
+// The layers of this container's root filesystem, from the bottom up, as they
+// would be published with the default options.
+func (r *Container) Layers(ctx context.Context) ([]ContainerLayer, error) {
+	q := r.q.Select("layers")
+
+	q = q.Select("createdBy diffID digest mediaType size")
+
+	type layers struct {
+		CreatedBy string
+		DiffID    string
+		Digest    string
+		MediaType string
+		Size      int
+	}
+
+	convert := func(fields []layers) []ContainerLayer {
+		out := []ContainerLayer{}
+
+		for i := range fields {
+			val := ContainerLayer{createdBy: &fields[i].CreatedBy, diffID: &fields[i].DiffID, digest: &fields[i].Digest, mediaType: &fields[i].MediaType, size: &fields[i].Size}
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []layers
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
 // Retrieves the list of paths where a directory is mounted.
 func (r *Container) Mounts(ctx context.Context) ([]string, error) {
 	q := r.q.Select("mounts")
@@ -726,6 +1041,29 @@ func (r *Container) Platform(ctx context.Context) (Platform, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// ContainerProvenanceOpts contains options for Container.Provenance
+type ContainerProvenanceOpts struct {
+	// Level of detail to include.
+	Mode ProvenanceMode
+}
+
+// The SLSA provenance predicate describing how this container's rootfs was
+// built, as attached to its image when publishing or exporting it.
+func (r *Container) Provenance(opts ...ContainerProvenanceOpts) *File {
+	q := r.q.Select("provenance")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `mode` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Mode) {
+			q = q.Arg("mode", opts[i].Mode)
+		}
+	}
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
 // ContainerPublishOpts contains options for Container.Publish
 type ContainerPublishOpts struct {
 	// Identifiers for other platform specific containers.
@@ -741,6 +1079,17 @@ type ContainerPublishOpts struct {
 	// is largely compatible with most recent registries, but Docker may be needed for older
 	// registries without OCI support.
 	MediaTypes ImageMediaTypes
+	// Container used to generate an SPDX SBOM attestation for each platform.
+	//
+	// It must implement the BuildKit SBOM scanner protocol
+	// (e.g., docker/buildkit-syft-scanner).
+	SbomScanner *Container
+	// Attach SLSA provenance attestations with the given level of detail.
+	Provenance ProvenanceMode
+	// Secret holding a PEM-encoded private key used to sign the published image.
+	//
+	// The signature is pushed alongside the image in the format used by cosign.
+	SignWith *Secret
 }
 
 // Publishes this container as a new image to the specified address.
@@ -765,6 +1114,18 @@ func (r *Container) Publish(ctx context.Context, address string, opts ...Contain
 		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
 			q = q.Arg("mediaTypes", opts[i].MediaTypes)
 		}
+		// `sbomScanner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SbomScanner) {
+			q = q.Arg("sbomScanner", opts[i].SbomScanner)
+		}
+		// `provenance` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Provenance) {
+			q = q.Arg("provenance", opts[i].Provenance)
+		}
+		// `signWith` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SignWith) {
+			q = q.Arg("signWith", opts[i].SignWith)
+		}
 	}
 	q = q.Arg("address", address)
 
@@ -774,6 +1135,75 @@ func (r *Container) Publish(ctx context.Context, address string, opts ...Contain
 	return response, q.Execute(ctx, r.c)
 }
 
+// ContainerPublishAllOpts contains options for Container.PublishAll
+type ContainerPublishAllOpts struct {
+	// Identifiers for other platform specific containers.
+	// Used for multi-platform image.
+	PlatformVariants []*Container
+	// Force each layer of the published image to use the specified compression algorithm.
+	// If this is unset, then if a layer already has a compressed blob in the engine's
+	// cache, that will be used (this can result in a mix of compression algorithms for
+	// different layers). If this is unset and a layer has no compressed blob in the
+	// engine's cache, then it will be compressed using Gzip.
+	ForcedCompression ImageLayerCompression
+	// Use the specified media types for the published image's layers. Defaults to OCI, which
+	// is largely compatible with most recent registries, but Docker may be needed for older
+	// registries without OCI support.
+	MediaTypes ImageMediaTypes
+	// Container used to generate an SPDX SBOM attestation for each platform.
+	//
+	// It must implement the BuildKit SBOM scanner protocol
+	// (e.g., docker/buildkit-syft-scanner).
+	SbomScanner *Container
+	// Attach SLSA provenance attestations with the given level of detail.
+	Provenance ProvenanceMode
+	// Secret holding a PEM-encoded private key used to sign the published image.
+	//
+	// The signature is pushed alongside the image in the format used by cosign.
+	SignWith *Secret
+}
+
+// Publishes this container as a new image to each of the specified addresses
+// in a single push.
+//
+// Returns the fully qualified ref of each address, in the same order.
+// It can also publish platform variants.
+func (r *Container) PublishAll(ctx context.Context, addresses []string, opts ...ContainerPublishAllOpts) ([]string, error) {
+	q := r.q.Select("publishAll")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `platformVariants` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
+			q = q.Arg("platformVariants", opts[i].PlatformVariants)
+		}
+		// `forcedCompression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
+			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
+		}
+		// `mediaTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
+			q = q.Arg("mediaTypes", opts[i].MediaTypes)
+		}
+		// `sbomScanner` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SbomScanner) {
+			q = q.Arg("sbomScanner", opts[i].SbomScanner)
+		}
+		// `provenance` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Provenance) {
+			q = q.Arg("provenance", opts[i].Provenance)
+		}
+		// `signWith` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SignWith) {
+			q = q.Arg("signWith", opts[i].SignWith)
+		}
+	}
+	q = q.Arg("addresses", addresses)
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // Retrieves this container's root filesystem. Mounts are not included.
 func (r *Container) Rootfs() *Directory {
 	q := r.q.Select("rootfs")
@@ -800,12 +1230,40 @@ func (r *Container) ShellEndpoint(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
-// The error stream of the last executed command.
+// ContainerSquashOpts contains options for Container.Squash
+type ContainerSquashOpts struct {
+	// The container whose layers are kept as they are.
+	//
+	// If not set, the whole root filesystem is collapsed into a single layer.
+	From *Container
+}
+
+// Retrieves this container with the layers of its root filesystem that are not
+// in another container's root filesystem (e.g., its base image) collapsed into
+// a single layer.
 //
-// Will execute default command if none is set, or error if there's no default.
-func (r *Container) Stderr(ctx context.Context) (string, error) {
-	if r.stderr != nil {
-		return *r.stderr, nil
+// Any layer boundaries are removed.
+func (r *Container) Squash(opts ...ContainerSquashOpts) *Container {
+	q := r.q.Select("squash")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `from` optional argument
+		if !querybuilder.IsZeroValue(opts[i].From) {
+			q = q.Arg("from", opts[i].From)
+		}
+	}
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// The error stream of the last executed command.
+//
+// Will execute default command if none is set, or error if there's no default.
+func (r *Container) Stderr(ctx context.Context) (string, error) {
+	if r.stderr != nil {
+		return *r.stderr, nil
 	}
 	q := r.q.Select("stderr")
 
@@ -852,6 +1310,36 @@ func (r *Container) User(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// ContainerWithAnnotationOpts contains options for Container.WithAnnotation
+type ContainerWithAnnotationOpts struct {
+	// Set the annotation on the image index rather than the image manifest.
+	//
+	// An image index is always created when there are index annotations, even
+	// when publishing or exporting a single platform.
+	Index bool
+}
+
+// Retrieves this container plus the given OCI annotation.
+//
+// Annotations are set on the image manifest (or the image index) when the
+// container is published or exported.
+func (r *Container) WithAnnotation(name string, value string, opts ...ContainerWithAnnotationOpts) *Container {
+	q := r.q.Select("withAnnotation")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `index` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Index) {
+			q = q.Arg("index", opts[i].Index)
+		}
+	}
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // ContainerWithDefaultArgsOpts contains options for Container.WithDefaultArgs
 type ContainerWithDefaultArgsOpts struct {
 	// Arguments to prepend to future executions (e.g., ["-v", "--no-cache"]).
@@ -971,6 +1459,24 @@ type ContainerWithExecOpts struct {
 	// does not provide any security guarantees when using this option. It should only be used
 	// when absolutely necessary and only with trusted commands.
 	InsecureRootCapabilities bool
+	// Do not fail if the command exits with a non-zero code.
+	//
+	// The exit code is recorded instead, and can be retrieved along with the
+	// command's output using exitCode, stdout and stderr.
+	ExpectNonZeroExit bool
+	// Kill the command if it runs for longer than the given number of seconds.
+	//
+	// A command that times out fails with an EXEC_TIMEOUT_ERROR, even if
+	// expectNonZeroExit is set.
+	Timeout int
+	// Number of CPUs the command may use (e.g., 1.5).
+	CPULimit float64
+	// Maximum memory the command may use (e.g., "512m" or "2g").
+	MemoryLimit string
+	// Maximum number of processes the command may run at once.
+	PidsLimit int
+	// Per-process limits in the form name=soft[:hard] (e.g., ["nofile=1024:2048"]).
+	Ulimits []string
 }
 
 // Retrieves this container after executing the specified command inside it.
@@ -1001,6 +1507,30 @@ func (r *Container) WithExec(args []string, opts ...ContainerWithExecOpts) *Cont
 		if !querybuilder.IsZeroValue(opts[i].InsecureRootCapabilities) {
 			q = q.Arg("insecureRootCapabilities", opts[i].InsecureRootCapabilities)
 		}
+		// `expectNonZeroExit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExpectNonZeroExit) {
+			q = q.Arg("expectNonZeroExit", opts[i].ExpectNonZeroExit)
+		}
+		// `timeout` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Timeout) {
+			q = q.Arg("timeout", opts[i].Timeout)
+		}
+		// `cpuLimit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].CPULimit) {
+			q = q.Arg("cpuLimit", opts[i].CPULimit)
+		}
+		// `memoryLimit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MemoryLimit) {
+			q = q.Arg("memoryLimit", opts[i].MemoryLimit)
+		}
+		// `pidsLimit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PidsLimit) {
+			q = q.Arg("pidsLimit", opts[i].PidsLimit)
+		}
+		// `ulimits` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Ulimits) {
+			q = q.Arg("ulimits", opts[i].Ulimits)
+		}
 	}
 	q = q.Arg("args", args)
 
@@ -1016,6 +1546,11 @@ type ContainerWithExposedPortOpts struct {
 	Protocol NetworkProtocol
 	// Optional port description
 	Description string
+	// HTTP path to poll once the port accepts connections, e.g. /healthz.
+	//
+	// The service is only considered healthy once the path responds with a
+	// non-error status code. Only supported for TCP ports.
+	HealthcheckPath string
 }
 
 // Expose a network port.
@@ -1034,6 +1569,10 @@ func (r *Container) WithExposedPort(port int, opts ...ContainerWithExposedPortOp
 		if !querybuilder.IsZeroValue(opts[i].Description) {
 			q = q.Arg("description", opts[i].Description)
 		}
+		// `healthcheckPath` optional argument
+		if !querybuilder.IsZeroValue(opts[i].HealthcheckPath) {
+			q = q.Arg("healthcheckPath", opts[i].HealthcheckPath)
+		}
 	}
 	q = q.Arg("port", port)
 
@@ -1091,6 +1630,73 @@ func (r *Container) WithFocus() *Container {
 	}
 }
 
+// ContainerWithHealthcheckOpts contains options for Container.WithHealthcheck
+type ContainerWithHealthcheckOpts struct {
+	// Seconds to wait between checks (default: 1).
+	Interval int
+	// Seconds after which a single check fails (default: 30).
+	Timeout int
+	// Number of consecutive failures after which the service is unhealthy (default: 10).
+	Retries int
+	// Seconds the service is given to start, during which failures do not count towards retries.
+	StartPeriod int
+}
+
+// Configures a command to run periodically when the container is started as a
+// service, like the HEALTHCHECK instruction of a Dockerfile.
+//
+// The service is only considered started once the command exits 0, after any
+// exposed ports accept connections. Dependent services and containers wait for
+// it to be healthy.
+//
+// The command is stored as the HEALTHCHECK of the image config, replacing any
+// health check inherited from the base image, and is published with it. A
+// HEALTHCHECK set with withImageConfig or pulled with the image is run the same
+// way; set its Test to ["NONE"] to disable it.
+func (r *Container) WithHealthcheck(args []string, opts ...ContainerWithHealthcheckOpts) *Container {
+	q := r.q.Select("withHealthcheck")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `interval` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Interval) {
+			q = q.Arg("interval", opts[i].Interval)
+		}
+		// `timeout` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Timeout) {
+			q = q.Arg("timeout", opts[i].Timeout)
+		}
+		// `retries` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Retries) {
+			q = q.Arg("retries", opts[i].Retries)
+		}
+		// `startPeriod` optional argument
+		if !querybuilder.IsZeroValue(opts[i].StartPeriod) {
+			q = q.Arg("startPeriod", opts[i].StartPeriod)
+		}
+	}
+	q = q.Arg("args", args)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Retrieves this container with the given JSON merge patch (RFC 7386) applied
+// to its image config.
+//
+// Fields are named as in the Docker image config, e.g.
+// {"StopSignal": "SIGQUIT", "Volumes": {"/data": {}}, "OnBuild": null}.
+// Healthcheck durations are in nanoseconds.
+func (r *Container) WithImageConfig(patch JSON) *Container {
+	q := r.q.Select("withImageConfig")
+	q = q.Arg("patch", patch)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves this container plus the given label.
 func (r *Container) WithLabel(name string, value string) *Container {
 	q := r.q.Select("withLabel")
@@ -1103,6 +1709,21 @@ func (r *Container) WithLabel(name string, value string) *Container {
 	}
 }
 
+// Retrieves this container with a layer boundary at its current root
+// filesystem.
+//
+// When the image is published or exported, the layers added between two
+// boundaries are squashed into a single layer. Layers below the first boundary
+// and above the last one are kept as they are.
+func (r *Container) WithLayerBoundary() *Container {
+	q := r.q.Select("withLayerBoundary")
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // ContainerWithMountedCacheOpts contains options for Container.WithMountedCache
 type ContainerWithMountedCacheOpts struct {
 	// Identifier of the directory to use as the cache volume's root.
@@ -1271,6 +1892,36 @@ type ContainerWithNewFileOpts struct {
 	Owner string
 }
 
+// ContainerWithNetworkOpts contains options for Container.WithNetwork
+type ContainerWithNetworkOpts struct {
+	// Hostnames, IPs or CIDRs reachable in addition to bound services
+	// (e.g., ["proxy.golang.org", "10.0.0.0/8"]).
+	//
+	// Hostnames are resolved once, when each command starts, and only the
+	// addresses they resolve to at that time are reachable. Use a CIDR for
+	// destinations whose addresses may change while a command runs.
+	//
+	// Only allowed with the NETWORK_SERVICES_ONLY mode.
+	Allow []string
+}
+
+// Restricts the network access of commands run in this container.
+func (r *Container) WithNetwork(mode NetworkMode, opts ...ContainerWithNetworkOpts) *Container {
+	q := r.q.Select("withNetwork")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `allow` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Allow) {
+			q = q.Arg("allow", opts[i].Allow)
+		}
+	}
+	q = q.Arg("mode", mode)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves this container plus a new file written at the given path.
 func (r *Container) WithNewFile(path string, opts ...ContainerWithNewFileOpts) *Container {
 	q := r.q.Select("withNewFile")
@@ -1310,6 +1961,48 @@ func (r *Container) WithRegistryAuth(address string, username string, secret *Se
 	}
 }
 
+// ContainerWithResourceLimitsOpts contains options for Container.WithResourceLimits
+type ContainerWithResourceLimitsOpts struct {
+	// Number of CPUs the command may use (e.g., 1.5).
+	CPULimit float64
+	// Maximum memory the command may use (e.g., "512m" or "2g").
+	MemoryLimit string
+	// Maximum number of processes the command may run at once.
+	PidsLimit int
+	// Per-process limits in the form name=soft[:hard] (e.g., ["nofile=1024:2048"]).
+	Ulimits []string
+}
+
+// Sets the default resource limits for commands run in this container.
+//
+// Limits set on withExec take precedence over the defaults.
+func (r *Container) WithResourceLimits(opts ...ContainerWithResourceLimitsOpts) *Container {
+	q := r.q.Select("withResourceLimits")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `cpuLimit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].CPULimit) {
+			q = q.Arg("cpuLimit", opts[i].CPULimit)
+		}
+		// `memoryLimit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MemoryLimit) {
+			q = q.Arg("memoryLimit", opts[i].MemoryLimit)
+		}
+		// `pidsLimit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PidsLimit) {
+			q = q.Arg("pidsLimit", opts[i].PidsLimit)
+		}
+		// `ulimits` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Ulimits) {
+			q = q.Arg("ulimits", opts[i].Ulimits)
+		}
+	}
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Initializes this container from this DirectoryID.
 func (r *Container) WithRootfs(directory *Directory) *Container {
 	assertNotNil("directory", directory)
@@ -1335,6 +2028,13 @@ func (r *Container) WithSecretVariable(name string, secret *Secret) *Container {
 	}
 }
 
+// ContainerWithServiceBindingOpts contains options for Container.WithServiceBinding
+type ContainerWithServiceBindingOpts struct {
+	// Aliases of services already bound to the container that must be started
+	// and healthy before this service is started (e.g., ["db"]).
+	DependsOn []string
+}
+
 // Establish a runtime dependency on a service.
 //
 // The service will be started automatically when needed and detached when it is
@@ -1343,9 +2043,19 @@ func (r *Container) WithSecretVariable(name string, secret *Secret) *Container {
 // The service will be reachable from the container via the provided hostname alias.
 //
 // The service dependency will also convey to any files or directories produced by the container.
-func (r *Container) WithServiceBinding(alias string, service *Service) *Container {
+//
+// Bound services are started in parallel unless they depend on each other, in
+// which case each service is started only once its dependencies are healthy, and
+// stopped before them.
+func (r *Container) WithServiceBinding(alias string, service *Service, opts ...ContainerWithServiceBindingOpts) *Container {
 	assertNotNil("service", service)
 	q := r.q.Select("withServiceBinding")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `dependsOn` optional argument
+		if !querybuilder.IsZeroValue(opts[i].DependsOn) {
+			q = q.Arg("dependsOn", opts[i].DependsOn)
+		}
+	}
 	q = q.Arg("alias", alias)
 	q = q.Arg("service", service)
 
@@ -1406,6 +2116,29 @@ func (r *Container) WithWorkdir(path string) *Container {
 	}
 }
 
+// ContainerWithoutAnnotationOpts contains options for Container.WithoutAnnotation
+type ContainerWithoutAnnotationOpts struct {
+	// Remove the annotation from the image index rather than the image manifest.
+	Index bool
+}
+
+// Retrieves this container minus the given OCI annotation.
+func (r *Container) WithoutAnnotation(name string, opts ...ContainerWithoutAnnotationOpts) *Container {
+	q := r.q.Select("withoutAnnotation")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `index` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Index) {
+			q = q.Arg("index", opts[i].Index)
+		}
+	}
+	q = q.Arg("name", name)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves this container minus the given environment variable.
 func (r *Container) WithoutEnvVariable(name string) *Container {
 	q := r.q.Select("withoutEnvVariable")
@@ -1510,6 +2243,84 @@ func (r *Container) Workdir(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// A layer of a container's root filesystem.
+type ContainerLayer struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	createdBy *string
+	diffID    *string
+	digest    *string
+	mediaType *string
+	size      *int
+}
+
+// The command that produced the layer, or a description of where it came from
+// (e.g., a pulled image layer).
+func (r *ContainerLayer) CreatedBy(ctx context.Context) (string, error) {
+	if r.createdBy != nil {
+		return *r.createdBy, nil
+	}
+	q := r.q.Select("createdBy")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The digest of the uncompressed layer.
+func (r *ContainerLayer) DiffID(ctx context.Context) (string, error) {
+	if r.diffID != nil {
+		return *r.diffID, nil
+	}
+	q := r.q.Select("diffID")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The digest of the compressed layer.
+func (r *ContainerLayer) Digest(ctx context.Context) (string, error) {
+	if r.digest != nil {
+		return *r.digest, nil
+	}
+	q := r.q.Select("digest")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The media type of the layer.
+func (r *ContainerLayer) MediaType(ctx context.Context) (string, error) {
+	if r.mediaType != nil {
+		return *r.mediaType, nil
+	}
+	q := r.q.Select("mediaType")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The size of the compressed layer in bytes.
+func (r *ContainerLayer) Size(ctx context.Context) (int, error) {
+	if r.size != nil {
+		return *r.size, nil
+	}
+	q := r.q.Select("size")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // A directory.
 type Directory struct {
 	q *querybuilder.Selection
@@ -1528,6 +2339,38 @@ func (r *Directory) With(f WithDirectoryFunc) *Directory {
 	return f(r)
 }
 
+// DirectoryAsArchiveOpts contains options for Directory.AsArchive
+type DirectoryAsArchiveOpts struct {
+	// Format of the archive.
+	Format ArchiveFormat
+	// Compression of a tar archive: Gzip, Zstd or Uncompressed (the default).
+	//
+	// Zip archives are always compressed.
+	Compression ImageLayerCompression
+}
+
+// Returns an archive of this directory's contents.
+//
+// Entries are written in lexical order with a fixed modification time, so the same contents always produce the same archive.
+func (r *Directory) AsArchive(opts ...DirectoryAsArchiveOpts) *File {
+	q := r.q.Select("asArchive")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `format` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Format) {
+			q = q.Arg("format", opts[i].Format)
+		}
+		// `compression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Compression) {
+			q = q.Arg("compression", opts[i].Compression)
+		}
+	}
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
 // DirectoryAsModuleOpts contains options for Directory.AsModule
 type DirectoryAsModuleOpts struct {
 	// An optional subpath of the directory which contains the module's source
@@ -1559,6 +2402,67 @@ func (r *Directory) AsModule(opts ...DirectoryAsModuleOpts) *Module {
 	}
 }
 
+// Returns a unified diff of the changes that turn this directory into another
+// directory, in the format of git diff.
+//
+// Changes to directories, ownership and modification times are not part of
+// the patch. Changes to binary files are only noted with a "Binary files ...
+// differ" line, so a patch that contains them cannot be applied with withPatch.
+func (r *Directory) AsPatch(other *Directory) *File {
+	assertNotNil("other", other)
+	q := r.q.Select("asPatch")
+	q = q.Arg("other", other)
+
+	return &File{
+		q: q,
+		c: r.c,
+	}
+}
+
+// The changes that turn this directory into another directory.
+//
+// Paths whose contents and metadata only differ by their modification time
+// are not reported, and files that were moved without changing their contents
+// are reported as renames.
+func (r *Directory) Changes(ctx context.Context, other *Directory) ([]FileChange, error) {
+	assertNotNil("other", other)
+	q := r.q.Select("changes")
+	q = q.Arg("other", other)
+
+	q = q.Select("kind oldPath oldPermissions path permissions size type")
+
+	type changes struct {
+		Kind           ChangeKind
+		OldPath        string
+		OldPermissions int
+		Path           string
+		Permissions    int
+		Size           int
+		Type           FileType
+	}
+
+	convert := func(fields []changes) []FileChange {
+		out := []FileChange{}
+
+		for i := range fields {
+			val := FileChange{kind: &fields[i].Kind, oldPath: &fields[i].OldPath, oldPermissions: &fields[i].OldPermissions, path: &fields[i].Path, permissions: &fields[i].Permissions, size: &fields[i].Size, type_: &fields[i].Type}
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []changes
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
 // Gets the difference between this directory and an another directory.
 func (r *Directory) Diff(other *Directory) *Directory {
 	assertNotNil("other", other)
@@ -1571,6 +2475,37 @@ func (r *Directory) Diff(other *Directory) *Directory {
 	}
 }
 
+// DirectoryDigestOpts contains options for Directory.Digest
+type DirectoryDigestOpts struct {
+	// Exclude entries matching the given patterns from the hash (e.g., ["node_modules"]).
+	Exclude []string
+	// Only hash entries matching the given patterns (e.g., ["**/*.go"]).
+	Include []string
+}
+
+// Returns a hash of this directory's contents (e.g., "sha256:...").
+//
+// The hash covers the names, contents, permissions and ownership of the
+// directory's entries, but not their modification times.
+func (r *Directory) Digest(ctx context.Context, opts ...DirectoryDigestOpts) (string, error) {
+	q := r.q.Select("digest")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `exclude` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Exclude) {
+			q = q.Arg("exclude", opts[i].Exclude)
+		}
+		// `include` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Include) {
+			q = q.Arg("include", opts[i].Include)
+		}
+	}
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // Retrieves a directory at the given path.
 func (r *Directory) Directory(path string) *Directory {
 	q := r.q.Select("directory")
@@ -1654,12 +2589,32 @@ func (r *Directory) Entries(ctx context.Context, opts ...DirectoryEntriesOpts) (
 	return response, q.Execute(ctx, r.c)
 }
 
+// DirectoryExportOpts contains options for Directory.Export
+type DirectoryExportOpts struct {
+	// Exclude artifacts that match the given pattern (e.g., [".git", "**/.env"]).
+	// Excluded paths are neither written nor removed on the host.
+	Exclude []string
+	// Remove any files on the host path that aren't in this directory, so that
+	// it mirrors the directory exactly.
+	Mirror bool
+}
+
 // Writes the contents of the directory to a path on the host.
-func (r *Directory) Export(ctx context.Context, path string) (bool, error) {
+func (r *Directory) Export(ctx context.Context, path string, opts ...DirectoryExportOpts) (bool, error) {
 	if r.export != nil {
 		return *r.export, nil
 	}
 	q := r.q.Select("export")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `exclude` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Exclude) {
+			q = q.Arg("exclude", opts[i].Exclude)
+		}
+		// `mirror` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Mirror) {
+			q = q.Arg("mirror", opts[i].Mirror)
+		}
+	}
 	q = q.Arg("path", path)
 
 	var response bool
@@ -1668,7 +2623,66 @@ func (r *Directory) Export(ctx context.Context, path string) (bool, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
-// Retrieves a file at the given path.
+// DirectoryExportChangesOpts contains options for Directory.ExportChanges
+type DirectoryExportChangesOpts struct {
+	// Exclude artifacts that match the given pattern (e.g., [".git", "**/.env"]).
+	Exclude []string
+	// Whether the export would remove files on the host path that aren't in
+	// this directory.
+	Mirror bool
+}
+
+// Returns the changes that exporting this directory to a path on the host
+// would make, without writing anything.
+func (r *Directory) ExportChanges(ctx context.Context, path string, opts ...DirectoryExportChangesOpts) ([]FileChange, error) {
+	q := r.q.Select("exportChanges")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `exclude` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Exclude) {
+			q = q.Arg("exclude", opts[i].Exclude)
+		}
+		// `mirror` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Mirror) {
+			q = q.Arg("mirror", opts[i].Mirror)
+		}
+	}
+	q = q.Arg("path", path)
+
+	q = q.Select("kind oldPath oldPermissions path permissions size type")
+
+	type exportChanges struct {
+		Kind           ChangeKind
+		OldPath        string
+		OldPermissions int
+		Path           string
+		Permissions    int
+		Size           int
+		Type           FileType
+	}
+
+	convert := func(fields []exportChanges) []FileChange {
+		out := []FileChange{}
+
+		for i := range fields {
+			val := FileChange{kind: &fields[i].Kind, oldPath: &fields[i].OldPath, oldPermissions: &fields[i].OldPermissions, path: &fields[i].Path, permissions: &fields[i].Permissions, size: &fields[i].Size, type_: &fields[i].Type}
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []exportChanges
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// Retrieves a file at the given path.
 func (r *Directory) File(path string) *File {
 	q := r.q.Select("file")
 	q = q.Arg("path", path)
@@ -1759,6 +2773,17 @@ func (r *Directory) Pipeline(name string, opts ...DirectoryPipelineOpts) *Direct
 	}
 }
 
+// Returns metadata for the file, directory or symlink at the given path.
+func (r *Directory) Stat(path string) *DirectoryEntry {
+	q := r.q.Select("stat")
+	q = q.Arg("path", path)
+
+	return &DirectoryEntry{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Force evaluation in the engine.
 func (r *Directory) Sync(ctx context.Context) (*Directory, error) {
 	q := r.q.Select("sync")
@@ -1766,6 +2791,81 @@ func (r *Directory) Sync(ctx context.Context) (*Directory, error) {
 	return r, q.Execute(ctx, r.c)
 }
 
+// DirectoryWalkOpts contains options for Directory.Walk
+type DirectoryWalkOpts struct {
+	// Location of the directory to walk (e.g., "/src").
+	Path string
+	// Only return entries matching the given patterns (e.g., ["**/*.go"]).
+	//
+	// Patterns are relative to the walked path.
+	Include []string
+	// Skip entries matching the given patterns (e.g., ["node_modules"]).
+	//
+	// Excluded directories are not walked. Patterns are relative to the walked path.
+	Exclude []string
+	// How many levels of subdirectories to walk, e.g. 1 for only the immediate
+	// entries. Walks the entire tree if unset.
+	MaxDepth int
+}
+
+// Returns metadata for every file, directory and symlink under the given path,
+// recursing into subdirectories.
+//
+// Returned paths are relative to this directory.
+func (r *Directory) Walk(ctx context.Context, opts ...DirectoryWalkOpts) ([]DirectoryEntry, error) {
+	q := r.q.Select("walk")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `path` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Path) {
+			q = q.Arg("path", opts[i].Path)
+		}
+		// `include` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Include) {
+			q = q.Arg("include", opts[i].Include)
+		}
+		// `exclude` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Exclude) {
+			q = q.Arg("exclude", opts[i].Exclude)
+		}
+		// `maxDepth` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MaxDepth) {
+			q = q.Arg("maxDepth", opts[i].MaxDepth)
+		}
+	}
+
+	q = q.Select("modifiedAt path permissions size symlinkTarget type")
+
+	type walk struct {
+		ModifiedAt    int
+		Path          string
+		Permissions   int
+		Size          int
+		SymlinkTarget string
+		Type          FileType
+	}
+
+	convert := func(fields []walk) []DirectoryEntry {
+		out := []DirectoryEntry{}
+
+		for i := range fields {
+			val := DirectoryEntry{modifiedAt: &fields[i].ModifiedAt, path: &fields[i].Path, permissions: &fields[i].Permissions, size: &fields[i].Size, symlinkTarget: &fields[i].SymlinkTarget, type_: &fields[i].Type}
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []walk
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
 // DirectoryWithDirectoryOpts contains options for Directory.WithDirectory
 type DirectoryWithDirectoryOpts struct {
 	// Exclude artifacts that match the given pattern (e.g., ["node_modules/", ".git*"]).
@@ -1875,6 +2975,23 @@ func (r *Directory) WithNewFile(path string, contents string, opts ...DirectoryW
 	}
 }
 
+// Retrieves this directory with a unified diff applied to it.
+//
+// The first component of the paths in the patch is stripped, as with
+// patch -p1 (e.g., "a/src/main.go" patches "src/main.go").
+//
+// Binary patches are not supported.
+func (r *Directory) WithPatch(patch *File) *Directory {
+	assertNotNil("patch", patch)
+	q := r.q.Select("withPatch")
+	q = q.Arg("patch", patch)
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves this directory with all file/dir timestamps set to the given time.
 func (r *Directory) WithTimestamps(timestamp int) *Directory {
 	q := r.q.Select("withTimestamps")
@@ -1908,6 +3025,97 @@ func (r *Directory) WithoutFile(path string) *Directory {
 	}
 }
 
+// Metadata describing an entry in a directory.
+type DirectoryEntry struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	modifiedAt    *int
+	path          *string
+	permissions   *int
+	size          *int
+	symlinkTarget *string
+	type_         *FileType
+}
+
+// The entry's modification time, as a Unix timestamp.
+func (r *DirectoryEntry) ModifiedAt(ctx context.Context) (int, error) {
+	if r.modifiedAt != nil {
+		return *r.modifiedAt, nil
+	}
+	q := r.q.Select("modifiedAt")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The path of the entry, relative to the directory (e.g., "src/main.go").
+func (r *DirectoryEntry) Path(ctx context.Context) (string, error) {
+	if r.path != nil {
+		return *r.path, nil
+	}
+	q := r.q.Select("path")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The entry's permission bits (e.g., 0644).
+func (r *DirectoryEntry) Permissions(ctx context.Context) (int, error) {
+	if r.permissions != nil {
+		return *r.permissions, nil
+	}
+	q := r.q.Select("permissions")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The size of the entry in bytes.
+func (r *DirectoryEntry) Size(ctx context.Context) (int, error) {
+	if r.size != nil {
+		return *r.size, nil
+	}
+	q := r.q.Select("size")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The target of the entry, if it is a symlink.
+func (r *DirectoryEntry) SymlinkTarget(ctx context.Context) (string, error) {
+	if r.symlinkTarget != nil {
+		return *r.symlinkTarget, nil
+	}
+	q := r.q.Select("symlinkTarget")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The type of the entry.
+func (r *DirectoryEntry) Type(ctx context.Context) (FileType, error) {
+	if r.type_ != nil {
+		return *r.type_, nil
+	}
+	q := r.q.Select("type")
+
+	var response FileType
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // A simple key value object that represents an environment variable.
 type EnvVariable struct {
 	q *querybuilder.Selection
@@ -2023,6 +3231,28 @@ func (r *File) Contents(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// FileDigestOpts contains options for File.Digest
+type FileDigestOpts struct {
+	// The hash algorithm to use.
+	Algorithm DigestAlgorithm
+}
+
+// Returns the digest of the file's contents (e.g., "sha256:...").
+func (r *File) Digest(ctx context.Context, opts ...FileDigestOpts) (string, error) {
+	q := r.q.Select("digest")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `algorithm` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Algorithm) {
+			q = q.Arg("algorithm", opts[i].Algorithm)
+		}
+	}
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // FileExportOpts contains options for File.Export
 type FileExportOpts struct {
 	// If allowParentDirPath is true, the path argument can be a directory path, in which case
@@ -2050,6 +3280,18 @@ func (r *File) Export(ctx context.Context, path string, opts ...FileExportOpts)
 	return response, q.Execute(ctx, r.c)
 }
 
+// Extracts this file as an archive into a directory.
+//
+// Tar archives (optionally compressed with gzip or zstd) and zip archives are supported. The format is detected from the file's contents.
+func (r *File) Extract() *Directory {
+	q := r.q.Select("extract")
+
+	return &Directory{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Retrieves the content-addressed identifier of the file.
 func (r *File) ID(ctx context.Context) (FileID, error) {
 	if r.id != nil {
@@ -2121,6 +3363,112 @@ func (r *File) WithTimestamps(timestamp int) *File {
 	}
 }
 
+// A change to a path between two directories.
+type FileChange struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	kind           *ChangeKind
+	oldPath        *string
+	oldPermissions *int
+	path           *string
+	permissions    *int
+	size           *int
+	type_          *FileType
+}
+
+// The kind of change.
+func (r *FileChange) Kind(ctx context.Context) (ChangeKind, error) {
+	if r.kind != nil {
+		return *r.kind, nil
+	}
+	q := r.q.Select("kind")
+
+	var response ChangeKind
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The path the entry was renamed from, if it was renamed.
+func (r *FileChange) OldPath(ctx context.Context) (string, error) {
+	if r.oldPath != nil {
+		return *r.oldPath, nil
+	}
+	q := r.q.Select("oldPath")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The entry's permission bits before the change, or 0 if it was added.
+func (r *FileChange) OldPermissions(ctx context.Context) (int, error) {
+	if r.oldPermissions != nil {
+		return *r.oldPermissions, nil
+	}
+	q := r.q.Select("oldPermissions")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The path of the entry, relative to the directory (e.g., "src/main.go").
+func (r *FileChange) Path(ctx context.Context) (string, error) {
+	if r.path != nil {
+		return *r.path, nil
+	}
+	q := r.q.Select("path")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The entry's permission bits after the change (e.g., 0644), or 0 if it was deleted.
+func (r *FileChange) Permissions(ctx context.Context) (int, error) {
+	if r.permissions != nil {
+		return *r.permissions, nil
+	}
+	q := r.q.Select("permissions")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The size of the entry after the change in bytes, or 0 if it was deleted or
+// is not a regular file.
+func (r *FileChange) Size(ctx context.Context) (int, error) {
+	if r.size != nil {
+		return *r.size, nil
+	}
+	q := r.q.Select("size")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The type of the entry, or its former type if it was deleted.
+func (r *FileChange) Type(ctx context.Context) (FileType, error) {
+	if r.type_ != nil {
+		return *r.type_, nil
+	}
+	q := r.q.Select("type")
+
+	var response FileType
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // Function represents a resolver provided by a Module.
 //
 // A function always evaluates against a parent object and is given a set of
@@ -2735,6 +4083,10 @@ type HostDirectoryOpts struct {
 	Exclude []string
 	// Include only artifacts that match the given pattern (e.g., ["app/", "package.*"]).
 	Include []string
+	// Exclude artifacts ignored by the .gitignore files in the directory and its subdirectories.
+	Gitignore bool
+	// Exclude artifacts ignored by the given .dockerignore-style file, relative to the directory (e.g., ".dockerignore").
+	IgnoreFile string
 }
 
 // Accesses a directory on the host.
@@ -2749,6 +4101,14 @@ func (r *Host) Directory(path string, opts ...HostDirectoryOpts) *Directory {
 		if !querybuilder.IsZeroValue(opts[i].Include) {
 			q = q.Arg("include", opts[i].Include)
 		}
+		// `gitignore` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Gitignore) {
+			q = q.Arg("gitignore", opts[i].Gitignore)
+		}
+		// `ignoreFile` optional argument
+		if !querybuilder.IsZeroValue(opts[i].IgnoreFile) {
+			q = q.Arg("ignoreFile", opts[i].IgnoreFile)
+		}
 	}
 	q = q.Arg("path", path)
 
@@ -2769,93 +4129,456 @@ func (r *Host) File(path string) *File {
 	}
 }
 
-// HostServiceOpts contains options for Host.Service
-type HostServiceOpts struct {
-	// Upstream host to forward traffic to.
-	Host string
+// HostServiceOpts contains options for Host.Service
+type HostServiceOpts struct {
+	// Upstream host to forward traffic to.
+	Host string
+}
+
+// Creates a service that forwards traffic to a specified address via the host.
+func (r *Host) Service(ports []PortForward, opts ...HostServiceOpts) *Service {
+	q := r.q.Select("service")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `host` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Host) {
+			q = q.Arg("host", opts[i].Host)
+		}
+	}
+	q = q.Arg("ports", ports)
+
+	return &Service{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Sets a secret given a user-defined name and the file path on the host, and returns the secret.
+// The file is limited to a size of 512000 bytes.
+func (r *Host) SetSecretFile(name string, path string) *Secret {
+	q := r.q.Select("setSecretFile")
+	q = q.Arg("name", name)
+	q = q.Arg("path", path)
+
+	return &Secret{
+		q: q,
+		c: r.c,
+	}
+}
+
+// HostTunnelOpts contains options for Host.Tunnel
+type HostTunnelOpts struct {
+	// Map each service port to the same port on the host, as if the service were
+	// running natively.
+	//
+	// Note: enabling may result in port conflicts.
+	Native bool
+	// Configure explicit port forwarding rules for the tunnel.
+	//
+	// If a port's frontend is unspecified or 0, a random port will be chosen by
+	// the host.
+	//
+	// If no ports are given, all of the service's ports are forwarded. If native
+	// is true, each port maps to the same port on the host. If native is false,
+	// each port maps to a random port chosen by the host.
+	//
+	// If ports are given and native is true, the ports are additive.
+	Ports []PortForward
+}
+
+// Creates a tunnel that forwards traffic from the host to a service.
+func (r *Host) Tunnel(service *Service, opts ...HostTunnelOpts) *Service {
+	assertNotNil("service", service)
+	q := r.q.Select("tunnel")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `native` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Native) {
+			q = q.Arg("native", opts[i].Native)
+		}
+		// `ports` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Ports) {
+			q = q.Arg("ports", opts[i].Ports)
+		}
+	}
+	q = q.Arg("service", service)
+
+	return &Service{
+		q: q,
+		c: r.c,
+	}
+}
+
+// Accesses a Unix socket on the host.
+func (r *Host) UnixSocket(path string) *Socket {
+	q := r.q.Select("unixSocket")
+	q = q.Arg("path", path)
+
+	return &Socket{
+		q: q,
+		c: r.c,
+	}
+}
+
+// A layer of an image.
+type ImageLayer struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	digest    *string
+	mediaType *string
+	size      *int
+}
+
+// The digest of the compressed layer.
+func (r *ImageLayer) Digest(ctx context.Context) (string, error) {
+	if r.digest != nil {
+		return *r.digest, nil
+	}
+	q := r.q.Select("digest")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The media type of the layer.
+func (r *ImageLayer) MediaType(ctx context.Context) (string, error) {
+	if r.mediaType != nil {
+		return *r.mediaType, nil
+	}
+	q := r.q.Select("mediaType")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The compressed size of the layer, in bytes.
+func (r *ImageLayer) Size(ctx context.Context) (int, error) {
+	if r.size != nil {
+		return *r.size, nil
+	}
+	q := r.q.Select("size")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The manifest of a single platform of an image.
+type ImageManifest struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	config    *JSON
+	digest    *string
+	mediaType *string
+	platform  *Platform
+}
+
+// The annotations of the manifest.
+func (r *ImageManifest) Annotations(ctx context.Context) ([]Label, error) {
+	q := r.q.Select("annotations")
+
+	q = q.Select("name value")
+
+	type annotations struct {
+		Name  string
+		Value string
+	}
+
+	convert := func(fields []annotations) []Label {
+		out := []Label{}
+
+		for i := range fields {
+			val := Label{name: &fields[i].Name, value: &fields[i].Value}
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []annotations
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// The image config.
+func (r *ImageManifest) Config(ctx context.Context) (JSON, error) {
+	if r.config != nil {
+		return *r.config, nil
+	}
+	q := r.q.Select("config")
+
+	var response JSON
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The digest of the manifest.
+func (r *ImageManifest) Digest(ctx context.Context) (string, error) {
+	if r.digest != nil {
+		return *r.digest, nil
+	}
+	q := r.q.Select("digest")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The labels set in the image config.
+func (r *ImageManifest) Labels(ctx context.Context) ([]Label, error) {
+	q := r.q.Select("labels")
+
+	q = q.Select("name value")
+
+	type labels struct {
+		Name  string
+		Value string
+	}
+
+	convert := func(fields []labels) []Label {
+		out := []Label{}
+
+		for i := range fields {
+			val := Label{name: &fields[i].Name, value: &fields[i].Value}
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []labels
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// The layers of the manifest.
+func (r *ImageManifest) Layers(ctx context.Context) ([]ImageLayer, error) {
+	q := r.q.Select("layers")
+
+	q = q.Select("digest mediaType size")
+
+	type layers struct {
+		Digest    string
+		MediaType string
+		Size      int
+	}
+
+	convert := func(fields []layers) []ImageLayer {
+		out := []ImageLayer{}
+
+		for i := range fields {
+			val := ImageLayer{digest: &fields[i].Digest, mediaType: &fields[i].MediaType, size: &fields[i].Size}
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []layers
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// The media type of the manifest.
+func (r *ImageManifest) MediaType(ctx context.Context) (string, error) {
+	if r.mediaType != nil {
+		return *r.mediaType, nil
+	}
+	q := r.q.Select("mediaType")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The platform of the manifest.
+func (r *ImageManifest) Platform(ctx context.Context) (Platform, error) {
+	if r.platform != nil {
+		return *r.platform, nil
+	}
+	q := r.q.Select("platform")
+
+	var response Platform
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The metadata of an image in a registry.
+type ImageMetadata struct {
+	q *querybuilder.Selection
+	c graphql.Client
+
+	digest    *string
+	mediaType *string
+	ref       *string
+}
+
+// The annotations of the image's index or manifest.
+func (r *ImageMetadata) Annotations(ctx context.Context) ([]Label, error) {
+	q := r.q.Select("annotations")
+
+	q = q.Select("name value")
+
+	type annotations struct {
+		Name  string
+		Value string
+	}
+
+	convert := func(fields []annotations) []Label {
+		out := []Label{}
+
+		for i := range fields {
+			val := Label{name: &fields[i].Name, value: &fields[i].Value}
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []annotations
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// The digest of the image's index or manifest.
+func (r *ImageMetadata) Digest(ctx context.Context) (string, error) {
+	if r.digest != nil {
+		return *r.digest, nil
+	}
+	q := r.q.Select("digest")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// ImageMetadataManifestOpts contains options for ImageMetadata.Manifest
+type ImageMetadataManifestOpts struct {
+	Platform Platform
 }
 
-// Creates a service that forwards traffic to a specified address via the host.
-func (r *Host) Service(ports []PortForward, opts ...HostServiceOpts) *Service {
-	q := r.q.Select("service")
+// The manifest of a single platform of the image.
+//
+// Defaults to the only platform of a single-platform image, or the engine's
+// default platform.
+func (r *ImageMetadata) Manifest(opts ...ImageMetadataManifestOpts) *ImageManifest {
+	q := r.q.Select("manifest")
 	for i := len(opts) - 1; i >= 0; i-- {
-		// `host` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Host) {
-			q = q.Arg("host", opts[i].Host)
+		// `platform` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Platform) {
+			q = q.Arg("platform", opts[i].Platform)
 		}
 	}
-	q = q.Arg("ports", ports)
 
-	return &Service{
+	return &ImageManifest{
 		q: q,
 		c: r.c,
 	}
 }
 
-// Sets a secret given a user-defined name and the file path on the host, and returns the secret.
-// The file is limited to a size of 512000 bytes.
-func (r *Host) SetSecretFile(name string, path string) *Secret {
-	q := r.q.Select("setSecretFile")
-	q = q.Arg("name", name)
-	q = q.Arg("path", path)
+// The manifest of each platform of the image.
+func (r *ImageMetadata) Manifests(ctx context.Context) ([]ImageManifest, error) {
+	q := r.q.Select("manifests")
 
-	return &Secret{
-		q: q,
-		c: r.c,
+	q = q.Select("config digest mediaType platform")
+
+	type manifests struct {
+		Config    JSON
+		Digest    string
+		MediaType string
+		Platform  Platform
 	}
-}
 
-// HostTunnelOpts contains options for Host.Tunnel
-type HostTunnelOpts struct {
-	// Map each service port to the same port on the host, as if the service were
-	// running natively.
-	//
-	// Note: enabling may result in port conflicts.
-	Native bool
-	// Configure explicit port forwarding rules for the tunnel.
-	//
-	// If a port's frontend is unspecified or 0, a random port will be chosen by
-	// the host.
-	//
-	// If no ports are given, all of the service's ports are forwarded. If native
-	// is true, each port maps to the same port on the host. If native is false,
-	// each port maps to a random port chosen by the host.
-	//
-	// If ports are given and native is true, the ports are additive.
-	Ports []PortForward
-}
+	convert := func(fields []manifests) []ImageManifest {
+		out := []ImageManifest{}
 
-// Creates a tunnel that forwards traffic from the host to a service.
-func (r *Host) Tunnel(service *Service, opts ...HostTunnelOpts) *Service {
-	assertNotNil("service", service)
-	q := r.q.Select("tunnel")
-	for i := len(opts) - 1; i >= 0; i-- {
-		// `native` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Native) {
-			q = q.Arg("native", opts[i].Native)
-		}
-		// `ports` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Ports) {
-			q = q.Arg("ports", opts[i].Ports)
+		for i := range fields {
+			val := ImageManifest{config: &fields[i].Config, digest: &fields[i].Digest, mediaType: &fields[i].MediaType, platform: &fields[i].Platform}
+			out = append(out, val)
 		}
+
+		return out
 	}
-	q = q.Arg("service", service)
+	var response []manifests
 
-	return &Service{
-		q: q,
-		c: r.c,
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
 	}
+
+	return convert(response), nil
 }
 
-// Accesses a Unix socket on the host.
-func (r *Host) UnixSocket(path string) *Socket {
-	q := r.q.Select("unixSocket")
-	q = q.Arg("path", path)
+// The media type of the image's index or manifest.
+func (r *ImageMetadata) MediaType(ctx context.Context) (string, error) {
+	if r.mediaType != nil {
+		return *r.mediaType, nil
+	}
+	q := r.q.Select("mediaType")
 
-	return &Socket{
-		q: q,
-		c: r.c,
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The platforms of the image.
+func (r *ImageMetadata) Platforms(ctx context.Context) ([]Platform, error) {
+	q := r.q.Select("platforms")
+
+	var response []Platform
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The fully qualified ref of the image, including its digest.
+func (r *ImageMetadata) Ref(ctx context.Context) (string, error) {
+	if r.ref != nil {
+		return *r.ref, nil
 	}
+	q := r.q.Select("ref")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
 }
 
 // A simple key value object that represents a label.
@@ -3333,9 +5056,10 @@ type Port struct {
 	q *querybuilder.Selection
 	c graphql.Client
 
-	description *string
-	port        *int
-	protocol    *NetworkProtocol
+	description     *string
+	healthcheckPath *string
+	port            *int
+	protocol        *NetworkProtocol
 }
 
 // The port description.
@@ -3351,6 +5075,19 @@ func (r *Port) Description(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx, r.c)
 }
 
+// The HTTP path polled to check the health of the service, if any.
+func (r *Port) HealthcheckPath(ctx context.Context) (string, error) {
+	if r.healthcheckPath != nil {
+		return *r.healthcheckPath, nil
+	}
+	q := r.q.Select("healthcheckPath")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // The port number.
 func (r *Port) Port(ctx context.Context) (int, error) {
 	if r.port != nil {
@@ -3397,6 +5134,40 @@ func (r *Client) CacheVolume(key string) *CacheVolume {
 	}
 }
 
+// Lists the cache volumes stored on the engine.
+func (r *Client) CacheVolumes(ctx context.Context) ([]CacheVolume, error) {
+	q := r.q.Select("cacheVolumes")
+
+	q = q.Select("id")
+
+	type cacheVolumes struct {
+		Id CacheVolumeID
+	}
+
+	convert := func(fields []cacheVolumes) []CacheVolume {
+		out := []CacheVolume{}
+
+		for i := range fields {
+			val := CacheVolume{id: &fields[i].Id}
+			val.q = querybuilder.Query().Select("loadCacheVolumeFromID").Arg("id", fields[i].Id)
+			val.c = r.c
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []cacheVolumes
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
 // Checks if the current Dagger Engine is compatible with an SDK's required version.
 func (r *Client) CheckVersionCompatibility(ctx context.Context, version string) (bool, error) {
 	q := r.q.Select("checkVersionCompatibility")
@@ -3438,6 +5209,21 @@ func (r *Client) Container(opts ...ContainerOpts) *Container {
 	}
 }
 
+// Copies an image between registries without unpacking it, including every
+// platform of a multi-platform image.
+//
+// Returns the fully qualified ref of each destination, in the same order.
+func (r *Client) CopyImage(ctx context.Context, source string, destinations []string) ([]string, error) {
+	q := r.q.Select("copyImage")
+	q = q.Arg("source", source)
+	q = q.Arg("destinations", destinations)
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // The FunctionCall context that the SDK caller is currently executing in.
 // If the caller is not currently executing in a function, this will return
 // an error.
@@ -3460,6 +5246,26 @@ func (r *Client) CurrentModule() *Module {
 	}
 }
 
+// Loads the state of a failed exec as a container, with the exec's filesystem,
+// environment, mounts and working directory.
+//
+// Cache mounts, tmpfs mounts, secrets, sockets and service bindings are not
+// restored.
+//
+// Failed execs are only kept if the session was started to debug them (e.g.,
+// with dagger call --debug-on-failure or dagger run --interactive), and only
+// the 10 most recent ones. If a module function fails, its debug ID is the one
+// of the last exec that failed in the function, if any.
+func (r *Client) DebugContainer(id string) *Container {
+	q := r.q.Select("debugContainer")
+	q = q.Arg("id", id)
+
+	return &Container{
+		q: q,
+		c: r.c,
+	}
+}
+
 // The default platform of the builder.
 func (r *Client) DefaultPlatform(ctx context.Context) (Platform, error) {
 	q := r.q.Select("defaultPlatform")
@@ -3604,6 +5410,17 @@ func (r *Client) HTTP(url string, opts ...HTTPOpts) *File {
 	}
 }
 
+// Fetches the metadata of an image in a registry without pulling its layers.
+func (r *Client) ImageMetadata(address string) *ImageMetadata {
+	q := r.q.Select("imageMetadata")
+	q = q.Arg("address", address)
+
+	return &ImageMetadata{
+		q: q,
+		c: r.c,
+	}
+}
+
 // Load a CacheVolume from its ID.
 func (r *Client) LoadCacheVolumeFromID(id CacheVolumeID) *CacheVolume {
 	q := r.q.Select("loadCacheVolumeFromID")
@@ -3824,6 +5641,26 @@ func (r *Client) SetSecret(name string, plaintext string) *Secret {
 	}
 }
 
+// Sets a secret given a user defined name to a URI reference to its value and
+// returns the secret.
+//
+// The reference is resolved by the calling client each time the secret is
+// used, so the plaintext is never sent ahead of time. Supported schemes are
+// env://NAME (environment variable), file://PATH (file contents), cmd://COMMAND
+// (stdout of a shell command) and vault://PATH?field=KEY (Vault-compatible KV
+// HTTP API, configured by VAULT_ADDR and VAULT_TOKEN). Clients may register
+// additional schemes.
+func (r *Client) SetSecretURI(name string, uri string) *Secret {
+	q := r.q.Select("setSecretURI")
+	q = q.Arg("name", name)
+	q = q.Arg("uri", uri)
+
+	return &Secret{
+		q: q,
+		c: r.c,
+	}
+}
+
 // SocketOpts contains options for Client.Socket
 type SocketOpts struct {
 	ID SocketID
@@ -3924,9 +5761,14 @@ type Service struct {
 	c graphql.Client
 
 	endpoint *string
+	exitCode *int
+	health   *ServiceHealthStatus
 	hostname *string
 	id       *ServiceID
 	start    *ServiceID
+	status   *ServiceStatus
+	stderr   *string
+	stdout   *string
 	stop     *ServiceID
 }
 
@@ -3965,6 +5807,38 @@ func (r *Service) Endpoint(ctx context.Context, opts ...ServiceEndpointOpts) (st
 	return response, q.Execute(ctx, r.c)
 }
 
+// The exit code of the service's process, if it has exited.
+//
+// Returns null while the service is still running. If the service was stopped,
+// this is the exit code of its last run.
+func (r *Service) ExitCode(ctx context.Context) (int, error) {
+	if r.exitCode != nil {
+		return *r.exitCode, nil
+	}
+	q := r.q.Select("exitCode")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// Retrieves the current health of the service.
+//
+// The service must have been started, e.g. with start or by binding it to a
+// container that has run.
+func (r *Service) Health(ctx context.Context) (ServiceHealthStatus, error) {
+	if r.health != nil {
+		return *r.health, nil
+	}
+	q := r.q.Select("health")
+
+	var response ServiceHealthStatus
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // Retrieves a hostname which can be used by clients to reach this container.
 func (r *Service) Hostname(ctx context.Context) (string, error) {
 	if r.hostname != nil {
@@ -4022,19 +5896,20 @@ func (r *Service) MarshalJSON() ([]byte, error) {
 func (r *Service) Ports(ctx context.Context) ([]Port, error) {
 	q := r.q.Select("ports")
 
-	q = q.Select("description port protocol")
+	q = q.Select("description healthcheckPath port protocol")
 
 	type ports struct {
-		Description string
-		Port        int
-		Protocol    NetworkProtocol
+		Description     string
+		HealthcheckPath string
+		Port            int
+		Protocol        NetworkProtocol
 	}
 
 	convert := func(fields []ports) []Port {
 		out := []Port{}
 
 		for i := range fields {
-			val := Port{description: &fields[i].Description, port: &fields[i].Port, protocol: &fields[i].Protocol}
+			val := Port{description: &fields[i].Description, healthcheckPath: &fields[i].HealthcheckPath, port: &fields[i].Port, protocol: &fields[i].Protocol}
 			out = append(out, val)
 		}
 
@@ -4061,6 +5936,51 @@ func (r *Service) Start(ctx context.Context) (*Service, error) {
 	return r, q.Execute(ctx, r.c)
 }
 
+// Retrieves the current lifecycle status of the service.
+func (r *Service) Status(ctx context.Context) (ServiceStatus, error) {
+	if r.status != nil {
+		return *r.status, nil
+	}
+	q := r.q.Select("status")
+
+	var response ServiceStatus
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The most recent output of the service's process to stderr.
+//
+// Up to the last 1MiB is retained, including after the service has exited or
+// been stopped. The service must have been started.
+func (r *Service) Stderr(ctx context.Context) (string, error) {
+	if r.stderr != nil {
+		return *r.stderr, nil
+	}
+	q := r.q.Select("stderr")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
+// The most recent output of the service's process to stdout.
+//
+// Up to the last 1MiB is retained, including after the service has exited or
+// been stopped. The service must have been started.
+func (r *Service) Stdout(ctx context.Context) (string, error) {
+	if r.stdout != nil {
+		return *r.stdout, nil
+	}
+	q := r.q.Select("stdout")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx, r.c)
+}
+
 // Stop the service.
 func (r *Service) Stop(ctx context.Context) (*Service, error) {
 	q := r.q.Select("stop")
@@ -4317,6 +6237,15 @@ func (r *TypeDef) WithOptional(optional bool) *TypeDef {
 	}
 }
 
+type ArchiveFormat string
+
+func (ArchiveFormat) IsEnum() {}
+
+const (
+	Tar ArchiveFormat = "TAR"
+	Zip ArchiveFormat = "ZIP"
+)
+
 type CacheSharingMode string
 
 func (CacheSharingMode) IsEnum() {}
@@ -4327,6 +6256,38 @@ const (
 	Shared  CacheSharingMode = "SHARED"
 )
 
+type ChangeKind string
+
+func (ChangeKind) IsEnum() {}
+
+const (
+	Added    ChangeKind = "ADDED"
+	Deleted  ChangeKind = "DELETED"
+	Modified ChangeKind = "MODIFIED"
+	Renamed  ChangeKind = "RENAMED"
+)
+
+type DigestAlgorithm string
+
+func (DigestAlgorithm) IsEnum() {}
+
+const (
+	Sha256 DigestAlgorithm = "SHA256"
+	Sha384 DigestAlgorithm = "SHA384"
+	Sha512 DigestAlgorithm = "SHA512"
+)
+
+type FileType string
+
+func (FileType) IsEnum() {}
+
+const (
+	Dir     FileType = "DIR"
+	Other   FileType = "OTHER"
+	Regular FileType = "REGULAR"
+	Symlink FileType = "SYMLINK"
+)
+
 type ImageLayerCompression string
 
 func (ImageLayerCompression) IsEnum() {}
@@ -4347,6 +6308,16 @@ const (
 	Ocimediatypes    ImageMediaTypes = "OCIMediaTypes"
 )
 
+type NetworkMode string
+
+func (NetworkMode) IsEnum() {}
+
+const (
+	NetworkDefault      NetworkMode = "NETWORK_DEFAULT"
+	NetworkNone         NetworkMode = "NETWORK_NONE"
+	NetworkServicesOnly NetworkMode = "NETWORK_SERVICES_ONLY"
+)
+
 type NetworkProtocol string
 
 func (NetworkProtocol) IsEnum() {}
@@ -4356,6 +6327,35 @@ const (
 	Udp NetworkProtocol = "UDP"
 )
 
+type ProvenanceMode string
+
+func (ProvenanceMode) IsEnum() {}
+
+const (
+	ProvenanceMax ProvenanceMode = "PROVENANCE_MAX"
+	ProvenanceMin ProvenanceMode = "PROVENANCE_MIN"
+)
+
+type ServiceHealthStatus string
+
+func (ServiceHealthStatus) IsEnum() {}
+
+const (
+	Healthy   ServiceHealthStatus = "HEALTHY"
+	Unhealthy ServiceHealthStatus = "UNHEALTHY"
+)
+
+type ServiceStatus string
+
+func (ServiceStatus) IsEnum() {}
+
+const (
+	Exited   ServiceStatus = "EXITED"
+	Running  ServiceStatus = "RUNNING"
+	Starting ServiceStatus = "STARTING"
+	Stopped  ServiceStatus = "STOPPED"
+)
+
 type TypeDefKind string
 
 func (TypeDefKind) IsEnum() {}
@@ -7,6 +7,18 @@ import (
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
+// Error types reported by the API in the "_type" error extension.
+const (
+	execErrorType          = "EXEC_ERROR"
+	execTimeoutErrorType   = "EXEC_TIMEOUT_ERROR"
+	registryAuthErrorType  = "REGISTRY_AUTH_ERROR"
+	imageNotFoundErrorType = "IMAGE_NOT_FOUND_ERROR"
+	pathNotFoundErrorType  = "PATH_NOT_FOUND_ERROR"
+	serviceStartErrorType  = "SERVICE_START_ERROR"
+	serviceHealthErrorType = "SERVICE_HEALTH_ERROR"
+	invalidInputErrorType  = "INVALID_INPUT_ERROR"
+)
+
 // getCustomError parses a GraphQL error into a more specific error type.
 func getCustomError(err error) error {
 	var gqlErr *gqlerror.Error
@@ -22,19 +34,32 @@ func getCustomError(err error) error {
 		return nil
 	}
 
-	if typ == "EXEC_ERROR" {
+	switch typ {
+	case execErrorType:
 		e := &ExecError{
 			original: err,
 		}
 		if code, ok := ext["exitCode"].(float64); ok {
 			e.ExitCode = int(code)
 		}
-		if args, ok := ext["cmd"].([]interface{}); ok {
-			cmd := make([]string, len(args))
-			for i, v := range args {
-				cmd[i] = v.(string)
-			}
-			e.Cmd = cmd
+		e.Cmd = extStrings(ext, "cmd")
+		if stdout, ok := ext["stdout"].(string); ok {
+			e.Stdout = stdout
+		}
+		if stderr, ok := ext["stderr"].(string); ok {
+			e.Stderr = stderr
+		}
+		if debugID, ok := ext["debugID"].(string); ok {
+			e.DebugID = debugID
+		}
+		return e
+	case execTimeoutErrorType:
+		e := &ExecTimeoutError{
+			original: err,
+		}
+		e.Cmd = extStrings(ext, "cmd")
+		if timeout, ok := ext["timeout"].(float64); ok {
+			e.Timeout = int(timeout)
 		}
 		if stdout, ok := ext["stdout"].(string); ok {
 			e.Stdout = stdout
@@ -42,12 +67,81 @@ func getCustomError(err error) error {
 		if stderr, ok := ext["stderr"].(string); ok {
 			e.Stderr = stderr
 		}
+		if debugID, ok := ext["debugID"].(string); ok {
+			e.DebugID = debugID
+		}
+		return e
+	case registryAuthErrorType:
+		e := &RegistryAuthError{
+			original: err,
+		}
+		if address, ok := ext["address"].(string); ok {
+			e.Address = address
+		}
+		return e
+	case imageNotFoundErrorType:
+		e := &ImageNotFoundError{
+			original: err,
+		}
+		if address, ok := ext["address"].(string); ok {
+			e.Address = address
+		}
 		return e
+	case pathNotFoundErrorType:
+		e := &PathNotFoundError{
+			original: err,
+		}
+		if path, ok := ext["path"].(string); ok {
+			e.Path = path
+		}
+		return e
+	case serviceStartErrorType:
+		e := &ServiceStartError{
+			original: err,
+		}
+		if hostname, ok := ext["hostname"].(string); ok {
+			e.Hostname = hostname
+		}
+		e.Cmd = extStrings(ext, "cmd")
+		if code, ok := ext["exitCode"].(float64); ok {
+			e.ExitCode = int(code)
+		}
+		if output, ok := ext["output"].(string); ok {
+			e.Output = output
+		}
+		return e
+	case serviceHealthErrorType:
+		e := &ServiceHealthError{
+			original: err,
+		}
+		if hostname, ok := ext["hostname"].(string); ok {
+			e.Hostname = hostname
+		}
+		e.Ports = extStrings(ext, "ports")
+		return e
+	case invalidInputErrorType:
+		return &InvalidInputError{
+			original: err,
+		}
 	}
 
 	return nil
 }
 
+func extStrings(ext map[string]interface{}, key string) []string {
+	vals, ok := ext[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	strs := make([]string, 0, len(vals))
+	for _, v := range vals {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
 // ExecError is an API error from an exec operation.
 type ExecError struct {
 	original error
@@ -55,6 +149,10 @@ type ExecError struct {
 	ExitCode int
 	Stdout   string
 	Stderr   string
+	// DebugID identifies the state of the failed exec, which can be loaded
+	// with Client.DebugContainer. It is empty unless the session keeps
+	// failed execs.
+	DebugID string
 }
 
 func (e *ExecError) Error() string {
@@ -75,3 +173,124 @@ func (e *ExecError) Message() string {
 func (e *ExecError) Unwrap() error {
 	return e.original
 }
+
+// ExecTimeoutError is an API error returned when an exec operation is killed
+// for running longer than its timeout.
+type ExecTimeoutError struct {
+	original error
+	Cmd      []string
+	Timeout  int
+	Stdout   string
+	Stderr   string
+	// DebugID identifies the state of the failed exec, which can be loaded
+	// with Client.DebugContainer. It is empty unless the session keeps
+	// failed execs.
+	DebugID string
+}
+
+func (e *ExecTimeoutError) Error() string {
+	return fmt.Sprintf(
+		"%s\nStdout:\n%s\nStderr:\n%s",
+		e.original.Error(),
+		e.Stdout,
+		e.Stderr,
+	)
+}
+
+func (e *ExecTimeoutError) Unwrap() error {
+	return e.original
+}
+
+// RegistryAuthError is an API error returned when a registry rejects the
+// credentials used to access an image.
+type RegistryAuthError struct {
+	original error
+	Address  string
+}
+
+func (e *RegistryAuthError) Error() string {
+	return e.original.Error()
+}
+
+func (e *RegistryAuthError) Unwrap() error {
+	return e.original
+}
+
+// ImageNotFoundError is an API error returned when an image address cannot be
+// resolved.
+type ImageNotFoundError struct {
+	original error
+	Address  string
+}
+
+func (e *ImageNotFoundError) Error() string {
+	return e.original.Error()
+}
+
+func (e *ImageNotFoundError) Unwrap() error {
+	return e.original
+}
+
+// PathNotFoundError is an API error returned when a path does not exist in a
+// directory or container.
+type PathNotFoundError struct {
+	original error
+	Path     string
+}
+
+func (e *PathNotFoundError) Error() string {
+	return e.original.Error()
+}
+
+func (e *PathNotFoundError) Unwrap() error {
+	return e.original
+}
+
+// ServiceStartError is an API error returned when a service exits before it
+// becomes healthy.
+type ServiceStartError struct {
+	original error
+	Hostname string
+	Cmd      []string
+	ExitCode int
+	Output   string
+}
+
+func (e *ServiceStartError) Error() string {
+	return e.original.Error()
+}
+
+func (e *ServiceStartError) Unwrap() error {
+	return e.original
+}
+
+// ServiceHealthError is an API error returned when a service fails its health
+// check.
+type ServiceHealthError struct {
+	original error
+	Hostname string
+	// Ports checked by the health check, formatted as port/protocol (e.g. "80/tcp").
+	Ports []string
+}
+
+func (e *ServiceHealthError) Error() string {
+	return e.original.Error()
+}
+
+func (e *ServiceHealthError) Unwrap() error {
+	return e.original
+}
+
+// InvalidInputError is an API error returned when an argument value is
+// malformed.
+type InvalidInputError struct {
+	original error
+}
+
+func (e *InvalidInputError) Error() string {
+	return e.original.Error()
+}
+
+func (e *InvalidInputError) Unwrap() error {
+	return e.original
+}
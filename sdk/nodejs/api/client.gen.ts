@@ -61,6 +61,20 @@ class BaseClient {
   }
 }
 
+/**
+ * The format of an archive.
+ */
+export enum ArchiveFormat {
+  /**
+   * A tar archive.
+   */
+  Tar = "TAR",
+
+  /**
+   * A zip archive.
+   */
+  Zip = "ZIP",
+}
 export type BuildArg = {
   /**
    * The build argument name.
@@ -98,6 +112,30 @@ export enum CacheSharingMode {
  */
 export type CacheVolumeID = string & { __CacheVolumeID: never }
 
+/**
+ * The kind of a change to a path.
+ */
+export enum ChangeKind {
+  /**
+   * The path was added.
+   */
+  Added = "ADDED",
+
+  /**
+   * The path was deleted.
+   */
+  Deleted = "DELETED",
+
+  /**
+   * The path was modified.
+   */
+  Modified = "MODIFIED",
+
+  /**
+   * The path was renamed. Only reported for changes between directories.
+   */
+  Renamed = "RENAMED",
+}
 export type ContainerAsTarballOpts = {
   /**
    * Identifiers for other platform specific containers.
@@ -174,6 +212,28 @@ export type ContainerExportOpts = {
    * for older runtimes without OCI support.
    */
   mediaTypes?: ImageMediaTypes
+
+  /**
+   * Container used to generate an SPDX SBOM attestation for each platform.
+   *
+   * It must implement the BuildKit SBOM scanner protocol
+   * (e.g., docker/buildkit-syft-scanner).
+   */
+  sbomScanner?: Container
+
+  /**
+   * Attach SLSA provenance attestations with the given level of detail.
+   */
+  provenance?: ProvenanceMode
+}
+
+export type ContainerFromOpts = {
+  /**
+   * PEM-encoded public key the image must have a cosign signature for.
+   *
+   * If set, unsigned images and images signed with another key are refused.
+   */
+  verifyWith?: string
 }
 
 export type ContainerImportOpts = {
@@ -196,6 +256,13 @@ export type ContainerPipelineOpts = {
   labels?: PipelineLabel[]
 }
 
+export type ContainerProvenanceOpts = {
+  /**
+   * Level of detail to include.
+   */
+  mode?: ProvenanceMode
+}
+
 export type ContainerPublishOpts = {
   /**
    * Identifiers for other platform specific containers.
@@ -218,6 +285,89 @@ export type ContainerPublishOpts = {
    * registries without OCI support.
    */
   mediaTypes?: ImageMediaTypes
+
+  /**
+   * Container used to generate an SPDX SBOM attestation for each platform.
+   *
+   * It must implement the BuildKit SBOM scanner protocol
+   * (e.g., docker/buildkit-syft-scanner).
+   */
+  sbomScanner?: Container
+
+  /**
+   * Attach SLSA provenance attestations with the given level of detail.
+   */
+  provenance?: ProvenanceMode
+
+  /**
+   * Secret holding a PEM-encoded private key used to sign the published image.
+   *
+   * The signature is pushed alongside the image in the format used by cosign.
+   */
+  signWith?: Secret
+}
+
+export type ContainerPublishAllOpts = {
+  /**
+   * Identifiers for other platform specific containers.
+   * Used for multi-platform image.
+   */
+  platformVariants?: Container[]
+
+  /**
+   * Force each layer of the published image to use the specified compression algorithm.
+   * If this is unset, then if a layer already has a compressed blob in the engine's
+   * cache, that will be used (this can result in a mix of compression algorithms for
+   * different layers). If this is unset and a layer has no compressed blob in the
+   * engine's cache, then it will be compressed using Gzip.
+   */
+  forcedCompression?: ImageLayerCompression
+
+  /**
+   * Use the specified media types for the published image's layers. Defaults to OCI, which
+   * is largely compatible with most recent registries, but Docker may be needed for older
+   * registries without OCI support.
+   */
+  mediaTypes?: ImageMediaTypes
+
+  /**
+   * Container used to generate an SPDX SBOM attestation for each platform.
+   *
+   * It must implement the BuildKit SBOM scanner protocol
+   * (e.g., docker/buildkit-syft-scanner).
+   */
+  sbomScanner?: Container
+
+  /**
+   * Attach SLSA provenance attestations with the given level of detail.
+   */
+  provenance?: ProvenanceMode
+
+  /**
+   * Secret holding a PEM-encoded private key used to sign the published image.
+   *
+   * The signature is pushed alongside the image in the format used by cosign.
+   */
+  signWith?: Secret
+}
+
+export type ContainerSquashOpts = {
+  /**
+   * The container whose layers are kept as they are.
+   *
+   * If not set, the whole root filesystem is collapsed into a single layer.
+   */
+  from?: Container
+}
+
+export type ContainerWithAnnotationOpts = {
+  /**
+   * Set the annotation on the image index rather than the image manifest.
+   *
+   * An image index is always created when there are index annotations, even
+   * when publishing or exporting a single platform.
+   */
+  index?: boolean
 }
 
 export type ContainerWithDefaultArgsOpts = {
@@ -292,6 +442,42 @@ export type ContainerWithExecOpts = {
    * when absolutely necessary and only with trusted commands.
    */
   insecureRootCapabilities?: boolean
+
+  /**
+   * Do not fail if the command exits with a non-zero code.
+   *
+   * The exit code is recorded instead, and can be retrieved along with the
+   * command's output using exitCode, stdout and stderr.
+   */
+  expectNonZeroExit?: boolean
+
+  /**
+   * Kill the command if it runs for longer than the given number of seconds.
+   *
+   * A command that times out fails with an EXEC_TIMEOUT_ERROR, even if
+   * expectNonZeroExit is set.
+   */
+  timeout?: number
+
+  /**
+   * Number of CPUs the command may use (e.g., 1.5).
+   */
+  cpuLimit?: number
+
+  /**
+   * Maximum memory the command may use (e.g., "512m" or "2g").
+   */
+  memoryLimit?: string
+
+  /**
+   * Maximum number of processes the command may run at once.
+   */
+  pidsLimit?: number
+
+  /**
+   * Per-process limits in the form name=soft[:hard] (e.g., ["nofile=1024:2048"]).
+   */
+  ulimits?: string[]
 }
 
 export type ContainerWithExposedPortOpts = {
@@ -304,6 +490,14 @@ export type ContainerWithExposedPortOpts = {
    * Optional port description
    */
   description?: string
+
+  /**
+   * HTTP path to poll once the port accepts connections, e.g. /healthz.
+   *
+   * The service is only considered healthy once the path responds with a
+   * non-error status code. Only supported for TCP ports.
+   */
+  healthcheckPath?: string
 }
 
 export type ContainerWithFileOpts = {
@@ -324,6 +518,28 @@ export type ContainerWithFileOpts = {
   owner?: string
 }
 
+export type ContainerWithHealthcheckOpts = {
+  /**
+   * Seconds to wait between checks (default: 1).
+   */
+  interval?: number
+
+  /**
+   * Seconds after which a single check fails (default: 30).
+   */
+  timeout?: number
+
+  /**
+   * Number of consecutive failures after which the service is unhealthy (default: 10).
+   */
+  retries?: number
+
+  /**
+   * Seconds the service is given to start, during which failures do not count towards retries.
+   */
+  startPeriod?: number
+}
+
 export type ContainerWithMountedCacheOpts = {
   /**
    * Identifier of the directory to use as the cache volume's root.
@@ -390,6 +606,20 @@ export type ContainerWithMountedSecretOpts = {
   mode?: number
 }
 
+export type ContainerWithNetworkOpts = {
+  /**
+   * Hostnames, IPs or CIDRs reachable in addition to bound services
+   * (e.g., ["proxy.golang.org", "10.0.0.0/8"]).
+   *
+   * Hostnames are resolved once, when each command starts, and only the
+   * addresses they resolve to at that time are reachable. Use a CIDR for
+   * destinations whose addresses may change while a command runs.
+   *
+   * Only allowed with the NETWORK_SERVICES_ONLY mode.
+   */
+  allow?: string[]
+}
+
 export type ContainerWithNewFileOpts = {
   /**
    * Content of the file to write (e.g., "Hello world!").
@@ -413,6 +643,36 @@ export type ContainerWithNewFileOpts = {
   owner?: string
 }
 
+export type ContainerWithResourceLimitsOpts = {
+  /**
+   * Number of CPUs the command may use (e.g., 1.5).
+   */
+  cpuLimit?: number
+
+  /**
+   * Maximum memory the command may use (e.g., "512m" or "2g").
+   */
+  memoryLimit?: string
+
+  /**
+   * Maximum number of processes the command may run at once.
+   */
+  pidsLimit?: number
+
+  /**
+   * Per-process limits in the form name=soft[:hard] (e.g., ["nofile=1024:2048"]).
+   */
+  ulimits?: string[]
+}
+
+export type ContainerWithServiceBindingOpts = {
+  /**
+   * Aliases of services already bound to the container that must be started
+   * and healthy before this service is started (e.g., ["db"]).
+   */
+  dependsOn?: string[]
+}
+
 export type ContainerWithUnixSocketOpts = {
   /**
    * A user:group to set for the mounted socket.
@@ -424,6 +684,13 @@ export type ContainerWithUnixSocketOpts = {
   owner?: string
 }
 
+export type ContainerWithoutAnnotationOpts = {
+  /**
+   * Remove the annotation from the image index rather than the image manifest.
+   */
+  index?: boolean
+}
+
 export type ContainerWithoutExposedPortOpts = {
   /**
    * Port protocol to unexpose
@@ -441,6 +708,28 @@ export type ContainerID = string & { __ContainerID: never }
  */
 export type DateTime = string & { __DateTime: never }
 
+/**
+ * A hash algorithm.
+ */
+export enum DigestAlgorithm {
+  Sha256 = "SHA256",
+  Sha384 = "SHA384",
+  Sha512 = "SHA512",
+}
+export type DirectoryAsArchiveOpts = {
+  /**
+   * Format of the archive.
+   */
+  format?: ArchiveFormat
+
+  /**
+   * Compression of a tar archive: Gzip, Zstd or Uncompressed (the default).
+   *
+   * Zip archives are always compressed.
+   */
+  compression?: ImageLayerCompression
+}
+
 export type DirectoryAsModuleOpts = {
   /**
    * An optional subpath of the directory which contains the module's source
@@ -457,6 +746,18 @@ export type DirectoryAsModuleOpts = {
   sourceSubpath?: string
 }
 
+export type DirectoryDigestOpts = {
+  /**
+   * Exclude entries matching the given patterns from the hash (e.g., ["node_modules"]).
+   */
+  exclude?: string[]
+
+  /**
+   * Only hash entries matching the given patterns (e.g., ["**/*.go"]).
+   */
+  include?: string[]
+}
+
 export type DirectoryDockerBuildOpts = {
   /**
    * Path to the Dockerfile to use (e.g., "frontend.Dockerfile").
@@ -495,6 +796,33 @@ export type DirectoryEntriesOpts = {
   path?: string
 }
 
+export type DirectoryExportOpts = {
+  /**
+   * Remove any files on the host path that aren't in this directory, so that
+   * it mirrors the directory exactly.
+   */
+  mirror?: boolean
+
+  /**
+   * Exclude artifacts that match the given pattern (e.g., [".git", "**/.env"]).
+   * Excluded paths are neither written nor removed on the host.
+   */
+  exclude?: string[]
+}
+
+export type DirectoryExportChangesOpts = {
+  /**
+   * Whether the export would remove files on the host path that aren't in
+   * this directory.
+   */
+  mirror?: boolean
+
+  /**
+   * Exclude artifacts that match the given pattern (e.g., [".git", "**/.env"]).
+   */
+  exclude?: string[]
+}
+
 export type DirectoryPipelineOpts = {
   /**
    * Pipeline description.
@@ -507,6 +835,33 @@ export type DirectoryPipelineOpts = {
   labels?: PipelineLabel[]
 }
 
+export type DirectoryWalkOpts = {
+  /**
+   * Location of the directory to walk (e.g., "/src").
+   */
+  path?: string
+
+  /**
+   * Only return entries matching the given patterns (e.g., ["**/*.go"]).
+   *
+   * Patterns are relative to the walked path.
+   */
+  include?: string[]
+
+  /**
+   * Skip entries matching the given patterns (e.g., ["node_modules"]).
+   *
+   * Excluded directories are not walked. Patterns are relative to the walked path.
+   */
+  exclude?: string[]
+
+  /**
+   * How many levels of subdirectories to walk, e.g. 1 for only the immediate
+   * entries. Walks the entire tree if unset.
+   */
+  maxDepth?: number
+}
+
 export type DirectoryWithDirectoryOpts = {
   /**
    * Exclude artifacts that match the given pattern (e.g., ["node_modules/", ".git*"]).
@@ -551,6 +906,13 @@ export type DirectoryWithNewFileOpts = {
  */
 export type DirectoryID = string & { __DirectoryID: never }
 
+export type FileDigestOpts = {
+  /**
+   * The hash algorithm to use.
+   */
+  algorithm?: DigestAlgorithm
+}
+
 export type FileExportOpts = {
   /**
    * If allowParentDirPath is true, the path argument can be a directory path, in which case
@@ -564,6 +926,30 @@ export type FileExportOpts = {
  */
 export type FileID = string & { __FileID: never }
 
+/**
+ * The type of an entry in a directory.
+ */
+export enum FileType {
+  /**
+   * A directory.
+   */
+  Dir = "DIR",
+
+  /**
+   * Any other type of entry, such as a device or named pipe.
+   */
+  Other = "OTHER",
+
+  /**
+   * A regular file.
+   */
+  Regular = "REGULAR",
+
+  /**
+   * A symbolic link.
+   */
+  Symlink = "SYMLINK",
+}
 export type FunctionWithArgOpts = {
   /**
    * A doc string for the argument, if any
@@ -606,6 +992,16 @@ export type HostDirectoryOpts = {
    * Include only artifacts that match the given pattern (e.g., ["app/", "package.*"]).
    */
   include?: string[]
+
+  /**
+   * Exclude artifacts ignored by the .gitignore files in the directory and its subdirectories.
+   */
+  gitignore?: boolean
+
+  /**
+   * Exclude artifacts ignored by the given .dockerignore-style file, relative to the directory (e.g., ".dockerignore").
+   */
+  ignoreFile?: string
 }
 
 export type HostServiceOpts = {
@@ -660,6 +1056,10 @@ export enum ImageMediaTypes {
   Dockermediatypes = "DockerMediaTypes",
   Ocimediatypes = "OCIMediaTypes",
 }
+export type ImageMetadataManifestOpts = {
+  platform?: Platform
+}
+
 /**
  * An arbitrary JSON-encoded value.
  */
@@ -670,6 +1070,25 @@ export type JSON = string & { __JSON: never }
  */
 export type ModuleID = string & { __ModuleID: never }
 
+/**
+ * Network access allowed to a container's commands.
+ */
+export enum NetworkMode {
+  /**
+   * Unrestricted network access.
+   */
+  NetworkDefault = "NETWORK_DEFAULT",
+
+  /**
+   * No network access apart from the loopback interface.
+   */
+  NetworkNone = "NETWORK_NONE",
+
+  /**
+   * Network access to bound services, DNS and explicitly allowed destinations only.
+   */
+  NetworkServicesOnly = "NETWORK_SERVICES_ONLY",
+}
 /**
  * Transport layer network protocol associated to a port.
  */
@@ -720,13 +1139,27 @@ export type PortForward = {
   protocol?: NetworkProtocol
 }
 
-export type ClientContainerOpts = {
-  id?: ContainerID
-  platform?: Platform
-}
-
-export type ClientDirectoryOpts = {
-  id?: DirectoryID
+/**
+ * Level of detail included in SLSA provenance attestations.
+ */
+export enum ProvenanceMode {
+  /**
+   * Also the image config and the full build definition.
+   */
+  ProvenanceMax = "PROVENANCE_MAX",
+
+  /**
+   * The builder, build platform and source images only.
+   */
+  ProvenanceMin = "PROVENANCE_MIN",
+}
+export type ClientContainerOpts = {
+  id?: ContainerID
+  platform?: Platform
+}
+
+export type ClientDirectoryOpts = {
+  id?: DirectoryID
 }
 
 export type ClientGitOpts = {
@@ -795,11 +1228,49 @@ export type ServiceEndpointOpts = {
   scheme?: string
 }
 
+/**
+ * The health of a running service.
+ */
+export enum ServiceHealthStatus {
+  /**
+   * The service has passed all of its health checks.
+   */
+  Healthy = "HEALTHY",
+
+  /**
+   * The service's health check command has failed too many times in a row.
+   */
+  Unhealthy = "UNHEALTHY",
+}
 /**
  * A unique service identifier.
  */
 export type ServiceID = string & { __ServiceID: never }
 
+/**
+ * The lifecycle status of a service.
+ */
+export enum ServiceStatus {
+  /**
+   * The service's process exited on its own after starting.
+   */
+  Exited = "EXITED",
+
+  /**
+   * The service has started and is still running.
+   */
+  Running = "RUNNING",
+
+  /**
+   * The service is starting and waiting for its health checks to pass.
+   */
+  Starting = "STARTING",
+
+  /**
+   * The service is not running; it was either never started or has been stopped.
+   */
+  Stopped = "STOPPED",
+}
 /**
  * A content-addressed socket identifier.
  */
@@ -883,17 +1354,29 @@ export type __TypeFieldsOpts = {
  */
 export class CacheVolume extends BaseClient {
   private readonly _id?: CacheVolumeID = undefined
+  private readonly _key?: string = undefined
+  private readonly _lastUsed?: number = undefined
+  private readonly _prune?: number = undefined
+  private readonly _size?: number = undefined
 
   /**
    * Constructor is used for internal usage only, do not create object from it.
    */
   constructor(
     parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
-    _id?: CacheVolumeID
+    _id?: CacheVolumeID,
+    _key?: string,
+    _lastUsed?: number,
+    _prune?: number,
+    _size?: number
   ) {
     super(parent)
 
     this._id = _id
+    this._key = _key
+    this._lastUsed = _lastUsed
+    this._prune = _prune
+    this._size = _size
   }
   async id(): Promise<CacheVolumeID> {
     if (this._id) {
@@ -912,6 +1395,240 @@ export class CacheVolume extends BaseClient {
 
     return response
   }
+
+  /**
+   * The key identifying this cache volume.
+   *
+   * Empty if the key is not known, e.g. for a volume that has not been used
+   * since the engine restarted.
+   */
+  async key(): Promise<string> {
+    if (this._key) {
+      return this._key
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "key",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The last time this cache volume was mounted, as a Unix timestamp.
+   *
+   * Null if the volume has never been used.
+   */
+  async lastUsed(): Promise<number> {
+    if (this._lastUsed) {
+      return this._lastUsed
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "lastUsed",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * Wipes the contents of this cache volume, returning the number of bytes
+   * reclaimed.
+   *
+   * Subsequent uses of the volume start from an empty directory.
+   */
+  async prune(): Promise<number> {
+    if (this._prune) {
+      return this._prune
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "prune",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The disk space used by this cache volume on the engine, in bytes.
+   */
+  async size(): Promise<number> {
+    if (this._size) {
+      return this._size
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "size",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * Returns a directory containing a copy of the current contents of this cache
+   * volume.
+   */
+  snapshot(): Directory {
+    return new Directory({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "snapshot",
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * Replaces the contents of this cache volume with the contents of the given
+   * directory, e.g. to pre-populate a package manager cache.
+   *
+   * This is a side effect: the contents are replaced on the engine when this
+   * field is resolved (e.g. when the ID of the returned volume is requested),
+   * affecting all pipelines using this cache volume, and again every time it is
+   * resolved. The returned cache volume is the same volume, with the same ID.
+   *
+   * Replacing the contents waits for any commands currently using this cache
+   * volume to finish.
+   * @param source Identifier of the directory to copy into the cache volume.
+   */
+  withContents(source: Directory): CacheVolume {
+    return new CacheVolume({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "withContents",
+          args: { source },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * Call the provided function with current CacheVolume.
+   *
+   * This is useful for reusability and readability by not breaking the calling chain.
+   */
+  with(arg: (param: CacheVolume) => CacheVolume) {
+    return arg(this)
+  }
+}
+
+/**
+ * A change to a path between two container filesystems.
+ */
+export class Change extends BaseClient {
+  private readonly _kind?: ChangeKind = undefined
+  private readonly _path?: string = undefined
+  private readonly _size?: number = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
+    _kind?: ChangeKind,
+    _path?: string,
+    _size?: number
+  ) {
+    super(parent)
+
+    this._kind = _kind
+    this._path = _path
+    this._size = _size
+  }
+
+  /**
+   * The kind of change.
+   */
+  async kind(): Promise<ChangeKind> {
+    if (this._kind) {
+      return this._kind
+    }
+
+    const response: Awaited<ChangeKind> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "kind",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The absolute path of the changed file or directory.
+   */
+  async path(): Promise<string> {
+    if (this._path) {
+      return this._path
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "path",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The size of the path in the newer filesystem in bytes, or 0 if it was
+   * deleted or is not a regular file.
+   */
+  async size(): Promise<number> {
+    if (this._size) {
+      return this._size
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "size",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
 }
 
 /**
@@ -919,8 +1636,12 @@ export class CacheVolume extends BaseClient {
  */
 export class Container extends BaseClient {
   private readonly _id?: ContainerID = undefined
+  private readonly _combinedOutput?: string = undefined
   private readonly _envVariable?: string = undefined
+  private readonly _exitCode?: number = undefined
   private readonly _export?: boolean = undefined
+  private readonly _imageConfig?: JSON = undefined
+  private readonly _imageDigest?: string = undefined
   private readonly _imageRef?: string = undefined
   private readonly _label?: string = undefined
   private readonly _platform?: Platform = undefined
@@ -938,8 +1659,12 @@ export class Container extends BaseClient {
   constructor(
     parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
     _id?: ContainerID,
+    _combinedOutput?: string,
     _envVariable?: string,
+    _exitCode?: number,
     _export?: boolean,
+    _imageConfig?: JSON,
+    _imageDigest?: string,
     _imageRef?: string,
     _label?: string,
     _platform?: Platform,
@@ -954,8 +1679,12 @@ export class Container extends BaseClient {
     super(parent)
 
     this._id = _id
+    this._combinedOutput = _combinedOutput
     this._envVariable = _envVariable
+    this._exitCode = _exitCode
     this._export = _export
+    this._imageConfig = _imageConfig
+    this._imageDigest = _imageDigest
     this._imageRef = _imageRef
     this._label = _label
     this._platform = _platform
@@ -1070,14 +1799,21 @@ export class Container extends BaseClient {
   }
 
   /**
-   * Retrieves default arguments for future commands.
+   * The output and error streams of the last executed command, interleaved in
+   * the order they were written.
+   *
+   * Will execute default command if none is set, or error if there's no default.
    */
-  async defaultArgs(): Promise<string[]> {
-    const response: Awaited<string[]> = await computeQuery(
+  async combinedOutput(): Promise<string> {
+    if (this._combinedOutput) {
+      return this._combinedOutput
+    }
+
+    const response: Awaited<string> = await computeQuery(
       [
         ...this._queryTree,
         {
-          operation: "defaultArgs",
+          operation: "combinedOutput",
         },
       ],
       this.client
@@ -1087,34 +1823,14 @@ export class Container extends BaseClient {
   }
 
   /**
-   * Retrieves a directory at the given path.
-   *
-   * Mounts are included.
-   * @param path The path of the directory to retrieve (e.g., "./src").
-   */
-  directory(path: string): Directory {
-    return new Directory({
-      queryTree: [
-        ...this._queryTree,
-        {
-          operation: "directory",
-          args: { path },
-        },
-      ],
-      host: this.clientHost,
-      sessionToken: this.sessionToken,
-    })
-  }
-
-  /**
-   * Retrieves entrypoint to be prepended to the arguments of all commands.
+   * Retrieves default arguments for future commands.
    */
-  async entrypoint(): Promise<string[]> {
+  async defaultArgs(): Promise<string[]> {
     const response: Awaited<string[]> = await computeQuery(
       [
         ...this._queryTree,
         {
-          operation: "entrypoint",
+          operation: "defaultArgs",
         },
       ],
       this.client
@@ -1124,12 +1840,90 @@ export class Container extends BaseClient {
   }
 
   /**
-   * Retrieves the value of the specified environment variable.
-   * @param name The name of the environment variable to retrieve (e.g., "PATH").
+   * The changes to this container's root filesystem relative to another
+   * container's root filesystem (e.g., its base image).
+   * @param other The container to compare against.
    */
-  async envVariable(name: string): Promise<string> {
-    if (this._envVariable) {
-      return this._envVariable
+  async diff(other: Container): Promise<Change[]> {
+    type diff = {
+      kind: ChangeKind
+      path: string
+      size: number
+    }
+
+    const response: Awaited<diff[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "diff",
+          args: { other },
+        },
+        {
+          operation: "kind path size",
+        },
+      ],
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new Change(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.kind,
+          r.path,
+          r.size
+        )
+    )
+  }
+
+  /**
+   * Retrieves a directory at the given path.
+   *
+   * Mounts are included.
+   * @param path The path of the directory to retrieve (e.g., "./src").
+   */
+  directory(path: string): Directory {
+    return new Directory({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "directory",
+          args: { path },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * Retrieves entrypoint to be prepended to the arguments of all commands.
+   */
+  async entrypoint(): Promise<string[]> {
+    const response: Awaited<string[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "entrypoint",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * Retrieves the value of the specified environment variable.
+   * @param name The name of the environment variable to retrieve (e.g., "PATH").
+   */
+  async envVariable(name: string): Promise<string> {
+    if (this._envVariable) {
+      return this._envVariable
     }
 
     const response: Awaited<string> = await computeQuery(
@@ -1182,6 +1976,29 @@ export class Container extends BaseClient {
     )
   }
 
+  /**
+   * The exit code of the last executed command.
+   *
+   * Will execute default command if none is set, or error if there's no default.
+   */
+  async exitCode(): Promise<number> {
+    if (this._exitCode) {
+      return this._exitCode
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "exitCode",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
   /**
    * EXPERIMENTAL API! Subject to change/removal at any time.
    *
@@ -1238,6 +2055,11 @@ export class Container extends BaseClient {
    * @param opts.mediaTypes Use the specified media types for the exported image's layers. Defaults to OCI, which
    * is largely compatible with most recent container runtimes, but Docker may be needed
    * for older runtimes without OCI support.
+   * @param opts.sbomScanner Container used to generate an SPDX SBOM attestation for each platform.
+   *
+   * It must implement the BuildKit SBOM scanner protocol
+   * (e.g., docker/buildkit-syft-scanner).
+   * @param opts.provenance Attach SLSA provenance attestations with the given level of detail.
    */
   async export(path: string, opts?: ContainerExportOpts): Promise<boolean> {
     if (this._export) {
@@ -1247,6 +2069,7 @@ export class Container extends BaseClient {
     const metadata: Metadata = {
       forcedCompression: { is_enum: true },
       mediaTypes: { is_enum: true },
+      provenance: { is_enum: true },
     }
 
     const response: Awaited<boolean> = await computeQuery(
@@ -1272,6 +2095,7 @@ export class Container extends BaseClient {
   async exposedPorts(): Promise<Port[]> {
     type exposedPorts = {
       description: string
+      healthcheckPath: string
       port: number
       protocol: NetworkProtocol
     }
@@ -1283,7 +2107,7 @@ export class Container extends BaseClient {
           operation: "exposedPorts",
         },
         {
-          operation: "description port protocol",
+          operation: "description healthcheckPath port protocol",
         },
       ],
       this.client
@@ -1298,6 +2122,7 @@ export class Container extends BaseClient {
             sessionToken: this.sessionToken,
           },
           r.description,
+          r.healthcheckPath,
           r.port,
           r.protocol
         )
@@ -1329,14 +2154,17 @@ export class Container extends BaseClient {
    * @param address Image's address from its registry.
    *
    * Formatted as [host]/[user]/[repo]:[tag] (e.g., "docker.io/dagger/dagger:main").
+   * @param opts.verifyWith PEM-encoded public key the image must have a cosign signature for.
+   *
+   * If set, unsigned images and images signed with another key are refused.
    */
-  from(address: string): Container {
+  from(address: string, opts?: ContainerFromOpts): Container {
     return new Container({
       queryTree: [
         ...this._queryTree,
         {
           operation: "from",
-          args: { address },
+          args: { address, ...opts },
         },
       ],
       host: this.clientHost,
@@ -1344,6 +2172,52 @@ export class Container extends BaseClient {
     })
   }
 
+  /**
+   * The container's image config, as a JSON object using the Docker image
+   * config field names (e.g., "StopSignal", "Volumes", "Healthcheck").
+   */
+  async imageConfig(): Promise<JSON> {
+    if (this._imageConfig) {
+      return this._imageConfig
+    }
+
+    const response: Awaited<JSON> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "imageConfig",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The digest of this container's image, as published with the default options.
+   *
+   * The image is exported to the engine without being pushed, so this is cheap
+   * once the container has been published.
+   */
+  async imageDigest(): Promise<string> {
+    if (this._imageDigest) {
+      return this._imageDigest
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "imageDigest",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
   /**
    * The unique image reference which can only be retrieved immediately after the 'Container.From' call.
    */
@@ -1446,6 +2320,49 @@ export class Container extends BaseClient {
     )
   }
 
+  /**
+   * The layers of this container's root filesystem, from the bottom up, as they
+   * would be published with the default options.
+   */
+  async layers(): Promise<ContainerLayer[]> {
+    type layers = {
+      createdBy: string
+      diffID: string
+      digest: string
+      mediaType: string
+      size: number
+    }
+
+    const response: Awaited<layers[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "layers",
+        },
+        {
+          operation: "createdBy diffID digest mediaType size",
+        },
+      ],
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new ContainerLayer(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.createdBy,
+          r.diffID,
+          r.digest,
+          r.mediaType,
+          r.size
+        )
+    )
+  }
+
   /**
    * Retrieves the list of paths where a directory is mounted.
    */
@@ -1504,6 +2421,29 @@ export class Container extends BaseClient {
     return response
   }
 
+  /**
+   * The SLSA provenance predicate describing how this container's rootfs was
+   * built, as attached to its image when publishing or exporting it.
+   * @param opts.mode Level of detail to include.
+   */
+  provenance(opts?: ContainerProvenanceOpts): File {
+    const metadata: Metadata = {
+      mode: { is_enum: true },
+    }
+
+    return new File({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "provenance",
+          args: { ...opts, __metadata: metadata },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Publishes this container as a new image to the specified address.
    *
@@ -1522,6 +2462,14 @@ export class Container extends BaseClient {
    * @param opts.mediaTypes Use the specified media types for the published image's layers. Defaults to OCI, which
    * is largely compatible with most recent registries, but Docker may be needed for older
    * registries without OCI support.
+   * @param opts.sbomScanner Container used to generate an SPDX SBOM attestation for each platform.
+   *
+   * It must implement the BuildKit SBOM scanner protocol
+   * (e.g., docker/buildkit-syft-scanner).
+   * @param opts.provenance Attach SLSA provenance attestations with the given level of detail.
+   * @param opts.signWith Secret holding a PEM-encoded private key used to sign the published image.
+   *
+   * The signature is pushed alongside the image in the format used by cosign.
    */
   async publish(address: string, opts?: ContainerPublishOpts): Promise<string> {
     if (this._publish) {
@@ -1531,6 +2479,7 @@ export class Container extends BaseClient {
     const metadata: Metadata = {
       forcedCompression: { is_enum: true },
       mediaTypes: { is_enum: true },
+      provenance: { is_enum: true },
     }
 
     const response: Awaited<string> = await computeQuery(
@@ -1547,6 +2496,58 @@ export class Container extends BaseClient {
     return response
   }
 
+  /**
+   * Publishes this container as a new image to each of the specified addresses
+   * in a single push.
+   *
+   * Returns the fully qualified ref of each address, in the same order.
+   * It can also publish platform variants.
+   * @param addresses Registry addresses to publish the image to.
+   *
+   * Formatted as [host]/[user]/[repo]:[tag] (e.g. "docker.io/dagger/dagger:main").
+   * @param opts.platformVariants Identifiers for other platform specific containers.
+   * Used for multi-platform image.
+   * @param opts.forcedCompression Force each layer of the published image to use the specified compression algorithm.
+   * If this is unset, then if a layer already has a compressed blob in the engine's
+   * cache, that will be used (this can result in a mix of compression algorithms for
+   * different layers). If this is unset and a layer has no compressed blob in the
+   * engine's cache, then it will be compressed using Gzip.
+   * @param opts.mediaTypes Use the specified media types for the published image's layers. Defaults to OCI, which
+   * is largely compatible with most recent registries, but Docker may be needed for older
+   * registries without OCI support.
+   * @param opts.sbomScanner Container used to generate an SPDX SBOM attestation for each platform.
+   *
+   * It must implement the BuildKit SBOM scanner protocol
+   * (e.g., docker/buildkit-syft-scanner).
+   * @param opts.provenance Attach SLSA provenance attestations with the given level of detail.
+   * @param opts.signWith Secret holding a PEM-encoded private key used to sign the published image.
+   *
+   * The signature is pushed alongside the image in the format used by cosign.
+   */
+  async publishAll(
+    addresses: string[],
+    opts?: ContainerPublishAllOpts
+  ): Promise<string[]> {
+    const metadata: Metadata = {
+      forcedCompression: { is_enum: true },
+      mediaTypes: { is_enum: true },
+      provenance: { is_enum: true },
+    }
+
+    const response: Awaited<string[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "publishAll",
+          args: { addresses, ...opts, __metadata: metadata },
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
   /**
    * Retrieves this container's root filesystem. Mounts are not included.
    */
@@ -1587,6 +2588,30 @@ export class Container extends BaseClient {
     return response
   }
 
+  /**
+   * Retrieves this container with the layers of its root filesystem that are not
+   * in another container's root filesystem (e.g., its base image) collapsed into
+   * a single layer.
+   *
+   * Any layer boundaries are removed.
+   * @param opts.from The container whose layers are kept as they are.
+   *
+   * If not set, the whole root filesystem is collapsed into a single layer.
+   */
+  squash(opts?: ContainerSquashOpts): Container {
+    return new Container({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "squash",
+          args: { ...opts },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * The error stream of the last executed command.
    *
@@ -1673,6 +2698,36 @@ export class Container extends BaseClient {
     return response
   }
 
+  /**
+   * Retrieves this container plus the given OCI annotation.
+   *
+   * Annotations are set on the image manifest (or the image index) when the
+   * container is published or exported.
+   * @param name The name of the annotation (e.g., "org.opencontainers.image.source").
+   * @param value The value of the annotation (e.g., "https://github.com/dagger/dagger").
+   * @param opts.index Set the annotation on the image index rather than the image manifest.
+   *
+   * An image index is always created when there are index annotations, even
+   * when publishing or exporting a single platform.
+   */
+  withAnnotation(
+    name: string,
+    value: string,
+    opts?: ContainerWithAnnotationOpts
+  ): Container {
+    return new Container({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "withAnnotation",
+          args: { name, value, ...opts },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Configures default arguments for future commands.
    * @param opts.args Arguments to prepend to future executions (e.g., ["-v", "--no-cache"]).
@@ -1781,6 +2836,18 @@ export class Container extends BaseClient {
    * with "sudo" or executing `docker run` with the `--privileged` flag. Containerization
    * does not provide any security guarantees when using this option. It should only be used
    * when absolutely necessary and only with trusted commands.
+   * @param opts.expectNonZeroExit Do not fail if the command exits with a non-zero code.
+   *
+   * The exit code is recorded instead, and can be retrieved along with the
+   * command's output using exitCode, stdout and stderr.
+   * @param opts.timeout Kill the command if it runs for longer than the given number of seconds.
+   *
+   * A command that times out fails with an EXEC_TIMEOUT_ERROR, even if
+   * expectNonZeroExit is set.
+   * @param opts.cpuLimit Number of CPUs the command may use (e.g., 1.5).
+   * @param opts.memoryLimit Maximum memory the command may use (e.g., "512m" or "2g").
+   * @param opts.pidsLimit Maximum number of processes the command may run at once.
+   * @param opts.ulimits Per-process limits in the form name=soft[:hard] (e.g., ["nofile=1024:2048"]).
    */
   withExec(args: string[], opts?: ContainerWithExecOpts): Container {
     return new Container({
@@ -1805,6 +2872,10 @@ export class Container extends BaseClient {
    * @param port Port number to expose
    * @param opts.protocol Transport layer network protocol
    * @param opts.description Optional port description
+   * @param opts.healthcheckPath HTTP path to poll once the port accepts connections, e.g. /healthz.
+   *
+   * The service is only considered healthy once the path responds with a
+   * non-error status code. Only supported for TCP ports.
    */
   withExposedPort(
     port: number,
@@ -1876,17 +2947,33 @@ export class Container extends BaseClient {
   }
 
   /**
-   * Retrieves this container plus the given label.
-   * @param name The name of the label (e.g., "org.opencontainers.artifact.created").
-   * @param value The value of the label (e.g., "2023-01-01T00:00:00Z").
-   */
-  withLabel(name: string, value: string): Container {
+   * Configures a command to run periodically when the container is started as a
+   * service, like the HEALTHCHECK instruction of a Dockerfile.
+   *
+   * The service is only considered started once the command exits 0, after any
+   * exposed ports accept connections. Dependent services and containers wait for
+   * it to be healthy.
+   *
+   * The command is stored as the HEALTHCHECK of the image config, replacing any
+   * health check inherited from the base image, and is published with it. A
+   * HEALTHCHECK set with withImageConfig or pulled with the image is run the same
+   * way; set its Test to ["NONE"] to disable it.
+   * @param args Command to run to check the health of the service, e.g. ["pg_isready"].
+   * @param opts.interval Seconds to wait between checks (default: 1).
+   * @param opts.timeout Seconds after which a single check fails (default: 30).
+   * @param opts.retries Number of consecutive failures after which the service is unhealthy (default: 10).
+   * @param opts.startPeriod Seconds the service is given to start, during which failures do not count towards retries.
+   */
+  withHealthcheck(
+    args: string[],
+    opts?: ContainerWithHealthcheckOpts
+  ): Container {
     return new Container({
       queryTree: [
         ...this._queryTree,
         {
-          operation: "withLabel",
-          args: { name, value },
+          operation: "withHealthcheck",
+          args: { args, ...opts },
         },
       ],
       host: this.clientHost,
@@ -1895,11 +2982,74 @@ export class Container extends BaseClient {
   }
 
   /**
-   * Retrieves this container plus a cache volume mounted at the given path.
-   * @param path Location of the cache directory (e.g., "/cache/node_modules").
-   * @param cache Identifier of the cache volume to mount.
-   * @param opts.source Identifier of the directory to use as the cache volume's root.
-   * @param opts.sharing Sharing mode of the cache volume.
+   * Retrieves this container with the given JSON merge patch (RFC 7386) applied
+   * to its image config.
+   *
+   * Fields are named as in the Docker image config, e.g.
+   * {"StopSignal": "SIGQUIT", "Volumes": {"/data": {}}, "OnBuild": null}.
+   * Healthcheck durations are in nanoseconds.
+   * @param patch The JSON merge patch to apply.
+   */
+  withImageConfig(patch: JSON): Container {
+    return new Container({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "withImageConfig",
+          args: { patch },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * Retrieves this container plus the given label.
+   * @param name The name of the label (e.g., "org.opencontainers.artifact.created").
+   * @param value The value of the label (e.g., "2023-01-01T00:00:00Z").
+   */
+  withLabel(name: string, value: string): Container {
+    return new Container({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "withLabel",
+          args: { name, value },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * Retrieves this container with a layer boundary at its current root
+   * filesystem.
+   *
+   * When the image is published or exported, the layers added between two
+   * boundaries are squashed into a single layer. Layers below the first boundary
+   * and above the last one are kept as they are.
+   */
+  withLayerBoundary(): Container {
+    return new Container({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "withLayerBoundary",
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * Retrieves this container plus a cache volume mounted at the given path.
+   * @param path Location of the cache directory (e.g., "/cache/node_modules").
+   * @param cache Identifier of the cache volume to mount.
+   * @param opts.source Identifier of the directory to use as the cache volume's root.
+   * @param opts.sharing Sharing mode of the cache volume.
    * @param opts.owner A user:group to set for the mounted cache directory.
    *
    * Note that this changes the ownership of the specified mount along with the
@@ -2038,6 +3188,32 @@ export class Container extends BaseClient {
     })
   }
 
+  /**
+   * Restricts the network access of commands run in this container.
+   * @param mode Which destinations commands may reach.
+   * @param opts.allow Hostnames, IPs or CIDRs reachable in addition to bound services
+   * (e.g., ["proxy.golang.org", "10.0.0.0/8"]).
+   *
+   * Hostnames are resolved once, when each command starts, and only the
+   * addresses they resolve to at that time are reachable. Use a CIDR for
+   * destinations whose addresses may change while a command runs.
+   *
+   * Only allowed with the NETWORK_SERVICES_ONLY mode.
+   */
+  withNetwork(mode: NetworkMode, opts?: ContainerWithNetworkOpts): Container {
+    return new Container({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "withNetwork",
+          args: { mode, ...opts },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Retrieves this container plus a new file written at the given path.
    * @param path Location of the written file (e.g., "/tmp/file.txt").
@@ -2090,6 +3266,29 @@ export class Container extends BaseClient {
     })
   }
 
+  /**
+   * Sets the default resource limits for commands run in this container.
+   *
+   * Limits set on withExec take precedence over the defaults.
+   * @param opts.cpuLimit Number of CPUs the command may use (e.g., 1.5).
+   * @param opts.memoryLimit Maximum memory the command may use (e.g., "512m" or "2g").
+   * @param opts.pidsLimit Maximum number of processes the command may run at once.
+   * @param opts.ulimits Per-process limits in the form name=soft[:hard] (e.g., ["nofile=1024:2048"]).
+   */
+  withResourceLimits(opts?: ContainerWithResourceLimitsOpts): Container {
+    return new Container({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "withResourceLimits",
+          args: { ...opts },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Initializes this container from this DirectoryID.
    */
@@ -2135,16 +3334,26 @@ export class Container extends BaseClient {
    * The service will be reachable from the container via the provided hostname alias.
    *
    * The service dependency will also convey to any files or directories produced by the container.
+   *
+   * Bound services are started in parallel unless they depend on each other, in
+   * which case each service is started only once its dependencies are healthy, and
+   * stopped before them.
    * @param alias A name that can be used to reach the service from the container
    * @param service Identifier of the service container
+   * @param opts.dependsOn Aliases of services already bound to the container that must be started
+   * and healthy before this service is started (e.g., ["db"]).
    */
-  withServiceBinding(alias: string, service: Service): Container {
+  withServiceBinding(
+    alias: string,
+    service: Service,
+    opts?: ContainerWithServiceBindingOpts
+  ): Container {
     return new Container({
       queryTree: [
         ...this._queryTree,
         {
           operation: "withServiceBinding",
-          args: { alias, service },
+          args: { alias, service, ...opts },
         },
       ],
       host: this.clientHost,
@@ -2216,6 +3425,28 @@ export class Container extends BaseClient {
     })
   }
 
+  /**
+   * Retrieves this container minus the given OCI annotation.
+   * @param name The name of the annotation to remove (e.g., "org.opencontainers.image.source").
+   * @param opts.index Remove the annotation from the image index rather than the image manifest.
+   */
+  withoutAnnotation(
+    name: string,
+    opts?: ContainerWithoutAnnotationOpts
+  ): Container {
+    return new Container({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "withoutAnnotation",
+          args: { name, ...opts },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Retrieves this container minus the given environment variable.
    * @param name The name of the environment variable (e.g., "HOST").
@@ -2383,11 +3614,149 @@ export class Container extends BaseClient {
   }
 }
 
+/**
+ * A layer of a container's root filesystem.
+ */
+export class ContainerLayer extends BaseClient {
+  private readonly _createdBy?: string = undefined
+  private readonly _diffID?: string = undefined
+  private readonly _digest?: string = undefined
+  private readonly _mediaType?: string = undefined
+  private readonly _size?: number = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
+    _createdBy?: string,
+    _diffID?: string,
+    _digest?: string,
+    _mediaType?: string,
+    _size?: number
+  ) {
+    super(parent)
+
+    this._createdBy = _createdBy
+    this._diffID = _diffID
+    this._digest = _digest
+    this._mediaType = _mediaType
+    this._size = _size
+  }
+
+  /**
+   * The command that produced the layer, or a description of where it came from
+   * (e.g., a pulled image layer).
+   */
+  async createdBy(): Promise<string> {
+    if (this._createdBy) {
+      return this._createdBy
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "createdBy",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The digest of the uncompressed layer.
+   */
+  async diffID(): Promise<string> {
+    if (this._diffID) {
+      return this._diffID
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "diffID",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The digest of the compressed layer.
+   */
+  async digest(): Promise<string> {
+    if (this._digest) {
+      return this._digest
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "digest",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The media type of the layer.
+   */
+  async mediaType(): Promise<string> {
+    if (this._mediaType) {
+      return this._mediaType
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "mediaType",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The size of the compressed layer in bytes.
+   */
+  async size(): Promise<number> {
+    if (this._size) {
+      return this._size
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "size",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+}
+
 /**
  * A directory.
  */
 export class Directory extends BaseClient {
   private readonly _id?: DirectoryID = undefined
+  private readonly _digest?: string = undefined
   private readonly _export?: boolean = undefined
   private readonly _sync?: DirectoryID = undefined
 
@@ -2397,12 +3766,14 @@ export class Directory extends BaseClient {
   constructor(
     parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
     _id?: DirectoryID,
+    _digest?: string,
     _export?: boolean,
     _sync?: DirectoryID
   ) {
     super(parent)
 
     this._id = _id
+    this._digest = _digest
     this._export = _export
     this._sync = _sync
   }
@@ -2428,6 +3799,35 @@ export class Directory extends BaseClient {
     return response
   }
 
+  /**
+   * Returns an archive of this directory's contents.
+   *
+   * Entries are written in lexical order with a fixed modification time, so the
+   * same contents always produce the same archive.
+   * @param opts.format Format of the archive.
+   * @param opts.compression Compression of a tar archive: Gzip, Zstd or Uncompressed (the default).
+   *
+   * Zip archives are always compressed.
+   */
+  asArchive(opts?: DirectoryAsArchiveOpts): File {
+    const metadata: Metadata = {
+      format: { is_enum: true },
+      compression: { is_enum: true },
+    }
+
+    return new File({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "asArchive",
+          args: { ...opts, __metadata: metadata },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Load the directory as a Dagger module
    * @param opts.sourceSubpath An optional subpath of the directory which contains the module's source
@@ -2456,15 +3856,20 @@ export class Directory extends BaseClient {
   }
 
   /**
-   * Gets the difference between this directory and an another directory.
+   * Returns a unified diff of the changes that turn this directory into another
+   * directory, in the format of git diff.
+   *
+   * Changes to directories, ownership and modification times are not part of
+   * the patch. Changes to binary files are only noted with a "Binary files ...
+   * differ" line, so a patch that contains them cannot be applied with withPatch.
    * @param other Identifier of the directory to compare.
    */
-  diff(other: Directory): Directory {
-    return new Directory({
+  asPatch(other: Directory): File {
+    return new File({
       queryTree: [
         ...this._queryTree,
         {
-          operation: "diff",
+          operation: "asPatch",
           args: { other },
         },
       ],
@@ -2474,25 +3879,122 @@ export class Directory extends BaseClient {
   }
 
   /**
-   * Retrieves a directory at the given path.
-   * @param path Location of the directory to retrieve (e.g., "/src").
+   * The changes that turn this directory into another directory.
+   *
+   * Paths whose contents and metadata only differ by their modification time
+   * are not reported, and files that were moved without changing their contents
+   * are reported as renames.
+   * @param other Identifier of the directory to compare.
    */
-  directory(path: string): Directory {
-    return new Directory({
-      queryTree: [
+  async changes(other: Directory): Promise<FileChange[]> {
+    type changes = {
+      kind: ChangeKind
+      oldPath: string
+      oldPermissions: number
+      path: string
+      permissions: number
+      size: number
+      type: FileType
+    }
+
+    const response: Awaited<changes[]> = await computeQuery(
+      [
         ...this._queryTree,
         {
-          operation: "directory",
-          args: { path },
+          operation: "changes",
+          args: { other },
+        },
+        {
+          operation: "kind oldPath oldPermissions path permissions size type",
         },
       ],
-      host: this.clientHost,
-      sessionToken: this.sessionToken,
-    })
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new FileChange(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.kind,
+          r.oldPath,
+          r.oldPermissions,
+          r.path,
+          r.permissions,
+          r.size,
+          r.type
+        )
+    )
   }
 
   /**
-   * Builds a new Docker container from this directory.
+   * Gets the difference between this directory and an another directory.
+   * @param other Identifier of the directory to compare.
+   */
+  diff(other: Directory): Directory {
+    return new Directory({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "diff",
+          args: { other },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * Returns a hash of this directory's contents (e.g., "sha256:...").
+   *
+   * The hash covers the names, contents, permissions and ownership of the
+   * directory's entries, but not their modification times.
+   * @param opts.exclude Exclude entries matching the given patterns from the hash (e.g., ["node_modules"]).
+   * @param opts.include Only hash entries matching the given patterns (e.g., ["**/*.go"]).
+   */
+  async digest(opts?: DirectoryDigestOpts): Promise<string> {
+    if (this._digest) {
+      return this._digest
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "digest",
+          args: { ...opts },
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * Retrieves a directory at the given path.
+   * @param path Location of the directory to retrieve (e.g., "/src").
+   */
+  directory(path: string): Directory {
+    return new Directory({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "directory",
+          args: { path },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * Builds a new Docker container from this directory.
    * @param opts.dockerfile Path to the Dockerfile to use (e.g., "frontend.Dockerfile").
    *
    * Defaults: './Dockerfile'.
@@ -2539,8 +4041,12 @@ export class Directory extends BaseClient {
   /**
    * Writes the contents of the directory to a path on the host.
    * @param path Location of the copied directory (e.g., "logs/").
+   * @param opts.mirror Remove any files on the host path that aren't in this directory, so that
+   * it mirrors the directory exactly.
+   * @param opts.exclude Exclude artifacts that match the given pattern (e.g., [".git", "**/.env"]).
+   * Excluded paths are neither written nor removed on the host.
    */
-  async export(path: string): Promise<boolean> {
+  async export(path: string, opts?: DirectoryExportOpts): Promise<boolean> {
     if (this._export) {
       return this._export
     }
@@ -2550,7 +4056,7 @@ export class Directory extends BaseClient {
         ...this._queryTree,
         {
           operation: "export",
-          args: { path },
+          args: { path, ...opts },
         },
       ],
       this.client
@@ -2559,6 +4065,61 @@ export class Directory extends BaseClient {
     return response
   }
 
+  /**
+   * Returns the changes that exporting this directory to a path on the host
+   * would make, without writing anything.
+   * @param path Location of the copied directory (e.g., "logs/").
+   * @param opts.mirror Whether the export would remove files on the host path that aren't in
+   * this directory.
+   * @param opts.exclude Exclude artifacts that match the given pattern (e.g., [".git", "**/.env"]).
+   */
+  async exportChanges(
+    path: string,
+    opts?: DirectoryExportChangesOpts
+  ): Promise<FileChange[]> {
+    type exportChanges = {
+      kind: ChangeKind
+      oldPath: string
+      oldPermissions: number
+      path: string
+      permissions: number
+      size: number
+      type: FileType
+    }
+
+    const response: Awaited<exportChanges[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "exportChanges",
+          args: { path, ...opts },
+        },
+        {
+          operation: "kind oldPath oldPermissions path permissions size type",
+        },
+      ],
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new FileChange(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.kind,
+          r.oldPath,
+          r.oldPermissions,
+          r.path,
+          r.permissions,
+          r.size,
+          r.type
+        )
+    )
+  }
+
   /**
    * Retrieves a file at the given path.
    * @param path Location of the file to retrieve (e.g., "README.md").
@@ -2616,6 +4177,24 @@ export class Directory extends BaseClient {
     })
   }
 
+  /**
+   * Returns metadata for the file, directory or symlink at the given path.
+   * @param path Location of the entry to look at (e.g., "go.sum").
+   */
+  stat(path: string): DirectoryEntry {
+    return new DirectoryEntry({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "stat",
+          args: { path },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Force evaluation in the engine.
    */
@@ -2633,6 +4212,63 @@ export class Directory extends BaseClient {
     return this
   }
 
+  /**
+   * Returns metadata for every file, directory and symlink under the given path,
+   * recursing into subdirectories.
+   *
+   * Returned paths are relative to this directory.
+   * @param opts.path Location of the directory to walk (e.g., "/src").
+   * @param opts.include Only return entries matching the given patterns (e.g., ["**/*.go"]).
+   *
+   * Patterns are relative to the walked path.
+   * @param opts.exclude Skip entries matching the given patterns (e.g., ["node_modules"]).
+   *
+   * Excluded directories are not walked. Patterns are relative to the walked path.
+   * @param opts.maxDepth How many levels of subdirectories to walk, e.g. 1 for only the immediate
+   * entries. Walks the entire tree if unset.
+   */
+  async walk(opts?: DirectoryWalkOpts): Promise<DirectoryEntry[]> {
+    type walk = {
+      modifiedAt: number
+      path: string
+      permissions: number
+      size: number
+      symlinkTarget: string
+      type: FileType
+    }
+
+    const response: Awaited<walk[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "walk",
+          args: { ...opts },
+        },
+        {
+          operation: "modifiedAt path permissions size symlinkTarget type",
+        },
+      ],
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new DirectoryEntry(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.modifiedAt,
+          r.path,
+          r.permissions,
+          r.size,
+          r.symlinkTarget,
+          r.type
+        )
+    )
+  }
+
   /**
    * Retrieves this directory plus a directory written at the given path.
    * @param path Location of the written directory (e.g., "/src/").
@@ -2734,6 +4370,29 @@ export class Directory extends BaseClient {
     })
   }
 
+  /**
+   * Retrieves this directory with a unified diff applied to it.
+   *
+   * The first component of the paths in the patch is stripped, as with
+   * patch -p1 (e.g., "a/src/main.go" patches "src/main.go").
+   *
+   * Binary patches are not supported.
+   * @param patch Identifier of the file containing the patch.
+   */
+  withPatch(patch: File): Directory {
+    return new Directory({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "withPatch",
+          args: { patch },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Retrieves this directory with all file/dir timestamps set to the given time.
    * @param timestamp Timestamp to set dir/files in.
@@ -2800,6 +4459,166 @@ export class Directory extends BaseClient {
   }
 }
 
+/**
+ * Metadata describing an entry in a directory.
+ */
+export class DirectoryEntry extends BaseClient {
+  private readonly _modifiedAt?: number = undefined
+  private readonly _path?: string = undefined
+  private readonly _permissions?: number = undefined
+  private readonly _size?: number = undefined
+  private readonly _symlinkTarget?: string = undefined
+  private readonly _type?: FileType = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
+    _modifiedAt?: number,
+    _path?: string,
+    _permissions?: number,
+    _size?: number,
+    _symlinkTarget?: string,
+    _type?: FileType
+  ) {
+    super(parent)
+
+    this._modifiedAt = _modifiedAt
+    this._path = _path
+    this._permissions = _permissions
+    this._size = _size
+    this._symlinkTarget = _symlinkTarget
+    this._type = _type
+  }
+
+  /**
+   * The entry's modification time, as a Unix timestamp.
+   */
+  async modifiedAt(): Promise<number> {
+    if (this._modifiedAt) {
+      return this._modifiedAt
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "modifiedAt",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The path of the entry, relative to the directory (e.g., "src/main.go").
+   */
+  async path(): Promise<string> {
+    if (this._path) {
+      return this._path
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "path",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The entry's permission bits (e.g., 0644).
+   */
+  async permissions(): Promise<number> {
+    if (this._permissions) {
+      return this._permissions
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "permissions",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The size of the entry in bytes.
+   */
+  async size(): Promise<number> {
+    if (this._size) {
+      return this._size
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "size",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The target of the entry, if it is a symlink.
+   */
+  async symlinkTarget(): Promise<string> {
+    if (this._symlinkTarget) {
+      return this._symlinkTarget
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "symlinkTarget",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The type of the entry.
+   */
+  async type_(): Promise<FileType> {
+    if (this._type) {
+      return this._type
+    }
+
+    const response: Awaited<FileType> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "type",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+}
+
 /**
  * A simple key value object that represents an environment variable.
  */
@@ -2952,6 +4771,7 @@ export class FieldTypeDef extends BaseClient {
 export class File extends BaseClient {
   private readonly _id?: FileID = undefined
   private readonly _contents?: string = undefined
+  private readonly _digest?: string = undefined
   private readonly _export?: boolean = undefined
   private readonly _size?: number = undefined
   private readonly _sync?: FileID = undefined
@@ -2963,6 +4783,7 @@ export class File extends BaseClient {
     parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
     _id?: FileID,
     _contents?: string,
+    _digest?: string,
     _export?: boolean,
     _size?: number,
     _sync?: FileID
@@ -2971,6 +4792,7 @@ export class File extends BaseClient {
 
     this._id = _id
     this._contents = _contents
+    this._digest = _digest
     this._export = _export
     this._size = _size
     this._sync = _sync
@@ -3018,6 +4840,33 @@ export class File extends BaseClient {
     return response
   }
 
+  /**
+   * Returns the digest of the file's contents (e.g., "sha256:...").
+   * @param opts.algorithm The hash algorithm to use.
+   */
+  async digest(opts?: FileDigestOpts): Promise<string> {
+    if (this._digest) {
+      return this._digest
+    }
+
+    const metadata: Metadata = {
+      algorithm: { is_enum: true },
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "digest",
+          args: { ...opts, __metadata: metadata },
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
   /**
    * Writes the file to a file path on the host.
    * @param path Location of the written directory (e.g., "output.txt").
@@ -3043,6 +4892,25 @@ export class File extends BaseClient {
     return response
   }
 
+  /**
+   * Extracts this file as an archive into a directory.
+   *
+   * Tar archives (optionally compressed with gzip or zstd) and zip archives are
+   * supported. The format is detected from the file's contents.
+   */
+  extract(): Directory {
+    return new Directory({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "extract",
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Gets the size of the file, in bytes.
    */
@@ -3111,6 +4979,191 @@ export class File extends BaseClient {
   }
 }
 
+/**
+ * A change to a path between two directories.
+ */
+export class FileChange extends BaseClient {
+  private readonly _kind?: ChangeKind = undefined
+  private readonly _oldPath?: string = undefined
+  private readonly _oldPermissions?: number = undefined
+  private readonly _path?: string = undefined
+  private readonly _permissions?: number = undefined
+  private readonly _size?: number = undefined
+  private readonly _type?: FileType = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
+    _kind?: ChangeKind,
+    _oldPath?: string,
+    _oldPermissions?: number,
+    _path?: string,
+    _permissions?: number,
+    _size?: number,
+    _type?: FileType
+  ) {
+    super(parent)
+
+    this._kind = _kind
+    this._oldPath = _oldPath
+    this._oldPermissions = _oldPermissions
+    this._path = _path
+    this._permissions = _permissions
+    this._size = _size
+    this._type = _type
+  }
+
+  /**
+   * The kind of change.
+   */
+  async kind(): Promise<ChangeKind> {
+    if (this._kind) {
+      return this._kind
+    }
+
+    const response: Awaited<ChangeKind> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "kind",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The path the entry was renamed from, if it was renamed.
+   */
+  async oldPath(): Promise<string> {
+    if (this._oldPath) {
+      return this._oldPath
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "oldPath",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The entry's permission bits before the change, or 0 if it was added.
+   */
+  async oldPermissions(): Promise<number> {
+    if (this._oldPermissions) {
+      return this._oldPermissions
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "oldPermissions",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The path of the entry, relative to the directory (e.g., "src/main.go").
+   */
+  async path(): Promise<string> {
+    if (this._path) {
+      return this._path
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "path",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The entry's permission bits after the change (e.g., 0644), or 0 if it was deleted.
+   */
+  async permissions(): Promise<number> {
+    if (this._permissions) {
+      return this._permissions
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "permissions",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The size of the entry after the change in bytes, or 0 if it was deleted or
+   * is not a regular file.
+   */
+  async size(): Promise<number> {
+    if (this._size) {
+      return this._size
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "size",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The type of the entry, or its former type if it was deleted.
+   */
+  async type_(): Promise<FileType> {
+    if (this._type) {
+      return this._type
+    }
+
+    const response: Awaited<FileType> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "type",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+}
+
 /**
  * Function represents a resolver provided by a Module.
  *
@@ -3917,6 +5970,8 @@ export class Host extends BaseClient {
    * @param path Location of the directory to access (e.g., ".").
    * @param opts.exclude Exclude artifacts that match the given pattern (e.g., ["node_modules/", ".git*"]).
    * @param opts.include Include only artifacts that match the given pattern (e.g., ["app/", "package.*"]).
+   * @param opts.gitignore Exclude artifacts ignored by the .gitignore files in the directory and its subdirectories.
+   * @param opts.ignoreFile Exclude artifacts ignored by the given .dockerignore-style file, relative to the directory (e.g., ".dockerignore").
    */
   directory(path: string, opts?: HostDirectoryOpts): Directory {
     return new Directory({
@@ -3985,63 +6040,574 @@ export class Host extends BaseClient {
       queryTree: [
         ...this._queryTree,
         {
-          operation: "setSecretFile",
-          args: { name, path },
+          operation: "setSecretFile",
+          args: { name, path },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * Creates a tunnel that forwards traffic from the host to a service.
+   * @param service Service to send traffic from the tunnel.
+   * @param opts.native Map each service port to the same port on the host, as if the service were
+   * running natively.
+   *
+   * Note: enabling may result in port conflicts.
+   * @param opts.ports Configure explicit port forwarding rules for the tunnel.
+   *
+   * If a port's frontend is unspecified or 0, a random port will be chosen by
+   * the host.
+   *
+   * If no ports are given, all of the service's ports are forwarded. If native
+   * is true, each port maps to the same port on the host. If native is false,
+   * each port maps to a random port chosen by the host.
+   *
+   * If ports are given and native is true, the ports are additive.
+   */
+  tunnel(service: Service, opts?: HostTunnelOpts): Service {
+    return new Service({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "tunnel",
+          args: { service, ...opts },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * Accesses a Unix socket on the host.
+   * @param path Location of the Unix socket (e.g., "/var/run/docker.sock").
+   */
+  unixSocket(path: string): Socket {
+    return new Socket({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "unixSocket",
+          args: { path },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+}
+
+/**
+ * A layer of an image.
+ */
+export class ImageLayer extends BaseClient {
+  private readonly _digest?: string = undefined
+  private readonly _mediaType?: string = undefined
+  private readonly _size?: number = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
+    _digest?: string,
+    _mediaType?: string,
+    _size?: number
+  ) {
+    super(parent)
+
+    this._digest = _digest
+    this._mediaType = _mediaType
+    this._size = _size
+  }
+
+  /**
+   * The digest of the compressed layer.
+   */
+  async digest(): Promise<string> {
+    if (this._digest) {
+      return this._digest
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "digest",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The media type of the layer.
+   */
+  async mediaType(): Promise<string> {
+    if (this._mediaType) {
+      return this._mediaType
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "mediaType",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The compressed size of the layer, in bytes.
+   */
+  async size(): Promise<number> {
+    if (this._size) {
+      return this._size
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "size",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+}
+
+/**
+ * The manifest of a single platform of an image.
+ */
+export class ImageManifest extends BaseClient {
+  private readonly _config?: JSON = undefined
+  private readonly _digest?: string = undefined
+  private readonly _mediaType?: string = undefined
+  private readonly _platform?: Platform = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
+    _config?: JSON,
+    _digest?: string,
+    _mediaType?: string,
+    _platform?: Platform
+  ) {
+    super(parent)
+
+    this._config = _config
+    this._digest = _digest
+    this._mediaType = _mediaType
+    this._platform = _platform
+  }
+
+  /**
+   * The annotations of the manifest.
+   */
+  async annotations(): Promise<Label[]> {
+    type annotations = {
+      name: string
+      value: string
+    }
+
+    const response: Awaited<annotations[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "annotations",
+        },
+        {
+          operation: "name value",
+        },
+      ],
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new Label(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.name,
+          r.value
+        )
+    )
+  }
+
+  /**
+   * The image config.
+   */
+  async config(): Promise<JSON> {
+    if (this._config) {
+      return this._config
+    }
+
+    const response: Awaited<JSON> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "config",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The digest of the manifest.
+   */
+  async digest(): Promise<string> {
+    if (this._digest) {
+      return this._digest
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "digest",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The labels set in the image config.
+   */
+  async labels(): Promise<Label[]> {
+    type labels = {
+      name: string
+      value: string
+    }
+
+    const response: Awaited<labels[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "labels",
+        },
+        {
+          operation: "name value",
+        },
+      ],
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new Label(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.name,
+          r.value
+        )
+    )
+  }
+
+  /**
+   * The layers of the manifest.
+   */
+  async layers(): Promise<ImageLayer[]> {
+    type layers = {
+      digest: string
+      mediaType: string
+      size: number
+    }
+
+    const response: Awaited<layers[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "layers",
+        },
+        {
+          operation: "digest mediaType size",
+        },
+      ],
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new ImageLayer(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.digest,
+          r.mediaType,
+          r.size
+        )
+    )
+  }
+
+  /**
+   * The media type of the manifest.
+   */
+  async mediaType(): Promise<string> {
+    if (this._mediaType) {
+      return this._mediaType
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "mediaType",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The platform of the manifest.
+   */
+  async platform(): Promise<Platform> {
+    if (this._platform) {
+      return this._platform
+    }
+
+    const response: Awaited<Platform> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "platform",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+}
+
+/**
+ * The metadata of an image in a registry.
+ */
+export class ImageMetadata extends BaseClient {
+  private readonly _digest?: string = undefined
+  private readonly _mediaType?: string = undefined
+  private readonly _ref?: string = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
+    _digest?: string,
+    _mediaType?: string,
+    _ref?: string
+  ) {
+    super(parent)
+
+    this._digest = _digest
+    this._mediaType = _mediaType
+    this._ref = _ref
+  }
+
+  /**
+   * The annotations of the image's index or manifest.
+   */
+  async annotations(): Promise<Label[]> {
+    type annotations = {
+      name: string
+      value: string
+    }
+
+    const response: Awaited<annotations[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "annotations",
+        },
+        {
+          operation: "name value",
+        },
+      ],
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new Label(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.name,
+          r.value
+        )
+    )
+  }
+
+  /**
+   * The digest of the image's index or manifest.
+   */
+  async digest(): Promise<string> {
+    if (this._digest) {
+      return this._digest
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "digest",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The manifest of a single platform of the image.
+   *
+   * Defaults to the only platform of a single-platform image, or the engine's
+   * default platform.
+   */
+  manifest(opts?: ImageMetadataManifestOpts): ImageManifest {
+    return new ImageManifest({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "manifest",
+          args: { ...opts },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
+  /**
+   * The manifest of each platform of the image.
+   */
+  async manifests(): Promise<ImageManifest[]> {
+    type manifests = {
+      config: JSON
+      digest: string
+      mediaType: string
+      platform: Platform
+    }
+
+    const response: Awaited<manifests[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "manifests",
+        },
+        {
+          operation: "config digest mediaType platform",
+        },
+      ],
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new ImageManifest(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.config,
+          r.digest,
+          r.mediaType,
+          r.platform
+        )
+    )
+  }
+
+  /**
+   * The media type of the image's index or manifest.
+   */
+  async mediaType(): Promise<string> {
+    if (this._mediaType) {
+      return this._mediaType
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "mediaType",
         },
       ],
-      host: this.clientHost,
-      sessionToken: this.sessionToken,
-    })
+      this.client
+    )
+
+    return response
   }
 
   /**
-   * Creates a tunnel that forwards traffic from the host to a service.
-   * @param service Service to send traffic from the tunnel.
-   * @param opts.native Map each service port to the same port on the host, as if the service were
-   * running natively.
-   *
-   * Note: enabling may result in port conflicts.
-   * @param opts.ports Configure explicit port forwarding rules for the tunnel.
-   *
-   * If a port's frontend is unspecified or 0, a random port will be chosen by
-   * the host.
-   *
-   * If no ports are given, all of the service's ports are forwarded. If native
-   * is true, each port maps to the same port on the host. If native is false,
-   * each port maps to a random port chosen by the host.
-   *
-   * If ports are given and native is true, the ports are additive.
+   * The platforms of the image.
    */
-  tunnel(service: Service, opts?: HostTunnelOpts): Service {
-    return new Service({
-      queryTree: [
+  async platforms(): Promise<Platform[]> {
+    const response: Awaited<Platform[]> = await computeQuery(
+      [
         ...this._queryTree,
         {
-          operation: "tunnel",
-          args: { service, ...opts },
+          operation: "platforms",
         },
       ],
-      host: this.clientHost,
-      sessionToken: this.sessionToken,
-    })
+      this.client
+    )
+
+    return response
   }
 
   /**
-   * Accesses a Unix socket on the host.
-   * @param path Location of the Unix socket (e.g., "/var/run/docker.sock").
+   * The fully qualified ref of the image, including its digest.
    */
-  unixSocket(path: string): Socket {
-    return new Socket({
-      queryTree: [
+  async ref(): Promise<string> {
+    if (this._ref) {
+      return this._ref
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
         ...this._queryTree,
         {
-          operation: "unixSocket",
-          args: { path },
+          operation: "ref",
         },
       ],
-      host: this.clientHost,
-      sessionToken: this.sessionToken,
-    })
+      this.client
+    )
+
+    return response
   }
 }
 
@@ -4721,6 +7287,7 @@ export class ObjectTypeDef extends BaseClient {
  */
 export class Port extends BaseClient {
   private readonly _description?: string = undefined
+  private readonly _healthcheckPath?: string = undefined
   private readonly _port?: number = undefined
   private readonly _protocol?: NetworkProtocol = undefined
 
@@ -4730,12 +7297,14 @@ export class Port extends BaseClient {
   constructor(
     parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
     _description?: string,
+    _healthcheckPath?: string,
     _port?: number,
     _protocol?: NetworkProtocol
   ) {
     super(parent)
 
     this._description = _description
+    this._healthcheckPath = _healthcheckPath
     this._port = _port
     this._protocol = _protocol
   }
@@ -4761,6 +7330,27 @@ export class Port extends BaseClient {
     return response
   }
 
+  /**
+   * The HTTP path polled to check the health of the service, if any.
+   */
+  async healthcheckPath(): Promise<string> {
+    if (this._healthcheckPath) {
+      return this._healthcheckPath
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "healthcheckPath",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
   /**
    * The port number.
    */
@@ -4840,6 +7430,40 @@ export class Client extends BaseClient {
     })
   }
 
+  /**
+   * Lists the cache volumes stored on the engine.
+   */
+  async cacheVolumes(): Promise<CacheVolume[]> {
+    type cacheVolumes = {
+      id: CacheVolumeID
+    }
+
+    const response: Awaited<cacheVolumes[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "cacheVolumes",
+        },
+        {
+          operation: "id",
+        },
+      ],
+      this.client
+    )
+
+    return response.map(
+      (r) =>
+        new CacheVolume(
+          {
+            queryTree: this.queryTree,
+            host: this.clientHost,
+            sessionToken: this.sessionToken,
+          },
+          r.id
+        )
+    )
+  }
+
   /**
    * Checks if the current Dagger Engine is compatible with an SDK's required version.
    * @param version The SDK's required version.
@@ -4879,6 +7503,31 @@ export class Client extends BaseClient {
     })
   }
 
+  /**
+   * Copies an image between registries without unpacking it, including every
+   * platform of a multi-platform image.
+   *
+   * Returns the fully qualified ref of each destination, in the same order.
+   * @param source Address of the image to copy (e.g., "docker.io/dagger/dagger:main").
+   * @param destinations Registry addresses to copy the image to.
+   *
+   * Formatted as [host]/[user]/[repo]:[tag] (e.g. "docker.io/dagger/dagger:main").
+   */
+  async copyImage(source: string, destinations: string[]): Promise<string[]> {
+    const response: Awaited<string[]> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "copyImage",
+          args: { source, destinations },
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
   /**
    * The FunctionCall context that the SDK caller is currently executing in.
    * If the caller is not currently executing in a function, this will return
@@ -4913,6 +7562,33 @@ export class Client extends BaseClient {
     })
   }
 
+  /**
+   * Loads the state of a failed exec as a container, with the exec's filesystem,
+   * environment, mounts and working directory.
+   *
+   * Cache mounts, tmpfs mounts, secrets, sockets and service bindings are not
+   * restored.
+   *
+   * Failed execs are only kept if the session was started to debug them (e.g.,
+   * with dagger call --debug-on-failure or dagger run --interactive), and only
+   * the 10 most recent ones. If a module function fails, its debug ID is the one
+   * of the last exec that failed in the function, if any.
+   * @param id The debug ID reported in the extensions of the exec's error.
+   */
+  debugContainer(id: string): Container {
+    return new Container({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "debugContainer",
+          args: { id },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * The default platform of the builder.
    */
@@ -5059,6 +7735,24 @@ export class Client extends BaseClient {
     })
   }
 
+  /**
+   * Fetches the metadata of an image in a registry without pulling its layers.
+   * @param address Image's address from its registry (e.g., "docker.io/dagger/dagger:main").
+   */
+  imageMetadata(address: string): ImageMetadata {
+    return new ImageMetadata({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "imageMetadata",
+          args: { address },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Load a CacheVolume from its ID.
    */
@@ -5357,6 +8051,33 @@ export class Client extends BaseClient {
     })
   }
 
+  /**
+   * Sets a secret given a user defined name to a URI reference to its value and
+   * returns the secret.
+   *
+   * The reference is resolved by the calling client each time the secret is
+   * used, so the plaintext is never sent ahead of time. Supported schemes are
+   * env://NAME (environment variable), file://PATH (file contents), cmd://COMMAND
+   * (stdout of a shell command) and vault://PATH?field=KEY (Vault-compatible KV
+   * HTTP API, configured by VAULT_ADDR and VAULT_TOKEN). Clients may register
+   * additional schemes.
+   * @param name The user defined name for this secret
+   * @param uri The URI referencing the secret value (e.g., "env://GITHUB_TOKEN")
+   */
+  setSecretURI(name: string, uri: string): Secret {
+    return new Secret({
+      queryTree: [
+        ...this._queryTree,
+        {
+          operation: "setSecretURI",
+          args: { name, uri },
+        },
+      ],
+      host: this.clientHost,
+      sessionToken: this.sessionToken,
+    })
+  }
+
   /**
    * Loads a socket by its ID.
    * @deprecated Use loadSocketFromID instead.
@@ -5468,8 +8189,13 @@ export class Secret extends BaseClient {
 export class Service extends BaseClient {
   private readonly _id?: ServiceID = undefined
   private readonly _endpoint?: string = undefined
+  private readonly _exitCode?: number = undefined
+  private readonly _health?: ServiceHealthStatus = undefined
   private readonly _hostname?: string = undefined
   private readonly _start?: ServiceID = undefined
+  private readonly _status?: ServiceStatus = undefined
+  private readonly _stderr?: string = undefined
+  private readonly _stdout?: string = undefined
   private readonly _stop?: ServiceID = undefined
 
   /**
@@ -5479,16 +8205,26 @@ export class Service extends BaseClient {
     parent?: { queryTree?: QueryTree[]; host?: string; sessionToken?: string },
     _id?: ServiceID,
     _endpoint?: string,
+    _exitCode?: number,
+    _health?: ServiceHealthStatus,
     _hostname?: string,
     _start?: ServiceID,
+    _status?: ServiceStatus,
+    _stderr?: string,
+    _stdout?: string,
     _stop?: ServiceID
   ) {
     super(parent)
 
     this._id = _id
     this._endpoint = _endpoint
+    this._exitCode = _exitCode
+    this._health = _health
     this._hostname = _hostname
     this._start = _start
+    this._status = _status
+    this._stderr = _stderr
+    this._stdout = _stdout
     this._stop = _stop
   }
 
@@ -5541,6 +8277,54 @@ export class Service extends BaseClient {
     return response
   }
 
+  /**
+   * The exit code of the service's process, if it has exited.
+   *
+   * Returns null while the service is still running. If the service was stopped,
+   * this is the exit code of its last run.
+   */
+  async exitCode(): Promise<number> {
+    if (this._exitCode) {
+      return this._exitCode
+    }
+
+    const response: Awaited<number> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "exitCode",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * Retrieves the current health of the service.
+   *
+   * The service must have been started, e.g. with start or by binding it to a
+   * container that has run.
+   */
+  async health(): Promise<ServiceHealthStatus> {
+    if (this._health) {
+      return this._health
+    }
+
+    const response: Awaited<ServiceHealthStatus> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "health",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
   /**
    * Retrieves a hostname which can be used by clients to reach this container.
    */
@@ -5568,6 +8352,7 @@ export class Service extends BaseClient {
   async ports(): Promise<Port[]> {
     type ports = {
       description: string
+      healthcheckPath: string
       port: number
       protocol: NetworkProtocol
     }
@@ -5579,7 +8364,7 @@ export class Service extends BaseClient {
           operation: "ports",
         },
         {
-          operation: "description port protocol",
+          operation: "description healthcheckPath port protocol",
         },
       ],
       this.client
@@ -5594,6 +8379,7 @@ export class Service extends BaseClient {
             sessionToken: this.sessionToken,
           },
           r.description,
+          r.healthcheckPath,
           r.port,
           r.protocol
         )
@@ -5619,6 +8405,75 @@ export class Service extends BaseClient {
     return this
   }
 
+  /**
+   * Retrieves the current lifecycle status of the service.
+   */
+  async status(): Promise<ServiceStatus> {
+    if (this._status) {
+      return this._status
+    }
+
+    const response: Awaited<ServiceStatus> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "status",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The most recent output of the service's process to stderr.
+   *
+   * Up to the last 1MiB is retained, including after the service has exited or
+   * been stopped. The service must have been started.
+   */
+  async stderr(): Promise<string> {
+    if (this._stderr) {
+      return this._stderr
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "stderr",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
+  /**
+   * The most recent output of the service's process to stdout.
+   *
+   * Up to the last 1MiB is retained, including after the service has exited or
+   * been stopped. The service must have been started.
+   */
+  async stdout(): Promise<string> {
+    if (this._stdout) {
+      return this._stdout
+    }
+
+    const response: Awaited<string> = await computeQuery(
+      [
+        ...this._queryTree,
+        {
+          operation: "stdout",
+        },
+      ],
+      this.client
+    )
+
+    return response
+  }
+
   /**
    * Stop the service.
    */
@@ -74,6 +74,16 @@ class Void(Scalar):
     resolvers that do not return anything."""
 
 
+class ArchiveFormat(Enum):
+    """The format of an archive."""
+
+    TAR = "TAR"
+    """A tar archive."""
+
+    ZIP = "ZIP"
+    """A zip archive."""
+
+
 class CacheSharingMode(Enum):
     """Sharing mode of the cache volume."""
 
@@ -89,6 +99,48 @@ class CacheSharingMode(Enum):
     """Shares the cache volume amongst many build pipelines"""
 
 
+class ChangeKind(Enum):
+    """The kind of a change to a path."""
+
+    ADDED = "ADDED"
+    """The path was added."""
+
+    DELETED = "DELETED"
+    """The path was deleted."""
+
+    MODIFIED = "MODIFIED"
+    """The path was modified."""
+
+    RENAMED = "RENAMED"
+    """The path was renamed. Only reported for changes between directories."""
+
+
+class DigestAlgorithm(Enum):
+    """A hash algorithm."""
+
+    SHA256 = "SHA256"
+
+    SHA384 = "SHA384"
+
+    SHA512 = "SHA512"
+
+
+class FileType(Enum):
+    """The type of an entry in a directory."""
+
+    DIR = "DIR"
+    """A directory."""
+
+    OTHER = "OTHER"
+    """Any other type of entry, such as a device or named pipe."""
+
+    REGULAR = "REGULAR"
+    """A regular file."""
+
+    SYMLINK = "SYMLINK"
+    """A symbolic link."""
+
+
 class ImageLayerCompression(Enum):
     """Compression algorithm to use for image layers."""
 
@@ -109,6 +161,19 @@ class ImageMediaTypes(Enum):
     OCIMediaTypes = "OCIMediaTypes"
 
 
+class NetworkMode(Enum):
+    """Network access allowed to a container's commands."""
+
+    NETWORK_DEFAULT = "NETWORK_DEFAULT"
+    """Unrestricted network access."""
+
+    NETWORK_NONE = "NETWORK_NONE"
+    """No network access apart from the loopback interface."""
+
+    NETWORK_SERVICES_ONLY = "NETWORK_SERVICES_ONLY"
+    """Network access to bound services, DNS and explicitly allowed destinations only."""
+
+
 class NetworkProtocol(Enum):
     """Transport layer network protocol associated to a port."""
 
@@ -119,6 +184,42 @@ class NetworkProtocol(Enum):
     """UDP (User Datagram Protocol)"""
 
 
+class ProvenanceMode(Enum):
+    """Level of detail included in SLSA provenance attestations."""
+
+    PROVENANCE_MAX = "PROVENANCE_MAX"
+    """Also the image config and the full build definition."""
+
+    PROVENANCE_MIN = "PROVENANCE_MIN"
+    """The builder, build platform and source images only."""
+
+
+class ServiceHealthStatus(Enum):
+    """The health of a running service."""
+
+    HEALTHY = "HEALTHY"
+    """The service has passed all of its health checks."""
+
+    UNHEALTHY = "UNHEALTHY"
+    """The service's health check command has failed too many times in a row."""
+
+
+class ServiceStatus(Enum):
+    """The lifecycle status of a service."""
+
+    EXITED = "EXITED"
+    """The service's process exited on its own after starting."""
+
+    RUNNING = "RUNNING"
+    """The service has started and is still running."""
+
+    STARTING = "STARTING"
+    """The service is starting and waiting for its health checks to pass."""
+
+    STOPPED = "STOPPED"
+    """The service is not running; it was either never started or has been stopped."""
+
+
 class TypeDefKind(Enum):
     """Distinguishes the different kinds of TypeDefs."""
 
@@ -191,6 +292,18 @@ class PortForward(Input):
 class CacheVolume(Type):
     """A directory whose contents persist across runs."""
 
+    __slots__ = (
+        "_key",
+        "_last_used",
+        "_prune",
+        "_size",
+    )
+
+    _key: Optional[str]
+    _last_used: Optional[int]
+    _prune: Optional[int]
+    _size: Optional[int]
+
     @typecheck
     async def id(self) -> CacheVolumeID:
         """Note
@@ -221,6 +334,243 @@ class CacheVolume(Type):
     def _from_id_query_field(cls):
         return "loadCacheVolumeFromID"
 
+    @typecheck
+    async def key(self) -> str:
+        """The key identifying this cache volume.
+
+        Empty if the key is not known, e.g. for a volume that has not been
+        used
+        since the engine restarted.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_key"):
+            return self._key
+        _args: list[Arg] = []
+        _ctx = self._select("key", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def last_used(self) -> Optional[int]:
+        """The last time this cache volume was mounted, as a Unix timestamp.
+
+        Null if the volume has never been used.
+
+        Returns
+        -------
+        Optional[int]
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_last_used"):
+            return self._last_used
+        _args: list[Arg] = []
+        _ctx = self._select("lastUsed", _args)
+        return await _ctx.execute(Optional[int])
+
+    @typecheck
+    async def prune(self) -> int:
+        """Wipes the contents of this cache volume, returning the number of bytes
+        reclaimed.
+
+        Subsequent uses of the volume start from an empty directory.
+
+        Returns
+        -------
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_prune"):
+            return self._prune
+        _args: list[Arg] = []
+        _ctx = self._select("prune", _args)
+        return await _ctx.execute(int)
+
+    @typecheck
+    async def size(self) -> int:
+        """The disk space used by this cache volume on the engine, in bytes.
+
+        Returns
+        -------
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_size"):
+            return self._size
+        _args: list[Arg] = []
+        _ctx = self._select("size", _args)
+        return await _ctx.execute(int)
+
+    @typecheck
+    def snapshot(self) -> "Directory":
+        """Returns a directory containing a copy of the current contents of this
+        cache
+        volume.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("snapshot", _args)
+        return Directory(_ctx)
+
+    @typecheck
+    def with_contents(self, source: "Directory") -> "CacheVolume":
+        """Replaces the contents of this cache volume with the contents of the
+        given
+        directory, e.g. to pre-populate a package manager cache.
+
+        This is a side effect: the contents are replaced on the engine when
+        this
+        field is resolved (e.g. when the ID of the returned volume is
+        requested),
+        affecting all pipelines using this cache volume, and again every time
+        it is
+        resolved. The returned cache volume is the same volume, with the same
+        ID.
+
+        Replacing the contents waits for any commands currently using this
+        cache
+        volume to finish.
+
+        Parameters
+        ----------
+        source:
+            Identifier of the directory to copy into the cache volume.
+        """
+        _args = [
+            Arg("source", source),
+        ]
+        _ctx = self._select("withContents", _args)
+        return CacheVolume(_ctx)
+
+    def with_(self, cb: Callable[["CacheVolume"], "CacheVolume"]) -> "CacheVolume":
+        """Call the provided callable with current CacheVolume.
+
+        This is useful for reusability and readability by not breaking the calling chain.
+        """
+        return cb(self)
+
+
+class Change(Type):
+    """A change to a path between two container filesystems."""
+
+    __slots__ = (
+        "_kind",
+        "_path",
+        "_size",
+    )
+
+    _kind: Optional[ChangeKind]
+    _path: Optional[str]
+    _size: Optional[int]
+
+    @typecheck
+    async def kind(self) -> ChangeKind:
+        """The kind of change.
+
+        Returns
+        -------
+        ChangeKind
+            The kind of a change to a path.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_kind"):
+            return self._kind
+        _args: list[Arg] = []
+        _ctx = self._select("kind", _args)
+        return await _ctx.execute(ChangeKind)
+
+    @typecheck
+    async def path(self) -> str:
+        """The absolute path of the changed file or directory.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_path"):
+            return self._path
+        _args: list[Arg] = []
+        _ctx = self._select("path", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def size(self) -> int:
+        """The size of the path in the newer filesystem in bytes, or 0 if it was
+        deleted or is not a regular file.
+
+        Returns
+        -------
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_size"):
+            return self._size
+        _args: list[Arg] = []
+        _ctx = self._select("size", _args)
+        return await _ctx.execute(int)
+
 
 class Container(Type):
     """An OCI-compatible container, also known as a docker container."""
@@ -318,6 +668,33 @@ class Container(Type):
         _ctx = self._select("build", _args)
         return Container(_ctx)
 
+    @typecheck
+    async def combined_output(self) -> str:
+        """The output and error streams of the last executed command, interleaved
+        in
+        the order they were written.
+
+        Will execute default command if none is set, or error if there's no
+        default.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("combinedOutput", _args)
+        return await _ctx.execute(str)
+
     @typecheck
     async def default_args(self) -> Optional[list[str]]:
         """Retrieves default arguments for future commands.
@@ -340,6 +717,27 @@ class Container(Type):
         _ctx = self._select("defaultArgs", _args)
         return await _ctx.execute(Optional[list[str]])
 
+    @typecheck
+    async def diff(self, other: "Container") -> list[Change]:
+        """The changes to this container's root filesystem relative to another
+        container's root filesystem (e.g., its base image).
+
+        Parameters
+        ----------
+        other:
+            The container to compare against.
+        """
+        _args = [
+            Arg("other", other),
+        ]
+        _ctx = self._select("diff", _args)
+        _ctx = Change(_ctx)._select_multiple(
+            _kind="kind",
+            _path="path",
+            _size="size",
+        )
+        return await _ctx.execute(list[Change])
+
     @typecheck
     def directory(self, path: str) -> "Directory":
         """Retrieves a directory at the given path.
@@ -420,16 +818,41 @@ class Container(Type):
         return await _ctx.execute(list[EnvVariable])
 
     @typecheck
-    def experimental_with_all_gp_us(self) -> "Container":
-        """EXPERIMENTAL API! Subject to change/removal at any time.
-
-        experimentalWithAllGPUs configures all available GPUs on the host to
-        be accessible to this container.
-        This currently works for Nvidia devices only.
-        """
-        _args: list[Arg] = []
-        _ctx = self._select("experimentalWithAllGPUs", _args)
-        return Container(_ctx)
+    async def exit_code(self) -> int:
+        """The exit code of the last executed command.
+
+        Will execute default command if none is set, or error if there's no
+        default.
+
+        Returns
+        -------
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("exitCode", _args)
+        return await _ctx.execute(int)
+
+    @typecheck
+    def experimental_with_all_gp_us(self) -> "Container":
+        """EXPERIMENTAL API! Subject to change/removal at any time.
+
+        experimentalWithAllGPUs configures all available GPUs on the host to
+        be accessible to this container.
+        This currently works for Nvidia devices only.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("experimentalWithAllGPUs", _args)
+        return Container(_ctx)
 
     @typecheck
     def experimental_with_gpu(self, devices: Sequence[str]) -> "Container":
@@ -453,6 +876,8 @@ class Container(Type):
         platform_variants: Optional[Sequence["Container"]] = None,
         forced_compression: Optional[ImageLayerCompression] = None,
         media_types: Optional[ImageMediaTypes] = None,
+        sbom_scanner: Optional["Container"] = None,
+        provenance: Optional[ProvenanceMode] = None,
     ) -> bool:
         """Writes the container as an OCI tarball to the destination file path on
         the host for the specified platform variants.
@@ -484,6 +909,14 @@ class Container(Type):
             is largely compatible with most recent container runtimes, but
             Docker may be needed
             for older runtimes without OCI support.
+        sbom_scanner:
+            Container used to generate an SPDX SBOM attestation for each
+            platform.
+            It must implement the BuildKit SBOM scanner protocol
+            (e.g., docker/buildkit-syft-scanner).
+        provenance:
+            Attach SLSA provenance attestations with the given level of
+            detail.
 
         Returns
         -------
@@ -502,6 +935,8 @@ class Container(Type):
             Arg("platformVariants", platform_variants, None),
             Arg("forcedCompression", forced_compression, None),
             Arg("mediaTypes", media_types, None),
+            Arg("sbomScanner", sbom_scanner, None),
+            Arg("provenance", provenance, None),
         ]
         _ctx = self._select("export", _args)
         return await _ctx.execute(bool)
@@ -517,6 +952,7 @@ class Container(Type):
         _ctx = self._select("exposedPorts", _args)
         _ctx = Port(_ctx)._select_multiple(
             _description="description",
+            _healthcheck_path="healthcheckPath",
             _port="port",
             _protocol="protocol",
         )
@@ -540,7 +976,12 @@ class Container(Type):
         return File(_ctx)
 
     @typecheck
-    def from_(self, address: str) -> "Container":
+    def from_(
+        self,
+        address: str,
+        *,
+        verify_with: Optional[str] = None,
+    ) -> "Container":
         """Initializes this container from a pulled base image.
 
         Parameters
@@ -549,9 +990,14 @@ class Container(Type):
             Image's address from its registry.
             Formatted as [host]/[user]/[repo]:[tag] (e.g.,
             "docker.io/dagger/dagger:main").
+        verify_with:
+            PEM-encoded public key the image must have a cosign signature for.
+            If set, unsigned images and images signed with another key are
+            refused.
         """
         _args = [
             Arg("address", address),
+            Arg("verifyWith", verify_with, None),
         ]
         _ctx = self._select("from", _args)
         return Container(_ctx)
@@ -589,6 +1035,54 @@ class Container(Type):
     def _from_id_query_field(cls):
         return "loadContainerFromID"
 
+    @typecheck
+    async def image_config(self) -> JSON:
+        """The container's image config, as a JSON object using the Docker image
+        config field names (e.g., "StopSignal", "Volumes", "Healthcheck").
+
+        Returns
+        -------
+        JSON
+            An arbitrary JSON-encoded value.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("imageConfig", _args)
+        return await _ctx.execute(JSON)
+
+    @typecheck
+    async def image_digest(self) -> str:
+        """The digest of this container's image, as published with the default
+        options.
+
+        The image is exported to the engine without being pushed, so this is
+        cheap
+        once the container has been published.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("imageDigest", _args)
+        return await _ctx.execute(str)
+
     @typecheck
     async def image_ref(self) -> Optional[str]:
         """The unique image reference which can only be retrieved immediately
@@ -677,6 +1171,23 @@ class Container(Type):
         )
         return await _ctx.execute(list[Label])
 
+    @typecheck
+    async def layers(self) -> list["ContainerLayer"]:
+        """The layers of this container's root filesystem, from the bottom up, as
+        they
+        would be published with the default options.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("layers", _args)
+        _ctx = ContainerLayer(_ctx)._select_multiple(
+            _created_by="createdBy",
+            _diff_id="diffID",
+            _digest="digest",
+            _media_type="mediaType",
+            _size="size",
+        )
+        return await _ctx.execute(list[ContainerLayer])
+
     @typecheck
     async def mounts(self) -> list[str]:
         """Retrieves the list of paths where a directory is mounted.
@@ -748,6 +1259,27 @@ class Container(Type):
         _ctx = self._select("platform", _args)
         return await _ctx.execute(Platform)
 
+    @typecheck
+    def provenance(
+        self,
+        *,
+        mode: Optional[ProvenanceMode] = None,
+    ) -> "File":
+        """The SLSA provenance predicate describing how this container's rootfs
+        was
+        built, as attached to its image when publishing or exporting it.
+
+        Parameters
+        ----------
+        mode:
+            Level of detail to include.
+        """
+        _args = [
+            Arg("mode", mode, None),
+        ]
+        _ctx = self._select("provenance", _args)
+        return File(_ctx)
+
     @typecheck
     async def publish(
         self,
@@ -756,6 +1288,9 @@ class Container(Type):
         platform_variants: Optional[Sequence["Container"]] = None,
         forced_compression: Optional[ImageLayerCompression] = None,
         media_types: Optional[ImageMediaTypes] = None,
+        sbom_scanner: Optional["Container"] = None,
+        provenance: Optional[ProvenanceMode] = None,
+        sign_with: Optional["Secret"] = None,
     ) -> str:
         """Publishes this container as a new image to the specified address.
 
@@ -787,6 +1322,19 @@ class Container(Type):
             is largely compatible with most recent registries, but Docker may
             be needed for older
             registries without OCI support.
+        sbom_scanner:
+            Container used to generate an SPDX SBOM attestation for each
+            platform.
+            It must implement the BuildKit SBOM scanner protocol
+            (e.g., docker/buildkit-syft-scanner).
+        provenance:
+            Attach SLSA provenance attestations with the given level of
+            detail.
+        sign_with:
+            Secret holding a PEM-encoded private key used to sign the
+            published image.
+            The signature is pushed alongside the image in the format used by
+            cosign.
 
         Returns
         -------
@@ -807,10 +1355,97 @@ class Container(Type):
             Arg("platformVariants", platform_variants, None),
             Arg("forcedCompression", forced_compression, None),
             Arg("mediaTypes", media_types, None),
+            Arg("sbomScanner", sbom_scanner, None),
+            Arg("provenance", provenance, None),
+            Arg("signWith", sign_with, None),
         ]
         _ctx = self._select("publish", _args)
         return await _ctx.execute(str)
 
+    @typecheck
+    async def publish_all(
+        self,
+        addresses: Sequence[str],
+        *,
+        platform_variants: Optional[Sequence["Container"]] = None,
+        forced_compression: Optional[ImageLayerCompression] = None,
+        media_types: Optional[ImageMediaTypes] = None,
+        sbom_scanner: Optional["Container"] = None,
+        provenance: Optional[ProvenanceMode] = None,
+        sign_with: Optional["Secret"] = None,
+    ) -> list[str]:
+        """Publishes this container as a new image to each of the specified
+        addresses
+        in a single push.
+
+        Returns the fully qualified ref of each address, in the same order.
+        It can also publish platform variants.
+
+        Parameters
+        ----------
+        addresses:
+            Registry addresses to publish the image to.
+            Formatted as [host]/[user]/[repo]:[tag] (e.g.
+            "docker.io/dagger/dagger:main").
+        platform_variants:
+            Identifiers for other platform specific containers.
+            Used for multi-platform image.
+        forced_compression:
+            Force each layer of the published image to use the specified
+            compression algorithm.
+            If this is unset, then if a layer already has a compressed blob in
+            the engine's
+            cache, that will be used (this can result in a mix of compression
+            algorithms for
+            different layers). If this is unset and a layer has no compressed
+            blob in the
+            engine's cache, then it will be compressed using Gzip.
+        media_types:
+            Use the specified media types for the published image's layers.
+            Defaults to OCI, which
+            is largely compatible with most recent registries, but Docker may
+            be needed for older
+            registries without OCI support.
+        sbom_scanner:
+            Container used to generate an SPDX SBOM attestation for each
+            platform.
+            It must implement the BuildKit SBOM scanner protocol
+            (e.g., docker/buildkit-syft-scanner).
+        provenance:
+            Attach SLSA provenance attestations with the given level of
+            detail.
+        sign_with:
+            Secret holding a PEM-encoded private key used to sign the
+            published image.
+            The signature is pushed alongside the image in the format used by
+            cosign.
+
+        Returns
+        -------
+        list[str]
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args = [
+            Arg("addresses", addresses),
+            Arg("platformVariants", platform_variants, None),
+            Arg("forcedCompression", forced_compression, None),
+            Arg("mediaTypes", media_types, None),
+            Arg("sbomScanner", sbom_scanner, None),
+            Arg("provenance", provenance, None),
+            Arg("signWith", sign_with, None),
+        ]
+        _ctx = self._select("publishAll", _args)
+        return await _ctx.execute(list[str])
+
     @typecheck
     def rootfs(self) -> "Directory":
         """Retrieves this container's root filesystem. Mounts are not included."""
@@ -844,6 +1479,33 @@ class Container(Type):
         _ctx = self._select("shellEndpoint", _args)
         return await _ctx.execute(str)
 
+    @typecheck
+    def squash(
+        self,
+        *,
+        from_: Optional["Container"] = None,
+    ) -> "Container":
+        """Retrieves this container with the layers of its root filesystem that
+        are not
+        in another container's root filesystem (e.g., its base image)
+        collapsed into
+        a single layer.
+
+        Any layer boundaries are removed.
+
+        Parameters
+        ----------
+        from_:
+            The container whose layers are kept as they are.
+            If not set, the whole root filesystem is collapsed into a single
+            layer.
+        """
+        _args = [
+            Arg("from", from_, None),
+        ]
+        _ctx = self._select("squash", _args)
+        return Container(_ctx)
+
     @typecheck
     async def stderr(self) -> str:
         """The error stream of the last executed command.
@@ -940,6 +1602,43 @@ class Container(Type):
         _ctx = self._select("user", _args)
         return await _ctx.execute(Optional[str])
 
+    @typecheck
+    def with_annotation(
+        self,
+        name: str,
+        value: str,
+        *,
+        index: Optional[bool] = None,
+    ) -> "Container":
+        """Retrieves this container plus the given OCI annotation.
+
+        Annotations are set on the image manifest (or the image index) when
+        the
+        container is published or exported.
+
+        Parameters
+        ----------
+        name:
+            The name of the annotation (e.g.,
+            "org.opencontainers.image.source").
+        value:
+            The value of the annotation (e.g.,
+            "https://github.com/dagger/dagger").
+        index:
+            Set the annotation on the image index rather than the image
+            manifest.
+            An image index is always created when there are index annotations,
+            even
+            when publishing or exporting a single platform.
+        """
+        _args = [
+            Arg("name", name),
+            Arg("value", value),
+            Arg("index", index, None),
+        ]
+        _ctx = self._select("withAnnotation", _args)
+        return Container(_ctx)
+
     @typecheck
     def with_default_args(
         self,
@@ -1055,6 +1754,12 @@ class Container(Type):
         redirect_stderr: Optional[str] = None,
         experimental_privileged_nesting: Optional[bool] = None,
         insecure_root_capabilities: Optional[bool] = None,
+        expect_non_zero_exit: Optional[bool] = None,
+        timeout: Optional[int] = None,
+        cpu_limit: Optional[float] = None,
+        memory_limit: Optional[str] = None,
+        pids_limit: Optional[int] = None,
+        ulimits: Optional[Sequence[str]] = None,
     ) -> "Container":
         """Retrieves this container after executing the specified command inside
         it.
@@ -1091,6 +1796,25 @@ class Container(Type):
             does not provide any security guarantees when using this option.
             It should only be used
             when absolutely necessary and only with trusted commands.
+        expect_non_zero_exit:
+            Do not fail if the command exits with a non-zero code.
+            The exit code is recorded instead, and can be retrieved along with
+            the
+            command's output using exitCode, stdout and stderr.
+        timeout:
+            Kill the command if it runs for longer than the given number of
+            seconds.
+            A command that times out fails with an EXEC_TIMEOUT_ERROR, even if
+            expectNonZeroExit is set.
+        cpu_limit:
+            Number of CPUs the command may use (e.g., 1.5).
+        memory_limit:
+            Maximum memory the command may use (e.g., "512m" or "2g").
+        pids_limit:
+            Maximum number of processes the command may run at once.
+        ulimits:
+            Per-process limits in the form name=soft[:hard] (e.g.,
+            ["nofile=1024:2048"]).
         """
         _args = [
             Arg("args", args),
@@ -1100,6 +1824,12 @@ class Container(Type):
             Arg("redirectStderr", redirect_stderr, None),
             Arg("experimentalPrivilegedNesting", experimental_privileged_nesting, None),
             Arg("insecureRootCapabilities", insecure_root_capabilities, None),
+            Arg("expectNonZeroExit", expect_non_zero_exit, None),
+            Arg("timeout", timeout, None),
+            Arg("cpuLimit", cpu_limit, None),
+            Arg("memoryLimit", memory_limit, None),
+            Arg("pidsLimit", pids_limit, None),
+            Arg("ulimits", ulimits, None),
         ]
         _ctx = self._select("withExec", _args)
         return Container(_ctx)
@@ -1111,6 +1841,7 @@ class Container(Type):
         *,
         protocol: Optional[NetworkProtocol] = None,
         description: Optional[str] = None,
+        healthcheck_path: Optional[str] = None,
     ) -> "Container":
         """Expose a network port.
 
@@ -1126,11 +1857,18 @@ class Container(Type):
             Transport layer network protocol
         description:
             Optional port description
+        healthcheck_path:
+            HTTP path to poll once the port accepts connections, e.g.
+            /healthz.
+            The service is only considered healthy once the path responds with
+            a
+            non-error status code. Only supported for TCP ports.
         """
         _args = [
             Arg("port", port),
             Arg("protocol", protocol, None),
             Arg("description", description, None),
+            Arg("healthcheckPath", healthcheck_path, None),
         ]
         _ctx = self._select("withExposedPort", _args)
         return Container(_ctx)
@@ -1181,6 +1919,81 @@ class Container(Type):
         _ctx = self._select("withFocus", _args)
         return Container(_ctx)
 
+    @typecheck
+    def with_healthcheck(
+        self,
+        args: Sequence[str],
+        *,
+        interval: Optional[int] = None,
+        timeout: Optional[int] = None,
+        retries: Optional[int] = None,
+        start_period: Optional[int] = None,
+    ) -> "Container":
+        """Configures a command to run periodically when the container is started
+        as a
+        service, like the HEALTHCHECK instruction of a Dockerfile.
+
+        The service is only considered started once the command exits 0, after
+        any
+        exposed ports accept connections. Dependent services and containers
+        wait for
+        it to be healthy.
+
+        The command is stored as the HEALTHCHECK of the image config,
+        replacing any
+        health check inherited from the base image, and is published with it.
+        A
+        HEALTHCHECK set with withImageConfig or pulled with the image is run
+        the same
+        way; set its Test to ["NONE"] to disable it.
+
+        Parameters
+        ----------
+        args:
+            Command to run to check the health of the service, e.g.
+            ["pg_isready"].
+        interval:
+            Seconds to wait between checks (default: 1).
+        timeout:
+            Seconds after which a single check fails (default: 30).
+        retries:
+            Number of consecutive failures after which the service is
+            unhealthy (default: 10).
+        start_period:
+            Seconds the service is given to start, during which failures do
+            not count towards retries.
+        """
+        _args = [
+            Arg("args", args),
+            Arg("interval", interval, None),
+            Arg("timeout", timeout, None),
+            Arg("retries", retries, None),
+            Arg("startPeriod", start_period, None),
+        ]
+        _ctx = self._select("withHealthcheck", _args)
+        return Container(_ctx)
+
+    @typecheck
+    def with_image_config(self, patch: JSON) -> "Container":
+        """Retrieves this container with the given JSON merge patch (RFC 7386)
+        applied
+        to its image config.
+
+        Fields are named as in the Docker image config, e.g.
+        {"StopSignal": "SIGQUIT", "Volumes": {"/data": {}}, "OnBuild": null}.
+        Healthcheck durations are in nanoseconds.
+
+        Parameters
+        ----------
+        patch:
+            The JSON merge patch to apply.
+        """
+        _args = [
+            Arg("patch", patch),
+        ]
+        _ctx = self._select("withImageConfig", _args)
+        return Container(_ctx)
+
     @typecheck
     def with_label(self, name: str, value: str) -> "Container":
         """Retrieves this container plus the given label.
@@ -1200,6 +2013,20 @@ class Container(Type):
         _ctx = self._select("withLabel", _args)
         return Container(_ctx)
 
+    @typecheck
+    def with_layer_boundary(self) -> "Container":
+        """Retrieves this container with a layer boundary at its current root
+        filesystem.
+
+        When the image is published or exported, the layers added between two
+        boundaries are squashed into a single layer. Layers below the first
+        boundary
+        and above the last one are kept as they are.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("withLayerBoundary", _args)
+        return Container(_ctx)
+
     @typecheck
     def with_mounted_cache(
         self,
@@ -1357,6 +2184,36 @@ class Container(Type):
         _ctx = self._select("withMountedTemp", _args)
         return Container(_ctx)
 
+    @typecheck
+    def with_network(
+        self,
+        mode: NetworkMode,
+        *,
+        allow: Optional[Sequence[str]] = None,
+    ) -> "Container":
+        """Restricts the network access of commands run in this container.
+
+        Parameters
+        ----------
+        mode:
+            Which destinations commands may reach.
+        allow:
+            Hostnames, IPs or CIDRs reachable in addition to bound services
+            (e.g., ["proxy.golang.org", "10.0.0.0/8"]).
+            Hostnames are resolved once, when each command starts, and only
+            the
+            addresses they resolve to at that time are reachable. Use a CIDR
+            for
+            destinations whose addresses may change while a command runs.
+            Only allowed with the NETWORK_SERVICES_ONLY mode.
+        """
+        _args = [
+            Arg("mode", mode),
+            Arg("allow", allow, None),
+        ]
+        _ctx = self._select("withNetwork", _args)
+        return Container(_ctx)
+
     @typecheck
     def with_new_file(
         self,
@@ -1421,6 +2278,40 @@ class Container(Type):
         _ctx = self._select("withRegistryAuth", _args)
         return Container(_ctx)
 
+    @typecheck
+    def with_resource_limits(
+        self,
+        *,
+        cpu_limit: Optional[float] = None,
+        memory_limit: Optional[str] = None,
+        pids_limit: Optional[int] = None,
+        ulimits: Optional[Sequence[str]] = None,
+    ) -> "Container":
+        """Sets the default resource limits for commands run in this container.
+
+        Limits set on withExec take precedence over the defaults.
+
+        Parameters
+        ----------
+        cpu_limit:
+            Number of CPUs the command may use (e.g., 1.5).
+        memory_limit:
+            Maximum memory the command may use (e.g., "512m" or "2g").
+        pids_limit:
+            Maximum number of processes the command may run at once.
+        ulimits:
+            Per-process limits in the form name=soft[:hard] (e.g.,
+            ["nofile=1024:2048"]).
+        """
+        _args = [
+            Arg("cpuLimit", cpu_limit, None),
+            Arg("memoryLimit", memory_limit, None),
+            Arg("pidsLimit", pids_limit, None),
+            Arg("ulimits", ulimits, None),
+        ]
+        _ctx = self._select("withResourceLimits", _args)
+        return Container(_ctx)
+
     @typecheck
     def with_rootfs(self, directory: "Directory") -> "Container":
         """Initializes this container from this DirectoryID."""
@@ -1450,7 +2341,13 @@ class Container(Type):
         return Container(_ctx)
 
     @typecheck
-    def with_service_binding(self, alias: str, service: "Service") -> "Container":
+    def with_service_binding(
+        self,
+        alias: str,
+        service: "Service",
+        *,
+        depends_on: Optional[Sequence[str]] = None,
+    ) -> "Container":
         """Establish a runtime dependency on a service.
 
         The service will be started automatically when needed and detached
@@ -1463,16 +2360,27 @@ class Container(Type):
         The service dependency will also convey to any files or directories
         produced by the container.
 
+        Bound services are started in parallel unless they depend on each
+        other, in
+        which case each service is started only once its dependencies are
+        healthy, and
+        stopped before them.
+
         Parameters
         ----------
         alias:
             A name that can be used to reach the service from the container
         service:
             Identifier of the service container
+        depends_on:
+            Aliases of services already bound to the container that must be
+            started
+            and healthy before this service is started (e.g., ["db"]).
         """
         _args = [
             Arg("alias", alias),
             Arg("service", service),
+            Arg("dependsOn", depends_on, None),
         ]
         _ctx = self._select("withServiceBinding", _args)
         return Container(_ctx)
@@ -1538,6 +2446,31 @@ class Container(Type):
         _ctx = self._select("withWorkdir", _args)
         return Container(_ctx)
 
+    @typecheck
+    def without_annotation(
+        self,
+        name: str,
+        *,
+        index: Optional[bool] = None,
+    ) -> "Container":
+        """Retrieves this container minus the given OCI annotation.
+
+        Parameters
+        ----------
+        name:
+            The name of the annotation to remove (e.g.,
+            "org.opencontainers.image.source").
+        index:
+            Remove the annotation from the image index rather than the image
+            manifest.
+        """
+        _args = [
+            Arg("name", name),
+            Arg("index", index, None),
+        ]
+        _ctx = self._select("withoutAnnotation", _args)
+        return Container(_ctx)
+
     @typecheck
     def without_env_variable(self, name: str) -> "Container":
         """Retrieves this container minus the given environment variable.
@@ -1683,9 +2616,178 @@ class Container(Type):
         return cb(self)
 
 
+class ContainerLayer(Type):
+    """A layer of a container's root filesystem."""
+
+    __slots__ = (
+        "_created_by",
+        "_diff_id",
+        "_digest",
+        "_media_type",
+        "_size",
+    )
+
+    _created_by: Optional[str]
+    _diff_id: Optional[str]
+    _digest: Optional[str]
+    _media_type: Optional[str]
+    _size: Optional[int]
+
+    @typecheck
+    async def created_by(self) -> str:
+        """The command that produced the layer, or a description of where it came
+        from
+        (e.g., a pulled image layer).
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_created_by"):
+            return self._created_by
+        _args: list[Arg] = []
+        _ctx = self._select("createdBy", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def diff_id(self) -> str:
+        """The digest of the uncompressed layer.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_diff_id"):
+            return self._diff_id
+        _args: list[Arg] = []
+        _ctx = self._select("diffID", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def digest(self) -> str:
+        """The digest of the compressed layer.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_digest"):
+            return self._digest
+        _args: list[Arg] = []
+        _ctx = self._select("digest", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def media_type(self) -> str:
+        """The media type of the layer.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_media_type"):
+            return self._media_type
+        _args: list[Arg] = []
+        _ctx = self._select("mediaType", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def size(self) -> int:
+        """The size of the compressed layer in bytes.
+
+        Returns
+        -------
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_size"):
+            return self._size
+        _args: list[Arg] = []
+        _ctx = self._select("size", _args)
+        return await _ctx.execute(int)
+
+
 class Directory(Type):
     """A directory."""
 
+    @typecheck
+    def as_archive(
+        self,
+        *,
+        format: Optional[ArchiveFormat] = None,
+        compression: Optional[ImageLayerCompression] = None,
+    ) -> "File":
+        """Returns an archive of this directory's contents.
+
+        Entries are written in lexical order with a fixed modification time,
+        so the
+        same contents always produce the same archive.
+
+        Parameters
+        ----------
+        format:
+            Format of the archive.
+        compression:
+            Compression of a tar archive: Gzip, Zstd or Uncompressed (the
+            default).
+            Zip archives are always compressed.
+        """
+        _args = [
+            Arg("format", format, None),
+            Arg("compression", compression, None),
+        ]
+        _ctx = self._select("asArchive", _args)
+        return File(_ctx)
+
     @typecheck
     def as_module(
         self,
@@ -1716,6 +2818,60 @@ class Directory(Type):
         _ctx = self._select("asModule", _args)
         return Module(_ctx)
 
+    @typecheck
+    def as_patch(self, other: "Directory") -> "File":
+        """Returns a unified diff of the changes that turn this directory into
+        another
+        directory, in the format of git diff.
+
+        Changes to directories, ownership and modification times are not part
+        of
+        the patch. Changes to binary files are only noted with a "Binary files
+        ...
+        differ" line, so a patch that contains them cannot be applied with
+        withPatch.
+
+        Parameters
+        ----------
+        other:
+            Identifier of the directory to compare.
+        """
+        _args = [
+            Arg("other", other),
+        ]
+        _ctx = self._select("asPatch", _args)
+        return File(_ctx)
+
+    @typecheck
+    async def changes(self, other: "Directory") -> list["FileChange"]:
+        """The changes that turn this directory into another directory.
+
+        Paths whose contents and metadata only differ by their modification
+        time
+        are not reported, and files that were moved without changing their
+        contents
+        are reported as renames.
+
+        Parameters
+        ----------
+        other:
+            Identifier of the directory to compare.
+        """
+        _args = [
+            Arg("other", other),
+        ]
+        _ctx = self._select("changes", _args)
+        _ctx = FileChange(_ctx)._select_multiple(
+            _kind="kind",
+            _old_path="oldPath",
+            _old_permissions="oldPermissions",
+            _path="path",
+            _permissions="permissions",
+            _size="size",
+            _type="type",
+        )
+        return await _ctx.execute(list[FileChange])
+
     @typecheck
     def diff(self, other: "Directory") -> "Directory":
         """Gets the difference between this directory and an another directory.
@@ -1731,6 +2887,47 @@ class Directory(Type):
         _ctx = self._select("diff", _args)
         return Directory(_ctx)
 
+    @typecheck
+    async def digest(
+        self,
+        *,
+        exclude: Optional[Sequence[str]] = None,
+        include: Optional[Sequence[str]] = None,
+    ) -> str:
+        """Returns a hash of this directory's contents (e.g., "sha256:...").
+
+        The hash covers the names, contents, permissions and ownership of the
+        directory's entries, but not their modification times.
+
+        Parameters
+        ----------
+        exclude:
+            Exclude entries matching the given patterns from the hash (e.g.,
+            ["node_modules"]).
+        include:
+            Only hash entries matching the given patterns (e.g., ["**/*.go"]).
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args = [
+            Arg("exclude", exclude, None),
+            Arg("include", include, None),
+        ]
+        _ctx = self._select("digest", _args)
+        return await _ctx.execute(str)
+
     @typecheck
     def directory(self, path: str) -> "Directory":
         """Retrieves a directory at the given path.
@@ -1813,13 +3010,27 @@ class Directory(Type):
         return await _ctx.execute(list[str])
 
     @typecheck
-    async def export(self, path: str) -> bool:
+    async def export(
+        self,
+        path: str,
+        *,
+        mirror: Optional[bool] = None,
+        exclude: Optional[Sequence[str]] = None,
+    ) -> bool:
         """Writes the contents of the directory to a path on the host.
 
         Parameters
         ----------
         path:
             Location of the copied directory (e.g., "logs/").
+        mirror:
+            Remove any files on the host path that aren't in this directory,
+            so that
+            it mirrors the directory exactly.
+        exclude:
+            Exclude artifacts that match the given pattern (e.g., [".git",
+            "**/.env"]).
+            Excluded paths are neither written nor removed on the host.
 
         Returns
         -------
@@ -1835,10 +3046,53 @@ class Directory(Type):
         """
         _args = [
             Arg("path", path),
+            Arg("mirror", mirror, None),
+            Arg("exclude", exclude, None),
         ]
         _ctx = self._select("export", _args)
         return await _ctx.execute(bool)
 
+    @typecheck
+    async def export_changes(
+        self,
+        path: str,
+        *,
+        mirror: Optional[bool] = None,
+        exclude: Optional[Sequence[str]] = None,
+    ) -> list["FileChange"]:
+        """Returns the changes that exporting this directory to a path on the
+        host
+        would make, without writing anything.
+
+        Parameters
+        ----------
+        path:
+            Location of the copied directory (e.g., "logs/").
+        mirror:
+            Whether the export would remove files on the host path that aren't
+            in
+            this directory.
+        exclude:
+            Exclude artifacts that match the given pattern (e.g., [".git",
+            "**/.env"]).
+        """
+        _args = [
+            Arg("path", path),
+            Arg("mirror", mirror, None),
+            Arg("exclude", exclude, None),
+        ]
+        _ctx = self._select("exportChanges", _args)
+        _ctx = FileChange(_ctx)._select_multiple(
+            _kind="kind",
+            _old_path="oldPath",
+            _old_permissions="oldPermissions",
+            _path="path",
+            _permissions="permissions",
+            _size="size",
+            _type="type",
+        )
+        return await _ctx.execute(list[FileChange])
+
     @typecheck
     def file(self, path: str) -> "File":
         """Retrieves a file at the given path.
@@ -1942,6 +3196,21 @@ class Directory(Type):
         _ctx = self._select("pipeline", _args)
         return Directory(_ctx)
 
+    @typecheck
+    def stat(self, path: str) -> "DirectoryEntry":
+        """Returns metadata for the file, directory or symlink at the given path.
+
+        Parameters
+        ----------
+        path:
+            Location of the entry to look at (e.g., "go.sum").
+        """
+        _args = [
+            Arg("path", path),
+        ]
+        _ctx = self._select("stat", _args)
+        return DirectoryEntry(_ctx)
+
     @typecheck
     async def sync(self) -> "Directory":
         """Force evaluation in the engine.
@@ -1964,6 +3233,55 @@ class Directory(Type):
     def __await__(self):
         return self.sync().__await__()
 
+    @typecheck
+    async def walk(
+        self,
+        *,
+        path: Optional[str] = None,
+        include: Optional[Sequence[str]] = None,
+        exclude: Optional[Sequence[str]] = None,
+        max_depth: Optional[int] = None,
+    ) -> list["DirectoryEntry"]:
+        """Returns metadata for every file, directory and symlink under the given
+        path,
+        recursing into subdirectories.
+
+        Returned paths are relative to this directory.
+
+        Parameters
+        ----------
+        path:
+            Location of the directory to walk (e.g., "/src").
+        include:
+            Only return entries matching the given patterns (e.g.,
+            ["**/*.go"]).
+            Patterns are relative to the walked path.
+        exclude:
+            Skip entries matching the given patterns (e.g., ["node_modules"]).
+            Excluded directories are not walked. Patterns are relative to the
+            walked path.
+        max_depth:
+            How many levels of subdirectories to walk, e.g. 1 for only the
+            immediate
+            entries. Walks the entire tree if unset.
+        """
+        _args = [
+            Arg("path", path, None),
+            Arg("include", include, None),
+            Arg("exclude", exclude, None),
+            Arg("maxDepth", max_depth, None),
+        ]
+        _ctx = self._select("walk", _args)
+        _ctx = DirectoryEntry(_ctx)._select_multiple(
+            _modified_at="modifiedAt",
+            _path="path",
+            _permissions="permissions",
+            _size="size",
+            _symlink_target="symlinkTarget",
+            _type="type",
+        )
+        return await _ctx.execute(list[DirectoryEntry])
+
     @typecheck
     def with_directory(
         self,
@@ -2079,6 +3397,26 @@ class Directory(Type):
         _ctx = self._select("withNewFile", _args)
         return Directory(_ctx)
 
+    @typecheck
+    def with_patch(self, patch: "File") -> "Directory":
+        """Retrieves this directory with a unified diff applied to it.
+
+        The first component of the paths in the patch is stripped, as with
+        patch -p1 (e.g., "a/src/main.go" patches "src/main.go").
+
+        Binary patches are not supported.
+
+        Parameters
+        ----------
+        patch:
+            Identifier of the file containing the patch.
+        """
+        _args = [
+            Arg("patch", patch),
+        ]
+        _ctx = self._select("withPatch", _args)
+        return Directory(_ctx)
+
     @typecheck
     def with_timestamps(self, timestamp: int) -> "Directory":
         """Retrieves this directory with all file/dir timestamps set to the given
@@ -2134,27 +3472,190 @@ class Directory(Type):
         return cb(self)
 
 
-class EnvVariable(Type):
-    """A simple key value object that represents an environment
-    variable."""
+class DirectoryEntry(Type):
+    """Metadata describing an entry in a directory."""
 
     __slots__ = (
-        "_name",
-        "_value",
+        "_modified_at",
+        "_path",
+        "_permissions",
+        "_size",
+        "_symlink_target",
+        "_type",
     )
 
-    _name: Optional[str]
-    _value: Optional[str]
+    _modified_at: Optional[int]
+    _path: Optional[str]
+    _permissions: Optional[int]
+    _size: Optional[int]
+    _symlink_target: Optional[str]
+    _type: Optional[FileType]
 
     @typecheck
-    async def name(self) -> str:
-        """The environment variable name.
+    async def modified_at(self) -> int:
+        """The entry's modification time, as a Unix timestamp.
 
         Returns
         -------
-        str
-            The `String` scalar type represents textual data, represented as
-            UTF-8 character sequences. The String type is most often used by
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_modified_at"):
+            return self._modified_at
+        _args: list[Arg] = []
+        _ctx = self._select("modifiedAt", _args)
+        return await _ctx.execute(int)
+
+    @typecheck
+    async def path(self) -> str:
+        """The path of the entry, relative to the directory (e.g.,
+        "src/main.go").
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_path"):
+            return self._path
+        _args: list[Arg] = []
+        _ctx = self._select("path", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def permissions(self) -> int:
+        """The entry's permission bits (e.g., 0644).
+
+        Returns
+        -------
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_permissions"):
+            return self._permissions
+        _args: list[Arg] = []
+        _ctx = self._select("permissions", _args)
+        return await _ctx.execute(int)
+
+    @typecheck
+    async def size(self) -> int:
+        """The size of the entry in bytes.
+
+        Returns
+        -------
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_size"):
+            return self._size
+        _args: list[Arg] = []
+        _ctx = self._select("size", _args)
+        return await _ctx.execute(int)
+
+    @typecheck
+    async def symlink_target(self) -> Optional[str]:
+        """The target of the entry, if it is a symlink.
+
+        Returns
+        -------
+        Optional[str]
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_symlink_target"):
+            return self._symlink_target
+        _args: list[Arg] = []
+        _ctx = self._select("symlinkTarget", _args)
+        return await _ctx.execute(Optional[str])
+
+    @typecheck
+    async def type(self) -> FileType:
+        """The type of the entry.
+
+        Returns
+        -------
+        FileType
+            The type of an entry in a directory.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_type"):
+            return self._type
+        _args: list[Arg] = []
+        _ctx = self._select("type", _args)
+        return await _ctx.execute(FileType)
+
+
+class EnvVariable(Type):
+    """A simple key value object that represents an environment
+    variable."""
+
+    __slots__ = (
+        "_name",
+        "_value",
+    )
+
+    _name: Optional[str]
+    _value: Optional[str]
+
+    @typecheck
+    async def name(self) -> str:
+        """The environment variable name.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
             GraphQL to represent free-form human-readable text.
 
         Raises
@@ -2290,6 +3791,39 @@ class File(Type):
         _ctx = self._select("contents", _args)
         return await _ctx.execute(str)
 
+    @typecheck
+    async def digest(
+        self,
+        *,
+        algorithm: Optional[DigestAlgorithm] = None,
+    ) -> str:
+        """Returns the digest of the file's contents (e.g., "sha256:...").
+
+        Parameters
+        ----------
+        algorithm:
+            The hash algorithm to use.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args = [
+            Arg("algorithm", algorithm, None),
+        ]
+        _ctx = self._select("digest", _args)
+        return await _ctx.execute(str)
+
     @typecheck
     async def export(
         self,
@@ -2327,6 +3861,18 @@ class File(Type):
         _ctx = self._select("export", _args)
         return await _ctx.execute(bool)
 
+    @typecheck
+    def extract(self) -> Directory:
+        """Extracts this file as an archive into a directory.
+
+        Tar archives (optionally compressed with gzip or zstd) and zip
+        archives are
+        supported. The format is detected from the file's contents.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("extract", _args)
+        return Directory(_ctx)
+
     @typecheck
     async def id(self) -> FileID:
         """Retrieves the content-addressed identifier of the file.
@@ -2426,34 +3972,52 @@ class File(Type):
         return cb(self)
 
 
-class Function(Type):
-    """Function represents a resolver provided by a Module.  A function
-    always evaluates against a parent object and is given a set of named
-    arguments."""
+class FileChange(Type):
+    """A change to a path between two directories."""
 
     __slots__ = (
-        "_description",
-        "_name",
+        "_kind",
+        "_old_path",
+        "_old_permissions",
+        "_path",
+        "_permissions",
+        "_size",
+        "_type",
     )
 
-    _description: Optional[str]
-    _name: Optional[str]
+    _kind: Optional[ChangeKind]
+    _old_path: Optional[str]
+    _old_permissions: Optional[int]
+    _path: Optional[str]
+    _permissions: Optional[int]
+    _size: Optional[int]
+    _type: Optional[FileType]
 
     @typecheck
-    async def args(self) -> list["FunctionArg"]:
-        """Arguments accepted by this function, if any"""
+    async def kind(self) -> ChangeKind:
+        """The kind of change.
+
+        Returns
+        -------
+        ChangeKind
+            The kind of a change to a path.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_kind"):
+            return self._kind
         _args: list[Arg] = []
-        _ctx = self._select("args", _args)
-        _ctx = FunctionArg(_ctx)._select_multiple(
-            _default_value="defaultValue",
-            _description="description",
-            _name="name",
-        )
-        return await _ctx.execute(list[FunctionArg])
+        _ctx = self._select("kind", _args)
+        return await _ctx.execute(ChangeKind)
 
     @typecheck
-    async def description(self) -> Optional[str]:
-        """A doc string for the function, if any
+    async def old_path(self) -> Optional[str]:
+        """The path the entry was renamed from, if it was renamed.
 
         Returns
         -------
@@ -2469,24 +4033,22 @@ class Function(Type):
         QueryError
             If the API returns an error.
         """
-        if hasattr(self, "_description"):
-            return self._description
+        if hasattr(self, "_old_path"):
+            return self._old_path
         _args: list[Arg] = []
-        _ctx = self._select("description", _args)
+        _ctx = self._select("oldPath", _args)
         return await _ctx.execute(Optional[str])
 
     @typecheck
-    async def id(self) -> FunctionID:
-        """The ID of the function
-
-        Note
-        ----
-        This is lazyly evaluated, no operation is actually run.
+    async def old_permissions(self) -> int:
+        """The entry's permission bits before the change, or 0 if it was added.
 
         Returns
         -------
-        FunctionID
-            A reference to a Function.
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
 
         Raises
         ------
@@ -2495,21 +4057,16 @@ class Function(Type):
         QueryError
             If the API returns an error.
         """
+        if hasattr(self, "_old_permissions"):
+            return self._old_permissions
         _args: list[Arg] = []
-        _ctx = self._select("id", _args)
-        return await _ctx.execute(FunctionID)
-
-    @classmethod
-    def _id_type(cls) -> type[Scalar]:
-        return FunctionID
-
-    @classmethod
-    def _from_id_query_field(cls):
-        return "loadFunctionFromID"
+        _ctx = self._select("oldPermissions", _args)
+        return await _ctx.execute(int)
 
     @typecheck
-    async def name(self) -> str:
-        """The name of the function
+    async def path(self) -> str:
+        """The path of the entry, relative to the directory (e.g.,
+        "src/main.go").
 
         Returns
         -------
@@ -2525,29 +4082,208 @@ class Function(Type):
         QueryError
             If the API returns an error.
         """
-        if hasattr(self, "_name"):
-            return self._name
+        if hasattr(self, "_path"):
+            return self._path
         _args: list[Arg] = []
-        _ctx = self._select("name", _args)
+        _ctx = self._select("path", _args)
         return await _ctx.execute(str)
 
     @typecheck
-    def return_type(self) -> "TypeDef":
-        """The type returned by this function"""
+    async def permissions(self) -> int:
+        """The entry's permission bits after the change (e.g., 0644), or 0 if it
+        was deleted.
+
+        Returns
+        -------
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_permissions"):
+            return self._permissions
         _args: list[Arg] = []
-        _ctx = self._select("returnType", _args)
-        return TypeDef(_ctx)
+        _ctx = self._select("permissions", _args)
+        return await _ctx.execute(int)
 
     @typecheck
-    def with_arg(
-        self,
-        name: str,
-        type_def: "TypeDef",
-        *,
-        description: Optional[str] = None,
-        default_value: Optional[JSON] = None,
-    ) -> "Function":
-        """Returns the function with the provided argument
+    async def size(self) -> int:
+        """The size of the entry after the change in bytes, or 0 if it was
+        deleted or
+        is not a regular file.
+
+        Returns
+        -------
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_size"):
+            return self._size
+        _args: list[Arg] = []
+        _ctx = self._select("size", _args)
+        return await _ctx.execute(int)
+
+    @typecheck
+    async def type(self) -> FileType:
+        """The type of the entry, or its former type if it was deleted.
+
+        Returns
+        -------
+        FileType
+            The type of an entry in a directory.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_type"):
+            return self._type
+        _args: list[Arg] = []
+        _ctx = self._select("type", _args)
+        return await _ctx.execute(FileType)
+
+
+class Function(Type):
+    """Function represents a resolver provided by a Module.  A function
+    always evaluates against a parent object and is given a set of named
+    arguments."""
+
+    __slots__ = (
+        "_description",
+        "_name",
+    )
+
+    _description: Optional[str]
+    _name: Optional[str]
+
+    @typecheck
+    async def args(self) -> list["FunctionArg"]:
+        """Arguments accepted by this function, if any"""
+        _args: list[Arg] = []
+        _ctx = self._select("args", _args)
+        _ctx = FunctionArg(_ctx)._select_multiple(
+            _default_value="defaultValue",
+            _description="description",
+            _name="name",
+        )
+        return await _ctx.execute(list[FunctionArg])
+
+    @typecheck
+    async def description(self) -> Optional[str]:
+        """A doc string for the function, if any
+
+        Returns
+        -------
+        Optional[str]
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_description"):
+            return self._description
+        _args: list[Arg] = []
+        _ctx = self._select("description", _args)
+        return await _ctx.execute(Optional[str])
+
+    @typecheck
+    async def id(self) -> FunctionID:
+        """The ID of the function
+
+        Note
+        ----
+        This is lazyly evaluated, no operation is actually run.
+
+        Returns
+        -------
+        FunctionID
+            A reference to a Function.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("id", _args)
+        return await _ctx.execute(FunctionID)
+
+    @classmethod
+    def _id_type(cls) -> type[Scalar]:
+        return FunctionID
+
+    @classmethod
+    def _from_id_query_field(cls):
+        return "loadFunctionFromID"
+
+    @typecheck
+    async def name(self) -> str:
+        """The name of the function
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_name"):
+            return self._name
+        _args: list[Arg] = []
+        _ctx = self._select("name", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    def return_type(self) -> "TypeDef":
+        """The type returned by this function"""
+        _args: list[Arg] = []
+        _ctx = self._select("returnType", _args)
+        return TypeDef(_ctx)
+
+    @typecheck
+    def with_arg(
+        self,
+        name: str,
+        type_def: "TypeDef",
+        *,
+        description: Optional[str] = None,
+        default_value: Optional[JSON] = None,
+    ) -> "Function":
+        """Returns the function with the provided argument
 
         Parameters
         ----------
@@ -3088,6 +4824,8 @@ class Host(Type):
         *,
         exclude: Optional[Sequence[str]] = None,
         include: Optional[Sequence[str]] = None,
+        gitignore: Optional[bool] = None,
+        ignore_file: Optional[str] = None,
     ) -> Directory:
         """Accesses a directory on the host.
 
@@ -3101,11 +4839,19 @@ class Host(Type):
         include:
             Include only artifacts that match the given pattern (e.g.,
             ["app/", "package.*"]).
+        gitignore:
+            Exclude artifacts ignored by the .gitignore files in the directory
+            and its subdirectories.
+        ignore_file:
+            Exclude artifacts ignored by the given .dockerignore-style file,
+            relative to the directory (e.g., ".dockerignore").
         """
         _args = [
             Arg("path", path),
             Arg("exclude", exclude, None),
             Arg("include", include, None),
+            Arg("gitignore", gitignore, None),
+            Arg("ignoreFile", ignore_file, None),
         ]
         _ctx = self._select("directory", _args)
         return Directory(_ctx)
@@ -3175,58 +4921,422 @@ class Host(Type):
         return Secret(_ctx)
 
     @typecheck
-    def tunnel(
-        self,
-        service: "Service",
-        *,
-        native: Optional[bool] = False,
-        ports: Optional[Sequence[PortForward]] = None,
-    ) -> "Service":
-        """Creates a tunnel that forwards traffic from the host to a service.
+    def tunnel(
+        self,
+        service: "Service",
+        *,
+        native: Optional[bool] = False,
+        ports: Optional[Sequence[PortForward]] = None,
+    ) -> "Service":
+        """Creates a tunnel that forwards traffic from the host to a service.
+
+        Parameters
+        ----------
+        service:
+            Service to send traffic from the tunnel.
+        native:
+            Map each service port to the same port on the host, as if the
+            service were
+            running natively.
+            Note: enabling may result in port conflicts.
+        ports:
+            Configure explicit port forwarding rules for the tunnel.
+            If a port's frontend is unspecified or 0, a random port will be
+            chosen by
+            the host.
+            If no ports are given, all of the service's ports are forwarded.
+            If native
+            is true, each port maps to the same port on the host. If native is
+            false,
+            each port maps to a random port chosen by the host.
+            If ports are given and native is true, the ports are additive.
+        """
+        _args = [
+            Arg("service", service),
+            Arg("native", native, False),
+            Arg("ports", ports, None),
+        ]
+        _ctx = self._select("tunnel", _args)
+        return Service(_ctx)
+
+    @typecheck
+    def unix_socket(self, path: str) -> "Socket":
+        """Accesses a Unix socket on the host.
+
+        Parameters
+        ----------
+        path:
+            Location of the Unix socket (e.g., "/var/run/docker.sock").
+        """
+        _args = [
+            Arg("path", path),
+        ]
+        _ctx = self._select("unixSocket", _args)
+        return Socket(_ctx)
+
+
+class ImageLayer(Type):
+    """A layer of an image."""
+
+    __slots__ = (
+        "_digest",
+        "_media_type",
+        "_size",
+    )
+
+    _digest: Optional[str]
+    _media_type: Optional[str]
+    _size: Optional[int]
+
+    @typecheck
+    async def digest(self) -> str:
+        """The digest of the compressed layer.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_digest"):
+            return self._digest
+        _args: list[Arg] = []
+        _ctx = self._select("digest", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def media_type(self) -> str:
+        """The media type of the layer.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_media_type"):
+            return self._media_type
+        _args: list[Arg] = []
+        _ctx = self._select("mediaType", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def size(self) -> int:
+        """The compressed size of the layer, in bytes.
+
+        Returns
+        -------
+        int
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_size"):
+            return self._size
+        _args: list[Arg] = []
+        _ctx = self._select("size", _args)
+        return await _ctx.execute(int)
+
+
+class ImageManifest(Type):
+    """The manifest of a single platform of an image."""
+
+    __slots__ = (
+        "_config",
+        "_digest",
+        "_media_type",
+        "_platform",
+    )
+
+    _config: Optional[JSON]
+    _digest: Optional[str]
+    _media_type: Optional[str]
+    _platform: Optional[Platform]
+
+    @typecheck
+    async def annotations(self) -> list["Label"]:
+        """The annotations of the manifest."""
+        _args: list[Arg] = []
+        _ctx = self._select("annotations", _args)
+        _ctx = Label(_ctx)._select_multiple(
+            _name="name",
+            _value="value",
+        )
+        return await _ctx.execute(list[Label])
+
+    @typecheck
+    async def config(self) -> JSON:
+        """The image config.
+
+        Returns
+        -------
+        JSON
+            An arbitrary JSON-encoded value.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_config"):
+            return self._config
+        _args: list[Arg] = []
+        _ctx = self._select("config", _args)
+        return await _ctx.execute(JSON)
+
+    @typecheck
+    async def digest(self) -> str:
+        """The digest of the manifest.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_digest"):
+            return self._digest
+        _args: list[Arg] = []
+        _ctx = self._select("digest", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def labels(self) -> list["Label"]:
+        """The labels set in the image config."""
+        _args: list[Arg] = []
+        _ctx = self._select("labels", _args)
+        _ctx = Label(_ctx)._select_multiple(
+            _name="name",
+            _value="value",
+        )
+        return await _ctx.execute(list[Label])
+
+    @typecheck
+    async def layers(self) -> list[ImageLayer]:
+        """The layers of the manifest."""
+        _args: list[Arg] = []
+        _ctx = self._select("layers", _args)
+        _ctx = ImageLayer(_ctx)._select_multiple(
+            _digest="digest",
+            _media_type="mediaType",
+            _size="size",
+        )
+        return await _ctx.execute(list[ImageLayer])
+
+    @typecheck
+    async def media_type(self) -> str:
+        """The media type of the manifest.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_media_type"):
+            return self._media_type
+        _args: list[Arg] = []
+        _ctx = self._select("mediaType", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def platform(self) -> Platform:
+        """The platform of the manifest.
+
+        Returns
+        -------
+        Platform
+            The platform config OS and architecture in a Container.  The
+            format is [os]/[platform]/[version] (e.g., "darwin/arm64/v7",
+            "windows/amd64", "linux/arm64").
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_platform"):
+            return self._platform
+        _args: list[Arg] = []
+        _ctx = self._select("platform", _args)
+        return await _ctx.execute(Platform)
+
+
+class ImageMetadata(Type):
+    """The metadata of an image in a registry."""
+
+    @typecheck
+    async def annotations(self) -> list["Label"]:
+        """The annotations of the image's index or manifest."""
+        _args: list[Arg] = []
+        _ctx = self._select("annotations", _args)
+        _ctx = Label(_ctx)._select_multiple(
+            _name="name",
+            _value="value",
+        )
+        return await _ctx.execute(list[Label])
+
+    @typecheck
+    async def digest(self) -> str:
+        """The digest of the image's index or manifest.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("digest", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    def manifest(
+        self,
+        *,
+        platform: Optional[Platform] = None,
+    ) -> ImageManifest:
+        """The manifest of a single platform of the image.
+
+        Defaults to the only platform of a single-platform image, or the
+        engine's
+        default platform.
+        """
+        _args = [
+            Arg("platform", platform, None),
+        ]
+        _ctx = self._select("manifest", _args)
+        return ImageManifest(_ctx)
+
+    @typecheck
+    async def manifests(self) -> list[ImageManifest]:
+        """The manifest of each platform of the image."""
+        _args: list[Arg] = []
+        _ctx = self._select("manifests", _args)
+        _ctx = ImageManifest(_ctx)._select_multiple(
+            _config="config",
+            _digest="digest",
+            _media_type="mediaType",
+            _platform="platform",
+        )
+        return await _ctx.execute(list[ImageManifest])
+
+    @typecheck
+    async def media_type(self) -> str:
+        """The media type of the image's index or manifest.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("mediaType", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def platforms(self) -> list[Platform]:
+        """The platforms of the image.
 
-        Parameters
-        ----------
-        service:
-            Service to send traffic from the tunnel.
-        native:
-            Map each service port to the same port on the host, as if the
-            service were
-            running natively.
-            Note: enabling may result in port conflicts.
-        ports:
-            Configure explicit port forwarding rules for the tunnel.
-            If a port's frontend is unspecified or 0, a random port will be
-            chosen by
-            the host.
-            If no ports are given, all of the service's ports are forwarded.
-            If native
-            is true, each port maps to the same port on the host. If native is
-            false,
-            each port maps to a random port chosen by the host.
-            If ports are given and native is true, the ports are additive.
+        Returns
+        -------
+        list[Platform]
+            The platform config OS and architecture in a Container.  The
+            format is [os]/[platform]/[version] (e.g., "darwin/arm64/v7",
+            "windows/amd64", "linux/arm64").
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
         """
-        _args = [
-            Arg("service", service),
-            Arg("native", native, False),
-            Arg("ports", ports, None),
-        ]
-        _ctx = self._select("tunnel", _args)
-        return Service(_ctx)
+        _args: list[Arg] = []
+        _ctx = self._select("platforms", _args)
+        return await _ctx.execute(list[Platform])
 
     @typecheck
-    def unix_socket(self, path: str) -> "Socket":
-        """Accesses a Unix socket on the host.
+    async def ref(self) -> str:
+        """The fully qualified ref of the image, including its digest.
 
-        Parameters
-        ----------
-        path:
-            Location of the Unix socket (e.g., "/var/run/docker.sock").
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
         """
-        _args = [
-            Arg("path", path),
-        ]
-        _ctx = self._select("unixSocket", _args)
-        return Socket(_ctx)
+        _args: list[Arg] = []
+        _ctx = self._select("ref", _args)
+        return await _ctx.execute(str)
 
 
 class Label(Type):
@@ -3767,11 +5877,13 @@ class Port(Type):
 
     __slots__ = (
         "_description",
+        "_healthcheck_path",
         "_port",
         "_protocol",
     )
 
     _description: Optional[str]
+    _healthcheck_path: Optional[str]
     _port: Optional[int]
     _protocol: Optional[NetworkProtocol]
 
@@ -3799,6 +5911,30 @@ class Port(Type):
         _ctx = self._select("description", _args)
         return await _ctx.execute(Optional[str])
 
+    @typecheck
+    async def healthcheck_path(self) -> Optional[str]:
+        """The HTTP path polled to check the health of the service, if any.
+
+        Returns
+        -------
+        Optional[str]
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        if hasattr(self, "_healthcheck_path"):
+            return self._healthcheck_path
+        _args: list[Arg] = []
+        _ctx = self._select("healthcheckPath", _args)
+        return await _ctx.execute(Optional[str])
+
     @typecheck
     async def port(self) -> int:
         """The port number.
@@ -3863,6 +5999,19 @@ class Client(Root):
         _ctx = self._select("cacheVolume", _args)
         return CacheVolume(_ctx)
 
+    @typecheck
+    async def cache_volumes(self) -> list[CacheVolume]:
+        """Lists the cache volumes stored on the engine."""
+        _args: list[Arg] = []
+        _ctx = self._select("cacheVolumes", _args)
+        _ctx = CacheVolume(_ctx)._select_multiple(
+            _key="key",
+            _last_used="lastUsed",
+            _prune="prune",
+            _size="size",
+        )
+        return await _ctx.execute(list[CacheVolume])
+
     @typecheck
     async def check_version_compatibility(self, version: str) -> bool:
         """Checks if the current Dagger Engine is compatible with an SDK's
@@ -3911,6 +6060,50 @@ class Client(Root):
         _ctx = self._select("container", _args)
         return Container(_ctx)
 
+    @typecheck
+    async def copy_image(
+        self,
+        source: str,
+        destinations: Sequence[str],
+    ) -> list[str]:
+        """Copies an image between registries without unpacking it, including
+        every
+        platform of a multi-platform image.
+
+        Returns the fully qualified ref of each destination, in the same
+        order.
+
+        Parameters
+        ----------
+        source:
+            Address of the image to copy (e.g.,
+            "docker.io/dagger/dagger:main").
+        destinations:
+            Registry addresses to copy the image to.
+            Formatted as [host]/[user]/[repo]:[tag] (e.g.
+            "docker.io/dagger/dagger:main").
+
+        Returns
+        -------
+        list[str]
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args = [
+            Arg("source", source),
+            Arg("destinations", destinations),
+        ]
+        _ctx = self._select("copyImage", _args)
+        return await _ctx.execute(list[str])
+
     @typecheck
     def current_function_call(self) -> FunctionCall:
         """The FunctionCall context that the SDK caller is currently executing
@@ -3930,6 +6123,35 @@ class Client(Root):
         _ctx = self._select("currentModule", _args)
         return Module(_ctx)
 
+    @typecheck
+    def debug_container(self, id: str) -> Container:
+        """Loads the state of a failed exec as a container, with the exec's
+        filesystem,
+        environment, mounts and working directory.
+
+        Cache mounts, tmpfs mounts, secrets, sockets and service bindings are
+        not
+        restored.
+
+        Failed execs are only kept if the session was started to debug them
+        (e.g.,
+        with dagger call --debug-on-failure or dagger run --interactive), and
+        only
+        the 10 most recent ones. If a module function fails, its debug ID is
+        the one
+        of the last exec that failed in the function, if any.
+
+        Parameters
+        ----------
+        id:
+            The debug ID reported in the extensions of the exec's error.
+        """
+        _args = [
+            Arg("id", id),
+        ]
+        _ctx = self._select("debugContainer", _args)
+        return Container(_ctx)
+
     @typecheck
     async def default_platform(self) -> Platform:
         """The default platform of the builder.
@@ -4073,6 +6295,23 @@ class Client(Root):
         _ctx = self._select("http", _args)
         return File(_ctx)
 
+    @typecheck
+    def image_metadata(self, address: str) -> ImageMetadata:
+        """Fetches the metadata of an image in a registry without pulling its
+        layers.
+
+        Parameters
+        ----------
+        address:
+            Image's address from its registry (e.g.,
+            "docker.io/dagger/dagger:main").
+        """
+        _args = [
+            Arg("address", address),
+        ]
+        _ctx = self._select("imageMetadata", _args)
+        return ImageMetadata(_ctx)
+
     @typecheck
     def load_cache_volume_from_id(self, id: CacheVolumeID) -> CacheVolume:
         """Load a CacheVolume from its ID."""
@@ -4270,6 +6509,38 @@ class Client(Root):
         _ctx = self._select("setSecret", _args)
         return Secret(_ctx)
 
+    @typecheck
+    def set_secret_uri(self, name: str, uri: str) -> "Secret":
+        """Sets a secret given a user defined name to a URI reference to its
+        value and
+        returns the secret.
+
+        The reference is resolved by the calling client each time the secret
+        is
+        used, so the plaintext is never sent ahead of time. Supported schemes
+        are
+        env://NAME (environment variable), file://PATH (file contents),
+        cmd://COMMAND
+        (stdout of a shell command) and vault://PATH?field=KEY (Vault-
+        compatible KV
+        HTTP API, configured by VAULT_ADDR and VAULT_TOKEN). Clients may
+        register
+        additional schemes.
+
+        Parameters
+        ----------
+        name:
+            The user defined name for this secret
+        uri:
+            The URI referencing the secret value (e.g., "env://GITHUB_TOKEN")
+        """
+        _args = [
+            Arg("name", name),
+            Arg("uri", uri),
+        ]
+        _ctx = self._select("setSecretURI", _args)
+        return Secret(_ctx)
+
     @typecheck
     def socket(self, *, id: Optional[SocketID] = None) -> "Socket":
         """Loads a socket by its ID.
@@ -4406,6 +6677,56 @@ class Service(Type):
         _ctx = self._select("endpoint", _args)
         return await _ctx.execute(str)
 
+    @typecheck
+    async def exit_code(self) -> Optional[int]:
+        """The exit code of the service's process, if it has exited.
+
+        Returns null while the service is still running. If the service was
+        stopped,
+        this is the exit code of its last run.
+
+        Returns
+        -------
+        Optional[int]
+            The `Int` scalar type represents non-fractional signed whole
+            numeric values. Int can represent values between -(2^31) and 2^31
+            - 1.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("exitCode", _args)
+        return await _ctx.execute(Optional[int])
+
+    @typecheck
+    async def health(self) -> ServiceHealthStatus:
+        """Retrieves the current health of the service.
+
+        The service must have been started, e.g. with start or by binding it
+        to a
+        container that has run.
+
+        Returns
+        -------
+        ServiceHealthStatus
+            The health of a running service.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("health", _args)
+        return await _ctx.execute(ServiceHealthStatus)
+
     @typecheck
     async def hostname(self) -> str:
         """Retrieves a hostname which can be used by clients to reach this
@@ -4468,6 +6789,7 @@ class Service(Type):
         _ctx = self._select("ports", _args)
         _ctx = Port(_ctx)._select_multiple(
             _description="description",
+            _healthcheck_path="healthcheckPath",
             _port="port",
             _protocol="protocol",
         )
@@ -4492,6 +6814,78 @@ class Service(Type):
         _ctx = Client.from_context(_ctx)._select("loadServiceFromID", [Arg("id", _id)])
         return Service(_ctx)
 
+    @typecheck
+    async def status(self) -> ServiceStatus:
+        """Retrieves the current lifecycle status of the service.
+
+        Returns
+        -------
+        ServiceStatus
+            The lifecycle status of a service.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("status", _args)
+        return await _ctx.execute(ServiceStatus)
+
+    @typecheck
+    async def stderr(self) -> str:
+        """The most recent output of the service's process to stderr.
+
+        Up to the last 1MiB is retained, including after the service has
+        exited or
+        been stopped. The service must have been started.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("stderr", _args)
+        return await _ctx.execute(str)
+
+    @typecheck
+    async def stdout(self) -> str:
+        """The most recent output of the service's process to stdout.
+
+        Up to the last 1MiB is retained, including after the service has
+        exited or
+        been stopped. The service must have been started.
+
+        Returns
+        -------
+        str
+            The `String` scalar type represents textual data, represented as
+            UTF-8 character sequences. The String type is most often used by
+            GraphQL to represent free-form human-readable text.
+
+        Raises
+        ------
+        ExecuteTimeoutError
+            If the time to execute the query exceeds the configured timeout.
+        QueryError
+            If the API returns an error.
+        """
+        _args: list[Arg] = []
+        _ctx = self._select("stdout", _args)
+        return await _ctx.execute(str)
+
     @typecheck
     async def stop(self) -> "Service":
         """Stop the service.
@@ -4747,10 +7141,13 @@ class TypeDef(Type):
 
 _client = Client()
 cache_volume = _client.cache_volume
+cache_volumes = _client.cache_volumes
 check_version_compatibility = _client.check_version_compatibility
 container = _client.container
+copy_image = _client.copy_image
 current_function_call = _client.current_function_call
 current_module = _client.current_module
+debug_container = _client.debug_container
 default_platform = _client.default_platform
 directory = _client.directory
 file = _client.file
@@ -4759,6 +7156,7 @@ generated_code = _client.generated_code
 git = _client.git
 host = _client.host
 http = _client.http
+image_metadata = _client.image_metadata
 load_cache_volume_from_id = _client.load_cache_volume_from_id
 load_container_from_id = _client.load_container_from_id
 load_directory_from_id = _client.load_directory_from_id
@@ -4776,6 +7174,7 @@ module_config = _client.module_config
 pipeline = _client.pipeline
 secret = _client.secret
 set_secret = _client.set_secret
+set_secret_uri = _client.set_secret_uri
 socket = _client.socket
 type_def = _client.type_def
 
@@ -4786,19 +7185,27 @@ def default_client() -> Client:
 
 
 __all__ = [
+    "ArchiveFormat",
     "BuildArg",
     "CacheSharingMode",
     "CacheVolume",
     "CacheVolumeID",
+    "Change",
+    "ChangeKind",
     "Client",
     "Container",
     "ContainerID",
+    "ContainerLayer",
+    "DigestAlgorithm",
     "Directory",
+    "DirectoryEntry",
     "DirectoryID",
     "EnvVariable",
     "FieldTypeDef",
     "File",
+    "FileChange",
     "FileID",
+    "FileType",
     "Function",
     "FunctionArg",
     "FunctionArgID",
@@ -4810,24 +7217,31 @@ __all__ = [
     "GitRef",
     "GitRepository",
     "Host",
+    "ImageLayer",
     "ImageLayerCompression",
+    "ImageManifest",
     "ImageMediaTypes",
+    "ImageMetadata",
     "JSON",
     "Label",
     "ListTypeDef",
     "Module",
     "ModuleConfig",
     "ModuleID",
+    "NetworkMode",
     "NetworkProtocol",
     "ObjectTypeDef",
     "PipelineLabel",
     "Platform",
     "Port",
     "PortForward",
+    "ProvenanceMode",
     "Secret",
     "SecretID",
     "Service",
+    "ServiceHealthStatus",
     "ServiceID",
+    "ServiceStatus",
     "Socket",
     "SocketID",
     "TypeDef",
@@ -4835,10 +7249,13 @@ __all__ = [
     "TypeDefKind",
     "Void",
     "cache_volume",
+    "cache_volumes",
     "check_version_compatibility",
     "container",
+    "copy_image",
     "current_function_call",
     "current_module",
+    "debug_container",
     "default_client",
     "default_platform",
     "directory",
@@ -4848,6 +7265,7 @@ __all__ = [
     "git",
     "host",
     "http",
+    "image_metadata",
     "load_cache_volume_from_id",
     "load_container_from_id",
     "load_directory_from_id",
@@ -4865,6 +7283,7 @@ __all__ = [
     "pipeline",
     "secret",
     "set_secret",
+    "set_secret_uri",
     "socket",
     "type_def",
 ]